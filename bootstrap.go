@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/sychonet/vdash-be/config"
+	"github.com/sychonet/vdash-be/service"
+)
+
+// bootstrap imports the servers of the provider accounts once and prints the changes made to the inventory.
+func bootstrap(args []string) int {
+	fs := flag.NewFlagSet("bootstrap", flag.ContinueOnError)
+	dryRun := fs.Bool("dry-run", false, "report the changes without writing them")
+	if code, ok := loadConfig(fs, args); !ok {
+		return code
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	providers, err := newProviders()
+	if err != nil {
+		slog.Error("Invalid provider configuration", "error", err)
+		return exitUsage
+	}
+	databaseService := newDatabaseService()
+	if err := databaseService.Ping(ctx); err != nil {
+		slog.Error("Database unavailable", "error", err)
+		return exitUnavailable
+	}
+	libvirtService := service.NewLibvirtService("")
+	onboardingService := service.NewOnboardingService(databaseService, libvirtService, config.AppConfig.Onboarding.MinLibvirtVersion, config.AppConfig.Onboarding.MinHypervisorVersion, libvirtConnection())
+	reconcilerService := service.NewReconcilerService(databaseService, providers, onboardingService, 0)
+
+	diff, err := reconcilerService.Sync(ctx, *dryRun)
+	if err != nil {
+		slog.Error("Failed to import the servers", "error", err)
+		return exitFailure
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(diff); err != nil {
+		slog.Error(err.Error())
+		return exitFailure
+	}
+
+	// Servers which could not be fetched or onboarded are reported in the errors of the diff
+	if len(diff.Errors) > 0 {
+		return exitFailure
+	}
+
+	return exitOK
+}
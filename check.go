@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/sychonet/vdash-be/config"
+	"github.com/sychonet/vdash-be/service"
+)
+
+// nodeCheck is the outcome of the connectivity check of a node.
+type nodeCheck struct {
+	hostname string
+	version  string
+	err      error
+}
+
+// check validates the configuration and the connectivity to the database and to every node. Every check is printed and the exit code reports the first kind of failure.
+func check(args []string) int {
+	fs := flag.NewFlagSet("check", flag.ContinueOnError)
+	timeout := fs.Duration("timeout", 10*time.Second, "timeout of the database check")
+	if code, ok := loadConfig(fs, args); !ok {
+		return code
+	}
+	fmt.Fprintln(os.Stdout, "OK   config")
+
+	if _, err := newProviders(); err != nil {
+		fmt.Fprintf(os.Stdout, "FAIL providers: %v\n", err)
+		return exitUsage
+	}
+	fmt.Fprintln(os.Stdout, "OK   providers")
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	databaseService := newDatabaseService()
+	databaseCtx, cancel := context.WithTimeout(ctx, *timeout)
+	defer cancel()
+	if err := databaseService.Ping(databaseCtx); err != nil {
+		fmt.Fprintf(os.Stdout, "FAIL database: %v\n", err)
+		return exitUnavailable
+	}
+	fmt.Fprintln(os.Stdout, "OK   database")
+
+	servers, err := databaseService.GetServers(databaseCtx)
+	if err != nil {
+		slog.Error("Failed to get the servers", "error", err)
+		return exitUnavailable
+	}
+
+	libvirtService := service.NewLibvirtService("")
+	onboardingService := service.NewOnboardingService(databaseService, libvirtService, config.AppConfig.Onboarding.MinLibvirtVersion, config.AppConfig.Onboarding.MinHypervisorVersion, libvirtConnection())
+
+	// Probe the nodes in parallel, the results keep the order of the servers
+	results := make([]nodeCheck, len(servers))
+	var wg sync.WaitGroup
+	for i, server := range servers {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i].hostname = server.Hostname
+
+			uri, err := onboardingService.LibvirtURI(server)
+			if err != nil {
+				results[i].err = err
+				return
+			}
+			results[i].version, results[i].err = libvirtService.ProbeNode(ctx, uri)
+		}(i)
+	}
+	wg.Wait()
+
+	code := exitOK
+	for _, result := range results {
+		if result.err != nil {
+			fmt.Fprintf(os.Stdout, "FAIL node %s: %v\n", result.hostname, result.err)
+			code = exitUnavailable
+			continue
+		}
+		fmt.Fprintf(os.Stdout, "OK   node %s (libvirt %s)\n", result.hostname, result.version)
+	}
+
+	return code
+}
@@ -0,0 +1,90 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+
+	"github.com/sychonet/vdash-be/dto/request"
+	"github.com/sychonet/vdash-be/dto/response"
+)
+
+// CreateAPIKey creates an API key. The key is only returned by this call.
+func (c *Client) CreateAPIKey(ctx context.Context, req request.CreateAPIKeyRequest) (*response.CreateAPIKeyResponse, error) {
+	var resp response.CreateAPIKeyResponse
+	if err := c.do(ctx, call{method: http.MethodPost, path: "/v2/keys", body: req}, &resp); err != nil {
+		return nil, err
+	}
+
+	return &resp, nil
+}
+
+// ListAPIKeys returns the API keys.
+func (c *Client) ListAPIKeys(ctx context.Context) ([]response.APIKeyResponse, error) {
+	var resp []response.APIKeyResponse
+	if err := c.do(ctx, call{method: http.MethodGet, path: "/v2/keys"}, &resp); err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// RevokeAPIKey revokes an API key.
+func (c *Client) RevokeAPIKey(ctx context.Context, id string) error {
+	return c.do(ctx, call{method: http.MethodDelete, path: pathf("/v2/keys/%s", id)}, nil)
+}
+
+// ListRoles returns the roles.
+func (c *Client) ListRoles(ctx context.Context) ([]response.RoleResponse, error) {
+	var resp []response.RoleResponse
+	if err := c.do(ctx, call{method: http.MethodGet, path: "/v2/roles"}, &resp); err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// SaveRole creates a role or replaces its permissions.
+func (c *Client) SaveRole(ctx context.Context, req request.SaveRoleRequest) (*response.RoleResponse, error) {
+	var resp response.RoleResponse
+	if err := c.do(ctx, call{method: http.MethodPost, path: "/v2/roles", body: req, idempotent: true}, &resp); err != nil {
+		return nil, err
+	}
+
+	return &resp, nil
+}
+
+// DeleteRole deletes a role.
+func (c *Client) DeleteRole(ctx context.Context, name string) error {
+	return c.do(ctx, call{method: http.MethodDelete, path: pathf("/v2/roles/%s", name)}, nil)
+}
+
+// ListRoleBindings returns the role bindings of a subject, or every role binding if subject is empty.
+func (c *Client) ListRoleBindings(ctx context.Context, subject string) ([]response.RoleBindingResponse, error) {
+	var query url.Values
+	if subject != "" {
+		query = url.Values{"subject": {subject}}
+	}
+
+	var resp []response.RoleBindingResponse
+	if err := c.do(ctx, call{method: http.MethodGet, path: "/v2/rolebindings", query: query}, &resp); err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// CreateRoleBinding grants a role to a subject.
+func (c *Client) CreateRoleBinding(ctx context.Context, req request.CreateRoleBindingRequest) (*response.RoleBindingResponse, error) {
+	var resp response.RoleBindingResponse
+	if err := c.do(ctx, call{method: http.MethodPost, path: "/v2/rolebindings", body: req, idempotent: true}, &resp); err != nil {
+		return nil, err
+	}
+
+	return &resp, nil
+}
+
+// DeleteRoleBinding deletes a role binding.
+func (c *Client) DeleteRoleBinding(ctx context.Context, id string) error {
+	return c.do(ctx, call{method: http.MethodDelete, path: pathf("/v2/rolebindings/%s", id)}, nil)
+}
@@ -0,0 +1,89 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/sychonet/vdash-be/dto/response"
+)
+
+// AuditFilter selects audit entries. Empty fields match every entry. Route is a route pattern such as /v2/servers/{serverID} and Outcome is success or failure.
+type AuditFilter struct {
+	Actor    string
+	Method   string
+	Route    string
+	Project  string
+	Outcome  string
+	ServerID int
+	Since    time.Time
+	Until    time.Time
+}
+
+// values returns the query parameters of the filter.
+func (f AuditFilter) values() url.Values {
+	query := url.Values{}
+	for name, value := range map[string]string{"actor": f.Actor, "method": f.Method, "route": f.Route, "project": f.Project, "outcome": f.Outcome} {
+		if value != "" {
+			query.Set(name, value)
+		}
+	}
+
+	if f.ServerID > 0 {
+		query.Set("serverID", strconv.Itoa(f.ServerID))
+	}
+
+	if !f.Since.IsZero() {
+		query.Set("since", f.Since.Format(time.RFC3339))
+	}
+
+	if !f.Until.IsZero() {
+		query.Set("until", f.Until.Format(time.RFC3339))
+	}
+
+	return query
+}
+
+// GetAuditEntries returns the latest entries of the audit log matching the filter, most recent first. A limit of 0 uses the limit of the server.
+func (c *Client) GetAuditEntries(ctx context.Context, filter AuditFilter, limit int) ([]response.AuditEntryResponse, error) {
+	query := filter.values()
+	if limit > 0 {
+		query.Set("limit", strconv.Itoa(limit))
+	}
+
+	var resp []response.AuditEntryResponse
+	if err := c.do(ctx, call{method: http.MethodGet, path: "/v2/audit", query: query}, &resp); err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// ExportAuditEntries calls fn with every entry of the audit log matching the filter, oldest first, as the server streams them. The export stops at the first error returned by fn. It is not retried, since fn may already have seen some entries.
+func (c *Client) ExportAuditEntries(ctx context.Context, filter AuditFilter, fn func(entry response.AuditEntryResponse) error) error {
+	query := filter.values()
+	query.Set("format", "jsonl")
+
+	_, err := c.send(ctx, call{method: http.MethodGet, path: "/v2/audit", query: query}, nil, "", func(r io.Reader) error {
+		decoder := json.NewDecoder(r)
+		for {
+			var entry response.AuditEntryResponse
+			if err := decoder.Decode(&entry); errors.Is(err, io.EOF) {
+				return nil
+			} else if err != nil {
+				return err
+			}
+
+			if err := fn(entry); err != nil {
+				return err
+			}
+		}
+	})
+
+	return err
+}
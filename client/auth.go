@@ -0,0 +1,36 @@
+package client
+
+import (
+	"net/http"
+)
+
+// Authenticator adds the credentials of the caller to a request. It is called before every attempt, so that it can refresh expiring credentials.
+type Authenticator func(req *http.Request) error
+
+// APIKey authenticates the requests with an API key sent in the X-API-Key header.
+func APIKey(key string) Authenticator {
+	return func(req *http.Request) error {
+		req.Header.Set("X-API-Key", key)
+		return nil
+	}
+}
+
+// BearerToken authenticates the requests with a JWT or an API key sent in the Authorization header.
+func BearerToken(token string) Authenticator {
+	return TokenSource(func(req *http.Request) (string, error) {
+		return token, nil
+	})
+}
+
+// TokenSource authenticates the requests with the bearer token returned by source, such as a token refreshed from an identity provider. The request is passed so that source can use its context.
+func TokenSource(source func(req *http.Request) (string, error)) Authenticator {
+	return func(req *http.Request) error {
+		token, err := source(req)
+		if err != nil {
+			return err
+		}
+
+		req.Header.Set("Authorization", "Bearer "+token)
+		return nil
+	}
+}
@@ -0,0 +1,254 @@
+// Package client is the Go client of the vdash API. It only calls the v2 routes, which cover every operation of the v1 routes. It decodes the responses into the types of the dto packages and the errors into Error, and retries the requests which are safe to repeat.
+package client
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	mathrand "math/rand/v2"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Defaults of the client used when the options leave them unset.
+const (
+	DefaultTimeout    = 30 * time.Second
+	DefaultMaxRetries = 3
+	retryBaseDelay    = 500 * time.Millisecond
+	retryMaxDelay     = 30 * time.Second
+)
+
+// Options holds the settings of a client. Project is sent as the X-Project header of every request, the server uses the default project if it is empty. A zero MaxRetries uses DefaultMaxRetries and a negative one disables the retries. HTTPClient replaces the client built from Timeout.
+type Options struct {
+	Project    string
+	MaxRetries int
+	Timeout    time.Duration
+	UserAgent  string
+	HTTPClient *http.Client
+}
+
+// Client calls the vdash API. It is safe for concurrent use.
+type Client struct {
+	baseURL    string
+	client     *http.Client
+	auth       Authenticator
+	project    string
+	maxRetries int
+	userAgent  string
+}
+
+// New returns a client of the API served at baseURL, such as https://vdash.example.com. auth adds the credentials to every request and may be nil when the server does not authenticate requests.
+func New(baseURL string, auth Authenticator, options Options) *Client {
+	if options.Timeout <= 0 {
+		options.Timeout = DefaultTimeout
+	}
+
+	if options.MaxRetries == 0 {
+		options.MaxRetries = DefaultMaxRetries
+	}
+
+	httpClient := options.HTTPClient
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: options.Timeout}
+	}
+
+	return &Client{
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		client:     httpClient,
+		auth:       auth,
+		project:    options.Project,
+		maxRetries: max(options.MaxRetries, 0),
+		userAgent:  options.UserAgent,
+	}
+}
+
+// WithProject returns a copy of the client acting in another project.
+func (c *Client) WithProject(project string) *Client {
+	clone := *c
+	clone.project = project
+	return &clone
+}
+
+// call describes a request to the API. Idempotent requests are sent with an Idempotency-Key header, which makes them safe to retry.
+type call struct {
+	method     string
+	path       string
+	query      url.Values
+	body       any
+	idempotent bool
+}
+
+// do sends a request and decodes the JSON response into out, retrying it while the failure is temporary and the request is safe to repeat.
+func (c *Client) do(ctx context.Context, call call, out any) error {
+	var body []byte
+	if call.body != nil {
+		data, err := json.Marshal(call.body)
+		if err != nil {
+			return fmt.Errorf("failed to encode request body: %w", err)
+		}
+		body = data
+	}
+
+	// The key is kept across the attempts so that the server runs the request once
+	var key string
+	if call.idempotent {
+		key = newIdempotencyKey()
+	}
+
+	for attempt := 0; ; attempt++ {
+		retryAfter, err := c.send(ctx, call, body, key, func(r io.Reader) error {
+			if out == nil {
+				_, err := io.Copy(io.Discard, r)
+				return err
+			}
+			return json.NewDecoder(r).Decode(out)
+		})
+		if err == nil {
+			return nil
+		}
+
+		if attempt >= c.maxRetries || !retryable(call.method, key != "", err) {
+			return err
+		}
+
+		timer := time.NewTimer(backoff(attempt, retryAfter))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// send performs a single attempt of a request and hands the body of a successful response to read. It returns the delay requested by the Retry-After header, if any.
+func (c *Client) send(ctx context.Context, call call, body []byte, key string, read func(r io.Reader) error) (time.Duration, error) {
+	target := c.baseURL + call.path
+	if len(call.query) > 0 {
+		target += "?" + call.query.Encode()
+	}
+
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, call.method, target, reader)
+	if err != nil {
+		return 0, err
+	}
+
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	if c.project != "" {
+		req.Header.Set("X-Project", c.project)
+	}
+
+	if key != "" {
+		req.Header.Set("Idempotency-Key", key)
+	}
+
+	if c.userAgent != "" {
+		req.Header.Set("User-Agent", c.userAgent)
+	}
+
+	if c.auth != nil {
+		if err := c.auth(req); err != nil {
+			return 0, fmt.Errorf("failed to authenticate request: %w", err)
+		}
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+		apiErr := newError(call.method, call.path, resp.StatusCode, data)
+		apiErr.RetryAfter = retryAfterDelay(resp.Header.Get("Retry-After"))
+		return apiErr.RetryAfter, apiErr
+	}
+
+	return 0, read(resp.Body)
+}
+
+// retryable reports whether a failed request should be retried. Requests which are not idempotent are never retried since the server may have processed them.
+func retryable(method string, idempotent bool, err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete:
+	default:
+		if !idempotent {
+			return false
+		}
+	}
+
+	var apiErr *Error
+	if errors.As(err, &apiErr) {
+		return apiErr.Temporary()
+	}
+
+	return true
+}
+
+// backoff returns the delay before the next attempt. The delay doubles with every attempt, is jittered and honors the delay requested by the server.
+func backoff(attempt int, retryAfter time.Duration) time.Duration {
+	delay := retryBaseDelay << attempt
+	if delay <= 0 || delay > retryMaxDelay {
+		delay = retryMaxDelay
+	}
+
+	delay = delay/2 + mathrand.N(delay/2+1)
+	return max(delay, min(retryAfter, retryMaxDelay))
+}
+
+// retryAfterDelay parses the Retry-After header given either in seconds or as an HTTP date.
+func retryAfterDelay(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return time.Duration(seconds) * time.Second
+	}
+
+	if date, err := http.ParseTime(value); err == nil {
+		return time.Until(date)
+	}
+
+	return 0
+}
+
+// newIdempotencyKey returns a random key identifying a request and its retries.
+func newIdempotencyKey() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// pathf formats a path, escaping the string arguments as path segments.
+func pathf(format string, args ...any) string {
+	for i, arg := range args {
+		if s, ok := arg.(string); ok {
+			args[i] = url.PathEscape(s)
+		}
+	}
+
+	return fmt.Sprintf(format, args...)
+}
@@ -0,0 +1,370 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sychonet/vdash-be/dto/request"
+	"github.com/sychonet/vdash-be/dto/response"
+	"github.com/sychonet/vdash-be/openapi"
+)
+
+// newTestClient returns a client of a test server serving handler.
+func newTestClient(t *testing.T, auth Authenticator, options Options, handler http.HandlerFunc) *Client {
+	t.Helper()
+
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	return New(server.URL+"/", auth, options)
+}
+
+// failFirst returns a handler failing the first attempts with status, then answering with body.
+func failFirst(attempts *atomic.Int64, failures int64, status int, retryAfter string, body string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if attempts.Add(1) <= failures {
+			if retryAfter != "" {
+				w.Header().Set("Retry-After", retryAfter)
+			}
+			http.Error(w, http.StatusText(status), status)
+			return
+		}
+		w.Write([]byte(body))
+	}
+}
+
+func TestAuthenticators(t *testing.T) {
+	tests := []struct {
+		name   string
+		auth   Authenticator
+		header string
+		want   string
+	}{
+		{"API key", APIKey("secret"), "X-API-Key", "secret"},
+		{"bearer token", BearerToken("token"), "Authorization", "Bearer token"},
+		{"token source", TokenSource(func(req *http.Request) (string, error) { return "fresh", nil }), "Authorization", "Bearer fresh"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newTestClient(t, tt.auth, Options{Project: "team-a", UserAgent: "tools/1.0"}, func(w http.ResponseWriter, r *http.Request) {
+				if got := r.Header.Get(tt.header); got != tt.want {
+					t.Errorf("%s = %q, want %q", tt.header, got, tt.want)
+				}
+
+				if r.Header.Get("X-Project") != "team-a" || r.Header.Get("User-Agent") != "tools/1.0" {
+					t.Errorf("X-Project = %q and User-Agent = %q, want those of the options", r.Header.Get("X-Project"), r.Header.Get("User-Agent"))
+				}
+
+				w.Write([]byte(`{"items":[]}`))
+			})
+
+			if _, err := client.ListServers(context.Background(), ListOptions{}); err != nil {
+				t.Fatalf("ListServers() error = %v", err)
+			}
+		})
+	}
+}
+
+func TestAuthenticatorError(t *testing.T) {
+	client := newTestClient(t, TokenSource(func(req *http.Request) (string, error) {
+		return "", errors.New("identity provider unreachable")
+	}), Options{}, func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+	})
+
+	if _, err := client.ListServers(context.Background(), ListOptions{}); err == nil || !strings.Contains(err.Error(), "identity provider unreachable") {
+		t.Errorf("ListServers() error = %v, want the error of the token source", err)
+	}
+}
+
+func TestClientRetriesTemporaryErrors(t *testing.T) {
+	for _, status := range []int{http.StatusTooManyRequests, http.StatusInternalServerError, http.StatusServiceUnavailable} {
+		t.Run(http.StatusText(status), func(t *testing.T) {
+			var attempts atomic.Int64
+			client := newTestClient(t, nil, Options{}, failFirst(&attempts, 1, status, "", `{"items":[{"id":1}],"total":1}`))
+
+			page, err := client.ListServers(context.Background(), ListOptions{})
+			if err != nil {
+				t.Fatalf("ListServers() error = %v", err)
+			}
+
+			if attempts.Load() != 2 || len(page.Items) != 1 || page.Items[0].ID != 1 {
+				t.Errorf("ListServers() = %+v after %d attempts, want server 1 after 2 attempts", page, attempts.Load())
+			}
+		})
+	}
+}
+
+func TestClientRetriesConflictsInProgress(t *testing.T) {
+	var attempts atomic.Int64
+	var mu sync.Mutex
+	var keys []string
+	client := newTestClient(t, nil, Options{}, func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		keys = append(keys, r.Header.Get("Idempotency-Key"))
+		mu.Unlock()
+
+		failFirst(&attempts, 1, http.StatusConflict, "1", `{"id":4}`)(w, r)
+	})
+
+	start := time.Now()
+	resp, err := client.CreateServer(context.Background(), request.CreateServerRequest{})
+	if err != nil {
+		t.Fatalf("CreateServer() error = %v", err)
+	}
+
+	if elapsed := time.Since(start); elapsed < time.Second {
+		t.Errorf("retried after %s, want at least the 1s asked by Retry-After", elapsed)
+	}
+
+	if resp.ID != 4 {
+		t.Errorf("CreateServer() = %+v, want server 4", resp)
+	}
+
+	if len(keys) != 2 || keys[0] == "" || keys[0] != keys[1] {
+		t.Errorf("Idempotency-Key of the attempts = %q, want the same key twice", keys)
+	}
+}
+
+func TestClientSendsNewIdempotencyKeyPerCall(t *testing.T) {
+	var keys []string
+	client := newTestClient(t, nil, Options{}, func(w http.ResponseWriter, r *http.Request) {
+		keys = append(keys, r.Header.Get("Idempotency-Key"))
+		w.Write([]byte(`{}`))
+	})
+
+	for range 2 {
+		if _, err := client.CreateServer(context.Background(), request.CreateServerRequest{}); err != nil {
+			t.Fatalf("CreateServer() error = %v", err)
+		}
+	}
+
+	if len(keys) != 2 || keys[0] == keys[1] {
+		t.Errorf("Idempotency-Key of the calls = %q, want a key per call", keys)
+	}
+}
+
+func TestClientDoesNotRetry(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		options Options
+		call    func(c *Client) error
+	}{
+		{"client error", http.StatusNotFound, Options{}, func(c *Client) error {
+			_, err := c.ListServers(context.Background(), ListOptions{})
+			return err
+		}},
+		{"conflict without Retry-After", http.StatusConflict, Options{}, func(c *Client) error {
+			_, err := c.CreateServer(context.Background(), request.CreateServerRequest{})
+			return err
+		}},
+		{"POST without idempotency key", http.StatusServiceUnavailable, Options{}, func(c *Client) error {
+			_, err := c.SyncServers(context.Background(), false)
+			return err
+		}},
+		{"disabled retries", http.StatusServiceUnavailable, Options{MaxRetries: -1}, func(c *Client) error {
+			_, err := c.ListServers(context.Background(), ListOptions{})
+			return err
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var attempts atomic.Int64
+			client := newTestClient(t, nil, tt.options, failFirst(&attempts, 10, tt.status, "", ""))
+
+			var apiErr *Error
+			if err := tt.call(client); !errors.As(err, &apiErr) || apiErr.StatusCode != tt.status {
+				t.Errorf("error = %v, want a %d Error", err, tt.status)
+			}
+
+			if attempts.Load() != 1 {
+				t.Errorf("attempts = %d, want 1", attempts.Load())
+			}
+		})
+	}
+}
+
+func TestClientStopsWhenContextIsCanceled(t *testing.T) {
+	client := newTestClient(t, nil, Options{MaxRetries: 5}, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "30")
+		http.Error(w, "slow down", http.StatusTooManyRequests)
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	if _, err := client.ListServers(ctx, ListOptions{}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("ListServers() error = %v, want context.DeadlineExceeded", err)
+	}
+
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("ListServers() returned after %s, want it to stop waiting once the context is done", elapsed)
+	}
+}
+
+func TestErrorIs(t *testing.T) {
+	sentinels := []error{ErrInvalidRequest, ErrUnauthenticated, ErrForbidden, ErrQuotaExceeded, ErrNotFound, ErrConflict, ErrUnprocessable}
+	tests := []struct {
+		status  int
+		message string
+		want    []error
+	}{
+		{http.StatusBadRequest, "Invalid request: missing name", []error{ErrInvalidRequest}},
+		{http.StatusUnauthorized, "Unauthorized", []error{ErrUnauthenticated}},
+		{http.StatusForbidden, "Forbidden: missing domains:create permission", []error{ErrForbidden}},
+		{http.StatusForbidden, "Forbidden: quota exceeded in project default: domains", []error{ErrForbidden, ErrQuotaExceeded}},
+		{http.StatusNotFound, "Project not found", []error{ErrNotFound}},
+		{http.StatusConflict, "Domain already exists", []error{ErrConflict}},
+		{http.StatusUnprocessableEntity, "Server is cordoned", []error{ErrUnprocessable}},
+		{http.StatusInternalServerError, "Failed to get servers", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.message, func(t *testing.T) {
+			client := newTestClient(t, nil, Options{MaxRetries: -1}, func(w http.ResponseWriter, r *http.Request) {
+				http.Error(w, tt.message, tt.status)
+			})
+
+			_, err := client.ListServers(context.Background(), ListOptions{})
+
+			var apiErr *Error
+			if !errors.As(err, &apiErr) || apiErr.Method != http.MethodGet || apiErr.Path != "/v2/servers" || apiErr.Message != tt.message {
+				t.Fatalf("ListServers() error = %#v, want the Error of GET /v2/servers", err)
+			}
+
+			for _, sentinel := range sentinels {
+				if got, want := errors.Is(err, sentinel), slices.Contains(tt.want, sentinel); got != want {
+					t.Errorf("errors.Is(%v, %v) = %v, want %v", err, sentinel, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestErrorUnwrapsValidationErrors(t *testing.T) {
+	client := newTestClient(t, nil, Options{}, func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "Invalid request: missing hostname; body.port is not an integer", http.StatusBadRequest)
+	})
+
+	_, err := client.CreateServer(context.Background(), request.CreateServerRequest{})
+
+	var validationErr *openapi.ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("CreateServer() error = %v, want an openapi.ValidationError", err)
+	}
+
+	if want := []string{"missing hostname", "body.port is not an integer"}; !slices.Equal(validationErr.Problems, want) {
+		t.Errorf("Problems = %q, want %q", validationErr.Problems, want)
+	}
+
+	notFound := newError(http.MethodGet, "/v2/servers", http.StatusNotFound, []byte("Invalid request: nothing"))
+	if errors.As(notFound, &validationErr) {
+		t.Errorf("errors.As() of a 404 found an openapi.ValidationError")
+	}
+}
+
+func TestAll(t *testing.T) {
+	pages := map[string]string{
+		"":   `{"items":[{"id":1},{"id":2}],"nextCursor":"c2","total":3}`,
+		"c2": `{"items":[{"id":3}],"total":3}`,
+	}
+
+	var cursors []string
+	client := newTestClient(t, nil, Options{}, func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if query.Get("name") != "web" || !slices.Equal(query["label"], []string{"env=prod", "tier=front"}) || query.Get("limit") != "2" {
+			t.Errorf("query = %v, want the filters of the options on every page", query)
+		}
+
+		cursor := query.Get("cursor")
+		cursors = append(cursors, cursor)
+		w.Write([]byte(pages[cursor]))
+	})
+
+	options := ListOptions{Name: "web", Labels: map[string]string{"tier": "front", "env": "prod"}, Limit: 2}
+	servers, err := All(context.Background(), options, client.ListServers)
+	if err != nil {
+		t.Fatalf("All() error = %v", err)
+	}
+
+	var ids []int
+	for _, server := range servers {
+		ids = append(ids, server.ID)
+	}
+
+	if !slices.Equal(ids, []int{1, 2, 3}) || !slices.Equal(cursors, []string{"", "c2"}) {
+		t.Errorf("All() = %v after cursors %q, want servers 1, 2 and 3 after cursors \"\" and c2", ids, cursors)
+	}
+}
+
+func TestAllStopsOnError(t *testing.T) {
+	list := func(ctx context.Context, options ListOptions) (*response.ListResponse[int], error) {
+		if options.Cursor == "" {
+			return &response.ListResponse[int]{Items: []int{1}, NextCursor: "next"}, nil
+		}
+		return nil, ErrNotFound
+	}
+
+	if items, err := All(context.Background(), ListOptions{}, list); !errors.Is(err, ErrNotFound) || items != nil {
+		t.Errorf("All() = %v, %v, want no items and the error of the page", items, err)
+	}
+}
+
+func TestRetryable(t *testing.T) {
+	transportErr := errors.New("connection reset")
+	tests := []struct {
+		name       string
+		method     string
+		idempotent bool
+		err        error
+		want       bool
+	}{
+		{"temporary error of a GET", http.MethodGet, false, &Error{StatusCode: http.StatusBadGateway}, true},
+		{"temporary error of a POST", http.MethodPost, false, &Error{StatusCode: http.StatusBadGateway}, false},
+		{"temporary error of an idempotent POST", http.MethodPost, true, &Error{StatusCode: http.StatusBadGateway}, true},
+		{"not implemented", http.MethodGet, false, &Error{StatusCode: http.StatusNotImplemented}, false},
+		{"conflict in progress", http.MethodPost, true, &Error{StatusCode: http.StatusConflict, RetryAfter: time.Second}, true},
+		{"conflict", http.MethodPost, true, &Error{StatusCode: http.StatusConflict}, false},
+		{"transport error of a PUT", http.MethodPut, false, transportErr, true},
+		{"transport error of a POST", http.MethodPost, false, transportErr, false},
+		{"canceled context", http.MethodGet, false, context.Canceled, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := retryable(tt.method, tt.idempotent, tt.err); got != tt.want {
+				t.Errorf("retryable(%s, %v, %v) = %v, want %v", tt.method, tt.idempotent, tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	for attempt := range 5 {
+		delay := backoff(attempt, 0)
+		full := retryBaseDelay << attempt
+		if delay < full/2 || delay > full {
+			t.Errorf("backoff(%d, 0) = %s, want between %s and %s", attempt, delay, full/2, full)
+		}
+	}
+
+	if delay := backoff(40, 0); delay > retryMaxDelay {
+		t.Errorf("backoff(40, 0) = %s, want at most %s", delay, retryMaxDelay)
+	}
+
+	if delay := backoff(0, 5*time.Second); delay != 5*time.Second {
+		t.Errorf("backoff(0, 5s) = %s, want the 5s asked by the server", delay)
+	}
+}
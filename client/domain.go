@@ -0,0 +1,45 @@
+package client
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/sychonet/vdash-be/dto/request"
+	"github.com/sychonet/vdash-be/dto/response"
+)
+
+// CreateDomain creates a domain on the server named by the request. Without a server the domain is placed by the scheduler.
+func (c *Client) CreateDomain(ctx context.Context, req request.CreateDomainRequest) (*response.CreateDomainResponse, error) {
+	path := "/v2/domains"
+	if req.ServerID > 0 {
+		path = pathf("/v2/servers/%d/domains", req.ServerID)
+	}
+
+	var resp response.CreateDomainResponse
+	if err := c.do(ctx, call{method: http.MethodPost, path: path, body: req, idempotent: true}, &resp); err != nil {
+		return nil, err
+	}
+
+	return &resp, nil
+}
+
+// ListDomains returns a page of the domains of a server.
+func (c *Client) ListDomains(ctx context.Context, serverID int, options ListOptions) (*response.ListResponse[response.GetDomainResponse], error) {
+	var resp response.ListResponse[response.GetDomainResponse]
+	if err := c.do(ctx, call{method: http.MethodGet, path: pathf("/v2/servers/%d/domains", serverID), query: options.values()}, &resp); err != nil {
+		return nil, err
+	}
+
+	return &resp, nil
+}
+
+// DeleteDomain deletes a domain of a server.
+func (c *Client) DeleteDomain(ctx context.Context, serverID int, name string) error {
+	return c.do(ctx, call{method: http.MethodDelete, path: pathf("/v2/servers/%d/domains/%s", serverID, name)}, nil)
+}
+
+// ResizeDomain changes the memory, in MiB, and the vCPUs of a domain.
+func (c *Client) ResizeDomain(ctx context.Context, serverID int, name string, memory uint64, vcpu uint) error {
+	body := request.ResizeDomainRequest{Memory: memory, VCPU: vcpu}
+	return c.do(ctx, call{method: http.MethodPost, path: pathf("/v2/servers/%d/domains/%s/resize", serverID, name), body: body}, nil)
+}
@@ -0,0 +1,106 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/sychonet/vdash-be/openapi"
+)
+
+// Errors matched by the Error returned for the status codes used by the server, such as errors.Is(err, client.ErrNotFound).
+var (
+	// ErrInvalidRequest is matched by 400 responses.
+	ErrInvalidRequest = errors.New("invalid request")
+	// ErrUnauthenticated is matched by 401 responses.
+	ErrUnauthenticated = errors.New("unauthenticated")
+	// ErrForbidden is matched by 403 responses, including exceeded quotas.
+	ErrForbidden = errors.New("forbidden")
+	// ErrQuotaExceeded is matched by 403 responses refusing a request which would exceed the quota of the project.
+	ErrQuotaExceeded = errors.New("quota exceeded")
+	// ErrNotFound is matched by 404 responses.
+	ErrNotFound = errors.New("not found")
+	// ErrConflict is matched by 409 responses.
+	ErrConflict = errors.New("conflict")
+	// ErrUnprocessable is matched by 422 responses.
+	ErrUnprocessable = errors.New("unprocessable")
+)
+
+// Error is returned when the server answers with an unsuccessful status code. Message is the plain text error written by the server. RetryAfter is the delay requested by the Retry-After header, zero if none was sent.
+type Error struct {
+	Method     string
+	Path       string
+	StatusCode int
+	Message    string
+	RetryAfter time.Duration
+
+	// validation holds the problems of a request rejected by the validation of the server
+	validation *openapi.ValidationError
+}
+
+// newError decodes the body of an unsuccessful response.
+func newError(method, path string, statusCode int, body []byte) *Error {
+	e := &Error{Method: method, Path: path, StatusCode: statusCode, Message: strings.TrimSpace(string(body))}
+
+	if problems, ok := strings.CutPrefix(e.Message, "Invalid request: "); ok && statusCode == http.StatusBadRequest {
+		e.validation = &openapi.ValidationError{Problems: strings.Split(problems, "; ")}
+	}
+
+	return e
+}
+
+func (e *Error) Error() string {
+	message := e.Message
+	if message == "" {
+		message = http.StatusText(e.StatusCode)
+	}
+
+	return fmt.Sprintf("vdash: %s %s: %d %s", e.Method, e.Path, e.StatusCode, message)
+}
+
+// Is matches the error with the sentinel of its status code.
+func (e *Error) Is(target error) bool {
+	switch target {
+	case ErrInvalidRequest:
+		return e.StatusCode == http.StatusBadRequest
+	case ErrUnauthenticated:
+		return e.StatusCode == http.StatusUnauthorized
+	case ErrForbidden:
+		return e.StatusCode == http.StatusForbidden
+	case ErrQuotaExceeded:
+		return e.StatusCode == http.StatusForbidden && strings.Contains(e.Message, "quota exceeded")
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound
+	case ErrConflict:
+		return e.StatusCode == http.StatusConflict
+	case ErrUnprocessable:
+		return e.StatusCode == http.StatusUnprocessableEntity
+	}
+
+	return false
+}
+
+// Unwrap returns the openapi.ValidationError listing the problems of a request rejected by the validation of the server, so that it can be retrieved with errors.As.
+func (e *Error) Unwrap() error {
+	if e.validation == nil {
+		return nil
+	}
+
+	return e.validation
+}
+
+// Temporary reports whether the request may succeed when retried. A conflict with a Retry-After header is returned while a request with the same idempotency key is still in progress.
+func (e *Error) Temporary() bool {
+	switch {
+	case e.StatusCode == http.StatusTooManyRequests:
+		return true
+	case e.StatusCode == http.StatusConflict:
+		return e.RetryAfter > 0
+	case e.StatusCode == http.StatusNotImplemented:
+		return false
+	}
+
+	return e.StatusCode >= http.StatusInternalServerError
+}
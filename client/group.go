@@ -0,0 +1,65 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+
+	"github.com/sychonet/vdash-be/dto/request"
+	"github.com/sychonet/vdash-be/dto/response"
+)
+
+// CreateServerGroup creates a server group.
+func (c *Client) CreateServerGroup(ctx context.Context, req request.CreateServerGroupRequest) (*response.ServerGroupResponse, error) {
+	var resp response.ServerGroupResponse
+	if err := c.do(ctx, call{method: http.MethodPost, path: "/v2/groups", body: req, idempotent: true}, &resp); err != nil {
+		return nil, err
+	}
+
+	return &resp, nil
+}
+
+// ListServerGroups returns the server groups.
+func (c *Client) ListServerGroups(ctx context.Context) ([]response.ServerGroupResponse, error) {
+	var resp []response.ServerGroupResponse
+	if err := c.do(ctx, call{method: http.MethodGet, path: "/v2/groups"}, &resp); err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// GetServerGroup returns a server group by name. It returns an error matching ErrNotFound if there is none.
+func (c *Client) GetServerGroup(ctx context.Context, name string) (*response.ServerGroupResponse, error) {
+	var resp []response.ServerGroupResponse
+	if err := c.do(ctx, call{method: http.MethodGet, path: "/v2/groups", query: url.Values{"name": {name}}}, &resp); err != nil {
+		return nil, err
+	}
+
+	return &resp[0], nil
+}
+
+// GetServerGroupViolations returns the members of the server groups breaking the policy of their group.
+func (c *Client) GetServerGroupViolations(ctx context.Context) ([]response.GroupViolationResponse, error) {
+	var resp []response.GroupViolationResponse
+	if err := c.do(ctx, call{method: http.MethodGet, path: "/v2/groups/violations"}, &resp); err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// DeleteServerGroup deletes a server group.
+func (c *Client) DeleteServerGroup(ctx context.Context, name string) error {
+	return c.do(ctx, call{method: http.MethodDelete, path: pathf("/v2/groups/%s", name)}, nil)
+}
+
+// ExplainSchedule returns how the scheduler would place a domain, without creating it.
+func (c *Client) ExplainSchedule(ctx context.Context, req request.SchedulerExplainRequest) (*response.SchedulerExplainResponse, error) {
+	var resp response.SchedulerExplainResponse
+	if err := c.do(ctx, call{method: http.MethodPost, path: "/v2/scheduler/explain", body: req}, &resp); err != nil {
+		return nil, err
+	}
+
+	return &resp, nil
+}
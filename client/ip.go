@@ -0,0 +1,88 @@
+package client
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/sychonet/vdash-be/dto/request"
+	"github.com/sychonet/vdash-be/dto/response"
+)
+
+// AddPublicIP registers a public IP of a server.
+func (c *Client) AddPublicIP(ctx context.Context, req request.AddPublicIPRequest) (*response.AddIPResponse, error) {
+	var resp response.AddIPResponse
+	if err := c.do(ctx, call{method: http.MethodPost, path: "/v2/ips", body: req, idempotent: true}, &resp); err != nil {
+		return nil, err
+	}
+
+	return &resp, nil
+}
+
+// ListAvailablePublicIPs returns a page of the public IPs not attached to a domain.
+func (c *Client) ListAvailablePublicIPs(ctx context.Context, options ListOptions) (*response.ListResponse[response.GetAvailableIPsResponse], error) {
+	var resp response.ListResponse[response.GetAvailableIPsResponse]
+	if err := c.do(ctx, call{method: http.MethodGet, path: "/v2/ips", query: options.values()}, &resp); err != nil {
+		return nil, err
+	}
+
+	return &resp, nil
+}
+
+// ListAllocatedPublicIPs returns the public IPs attached to a domain.
+func (c *Client) ListAllocatedPublicIPs(ctx context.Context) ([]response.PublicIPResponse, error) {
+	var resp []response.PublicIPResponse
+	if err := c.do(ctx, call{method: http.MethodGet, path: "/v2/ips/allocated"}, &resp); err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// SyncPublicIPs reconciles the public IPs with the providers.
+func (c *Client) SyncPublicIPs(ctx context.Context) (*response.SyncPublicIPsResponse, error) {
+	var resp response.SyncPublicIPsResponse
+	if err := c.do(ctx, call{method: http.MethodPost, path: "/v2/ips/sync"}, &resp); err != nil {
+		return nil, err
+	}
+
+	return &resp, nil
+}
+
+// UpdatePublicIP replaces the settings of the public IP named by the request.
+func (c *Client) UpdatePublicIP(ctx context.Context, req request.UpdatePublicIPRequest) error {
+	return c.do(ctx, call{method: http.MethodPut, path: pathf("/v2/ips/%s", req.IP), body: req}, nil)
+}
+
+// DeletePublicIP deletes a public IP.
+func (c *Client) DeletePublicIP(ctx context.Context, ip string) error {
+	return c.do(ctx, call{method: http.MethodDelete, path: pathf("/v2/ips/%s", ip)}, nil)
+}
+
+// RoutePublicIP routes a failover IP to a server.
+func (c *Client) RoutePublicIP(ctx context.Context, ip string, serverID int) (*response.PublicIPResponse, error) {
+	body := request.RoutePublicIPRequest{ServerID: serverID}
+
+	var resp response.PublicIPResponse
+	if err := c.do(ctx, call{method: http.MethodPost, path: pathf("/v2/ips/%s/route", ip), body: body}, &resp); err != nil {
+		return nil, err
+	}
+
+	return &resp, nil
+}
+
+// GeneratePublicIPMAC creates a virtual MAC for a failover IP. macType is kvm, xen or vmware and defaults to kvm if empty.
+func (c *Client) GeneratePublicIPMAC(ctx context.Context, ip, macType string) (*response.PublicIPResponse, error) {
+	body := request.GeneratePublicIPMACRequest{Type: macType}
+
+	var resp response.PublicIPResponse
+	if err := c.do(ctx, call{method: http.MethodPost, path: pathf("/v2/ips/%s/mac", ip), body: body}, &resp); err != nil {
+		return nil, err
+	}
+
+	return &resp, nil
+}
+
+// DeletePublicIPMAC deletes the virtual MAC of a failover IP.
+func (c *Client) DeletePublicIPMAC(ctx context.Context, ip string) error {
+	return c.do(ctx, call{method: http.MethodDelete, path: pathf("/v2/ips/%s/mac", ip)}, nil)
+}
@@ -0,0 +1,82 @@
+package client
+
+import (
+	"context"
+	"maps"
+	"net/url"
+	"slices"
+	"strconv"
+
+	"github.com/sychonet/vdash-be/dto/response"
+)
+
+// ListOptions filters, sorts and paginates a list. Name matches the resources whose name starts with it, Labels the servers having every label and ServerID the public IPs of a server, the filters a list does not support being ignored by the server. Sort is the field to sort by, prefixed with - for a descending order. Cursor is the NextCursor of the previous page.
+type ListOptions struct {
+	Name     string
+	State    string
+	Labels   map[string]string
+	ServerID int
+	Sort     string
+	Limit    int
+	Cursor   string
+}
+
+// values returns the query parameters of the options.
+func (o ListOptions) values() url.Values {
+	query := url.Values{}
+	if o.Name != "" {
+		query.Set("name", o.Name)
+	}
+
+	if o.State != "" {
+		query.Set("state", o.State)
+	}
+
+	for _, key := range slices.Sorted(maps.Keys(o.Labels)) {
+		query.Add("label", key+"="+o.Labels[key])
+	}
+
+	if o.ServerID > 0 {
+		query.Set("serverID", strconv.Itoa(o.ServerID))
+	}
+
+	if o.Sort != "" {
+		query.Set("sort", o.Sort)
+	}
+
+	if o.Limit > 0 {
+		query.Set("limit", strconv.Itoa(o.Limit))
+	}
+
+	if o.Cursor != "" {
+		query.Set("cursor", o.Cursor)
+	}
+
+	return query
+}
+
+// All returns the items of every page of a list, such as client.All(ctx, options, c.ListServers).
+func All[T any](ctx context.Context, options ListOptions, list func(ctx context.Context, options ListOptions) (*response.ListResponse[T], error)) ([]T, error) {
+	var items []T
+	for {
+		page, err := list(ctx, options)
+		if err != nil {
+			return nil, err
+		}
+
+		items = append(items, page.Items...)
+		if page.NextCursor == "" {
+			return items, nil
+		}
+		options.Cursor = page.NextCursor
+	}
+}
+
+// limitQuery returns the limit query parameter, none if limit is not positive.
+func limitQuery(limit int) url.Values {
+	if limit <= 0 {
+		return nil
+	}
+
+	return url.Values{"limit": {strconv.Itoa(limit)}}
+}
@@ -0,0 +1,41 @@
+package client
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/sychonet/vdash-be/dto/request"
+	"github.com/sychonet/vdash-be/dto/response"
+)
+
+// CordonServer stops the scheduler from placing new domains on a server.
+func (c *Client) CordonServer(ctx context.Context, serverID int) error {
+	return c.do(ctx, call{method: http.MethodPost, path: pathf("/v2/servers/%d/cordon", serverID)}, nil)
+}
+
+// UncordonServer lets the scheduler place domains on a server again.
+func (c *Client) UncordonServer(ctx context.Context, serverID int) error {
+	return c.do(ctx, call{method: http.MethodPost, path: pathf("/v2/servers/%d/uncordon", serverID)}, nil)
+}
+
+// DrainServer starts moving every domain off a server. Running domains are live migrated if live is true and cold migrated otherwise.
+func (c *Client) DrainServer(ctx context.Context, serverID int, live bool) (*response.DrainResponse, error) {
+	body := request.DrainServerRequest{Live: live}
+
+	var resp response.DrainResponse
+	if err := c.do(ctx, call{method: http.MethodPost, path: pathf("/v2/servers/%d/drain", serverID), body: body}, &resp); err != nil {
+		return nil, err
+	}
+
+	return &resp, nil
+}
+
+// GetDrain returns the latest drain of a server.
+func (c *Client) GetDrain(ctx context.Context, serverID int) (*response.DrainResponse, error) {
+	var resp response.DrainResponse
+	if err := c.do(ctx, call{method: http.MethodGet, path: pathf("/v2/servers/%d/drain", serverID)}, &resp); err != nil {
+		return nil, err
+	}
+
+	return &resp, nil
+}
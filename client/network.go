@@ -0,0 +1,34 @@
+package client
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/sychonet/vdash-be/dto/request"
+	"github.com/sychonet/vdash-be/dto/response"
+)
+
+// CreateNetwork creates a network on a server.
+func (c *Client) CreateNetwork(ctx context.Context, serverID int, req request.NetworkRequest) (*response.NetworkResponse, error) {
+	var resp response.NetworkResponse
+	if err := c.do(ctx, call{method: http.MethodPost, path: pathf("/v2/servers/%d/networks", serverID), body: req, idempotent: true}, &resp); err != nil {
+		return nil, err
+	}
+
+	return &resp, nil
+}
+
+// ListNetworks returns a page of the networks of a server.
+func (c *Client) ListNetworks(ctx context.Context, serverID int, options ListOptions) (*response.ListResponse[response.NetworkResponse], error) {
+	var resp response.ListResponse[response.NetworkResponse]
+	if err := c.do(ctx, call{method: http.MethodGet, path: pathf("/v2/servers/%d/networks", serverID), query: options.values()}, &resp); err != nil {
+		return nil, err
+	}
+
+	return &resp, nil
+}
+
+// DeleteNetwork deletes a network of a server.
+func (c *Client) DeleteNetwork(ctx context.Context, serverID int, name string) error {
+	return c.do(ctx, call{method: http.MethodDelete, path: pathf("/v2/servers/%d/networks/%s", serverID, name)}, nil)
+}
@@ -0,0 +1,51 @@
+package client
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/sychonet/vdash-be/dto/request"
+	"github.com/sychonet/vdash-be/dto/response"
+)
+
+// BootServer boots a server. The reason is recorded with the power action and may be empty.
+func (c *Client) BootServer(ctx context.Context, serverID int, reason string) (*response.PowerActionResponse, error) {
+	return c.powerServer(ctx, serverID, "boot", reason)
+}
+
+// RebootServer reboots a server.
+func (c *Client) RebootServer(ctx context.Context, serverID int, reason string) (*response.PowerActionResponse, error) {
+	return c.powerServer(ctx, serverID, "reboot", reason)
+}
+
+// ShutdownServer shuts down a server.
+func (c *Client) ShutdownServer(ctx context.Context, serverID int, reason string) (*response.PowerActionResponse, error) {
+	return c.powerServer(ctx, serverID, "shutdown", reason)
+}
+
+// RescueServer boots a server into the rescue system of its provider.
+func (c *Client) RescueServer(ctx context.Context, serverID int, reason string) (*response.PowerActionResponse, error) {
+	return c.powerServer(ctx, serverID, "rescue", reason)
+}
+
+// GetPowerActions returns the latest power actions of a server. A limit of 0 uses the limit of the server.
+func (c *Client) GetPowerActions(ctx context.Context, serverID, limit int) ([]response.PowerActionResponse, error) {
+	var resp []response.PowerActionResponse
+	if err := c.do(ctx, call{method: http.MethodGet, path: pathf("/v2/servers/%d/power", serverID), query: limitQuery(limit)}, &resp); err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// powerServer requests a power action on a server.
+func (c *Client) powerServer(ctx context.Context, serverID int, action, reason string) (*response.PowerActionResponse, error) {
+	body := request.PowerServerRequest{Reason: reason}
+
+	var resp response.PowerActionResponse
+	if err := c.do(ctx, call{method: http.MethodPost, path: pathf("/v2/servers/%d/%s", serverID, action), body: body}, &resp); err != nil {
+		return nil, err
+	}
+
+	return &resp, nil
+}
@@ -0,0 +1,54 @@
+package client
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/sychonet/vdash-be/dto/request"
+	"github.com/sychonet/vdash-be/dto/response"
+)
+
+// ListProjects returns the projects.
+func (c *Client) ListProjects(ctx context.Context) ([]response.ProjectResponse, error) {
+	var resp []response.ProjectResponse
+	if err := c.do(ctx, call{method: http.MethodGet, path: "/v2/projects"}, &resp); err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// CreateProject creates a project.
+func (c *Client) CreateProject(ctx context.Context, req request.CreateProjectRequest) (*response.ProjectResponse, error) {
+	var resp response.ProjectResponse
+	if err := c.do(ctx, call{method: http.MethodPost, path: "/v2/projects", body: req, idempotent: true}, &resp); err != nil {
+		return nil, err
+	}
+
+	return &resp, nil
+}
+
+// DeleteProject deletes a project.
+func (c *Client) DeleteProject(ctx context.Context, name string) error {
+	return c.do(ctx, call{method: http.MethodDelete, path: pathf("/v2/projects/%s", name)}, nil)
+}
+
+// GetProjectQuota returns the quota of a project along with its usage.
+func (c *Client) GetProjectQuota(ctx context.Context, project string) (*response.QuotaResponse, error) {
+	var resp response.QuotaResponse
+	if err := c.do(ctx, call{method: http.MethodGet, path: pathf("/v2/projects/%s/quota", project)}, &resp); err != nil {
+		return nil, err
+	}
+
+	return &resp, nil
+}
+
+// UpdateProjectQuota replaces the quota of a project.
+func (c *Client) UpdateProjectQuota(ctx context.Context, project string, req request.UpdateQuotaRequest) (*response.QuotaResponse, error) {
+	var resp response.QuotaResponse
+	if err := c.do(ctx, call{method: http.MethodPut, path: pathf("/v2/projects/%s/quota", project), body: req}, &resp); err != nil {
+		return nil, err
+	}
+
+	return &resp, nil
+}
@@ -0,0 +1,93 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+
+	"github.com/sychonet/vdash-be/dto/request"
+	"github.com/sychonet/vdash-be/dto/response"
+)
+
+// CreateServer onboards a server.
+func (c *Client) CreateServer(ctx context.Context, req request.CreateServerRequest) (*response.CreateServerResponse, error) {
+	var resp response.CreateServerResponse
+	if err := c.do(ctx, call{method: http.MethodPost, path: "/v2/servers", body: req, idempotent: true}, &resp); err != nil {
+		return nil, err
+	}
+
+	return &resp, nil
+}
+
+// ListServers returns a page of the servers.
+func (c *Client) ListServers(ctx context.Context, options ListOptions) (*response.ListResponse[response.GetServersResponse], error) {
+	var resp response.ListResponse[response.GetServersResponse]
+	if err := c.do(ctx, call{method: http.MethodGet, path: "/v2/servers", query: options.values()}, &resp); err != nil {
+		return nil, err
+	}
+
+	return &resp, nil
+}
+
+// SyncServers reconciles the servers with the inventories of the providers. With dryRun the differences are only reported.
+func (c *Client) SyncServers(ctx context.Context, dryRun bool) (*response.SyncServersResponse, error) {
+	var query url.Values
+	if dryRun {
+		query = url.Values{"dryRun": {"true"}}
+	}
+
+	var resp response.SyncServersResponse
+	if err := c.do(ctx, call{method: http.MethodPost, path: "/v2/servers/sync", query: query}, &resp); err != nil {
+		return nil, err
+	}
+
+	return &resp, nil
+}
+
+// DeleteServer deletes a server.
+func (c *Client) DeleteServer(ctx context.Context, serverID int) error {
+	return c.do(ctx, call{method: http.MethodDelete, path: pathf("/v2/servers/%d", serverID)}, nil)
+}
+
+// UpdateServerLabels replaces the labels of a server.
+func (c *Client) UpdateServerLabels(ctx context.Context, serverID int, labels map[string]string) error {
+	body := request.UpdateServerLabelsRequest{Labels: labels}
+	return c.do(ctx, call{method: http.MethodPut, path: pathf("/v2/servers/%d/labels", serverID), body: body}, nil)
+}
+
+// UpdateServerConnection replaces the connection overrides of a server. A nil connection resets the server to the global settings.
+func (c *Client) UpdateServerConnection(ctx context.Context, serverID int, connection *request.ConnectionConfig) (*response.UpdateServerConnectionResponse, error) {
+	body := request.UpdateServerConnectionRequest{Connection: connection}
+
+	var resp response.UpdateServerConnectionResponse
+	if err := c.do(ctx, call{method: http.MethodPut, path: pathf("/v2/servers/%d/connection", serverID), body: body}, &resp); err != nil {
+		return nil, err
+	}
+
+	return &resp, nil
+}
+
+// DiscoverServer refreshes the capabilities of a server.
+func (c *Client) DiscoverServer(ctx context.Context, serverID int) (*response.NodeCapabilitiesResponse, error) {
+	var resp response.NodeCapabilitiesResponse
+	if err := c.do(ctx, call{method: http.MethodPost, path: pathf("/v2/servers/%d/discover", serverID)}, &resp); err != nil {
+		return nil, err
+	}
+
+	return &resp, nil
+}
+
+// GetServerEvents returns the latest health status changes of a server, or of every server if serverID is 0. A limit of 0 uses the limit of the server.
+func (c *Client) GetServerEvents(ctx context.Context, serverID, limit int) ([]response.ServerEventResponse, error) {
+	path := "/v2/servers/events"
+	if serverID > 0 {
+		path = pathf("/v2/servers/%d/events", serverID)
+	}
+
+	var resp []response.ServerEventResponse
+	if err := c.do(ctx, call{method: http.MethodGet, path: path, query: limitQuery(limit)}, &resp); err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,70 @@
+package client
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/sychonet/vdash-be/dto/request"
+	"github.com/sychonet/vdash-be/dto/response"
+)
+
+// CreateStoragePool creates a storage pool on a server.
+func (c *Client) CreateStoragePool(ctx context.Context, serverID int, req request.StoragePoolRequest) (*response.StoragePoolResponse, error) {
+	var resp response.StoragePoolResponse
+	if err := c.do(ctx, call{method: http.MethodPost, path: pathf("/v2/servers/%d/pools", serverID), body: req, idempotent: true}, &resp); err != nil {
+		return nil, err
+	}
+
+	return &resp, nil
+}
+
+// ListStoragePools returns a page of the storage pools of a server.
+func (c *Client) ListStoragePools(ctx context.Context, serverID int, options ListOptions) (*response.ListResponse[response.StoragePoolListResponse], error) {
+	var resp response.ListResponse[response.StoragePoolListResponse]
+	if err := c.do(ctx, call{method: http.MethodGet, path: pathf("/v2/servers/%d/pools", serverID), query: options.values()}, &resp); err != nil {
+		return nil, err
+	}
+
+	return &resp, nil
+}
+
+// DeleteStoragePool deletes a storage pool of a server.
+func (c *Client) DeleteStoragePool(ctx context.Context, serverID int, pool string) error {
+	return c.do(ctx, call{method: http.MethodDelete, path: pathf("/v2/servers/%d/pools/%s", serverID, pool)}, nil)
+}
+
+// CreateVolume creates a volume in the pool named by the request. Without a server the volume is placed by the scheduler.
+func (c *Client) CreateVolume(ctx context.Context, req request.CreateVolumeRequest) (*response.CreateVolumeResponse, error) {
+	path := "/v2/volumes"
+	if req.ServerID > 0 {
+		path = pathf("/v2/servers/%d/pools/%s/volumes", req.ServerID, req.PoolName)
+	}
+
+	var resp response.CreateVolumeResponse
+	if err := c.do(ctx, call{method: http.MethodPost, path: path, body: req, idempotent: true}, &resp); err != nil {
+		return nil, err
+	}
+
+	return &resp, nil
+}
+
+// ListVolumes returns a page of the volumes of a storage pool.
+func (c *Client) ListVolumes(ctx context.Context, serverID int, pool string, options ListOptions) (*response.ListResponse[response.CreateVolumeResponse], error) {
+	var resp response.ListResponse[response.CreateVolumeResponse]
+	if err := c.do(ctx, call{method: http.MethodGet, path: pathf("/v2/servers/%d/pools/%s/volumes", serverID, pool), query: options.values()}, &resp); err != nil {
+		return nil, err
+	}
+
+	return &resp, nil
+}
+
+// DeleteVolume deletes a volume of a storage pool.
+func (c *Client) DeleteVolume(ctx context.Context, serverID int, pool, name string) error {
+	return c.do(ctx, call{method: http.MethodDelete, path: pathf("/v2/servers/%d/pools/%s/volumes/%s", serverID, pool, name)}, nil)
+}
+
+// ResizeVolume grows a volume to size GB.
+func (c *Client) ResizeVolume(ctx context.Context, serverID int, pool, name string, size int) error {
+	body := request.ResizeVolumeRequest{Size: size}
+	return c.do(ctx, call{method: http.MethodPost, path: pathf("/v2/servers/%d/pools/%s/volumes/%s/resize", serverID, pool, name), body: body}, nil)
+}
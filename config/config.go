@@ -2,9 +2,16 @@ package config
 
 import (
 	"encoding/json"
-	"log"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
 
 	_ "embed"
+
+	"gopkg.in/yaml.v3"
 )
 
 //go:embed config.json
@@ -12,40 +19,270 @@ var configData []byte
 
 type Config struct {
 	Application ApplicationConfig `json:"application"`
-	Scaleway    ScalewayConfig    `json:"scaleway"`
+	Providers   []ProviderConfig  `json:"providers"`
 	Database    DatabaseConfig    `json:"database"`
+	Health      HealthConfig      `json:"health"`
+	Onboarding  OnboardingConfig  `json:"onboarding"`
+	Libvirt     LibvirtConfig     `json:"libvirt"`
+	Reconcile   ReconcileConfig   `json:"reconcile"`
+	Auth        AuthConfig        `json:"auth"`
+	Idempotency IdempotencyConfig `json:"idempotency"`
 }
 
+// ApplicationConfig holds the settings of the API server. The timeouts of the HTTP server are disabled when empty. ShutdownTimeout is how long in-flight requests and drains are given to finish on shutdown.
 type ApplicationConfig struct {
-	Port        string `json:"port"`
-	Environment string `json:"env"`
+	Port              string `json:"port"`
+	Environment       string `json:"env"`
+	ReadHeaderTimeout string `json:"readHeaderTimeout"`
+	ReadTimeout       string `json:"readTimeout"`
+	WriteTimeout      string `json:"writeTimeout"`
+	IdleTimeout       string `json:"idleTimeout"`
+	ShutdownTimeout   string `json:"shutdownTimeout"`
 }
 
-type ScalewayConfig struct {
-	BaseURL string `json:"baseurl"`
-	Token   string `json:"token"`
+// ProviderConfig holds the settings of a provider account. Type is scaleway, hetzner or static. Scaleway accounts use Token, Hetzner accounts use Username and Password and static inventories are read from File. TokenFile and PasswordFile take precedence over Token and Password. Only servers whose hostname starts with HostnamePrefix are imported.
+type ProviderConfig struct {
+	Name           string  `json:"name"`
+	Type           string  `json:"type"`
+	BaseURL        string  `json:"baseurl"`
+	Token          string  `json:"token"`
+	TokenFile      string  `json:"tokenFile"`
+	Username       string  `json:"username"`
+	Password       string  `json:"password"`
+	PasswordFile   string  `json:"passwordFile"`
+	File           string  `json:"file"`
+	HostnamePrefix string  `json:"hostnamePrefix"`
+	RescueImage    string  `json:"rescueImage"`
+	RateLimit      float64 `json:"rateLimit"`
+	Burst          int     `json:"burst"`
+	MaxRetries     int     `json:"maxRetries"`
+	Concurrency    int     `json:"concurrency"`
+	Timeout        string  `json:"timeout"`
 }
 
+// DatabaseConfig holds the settings of the mongodb database. URI is a full connection string which takes precedence over Host, Port, Username, Password, TLS and AuthSource. PasswordFile takes precedence over Password.
 type DatabaseConfig struct {
-	Host              string `json:"host"`
-	Port              string `json:"port"`
-	Username          string `json:"username"`
-	Password          string `json:"password"`
-	Name              string `json:"name"`
-	ServersCollection string `json:"serversCollection"`
-	IPsCollection     string `json:"ipsCollection"`
+	URI                   string `json:"uri"`
+	Host                  string `json:"host"`
+	Port                  string `json:"port"`
+	Username              string `json:"username"`
+	Password              string `json:"password"`
+	PasswordFile          string `json:"passwordFile"`
+	TLS                   bool   `json:"tls"`
+	AuthSource            string `json:"authSource"`
+	Name                  string `json:"name"`
+	ServersCollection     string `json:"serversCollection"`
+	IPsCollection         string `json:"ipsCollection"`
+	GroupsCollection      string `json:"groupsCollection"`
+	DomainsCollection     string `json:"domainsCollection"`
+	DrainsCollection      string `json:"drainsCollection"`
+	EventsCollection      string `json:"eventsCollection"`
+	PowerCollection       string `json:"powerCollection"`
+	MigrationsCollection  string `json:"migrationsCollection"`
+	KeysCollection        string `json:"keysCollection"`
+	RolesCollection       string `json:"rolesCollection"`
+	BindingsCollection    string `json:"bindingsCollection"`
+	ProjectsCollection    string `json:"projectsCollection"`
+	VolumesCollection     string `json:"volumesCollection"`
+	NetworksCollection    string `json:"networksCollection"`
+	AuditCollection       string `json:"auditCollection"`
+	IdempotencyCollection string `json:"idempotencyCollection"`
+}
+
+type OnboardingConfig struct {
+	MinLibvirtVersion    string `json:"minLibvirtVersion"`
+	MinHypervisorVersion string `json:"minHypervisorVersion"`
+}
+
+// LibvirtConfig holds the default settings used to build the libvirt connection URI of every server. They can be overridden per server.
+type LibvirtConfig struct {
+	Transport        string `json:"transport"`
+	User             string `json:"user"`
+	Port             string `json:"port"`
+	Path             string `json:"path"`
+	KeyFile          string `json:"keyFile"`
+	KnownHosts       string `json:"knownHosts"`
+	KnownHostsVerify string `json:"knownHostsVerify"`
+	NoVerify         bool   `json:"noVerify"`
+	PKIPath          string `json:"pkiPath"`
+	Socket           string `json:"socket"`
+}
+
+// ReconcileConfig holds the settings of the periodic sync with the provider inventories.
+type ReconcileConfig struct {
+	Interval string `json:"interval"`
+}
+
+// HealthConfig holds the settings of the health checks. Unreachable servers are rebooted through their provider after PowerCycleAfter, automatic power cycles are disabled if it is empty.
+type HealthConfig struct {
+	Interval         string `json:"interval"`
+	FailureThreshold int    `json:"failureThreshold"`
+	PowerCycleAfter  string `json:"powerCycleAfter"`
+}
+
+// AuthConfig holds the settings of the authentication of API requests. Callers authenticate with an API key or a JWT bearer token. Tokens are verified with the shared HMAC secret JWT.Secret or with the keys published at JWT.JWKSURL.
+type AuthConfig struct {
+	Enabled bool      `json:"enabled"`
+	JWT     JWTConfig `json:"jwt"`
+}
+
+// JWTConfig holds the settings used to verify JWT bearer tokens. Tokens are rejected if their issuer or audience differ from Issuer and Audience when those are set. SecretFile takes precedence over Secret. The roles listed in the RolesClaim claim of a token are granted in every project.
+type JWTConfig struct {
+	Secret     string `json:"secret"`
+	SecretFile string `json:"secretFile"`
+	JWKSURL    string `json:"jwksUrl"`
+	Issuer     string `json:"issuer"`
+	Audience   string `json:"audience"`
+	RolesClaim string `json:"rolesClaim"`
+}
+
+// IdempotencyConfig holds the settings of the Idempotency-Key header of create requests. TTL is how long the response to a request is kept to be replayed to its retries.
+type IdempotencyConfig struct {
+	TTL string `json:"ttl"`
 }
 
 var AppConfig Config
 
-func LoadConfig() {
-	// data, err := os.ReadFile("config.json")
-	// if err != nil {
-	// 	log.Fatalf("Error reading config file, %s", err)
-	// }
+// Load builds the configuration in layers and stores it in AppConfig: the embedded defaults, the configuration file given by the -config flag or VDASH_CONFIG, the VDASH_* environment variables and finally the command line flags. Secrets are then read from the files they refer to and the result is validated.
+func Load(flags *Flags) error {
+	var cfg Config
+	if err := json.Unmarshal(configData, &cfg); err != nil {
+		return fmt.Errorf("failed to parse defaults: %w", err)
+	}
+
+	path := flags.path
+	if path == "" {
+		path = os.Getenv(envPrefix + "_CONFIG")
+	}
+
+	if path != "" {
+		if err := loadFile(path, &cfg); err != nil {
+			return err
+		}
+	}
+
+	if err := applyEnv(&cfg, os.Environ()); err != nil {
+		return err
+	}
+
+	if err := flags.apply(&cfg); err != nil {
+		return err
+	}
 
-	err := json.Unmarshal(configData, &AppConfig)
+	if err := cfg.resolveSecrets(); err != nil {
+		return err
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+
+	AppConfig = cfg
+	return nil
+}
+
+// loadFile overlays the configuration file on the configuration. Files ending in .yaml or .yml are read as YAML, any other file as JSON.
+func loadFile(path string, cfg *Config) error {
+	data, err := os.ReadFile(path)
 	if err != nil {
-		log.Fatalf("Error parsing config file, %s", err)
+		return fmt.Errorf("failed to read config file: %w", err)
 	}
+
+	ext := strings.ToLower(filepath.Ext(path))
+	if ext == ".yaml" || ext == ".yml" {
+		// Convert the YAML document to JSON so that the json tags apply to both formats
+		var document any
+		if err := yaml.Unmarshal(data, &document); err != nil {
+			return fmt.Errorf("failed to parse config file %s: %w", path, err)
+		}
+
+		data, err = json.Marshal(document)
+		if err != nil {
+			return fmt.Errorf("failed to parse config file %s: %w", path, err)
+		}
+	}
+
+	// A list of providers in the file replaces the default one instead of being merged into it
+	var sections map[string]json.RawMessage
+	if err := json.Unmarshal(data, &sections); err != nil {
+		return fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+
+	if _, ok := sections["providers"]; ok {
+		cfg.Providers = nil
+	}
+
+	if err := json.Unmarshal(data, cfg); err != nil {
+		return fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+
+	return nil
+}
+
+// secretFile is a secret given as a path to a file along with the field it is read into.
+type secretFile struct {
+	path   string
+	target *string
+}
+
+// resolveSecrets reads the secrets which are given as paths to files, such as Docker and Kubernetes secrets.
+func (c *Config) resolveSecrets() error {
+	secrets := []secretFile{
+		{c.Database.PasswordFile, &c.Database.Password},
+		{c.Auth.JWT.SecretFile, &c.Auth.JWT.Secret},
+	}
+	for i := range c.Providers {
+		secrets = append(secrets,
+			secretFile{c.Providers[i].TokenFile, &c.Providers[i].Token},
+			secretFile{c.Providers[i].PasswordFile, &c.Providers[i].Password},
+		)
+	}
+
+	for _, secret := range secrets {
+		if secret.path == "" {
+			continue
+		}
+
+		value, err := readSecret(secret.path)
+		if err != nil {
+			return err
+		}
+		*secret.target = value
+	}
+
+	return nil
+}
+
+// readSecret returns the content of a secret file without its trailing newline.
+func readSecret(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read secret: %w", err)
+	}
+
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
+
+// MongoURI returns the connection string of the mongodb database.
+func (d DatabaseConfig) MongoURI() string {
+	if d.URI != "" {
+		return d.URI
+	}
+
+	u := url.URL{Scheme: "mongodb", Host: net.JoinHostPort(d.Host, d.Port), Path: "/"}
+	if d.Username != "" {
+		u.User = url.UserPassword(d.Username, d.Password)
+	}
+
+	query := url.Values{}
+	if d.TLS {
+		query.Set("tls", "true")
+	}
+
+	if d.AuthSource != "" {
+		query.Set("authSource", d.AuthSource)
+	}
+	u.RawQuery = query.Encode()
+
+	return u.String()
 }
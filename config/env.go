@@ -0,0 +1,235 @@
+package config
+
+import (
+	"flag"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// envPrefix is the prefix of the environment variables overriding the configuration.
+const envPrefix = "VDASH"
+
+// applyEnv overrides the configuration with the environment variables named after the JSON path of each setting, such as VDASH_DATABASE_SERVERS_COLLECTION or VDASH_PROVIDERS_0_TOKEN. A variable with the _FILE suffix gives the path to a file holding the value instead.
+func applyEnv(cfg *Config, environ []string) error {
+	env := map[string]string{}
+	for _, entry := range environ {
+		key, value, ok := strings.Cut(entry, "=")
+		if ok && strings.HasPrefix(key, envPrefix+"_") {
+			env[key] = value
+		}
+	}
+
+	return applyEnvValue(reflect.ValueOf(cfg).Elem(), envPrefix, env)
+}
+
+// applyEnvValue overrides the value and the values nested in it with the environment variables starting with name.
+func applyEnvValue(v reflect.Value, name string, env map[string]string) error {
+	switch v.Kind() {
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			tag := jsonName(v.Type().Field(i))
+			if tag == "" {
+				continue
+			}
+
+			if err := applyEnvValue(v.Field(i), name+"_"+envName(tag), env); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	case reflect.Slice:
+		// Elements are addressed by their index, the index past the last element appends one
+		for i := 0; ; i++ {
+			elementName := name + "_" + strconv.Itoa(i)
+			if i >= v.Len() {
+				if !hasEnvPrefix(env, elementName+"_") {
+					return nil
+				}
+				v.Set(reflect.Append(v, reflect.Zero(v.Type().Elem())))
+			}
+
+			if err := applyEnvValue(v.Index(i), elementName, env); err != nil {
+				return err
+			}
+		}
+	}
+
+	value, ok := env[name]
+	if path, isFile := env[name+"_FILE"]; isFile {
+		secret, err := readSecret(path)
+		if err != nil {
+			return fmt.Errorf("%s_FILE: %w", name, err)
+		}
+		value, ok = secret, true
+	}
+
+	if !ok {
+		return nil
+	}
+
+	if err := setValue(v, value); err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+
+	return nil
+}
+
+// hasEnvPrefix reports whether a variable starting with the prefix is set.
+func hasEnvPrefix(env map[string]string, prefix string) bool {
+	for key := range env {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// envName converts a camel case JSON name into the upper snake case used by environment variables.
+func envName(name string) string {
+	var b strings.Builder
+	runes := []rune(name)
+	for i, r := range runes {
+		if i > 0 && unicode.IsUpper(r) && !unicode.IsUpper(runes[i-1]) {
+			b.WriteByte('_')
+		}
+		b.WriteRune(unicode.ToUpper(r))
+	}
+
+	return b.String()
+}
+
+// jsonName returns the name of a field in the JSON configuration, or an empty string if the field is not part of it.
+func jsonName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if name == "-" {
+		return ""
+	}
+
+	return name
+}
+
+// setValue parses a setting into the value.
+func setValue(v reflect.Value, value string) error {
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("invalid boolean %q", value)
+		}
+		v.SetBool(b)
+	case reflect.Int:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("invalid integer %q", value)
+		}
+		v.SetInt(int64(n))
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return fmt.Errorf("invalid number %q", value)
+		}
+		v.SetFloat(f)
+	default:
+		return fmt.Errorf("unsupported setting of kind %s", v.Kind())
+	}
+
+	return nil
+}
+
+// setPath sets the setting at the dotted JSON path, such as database.name or providers.0.token.
+func setPath(v reflect.Value, path []string, value string) error {
+	if len(path) == 0 {
+		return setValue(v, value)
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if tag := jsonName(v.Type().Field(i)); tag != "" && strings.EqualFold(tag, path[0]) {
+				return setPath(v.Field(i), path[1:], value)
+			}
+		}
+	case reflect.Slice:
+		index, err := strconv.Atoi(path[0])
+		if err != nil || index < 0 || index > v.Len() {
+			return fmt.Errorf("invalid index %q", path[0])
+		}
+
+		if index == v.Len() {
+			v.Set(reflect.Append(v, reflect.Zero(v.Type().Elem())))
+		}
+
+		return setPath(v.Index(index), path[1:], value)
+	}
+
+	return fmt.Errorf("unknown setting %q", path[0])
+}
+
+// Flags holds the configuration settings given on the command line.
+type Flags struct {
+	path        string
+	port        string
+	environment string
+	databaseURI string
+	settings    settingFlags
+}
+
+// settingFlags collects the repeatable -set flag.
+type settingFlags []string
+
+func (s *settingFlags) String() string {
+	return strings.Join(*s, ",")
+}
+
+func (s *settingFlags) Set(value string) error {
+	*s = append(*s, value)
+	return nil
+}
+
+// RegisterFlags registers the configuration flags on the flag set.
+func RegisterFlags(fs *flag.FlagSet) *Flags {
+	flags := &Flags{}
+	fs.StringVar(&flags.path, "config", "", "path to a JSON or YAML configuration file (or "+envPrefix+"_CONFIG)")
+	fs.StringVar(&flags.port, "port", "", "port the API listens on")
+	fs.StringVar(&flags.environment, "env", "", "environment of the application")
+	fs.StringVar(&flags.databaseURI, "database-uri", "", "mongodb connection string")
+	fs.Var(&flags.settings, "set", "override a setting by its JSON path, e.g. -set database.name=vdash (repeatable)")
+
+	return flags
+}
+
+// apply overrides the configuration with the flags which were given.
+func (f *Flags) apply(cfg *Config) error {
+	for _, setting := range f.settings {
+		path, value, ok := strings.Cut(setting, "=")
+		if !ok {
+			return fmt.Errorf("-set %s: expected path=value", setting)
+		}
+
+		if err := setPath(reflect.ValueOf(cfg).Elem(), strings.Split(path, "."), value); err != nil {
+			return fmt.Errorf("-set %s: %w", path, err)
+		}
+	}
+
+	for _, flag := range []struct {
+		value  string
+		target *string
+	}{
+		{f.port, &cfg.Application.Port},
+		{f.environment, &cfg.Application.Environment},
+		{f.databaseURI, &cfg.Database.URI},
+	} {
+		if flag.value != "" {
+			*flag.target = flag.value
+		}
+	}
+
+	return nil
+}
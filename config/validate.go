@@ -0,0 +1,131 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// Validate returns every problem found in the configuration.
+func (c *Config) Validate() error {
+	var problems []error
+	problem := func(format string, args ...any) {
+		problems = append(problems, fmt.Errorf(format, args...))
+	}
+
+	if port, err := strconv.Atoi(c.Application.Port); err != nil || port <= 0 || port > 65535 {
+		problem("application.port: invalid port %q", c.Application.Port)
+	}
+
+	if c.Database.URI == "" && c.Database.Host == "" {
+		problem("database: either uri or host is required")
+	}
+
+	for _, setting := range []struct {
+		name  string
+		value string
+	}{
+		{"database.name", c.Database.Name},
+		{"database.serversCollection", c.Database.ServersCollection},
+		{"database.ipsCollection", c.Database.IPsCollection},
+		{"database.groupsCollection", c.Database.GroupsCollection},
+		{"database.domainsCollection", c.Database.DomainsCollection},
+		{"database.drainsCollection", c.Database.DrainsCollection},
+		{"database.eventsCollection", c.Database.EventsCollection},
+		{"database.powerCollection", c.Database.PowerCollection},
+		{"database.migrationsCollection", c.Database.MigrationsCollection},
+		{"database.keysCollection", c.Database.KeysCollection},
+		{"database.rolesCollection", c.Database.RolesCollection},
+		{"database.bindingsCollection", c.Database.BindingsCollection},
+		{"database.projectsCollection", c.Database.ProjectsCollection},
+		{"database.volumesCollection", c.Database.VolumesCollection},
+		{"database.networksCollection", c.Database.NetworksCollection},
+		{"database.auditCollection", c.Database.AuditCollection},
+		{"database.idempotencyCollection", c.Database.IdempotencyCollection},
+		{"application.shutdownTimeout", c.Application.ShutdownTimeout},
+		{"health.interval", c.Health.Interval},
+		{"reconcile.interval", c.Reconcile.Interval},
+		{"idempotency.ttl", c.Idempotency.TTL},
+	} {
+		if setting.value == "" {
+			problem("%s: required", setting.name)
+		}
+	}
+
+	for _, setting := range []struct {
+		name  string
+		value string
+	}{
+		{"application.readHeaderTimeout", c.Application.ReadHeaderTimeout},
+		{"application.readTimeout", c.Application.ReadTimeout},
+		{"application.writeTimeout", c.Application.WriteTimeout},
+		{"application.idleTimeout", c.Application.IdleTimeout},
+		{"application.shutdownTimeout", c.Application.ShutdownTimeout},
+		{"health.interval", c.Health.Interval},
+		{"health.powerCycleAfter", c.Health.PowerCycleAfter},
+		{"reconcile.interval", c.Reconcile.Interval},
+		{"idempotency.ttl", c.Idempotency.TTL},
+	} {
+		if setting.value == "" {
+			continue
+		}
+
+		if d, err := time.ParseDuration(setting.value); err != nil || d <= 0 {
+			problem("%s: invalid duration %q", setting.name, setting.value)
+		}
+	}
+
+	switch c.Libvirt.Transport {
+	case "", "ssh", "libssh", "tls", "tcp", "unix":
+	default:
+		problem("libvirt.transport: unknown transport %q", c.Libvirt.Transport)
+	}
+
+	if c.Auth.JWT.JWKSURL != "" {
+		if u, err := url.Parse(c.Auth.JWT.JWKSURL); err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+			problem("auth.jwt.jwksUrl: invalid URL %q", c.Auth.JWT.JWKSURL)
+		}
+	}
+
+	names := map[string]bool{}
+	for i, provider := range c.Providers {
+		field := fmt.Sprintf("providers.%d", i)
+		if provider.Name == "" {
+			problem("%s.name: required", field)
+		} else if names[provider.Name] {
+			problem("%s.name: duplicate provider %q", field, provider.Name)
+		}
+		names[provider.Name] = true
+
+		switch provider.Type {
+		case "scaleway":
+			if provider.BaseURL == "" {
+				problem("%s.baseurl: required", field)
+			}
+		case "hetzner":
+			if provider.Username == "" {
+				problem("%s.username: required", field)
+			}
+		case "static":
+			if provider.File == "" {
+				problem("%s.file: required", field)
+			}
+		default:
+			problem("%s.type: unknown provider type %q", field, provider.Type)
+		}
+
+		if provider.Timeout != "" {
+			if d, err := time.ParseDuration(provider.Timeout); err != nil || d <= 0 {
+				problem("%s.timeout: invalid duration %q", field, provider.Timeout)
+			}
+		}
+
+		if provider.RateLimit < 0 {
+			problem("%s.rateLimit: must not be negative", field)
+		}
+	}
+
+	return errors.Join(problems...)
+}
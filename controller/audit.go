@@ -0,0 +1,135 @@
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sychonet/vdash-be/db/entity"
+	response "github.com/sychonet/vdash-be/dto/response"
+	"github.com/sychonet/vdash-be/service"
+)
+
+// maxAuditLimit is the largest number of audit entries returned by a single request, larger exports use the jsonl format.
+const maxAuditLimit = 1000
+
+// GetAuditEntries returns the latest entries of the audit log, most recent first. The entries can be filtered by the actor, method, route, project, outcome, serverID, since and until query parameters, since and until being RFC 3339 times. With format=jsonl every matching entry is streamed as JSON lines, oldest first, and limit is ignored.
+func (c *ServerController) GetAuditEntries(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := service.AuditFilter{
+		Actor:   query.Get("actor"),
+		Method:  strings.ToUpper(query.Get("method")),
+		Route:   query.Get("route"),
+		Project: query.Get("project"),
+		Outcome: query.Get("outcome"),
+	}
+
+	if serverIDParam := query.Get("serverID"); serverIDParam != "" {
+		id, err := strconv.Atoi(serverIDParam)
+		if err != nil {
+			http.Error(w, "Invalid serverID query parameter", http.StatusBadRequest)
+			return
+		}
+		filter.ServerID = id
+	}
+
+	for _, param := range []struct {
+		name  string
+		value *time.Time
+	}{
+		{"since", &filter.Since},
+		{"until", &filter.Until},
+	} {
+		if value := query.Get(param.name); value != "" {
+			t, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				http.Error(w, fmt.Sprintf("Invalid %s query parameter, it must be an RFC 3339 time", param.name), http.StatusBadRequest)
+				return
+			}
+			*param.value = t.UTC()
+		}
+	}
+
+	switch format := query.Get("format"); format {
+	case "", "json":
+	case "jsonl":
+		c.exportAuditEntries(w, r, filter)
+		return
+	default:
+		http.Error(w, "Invalid format query parameter, it must be json or jsonl", http.StatusBadRequest)
+		return
+	}
+
+	limit := 100
+	if limitParam := query.Get("limit"); limitParam != "" {
+		l, err := strconv.Atoi(limitParam)
+		if err != nil || l <= 0 || l > maxAuditLimit {
+			http.Error(w, fmt.Sprintf("Invalid limit query parameter, it must be between 1 and %d", maxAuditLimit), http.StatusBadRequest)
+			return
+		}
+		limit = l
+	}
+
+	entries, err := c.auditService.GetEntries(r.Context(), filter, int64(limit))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to get audit entries: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	// Prepare the response
+	resp := []response.AuditEntryResponse{}
+	for _, entry := range entries {
+		resp = append(resp, newAuditEntryResponse(entry))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// exportAuditEntries streams every audit entry matching the filter as JSON lines. Once the first entry is written the status cannot change anymore, so later failures are only logged and cut the export short.
+func (c *ServerController) exportAuditEntries(w http.ResponseWriter, r *http.Request, filter service.AuditFilter) {
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.Header().Set("Content-Disposition", `attachment; filename="audit.jsonl"`)
+
+	encoder := json.NewEncoder(w)
+	err := c.auditService.Export(r.Context(), filter, func(entry entity.AuditEntry) error {
+		return encoder.Encode(newAuditEntryResponse(entry))
+	})
+	if err != nil {
+		slog.Error("Failed to export audit entries", "error", err)
+	}
+}
+
+// newAuditEntryResponse converts an audit entry into its response representation.
+func newAuditEntryResponse(entry entity.AuditEntry) response.AuditEntryResponse {
+	resp := response.AuditEntryResponse{
+		ID:          entry.ID.Hex(),
+		Time:        entry.Time,
+		Actor:       entry.Actor,
+		AuthMethod:  entry.AuthMethod,
+		SourceIP:    entry.SourceIP,
+		Method:      entry.Method,
+		Route:       entry.Route,
+		Path:        entry.Path,
+		Project:     entry.Project,
+		Body:        entry.Body,
+		ServerID:    entry.ServerID,
+		ResourceIDs: entry.ResourceIDs,
+		Status:      entry.Status,
+		Outcome:     entry.Outcome,
+		Error:       entry.Error,
+		DurationMS:  entry.DurationMS,
+	}
+
+	if resp.ResourceIDs == nil {
+		resp.ResourceIDs = []string{}
+	}
+
+	return resp
+}
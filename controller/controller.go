@@ -3,17 +3,37 @@ package controller
 import "github.com/sychonet/vdash-be/service"
 
 type ServerController struct {
-	scalewayService  *service.ScalewayService
-	dbService        *service.DatabaseService
-	libvirtService   *service.LibvirtService
-	schedulerService *service.SchedulerService
+	providers          *service.Providers
+	dbService          *service.DatabaseService
+	libvirtService     *service.LibvirtService
+	schedulerService   *service.SchedulerService
+	drainService       *service.DrainService
+	onboardingService  *service.OnboardingService
+	reconcilerService  *service.ReconcilerService
+	failoverService    *service.FailoverService
+	powerService       *service.PowerService
+	authService        *service.AuthService
+	rbacService        *service.RBACService
+	projectService     *service.ProjectService
+	auditService       *service.AuditService
+	idempotencyService *service.IdempotencyService
 }
 
-func NewServerController(scalewayService *service.ScalewayService, dbService *service.DatabaseService, libvirtService *service.LibvirtService, schedulerService *service.SchedulerService) *ServerController {
+func NewServerController(providers *service.Providers, dbService *service.DatabaseService, libvirtService *service.LibvirtService, schedulerService *service.SchedulerService, drainService *service.DrainService, onboardingService *service.OnboardingService, reconcilerService *service.ReconcilerService, failoverService *service.FailoverService, powerService *service.PowerService, authService *service.AuthService, rbacService *service.RBACService, projectService *service.ProjectService, auditService *service.AuditService, idempotencyService *service.IdempotencyService) *ServerController {
 	return &ServerController{
-		scalewayService:  scalewayService,
-		dbService:        dbService,
-		libvirtService:   libvirtService,
-		schedulerService: schedulerService,
+		providers:          providers,
+		dbService:          dbService,
+		libvirtService:     libvirtService,
+		schedulerService:   schedulerService,
+		drainService:       drainService,
+		onboardingService:  onboardingService,
+		reconcilerService:  reconcilerService,
+		failoverService:    failoverService,
+		powerService:       powerService,
+		authService:        authService,
+		rbacService:        rbacService,
+		projectService:     projectService,
+		auditService:       auditService,
+		idempotencyService: idempotencyService,
 	}
 }
@@ -1,14 +1,20 @@
 package controller
 
 import (
+	"cmp"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
 	"net/http"
 	"strconv"
+	"strings"
 
+	"github.com/sychonet/vdash-be/db/entity"
 	request "github.com/sychonet/vdash-be/dto/request"
 	response "github.com/sychonet/vdash-be/dto/response"
+	"github.com/sychonet/vdash-be/service"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
 // CreateDomain creates a new domain using the provided request.
@@ -19,12 +25,22 @@ func (c *ServerController) CreateDomain(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	c.createDomain(w, r, req)
+}
+
+// createDomain creates the domain described by the request.
+func (c *ServerController) createDomain(w http.ResponseWriter, r *http.Request, req request.CreateDomainRequest) {
 	// Validate the request
 	if req.Name == "" {
 		http.Error(w, "Invalid name", http.StatusBadRequest)
 		return
 	}
 
+	if err := service.ValidateName(req.Name); err != nil {
+		http.Error(w, "Invalid name: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
 	if req.Memory <= 0 {
 		http.Error(w, "Invalid memory", http.StatusBadRequest)
 		return
@@ -44,20 +60,44 @@ func (c *ServerController) CreateDomain(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	project, ok := c.project(w, r)
+	if !ok {
+		return
+	}
+
+	placement, ok := c.domainPlacement(w, r, req)
+	if !ok {
+		return
+	}
+
+	if serverID > 0 {
+		if reason := service.CheckGroupPolicy(placement.Group, serverID); reason != "" {
+			http.Error(w, "Server group policy violated: "+reason, http.StatusConflict)
+			return
+		}
+	}
+
 	if serverID == 0 {
 		// Get availableServerID from domain scheduler
-		availableServerID, err := c.schedulerService.GetServerIDForCreatingDomain(req.Memory, req.VCPU, req.PublicIP)
+		availableServerID, err := c.schedulerService.GetServerIDForCreatingDomain(r.Context(), req.Memory, req.VCPU, req.PublicIP, placement)
 		serverID = availableServerID
 		if err != nil {
 			http.Error(w, fmt.Sprintf("Failed to get serverID: %v", err), http.StatusInternalServerError)
 			return
 		}
+
+		if serverID == 0 {
+			http.Error(w, "No server available", http.StatusNotFound)
+			return
+		}
 	}
 
+	var capabilities *entity.NodeCapabilities
+	libvirtService := c.libvirtService
 	if serverID > 0 {
 		if req.PublicIP {
 			// Check if the server has a public IP
-			ip, err := c.dbService.CheckPublicIPAvailable(serverID)
+			ip, err := c.dbService.CheckPublicIPAvailable(r.Context(), serverID)
 			if err != nil {
 				http.Error(w, fmt.Sprintf("Failed to check public IP: %v", err), http.StatusNotFound)
 				return
@@ -67,54 +107,96 @@ func (c *ServerController) CreateDomain(w http.ResponseWriter, r *http.Request)
 		}
 
 		// Get the libvirt URI from the database
-		serverDetail, err := c.dbService.GetServer(serverID)
+		serverDetail, err := c.dbService.GetServer(r.Context(), serverID)
 		if err != nil {
 			http.Error(w, fmt.Sprintf("Failed to get server details: %v", err), http.StatusInternalServerError)
 			return
 		}
 
-		c.libvirtService.URI = serverDetail.LibvirtURI
+		if reason := service.CheckCapabilities(serverDetail.Capabilities, req.MachineType, req.CPUModel); reason != "" {
+			http.Error(w, "Server cannot host domain: "+reason, http.StatusUnprocessableEntity)
+			return
+		}
+
+		libvirtService = c.libvirtService.Connect(serverDetail.LibvirtURI)
+		capabilities = serverDetail.Capabilities
 	}
 
-	// Define the domain XML
-	domainXML := fmt.Sprintf(`
-	<domain type='kvm'>
-	<name>%s</name>
-	<memory unit='KiB'>%d</memory>
-	<vcpu>%d</vcpu>
-	<os>
-		<type arch='x86_64' machine='pc-i440fx-2.9'>hvm</type>
-		<boot dev='hd'/>
-	</os>
-	<devices>`, req.Name, req.Memory*1024, req.VCPU)
+	// The project is only locked once the server is picked, so that the probes of the scheduler do not hold up the other requests of the project
+	release, ok := c.reserveQuota(w, r, project, service.ResourceUsage{VCPU: int64(req.VCPU), Memory: int64(req.Memory), Domains: 1})
+	if !ok {
+		return
+	}
+	defer release()
 
-	for _, disk := range req.Disks {
-		domainXML += fmt.Sprintf(`
-    <disk type='file' device='disk'>
-      <driver name='qemu' type='qcow2'/>
-      <source file='%s'/>
-      <target dev='vda' bus='virtio'/>
-    </disk>`, disk)
+	// Domains of different projects may share a name on a server as the project is part of the libvirt name
+	libvirtName := service.LibvirtName(project, req.Name)
+	existing, err := c.dbService.GetDomainByLibvirtName(r.Context(), serverID, libvirtName)
+	if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+		http.Error(w, fmt.Sprintf("Failed to check domain name: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	if existing != nil {
+		http.Error(w, "Domain already exists", http.StatusConflict)
+		return
 	}
 
+	var networks []string
 	for _, network := range req.Networks {
-		domainXML += fmt.Sprintf(`
-    <interface type='network'>
-      <source network='%s'/>
-      <model type='virtio'/>
-    </interface>`, network)
+		networkName, err := c.projectService.NetworkLibvirtName(r.Context(), serverID, project, network)
+		if errors.Is(err, service.ErrNotOwned) {
+			http.Error(w, "Network not found: "+network, http.StatusNotFound)
+			return
+		}
+
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Failed to get network: %v", err), http.StatusInternalServerError)
+			return
+		}
+		networks = append(networks, networkName)
 	}
 
-	domainXML += `
-	</devices>
-	</domain>`
+	// Define the domain XML
+	domainXML := buildDomainXML(req, libvirtName, project, networks, capabilities)
 
-	err := c.libvirtService.CreateDomain(req.Name, domainXML)
+	uuid, err := libvirtService.CreateDomain(r.Context(), libvirtName, domainXML)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Failed to create domain: %v", err), http.StatusInternalServerError)
 		return
 	}
 
+	// Record the domain with its placement requirements so that it can be rescheduled when its server is drained
+	domain := entity.DomainInfo{
+		UUID:         uuid,
+		Name:         req.Name,
+		LibvirtName:  libvirtName,
+		Project:      project,
+		ServerID:     serverID,
+		Memory:       req.Memory,
+		VCPU:         req.VCPU,
+		NodeSelector: placement.NodeSelector,
+		Required:     placement.Required,
+		Preferred:    placement.Preferred,
+		Group:        req.Group,
+	}
+	if err := c.dbService.AddDomain(r.Context(), domain); err != nil {
+		// A domain without a record belongs to no project and escapes its quota, so it is removed again
+		if err := libvirtService.DeleteDomain(r.Context(), libvirtName); err != nil {
+			slog.Error("Failed to remove unrecorded domain " + libvirtName + ": " + err.Error())
+		}
+		http.Error(w, fmt.Sprintf("Failed to record domain: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	if placement.Group != nil {
+		// Record the domain as a member of its server group
+		member := entity.ServerGroupMember{Domain: libvirtName, ServerID: serverID}
+		if err := c.dbService.AddServerGroupMember(r.Context(), placement.Group.Name, member); err != nil {
+			slog.Error("Failed to add domain " + req.Name + " to server group " + placement.Group.Name + ": " + err.Error())
+		}
+	}
+
 	// Prepare the response
 	resp := response.CreateDomainResponse{
 		Name:     req.Name,
@@ -145,16 +227,43 @@ func (c *ServerController) GetDomains(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	c.getDomains(w, r, serverID)
+}
+
+// getDomains lists the domains of the project of the request on the server. They can be filtered by a prefix of their name and their state, and sorted by name, memory, vcpu or state.
+func (c *ServerController) getDomains(w http.ResponseWriter, r *http.Request, serverID int) {
+	filter, ok := parseListFilter(w, r)
+	if !ok {
+		return
+	}
+
+	project, ok := c.project(w, r)
+	if !ok {
+		return
+	}
+
 	// Get the libvirt URI from the database
-	serverDetail, err := c.dbService.GetServer(serverID)
+	serverDetail, err := c.dbService.GetServer(r.Context(), serverID)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Failed to get server details: %v", err), http.StatusInternalServerError)
 		return
 	}
 
-	c.libvirtService.URI = serverDetail.LibvirtURI
+	// Get the owners of the domains created through vdash on the server
+	records, err := c.dbService.GetDomainsOnServer(r.Context(), serverID)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to get domain owners: %v", err), http.StatusInternalServerError)
+		return
+	}
 
-	domains, err := c.libvirtService.GetDomains()
+	owners := map[string]service.Owner{}
+	for _, record := range records {
+		owners[record.LibvirtName] = service.Owner{Project: record.Project, Name: record.Name}
+	}
+
+	libvirtService := c.libvirtService.Connect(serverDetail.LibvirtURI)
+
+	domains, err := libvirtService.GetDomains(r.Context())
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Failed to list domains: %v", err), http.StatusInternalServerError)
 		return
@@ -169,30 +278,47 @@ func (c *ServerController) GetDomains(w http.ResponseWriter, r *http.Request) {
 	// Prepare the response
 	var resp []response.GetDomainResponse
 	for _, domain := range domains {
-		name, err := domain.GetName()
+		libvirtName, err := domain.GetName()
 		if err != nil {
 			http.Error(w, fmt.Sprintf("Failed to get domain name: %v", err), http.StatusInternalServerError)
 			return
 		}
 
+		// Only show the domains of the project
+		name, ok := service.VisibleName(owners, libvirtName, project)
+		if !ok || !filter.matchName(name) {
+			continue
+		}
+
 		info, err := domain.GetInfo()
 		if err != nil {
 			http.Error(w, fmt.Sprintf("Failed to get domain info: %v", err), http.StatusInternalServerError)
 			return
 		}
 
+		state := service.DomainStateName(info.State)
+		if !filter.matchState(state) {
+			continue
+		}
+
 		resp = append(resp, response.GetDomainResponse{
 			Name:   name,
 			Memory: info.Memory,
 			VCPU:   info.NrVirtCpu,
+			State:  state,
 		})
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	if err := json.NewEncoder(w).Encode(resp); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-	}
+	writeList(w, r, resp, listOrder[response.GetDomainResponse]{
+		key: func(d response.GetDomainResponse) string { return d.Name },
+		fields: map[string]func(a, b response.GetDomainResponse) int{
+			"name":   func(a, b response.GetDomainResponse) int { return strings.Compare(a.Name, b.Name) },
+			"memory": func(a, b response.GetDomainResponse) int { return cmp.Compare(a.Memory, b.Memory) },
+			"vcpu":   func(a, b response.GetDomainResponse) int { return cmp.Compare(a.VCPU, b.VCPU) },
+			"state":  func(a, b response.GetDomainResponse) int { return strings.Compare(a.State, b.State) },
+		},
+		defaultSort: "name",
+	})
 }
 
 // DeleteDomain deletes a domain on a given server.
@@ -203,21 +329,253 @@ func (c *ServerController) DeleteDomain(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	c.deleteDomain(w, r, req)
+}
+
+// deleteDomain deletes the domain given in the request.
+func (c *ServerController) deleteDomain(w http.ResponseWriter, r *http.Request, req request.DeleteDomainRequest) {
+	project, ok := c.project(w, r)
+	if !ok {
+		return
+	}
+
 	// Get the libvirt URI from the database
-	serverDetail, err := c.dbService.GetServer(req.ServerID)
+	serverDetail, err := c.dbService.GetServer(r.Context(), req.ServerID)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Failed to get server details: %v", err), http.StatusInternalServerError)
 		return
 	}
 
-	c.libvirtService.URI = serverDetail.LibvirtURI
+	// Refuse to delete a domain of another project, or one without an ownership record unless allowed in every project
+	libvirtName := service.LibvirtName(project, req.Name)
+	record, err := c.dbService.GetDomainByLibvirtName(r.Context(), req.ServerID, libvirtName)
+	if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+		http.Error(w, fmt.Sprintf("Failed to get domain owner: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	var owner string
+	if record != nil {
+		owner = record.Project
+	}
 
-	err = c.libvirtService.DeleteDomain(req.Name)
+	if !c.checkOwner(w, r, owner, project, service.ResourceDomains+":"+service.VerbDelete, "Domain not found") {
+		return
+	}
+
+	libvirtService := c.libvirtService.Connect(serverDetail.LibvirtURI)
+
+	err = libvirtService.DeleteDomain(r.Context(), libvirtName)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Failed to delete domain: %v", err), http.StatusInternalServerError)
 		return
 	}
 
+	if err := c.dbService.DeleteDomainByName(r.Context(), req.ServerID, libvirtName); err != nil {
+		slog.Error("Failed to delete record of domain " + libvirtName + ": " + err.Error())
+	}
+
+	// Remove the domain from the server groups it belongs to
+	member := entity.ServerGroupMember{Domain: libvirtName, ServerID: req.ServerID}
+	if err := c.dbService.RemoveServerGroupMember(r.Context(), member); err != nil {
+		slog.Error("Failed to remove domain " + libvirtName + " from server groups: " + err.Error())
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusNoContent)
 }
+
+// ResizeDomain changes the memory and vCPUs of a domain, they apply the next time the domain is started. Growing a domain counts against the quota of its project.
+func (c *ServerController) ResizeDomain(w http.ResponseWriter, r *http.Request) {
+	var req request.ResizeDomainRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	c.resizeDomain(w, r, req)
+}
+
+// resizeDomain changes the memory and vCPUs of the domain given in the request.
+func (c *ServerController) resizeDomain(w http.ResponseWriter, r *http.Request, req request.ResizeDomainRequest) {
+	// Validate the request
+	if req.ServerID <= 0 {
+		http.Error(w, "Invalid serverID", http.StatusBadRequest)
+		return
+	}
+
+	if req.Name == "" {
+		http.Error(w, "Invalid name", http.StatusBadRequest)
+		return
+	}
+
+	if req.Memory <= 0 {
+		http.Error(w, "Invalid memory", http.StatusBadRequest)
+		return
+	}
+
+	if req.VCPU <= 0 {
+		http.Error(w, "Invalid VCPU", http.StatusBadRequest)
+		return
+	}
+
+	project, ok := c.project(w, r)
+	if !ok {
+		return
+	}
+
+	// Get the libvirt URI from the database
+	serverDetail, err := c.dbService.GetServer(r.Context(), req.ServerID)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to get server details: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	libvirtName := service.LibvirtName(project, req.Name)
+	record, err := c.dbService.GetDomainByLibvirtName(r.Context(), req.ServerID, libvirtName)
+	if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+		http.Error(w, fmt.Sprintf("Failed to get domain owner: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	if record != nil && record.Project != project {
+		http.Error(w, "Domain not found", http.StatusNotFound)
+		return
+	}
+
+	// The quota is charged with the difference to the current resources of the domain
+	var memory uint64
+	var vcpu uint
+	if record != nil {
+		memory, vcpu = record.Memory, record.VCPU
+	} else {
+		specs, err := c.libvirtService.GetDomainSpecs(r.Context(), serverDetail.LibvirtURI)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Failed to get domain: %v", err), http.StatusInternalServerError)
+			return
+		}
+
+		found := false
+		for _, spec := range specs {
+			if spec.Name == libvirtName {
+				memory, vcpu, found = spec.Memory, spec.VCPU, true
+				break
+			}
+		}
+
+		if !found {
+			http.Error(w, "Domain not found", http.StatusNotFound)
+			return
+		}
+	}
+
+	release, ok := c.reserveQuota(w, r, project, service.ResourceUsage{VCPU: int64(req.VCPU) - int64(vcpu), Memory: int64(req.Memory) - int64(memory)})
+	if !ok {
+		return
+	}
+	defer release()
+
+	libvirtService := c.libvirtService.Connect(serverDetail.LibvirtURI)
+
+	if err := libvirtService.ResizeDomain(r.Context(), libvirtName, req.Memory, req.VCPU); err != nil {
+		http.Error(w, fmt.Sprintf("Failed to resize domain: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	if record != nil {
+		if err := c.dbService.UpdateDomainResources(r.Context(), record.UUID, req.Memory, req.VCPU); err != nil {
+			slog.Error("Failed to record resources of domain " + libvirtName + ": " + err.Error())
+		}
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// domainPlacement builds the scheduler placement of a new domain including the server group it joins. If the placement cannot be built an error response is written and false is returned.
+func (c *ServerController) domainPlacement(w http.ResponseWriter, r *http.Request, req request.CreateDomainRequest) (service.Placement, bool) {
+	placement, err := newPlacement(req.NodeSelector, req.RequiredConstraints, req.PreferredConstraints)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Invalid placement: %v", err), http.StatusBadRequest)
+		return placement, false
+	}
+
+	if req.Group != "" {
+		// Get the server group the domain joins so that its policy is honored
+		group, err := c.dbService.GetServerGroup(r.Context(), req.Group)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			http.Error(w, "Server group not found", http.StatusNotFound)
+			return placement, false
+		}
+
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Failed to get server group: %v", err), http.StatusInternalServerError)
+			return placement, false
+		}
+
+		placement.Group = group
+	}
+
+	placement.MachineType = req.MachineType
+	placement.CPUModel = req.CPUModel
+
+	return placement, true
+}
+
+// defaultMachineType is the machine type of domains created on servers whose capabilities were never discovered.
+const defaultMachineType = "pc-i440fx-2.9"
+
+// buildDomainXML returns the libvirt definition of a new domain of the project, defined under its libvirt name and attached to the networks with the given libvirt names. The machine type and CPU are picked from the capabilities of the server unless the request asks for specific ones.
+func buildDomainXML(req request.CreateDomainRequest, libvirtName, project string, networks []string, capabilities *entity.NodeCapabilities) string {
+	machineType := req.MachineType
+	if machineType == "" {
+		machineType = defaultMachineType
+		if capabilities != nil && capabilities.DefaultMachine != "" {
+			machineType = capabilities.DefaultMachine
+		}
+	}
+
+	cpu := ""
+	if req.CPUModel != "" {
+		cpu = fmt.Sprintf(`
+	<cpu mode='custom' match='exact'>
+		<model fallback='forbid'>%s</model>
+	</cpu>`, req.CPUModel)
+	} else if capabilities != nil && capabilities.HostModel {
+		cpu = `
+	<cpu mode='host-model'/>`
+	}
+
+	domainXML := fmt.Sprintf(`
+	<domain type='kvm'>
+	<name>%s</name>%s
+	<memory unit='KiB'>%d</memory>
+	<vcpu>%d</vcpu>%s
+	<os>
+		<type arch='x86_64' machine='%s'>hvm</type>
+		<boot dev='hd'/>
+	</os>
+	<devices>`, libvirtName, service.OwnerMetadata(project, req.Name), req.Memory*1024, req.VCPU, cpu, machineType)
+
+	for _, disk := range req.Disks {
+		domainXML += fmt.Sprintf(`
+    <disk type='file' device='disk'>
+      <driver name='qemu' type='qcow2'/>
+      <source file='%s'/>
+      <target dev='vda' bus='virtio'/>
+    </disk>`, disk)
+	}
+
+	for _, network := range networks {
+		domainXML += fmt.Sprintf(`
+    <interface type='network'>
+      <source network='%s'/>
+      <model type='virtio'/>
+    </interface>`, network)
+	}
+
+	domainXML += `
+	</devices>
+	</domain>`
+
+	return domainXML
+}
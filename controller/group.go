@@ -0,0 +1,193 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/sychonet/vdash-be/db/entity"
+	request "github.com/sychonet/vdash-be/dto/request"
+	response "github.com/sychonet/vdash-be/dto/response"
+	"github.com/sychonet/vdash-be/service"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// CreateServerGroup creates a new server group with an affinity or anti-affinity policy.
+func (c *ServerController) CreateServerGroup(w http.ResponseWriter, r *http.Request) {
+	var req request.CreateServerGroupRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Validate the request
+	if req.Name == "" {
+		http.Error(w, "Invalid name", http.StatusBadRequest)
+		return
+	}
+
+	if err := service.ValidatePolicy(req.Policy); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid policy: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	group := entity.ServerGroup{
+		Name:    req.Name,
+		Policy:  req.Policy,
+		Members: []entity.ServerGroupMember{},
+	}
+
+	err := c.dbService.AddServerGroup(r.Context(), group)
+	if mongo.IsDuplicateKeyError(err) {
+		http.Error(w, "Server group already exists", http.StatusConflict)
+		return
+	}
+
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to insert server group: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	// Prepare the response
+	resp := newServerGroupResponse(group)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// GetServerGroups returns all server groups along with their members. If the name query parameter is provided only that group is returned.
+func (c *ServerController) GetServerGroups(w http.ResponseWriter, r *http.Request) {
+	var groups []entity.ServerGroup
+
+	name := r.URL.Query().Get("name")
+	if name != "" {
+		group, err := c.dbService.GetServerGroup(r.Context(), name)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			http.Error(w, "Server group not found", http.StatusNotFound)
+			return
+		}
+
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Failed to get server group: %v", err), http.StatusInternalServerError)
+			return
+		}
+
+		groups = append(groups, *group)
+	} else {
+		allGroups, err := c.dbService.GetServerGroups(r.Context())
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Failed to get server groups: %v", err), http.StatusInternalServerError)
+			return
+		}
+
+		groups = allGroups
+	}
+
+	// Prepare the response
+	var resp []response.ServerGroupResponse
+	for _, group := range groups {
+		resp = append(resp, newServerGroupResponse(group))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// DeleteServerGroup deletes a server group which has no members left.
+func (c *ServerController) DeleteServerGroup(w http.ResponseWriter, r *http.Request) {
+	var req request.DeleteServerGroupRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	c.deleteServerGroup(w, r, req)
+}
+
+// deleteServerGroup deletes the server group given in the request.
+func (c *ServerController) deleteServerGroup(w http.ResponseWriter, r *http.Request, req request.DeleteServerGroupRequest) {
+	group, err := c.dbService.GetServerGroup(r.Context(), req.Name)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		http.Error(w, "Server group not found", http.StatusNotFound)
+		return
+	}
+
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to get server group: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	if len(group.Members) > 0 {
+		http.Error(w, "Server group still has members", http.StatusConflict)
+		return
+	}
+
+	err = c.dbService.DeleteServerGroup(r.Context(), req.Name)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to delete server group: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetServerGroupViolations returns the server groups whose members are currently placed against their policy.
+func (c *ServerController) GetServerGroupViolations(w http.ResponseWriter, r *http.Request) {
+	groups, err := c.dbService.GetServerGroups(r.Context())
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to get server groups: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	// Prepare the response
+	resp := []response.GroupViolationResponse{}
+	for _, group := range groups {
+		resp = append(resp, newGroupViolationResponses(service.GroupViolations(group))...)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// newServerGroupResponse converts a server group into its response representation.
+func newServerGroupResponse(group entity.ServerGroup) response.ServerGroupResponse {
+	resp := response.ServerGroupResponse{
+		Name:    group.Name,
+		Policy:  group.Policy,
+		Members: []response.ServerGroupMemberResponse{},
+	}
+
+	for _, member := range group.Members {
+		resp.Members = append(resp.Members, response.ServerGroupMemberResponse{
+			Domain:   member.Domain,
+			ServerID: member.ServerID,
+		})
+	}
+
+	return resp
+}
+
+// newGroupViolationResponses converts server group violations into their response representation.
+func newGroupViolationResponses(violations []entity.GroupViolation) []response.GroupViolationResponse {
+	var resp []response.GroupViolationResponse
+	for _, violation := range violations {
+		resp = append(resp, response.GroupViolationResponse{
+			Group:     violation.Group,
+			Policy:    violation.Policy,
+			Soft:      violation.Soft,
+			Message:   violation.Message,
+			Domains:   violation.Domains,
+			ServerIDs: violation.ServerIDs,
+		})
+	}
+
+	return resp
+}
@@ -1,15 +1,21 @@
 package controller
 
 import (
+	"cmp"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"strings"
 
 	"github.com/sychonet/vdash-be/db/entity"
 	request "github.com/sychonet/vdash-be/dto/request"
 	response "github.com/sychonet/vdash-be/dto/response"
+	"github.com/sychonet/vdash-be/service"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
+// AddPublicIP adds a public IP, allocated to the project of the request unless it is added as available. Adding an available IP fills the pool shared by every project, so it needs the permission in every project.
 func (c *ServerController) AddPublicIP(w http.ResponseWriter, r *http.Request) {
 	var req request.AddPublicIPRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -28,14 +34,33 @@ func (c *ServerController) AddPublicIP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if req.Available && !c.authorizeGlobally(w, r, service.ResourceIPs+":"+service.VerbCreate) {
+		return
+	}
+
 	ipInfo := entity.IPInfo{
 		ServerID:  req.ServerID,
 		IP:        req.IP,
 		Available: req.Available,
 	}
 
+	// An IP added as unavailable is allocated to the project of the request
+	if !req.Available {
+		project, ok := c.project(w, r)
+		if !ok {
+			return
+		}
+
+		release, ok := c.reserveQuota(w, r, project, service.ResourceUsage{PublicIPs: 1})
+		if !ok {
+			return
+		}
+		defer release()
+		ipInfo.Project = project
+	}
+
 	// Insert the public IP details
-	err := c.dbService.AddIP(ipInfo)
+	err := c.dbService.AddIP(r.Context(), ipInfo)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Failed to insert public IP: %v", err), http.StatusInternalServerError)
 		return
@@ -53,8 +78,14 @@ func (c *ServerController) AddPublicIP(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// GetAvailablePublicIPs returns the public IPs which are not allocated, which every project may list to pick the IPs it allocates. They can be filtered by a prefix of their address and by serverID, and sorted by publicIP or serverID.
 func (c *ServerController) GetAvailablePublicIPs(w http.ResponseWriter, r *http.Request) {
-	ips, err := c.dbService.GetAvailablePublicIPs()
+	filter, ok := parseListFilter(w, r)
+	if !ok {
+		return
+	}
+
+	ips, err := c.dbService.GetAvailablePublicIPs(r.Context())
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Failed to get active public IPs: %v", err), http.StatusInternalServerError)
 		return
@@ -62,15 +93,50 @@ func (c *ServerController) GetAvailablePublicIPs(w http.ResponseWriter, r *http.
 
 	var availableIPs []response.GetAvailableIPsResponse
 	for _, ipInfo := range ips {
+		if !filter.matchName(ipInfo.IP) || !filter.matchServer(ipInfo.ServerID) {
+			continue
+		}
+
 		var ip response.GetAvailableIPsResponse
 		ip.IP = ipInfo.IP
 		ip.ServerID = ipInfo.ServerID
+		ip.Provider = ipInfo.Provider
+		ip.Destination = ipInfo.Destination
+		ip.MAC = ipInfo.MAC
 		availableIPs = append(availableIPs, ip)
 	}
 
+	writeList(w, r, availableIPs, listOrder[response.GetAvailableIPsResponse]{
+		key: func(ip response.GetAvailableIPsResponse) string { return ip.IP },
+		fields: map[string]func(a, b response.GetAvailableIPsResponse) int{
+			"publicIP": func(a, b response.GetAvailableIPsResponse) int { return strings.Compare(a.IP, b.IP) },
+			"serverID": func(a, b response.GetAvailableIPsResponse) int { return cmp.Compare(a.ServerID, b.ServerID) },
+		},
+		defaultSort: "publicIP",
+	})
+}
+
+// GetAllocatedPublicIPs returns the public IPs allocated to the project of the request.
+func (c *ServerController) GetAllocatedPublicIPs(w http.ResponseWriter, r *http.Request) {
+	project, ok := c.project(w, r)
+	if !ok {
+		return
+	}
+
+	ips, err := c.dbService.GetProjectIPs(r.Context(), project)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to get allocated public IPs: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	// Prepare the response
+	resp := []response.PublicIPResponse{}
+	for _, ip := range ips {
+		resp = append(resp, newPublicIPResponse(ip))
+	}
+
 	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	if err := json.NewEncoder(w).Encode(availableIPs); err != nil {
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 	}
 }
@@ -82,13 +148,18 @@ func (c *ServerController) DeletePublicIP(w http.ResponseWriter, r *http.Request
 		return
 	}
 
+	c.deletePublicIP(w, r, req)
+}
+
+// deletePublicIP deletes the public IP given in the request.
+func (c *ServerController) deletePublicIP(w http.ResponseWriter, r *http.Request, req request.DeletePublicIPRequest) {
 	// Validate the request
 	if req.IP == "" {
 		http.Error(w, "Invalid Public IP", http.StatusBadRequest)
 		return
 	}
 
-	err := c.dbService.DeletePublicIP(req.IP)
+	err := c.dbService.DeletePublicIP(r.Context(), req.IP)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Failed to delete public IP: %v", err), http.StatusInternalServerError)
 		return
@@ -104,6 +175,11 @@ func (c *ServerController) UpdatePublicIP(w http.ResponseWriter, r *http.Request
 		return
 	}
 
+	c.updatePublicIP(w, r, req)
+}
+
+// updatePublicIP updates the public IP given in the request.
+func (c *ServerController) updatePublicIP(w http.ResponseWriter, r *http.Request, req request.UpdatePublicIPRequest) {
 	// Validate the request
 	if req.IP == "" {
 		http.Error(w, "Invalid Public IP", http.StatusBadRequest)
@@ -115,7 +191,48 @@ func (c *ServerController) UpdatePublicIP(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	err := c.dbService.UpdatePublicIP(req.IP, req.ServerID, req.Available)
+	project, ok := c.project(w, r)
+	if !ok {
+		return
+	}
+
+	ip, err := c.dbService.GetIP(r.Context(), req.IP)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		http.Error(w, "Public IP not found", http.StatusNotFound)
+		return
+	}
+
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to get public IP: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	// An allocated IP can only be changed or released by its project
+	if !ip.Available && ip.Project != "" && ip.Project != project {
+		http.Error(w, "Public IP is allocated to another project", http.StatusConflict)
+		return
+	}
+
+	// An IP which stays in the shared pool belongs to no project
+	if ip.Available && req.Available && !c.authorizeGlobally(w, r, service.ResourceIPs+":"+service.VerbUpdate) {
+		return
+	}
+
+	// Allocating the IP gives it to the project of the request, releasing it returns it to the shared pool
+	allocatedTo := ""
+	if !req.Available {
+		allocatedTo = project
+
+		if ip.Available || ip.Project != project {
+			release, ok := c.reserveQuota(w, r, project, service.ResourceUsage{PublicIPs: 1})
+			if !ok {
+				return
+			}
+			defer release()
+		}
+	}
+
+	err = c.dbService.UpdatePublicIP(r.Context(), req.IP, req.ServerID, req.Available, allocatedTo)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Failed to update public IP: %v", err), http.StatusInternalServerError)
 		return
@@ -123,3 +240,199 @@ func (c *ServerController) UpdatePublicIP(w http.ResponseWriter, r *http.Request
 
 	w.WriteHeader(http.StatusNoContent)
 }
+
+// SyncPublicIPs imports the failover IPs of every provider account right away and returns the differences which were applied.
+func (c *ServerController) SyncPublicIPs(w http.ResponseWriter, r *http.Request) {
+	diff, err := c.failoverService.Sync(r.Context())
+	if errors.Is(err, service.ErrIPSyncRunning) {
+		http.Error(w, "Failover IP sync is already running", http.StatusConflict)
+		return
+	}
+
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to sync public IPs: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	// Prepare the response
+	resp := response.SyncPublicIPsResponse{
+		Added:      []response.PublicIPResponse{},
+		Updated:    []response.PublicIPResponse{},
+		Removed:    []string{},
+		Orphaned:   []string{},
+		Errors:     []string{},
+		StartedAt:  diff.StartedAt,
+		FinishedAt: diff.FinishedAt,
+	}
+
+	for _, ip := range diff.Added {
+		resp.Added = append(resp.Added, newPublicIPResponse(ip))
+	}
+
+	for _, ip := range diff.Updated {
+		resp.Updated = append(resp.Updated, newPublicIPResponse(ip))
+	}
+
+	resp.Removed = append(resp.Removed, diff.Removed...)
+	resp.Orphaned = append(resp.Orphaned, diff.Orphaned...)
+	resp.Errors = append(resp.Errors, diff.Errors...)
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// RoutePublicIP routes a failover IP to another server of the same provider account through the provider API.
+func (c *ServerController) RoutePublicIP(w http.ResponseWriter, r *http.Request) {
+	var req request.RoutePublicIPRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	c.routePublicIP(w, r, req)
+}
+
+// routePublicIP routes the failover IP given in the request.
+func (c *ServerController) routePublicIP(w http.ResponseWriter, r *http.Request, req request.RoutePublicIPRequest) {
+	// Validate the request
+	if req.IP == "" {
+		http.Error(w, "Invalid publicIP", http.StatusBadRequest)
+		return
+	}
+
+	if req.ServerID <= 0 {
+		http.Error(w, "Invalid serverID", http.StatusBadRequest)
+		return
+	}
+
+	_, err := c.dbService.GetServer(r.Context(), req.ServerID)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		http.Error(w, "Server not found", http.StatusNotFound)
+		return
+	}
+
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to get server: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	ip, err := c.failoverService.Route(r.Context(), req.IP, req.ServerID)
+	if err != nil {
+		writeFailoverError(w, err, "route public IP")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(newPublicIPResponse(*ip)); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// GeneratePublicIPMAC creates a virtual MAC for a failover IP through the provider API.
+func (c *ServerController) GeneratePublicIPMAC(w http.ResponseWriter, r *http.Request) {
+	var req request.GeneratePublicIPMACRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	c.generatePublicIPMAC(w, r, req)
+}
+
+// generatePublicIPMAC creates a virtual MAC for the failover IP given in the request.
+func (c *ServerController) generatePublicIPMAC(w http.ResponseWriter, r *http.Request, req request.GeneratePublicIPMACRequest) {
+	// Validate the request
+	if req.IP == "" {
+		http.Error(w, "Invalid publicIP", http.StatusBadRequest)
+		return
+	}
+
+	if req.Type == "" {
+		req.Type = service.MACTypeKVM
+	}
+
+	if req.Type != service.MACTypeKVM && req.Type != service.MACTypeXen && req.Type != service.MACTypeVMware {
+		http.Error(w, "Invalid type", http.StatusBadRequest)
+		return
+	}
+
+	ip, err := c.failoverService.GenerateMAC(r.Context(), req.IP, req.Type)
+	if err != nil {
+		writeFailoverError(w, err, "generate MAC")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(newPublicIPResponse(*ip)); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// DeletePublicIPMAC deletes the virtual MAC of a failover IP through the provider API.
+func (c *ServerController) DeletePublicIPMAC(w http.ResponseWriter, r *http.Request) {
+	var req request.DeletePublicIPMACRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	c.deletePublicIPMAC(w, r, req)
+}
+
+// deletePublicIPMAC deletes the virtual MAC of the failover IP given in the request.
+func (c *ServerController) deletePublicIPMAC(w http.ResponseWriter, r *http.Request, req request.DeletePublicIPMACRequest) {
+	// Validate the request
+	if req.IP == "" {
+		http.Error(w, "Invalid publicIP", http.StatusBadRequest)
+		return
+	}
+
+	if err := c.failoverService.DeleteMAC(r.Context(), req.IP); err != nil {
+		writeFailoverError(w, err, "delete MAC")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// writeFailoverError writes the status matching an error returned while managing a failover IP.
+func writeFailoverError(w http.ResponseWriter, err error, action string) {
+	var apiErr *service.APIError
+
+	switch {
+	case errors.Is(err, mongo.ErrNoDocuments):
+		http.Error(w, "Public IP not found", http.StatusNotFound)
+	case errors.Is(err, service.ErrUnmanagedIP):
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+	case errors.Is(err, service.ErrProviderMismatch):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, service.ErrNotSupported):
+		http.Error(w, err.Error(), http.StatusNotImplemented)
+	case errors.As(err, &apiErr):
+		http.Error(w, fmt.Sprintf("Failed to %s: %v", action, err), http.StatusBadGateway)
+	default:
+		http.Error(w, fmt.Sprintf("Failed to %s: %v", action, err), http.StatusInternalServerError)
+	}
+}
+
+// newPublicIPResponse converts a public IP into its response representation.
+func newPublicIPResponse(ip entity.IPInfo) response.PublicIPResponse {
+	resp := response.PublicIPResponse{
+		IP:          ip.IP,
+		ServerID:    ip.ServerID,
+		Available:   ip.Available,
+		Provider:    ip.Provider,
+		Destination: ip.Destination,
+		MAC:         ip.MAC,
+		Project:     ip.Project,
+	}
+
+	if !ip.SyncedAt.IsZero() {
+		resp.SyncedAt = &ip.SyncedAt
+	}
+
+	return resp
+}
@@ -0,0 +1,150 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/sychonet/vdash-be/db/entity"
+	request "github.com/sychonet/vdash-be/dto/request"
+	response "github.com/sychonet/vdash-be/dto/response"
+	"github.com/sychonet/vdash-be/service"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// CreateAPIKey creates an API key. The key is part of the response and cannot be retrieved afterwards.
+func (c *ServerController) CreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	var req request.CreateAPIKeyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if req.Name == "" {
+		http.Error(w, "Invalid name", http.StatusBadRequest)
+		return
+	}
+
+	// Granting roles through a key needs the permission to grant roles, so that keys cannot be used to escalate privileges
+	if identity, ok := service.IdentityFromContext(r.Context()); ok && len(req.Roles) > 0 && !c.authorize(w, r, identity, service.ResourceRoles+":"+service.VerbCreate, "") {
+		return
+	}
+
+	err := c.rbacService.ValidateRoles(r.Context(), req.Roles)
+	if errors.Is(err, service.ErrUnknownRole) {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to validate roles: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	var expiresAt time.Time
+	if req.ExpiresAt != nil {
+		if !req.ExpiresAt.After(time.Now()) {
+			http.Error(w, "Invalid expiresAt, it must be in the future", http.StatusBadRequest)
+			return
+		}
+		expiresAt = req.ExpiresAt.UTC()
+	}
+
+	credential, key, err := c.authService.CreateAPIKey(r.Context(), req.Name, req.Roles, expiresAt, service.Actor(r.Context()))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to create API key: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(response.CreateAPIKeyResponse{APIKeyResponse: newAPIKeyResponse(*key), Key: credential}); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// GetAPIKeys returns every API key, including the revoked ones, without the keys themselves.
+func (c *ServerController) GetAPIKeys(w http.ResponseWriter, r *http.Request) {
+	keys, err := c.authService.GetAPIKeys(r.Context())
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to get API keys: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	// Prepare the response
+	resp := []response.APIKeyResponse{}
+	for _, key := range keys {
+		resp = append(resp, newAPIKeyResponse(key))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// RevokeAPIKey revokes an API key. The key is kept so that it still shows up in the list of keys.
+func (c *ServerController) RevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	var req request.RevokeAPIKeyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	c.revokeAPIKey(w, r, req)
+}
+
+// revokeAPIKey revokes the API key given in the request.
+func (c *ServerController) revokeAPIKey(w http.ResponseWriter, r *http.Request, req request.RevokeAPIKeyRequest) {
+	id, err := primitive.ObjectIDFromHex(req.ID)
+	if err != nil {
+		http.Error(w, "Invalid id", http.StatusBadRequest)
+		return
+	}
+
+	err = c.authService.RevokeAPIKey(r.Context(), id)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		http.Error(w, "API key not found", http.StatusNotFound)
+		return
+	}
+
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to revoke API key: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// newAPIKeyResponse converts an API key into its response representation.
+func newAPIKeyResponse(key entity.APIKey) response.APIKeyResponse {
+	resp := response.APIKeyResponse{
+		ID:        key.ID.Hex(),
+		Name:      key.Name,
+		Prefix:    key.Prefix,
+		Roles:     key.Roles,
+		CreatedBy: key.CreatedBy,
+		CreatedAt: key.CreatedAt,
+	}
+
+	if resp.Roles == nil {
+		resp.Roles = []string{}
+	}
+
+	if !key.ExpiresAt.IsZero() {
+		resp.ExpiresAt = &key.ExpiresAt
+	}
+
+	if !key.LastUsedAt.IsZero() {
+		resp.LastUsedAt = &key.LastUsedAt
+	}
+
+	if !key.RevokedAt.IsZero() {
+		resp.RevokedAt = &key.RevokedAt
+	}
+
+	return resp
+}
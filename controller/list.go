@@ -0,0 +1,184 @@
+package controller
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	response "github.com/sychonet/vdash-be/dto/response"
+)
+
+// Bounds of the number of items of a page of a v2 list.
+const (
+	defaultPageSize = 100
+	maxPageSize     = 1000
+)
+
+// listFilter holds the filters of a list request, given by the name, state, label and serverID query parameters. Name matches a prefix of the name, labels are given as key=value and must all match. Empty filters match every item.
+type listFilter struct {
+	Name     string
+	State    string
+	Labels   map[string]string
+	ServerID int
+}
+
+// parseListFilter reads the filters of a list request. If one is invalid an error response is written and false is returned.
+func parseListFilter(w http.ResponseWriter, r *http.Request) (listFilter, bool) {
+	query := r.URL.Query()
+	filter := listFilter{
+		Name:   query.Get("name"),
+		State:  query.Get("state"),
+		Labels: map[string]string{},
+	}
+
+	for _, label := range query["label"] {
+		key, value, ok := strings.Cut(label, "=")
+		if !ok || key == "" {
+			http.Error(w, "Invalid label query parameter, it must be key=value", http.StatusBadRequest)
+			return filter, false
+		}
+		filter.Labels[key] = value
+	}
+
+	if serverIDParam := query.Get("serverID"); serverIDParam != "" {
+		id, err := strconv.Atoi(serverIDParam)
+		if err != nil {
+			http.Error(w, "Invalid serverID query parameter", http.StatusBadRequest)
+			return filter, false
+		}
+		filter.ServerID = id
+	}
+
+	return filter, true
+}
+
+func (f listFilter) matchName(name string) bool {
+	return strings.HasPrefix(name, f.Name)
+}
+
+func (f listFilter) matchState(state string) bool {
+	return f.State == "" || f.State == state
+}
+
+func (f listFilter) matchLabels(labels map[string]string) bool {
+	for key, value := range f.Labels {
+		if labels[key] != value {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (f listFilter) matchServer(serverID int) bool {
+	return f.ServerID == 0 || f.ServerID == serverID
+}
+
+// listOrder describes how the items of a list are sorted. Fields maps the fields given by the sort query parameter, prefixed with - for a descending order, to the comparison of two items. Key identifies an item, it breaks ties and positions the cursors.
+type listOrder[T any] struct {
+	key         func(T) string
+	fields      map[string]func(a, b T) int
+	defaultSort string
+}
+
+// pageCursor is the position after the last item of a page. Offset is used if that item is gone when the next page is requested.
+type pageCursor struct {
+	Sort   string `json:"s"`
+	Key    string `json:"k"`
+	Offset int    `json:"o"`
+}
+
+// writeList sorts the items of a list and writes them. v2 requests get the page selected by the limit and cursor query parameters in a ListResponse, v1 requests keep getting every item in a bare array.
+func writeList[T any](w http.ResponseWriter, r *http.Request, items []T, order listOrder[T]) {
+	query := r.URL.Query()
+
+	sortParam := query.Get("sort")
+	if sortParam == "" {
+		sortParam = order.defaultSort
+	}
+
+	field, desc := strings.CutPrefix(sortParam, "-")
+	compare, ok := order.fields[field]
+	if !ok {
+		fields := slices.Sorted(maps.Keys(order.fields))
+		http.Error(w, "Invalid sort query parameter, it must be one of "+strings.Join(fields, ", "), http.StatusBadRequest)
+		return
+	}
+
+	slices.SortStableFunc(items, func(a, b T) int {
+		result := compare(a, b)
+		if result == 0 {
+			result = strings.Compare(order.key(a), order.key(b))
+		}
+
+		if desc {
+			return -result
+		}
+		return result
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+
+	if !strings.HasPrefix(r.URL.Path, "/v2/") {
+		if err := json.NewEncoder(w).Encode(items); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	limit := defaultPageSize
+	if limitParam := query.Get("limit"); limitParam != "" {
+		l, err := strconv.Atoi(limitParam)
+		if err != nil || l <= 0 || l > maxPageSize {
+			http.Error(w, fmt.Sprintf("Invalid limit query parameter, it must be between 1 and %d", maxPageSize), http.StatusBadRequest)
+			return
+		}
+		limit = l
+	}
+
+	start := 0
+	if cursorParam := query.Get("cursor"); cursorParam != "" {
+		cursor, err := decodeCursor(cursorParam)
+		if err != nil || cursor.Sort != sortParam || cursor.Offset < 0 {
+			http.Error(w, "Invalid cursor query parameter", http.StatusBadRequest)
+			return
+		}
+
+		start = min(cursor.Offset, len(items))
+		if i := slices.IndexFunc(items, func(item T) bool { return order.key(item) == cursor.Key }); i >= 0 {
+			start = i + 1
+		}
+	}
+
+	end := min(start+limit, len(items))
+	page := response.ListResponse[T]{Items: append([]T{}, items[start:end]...), Total: len(items)}
+	if end < len(items) {
+		page.NextCursor = encodeCursor(pageCursor{Sort: sortParam, Key: order.key(items[end-1]), Offset: end})
+	}
+
+	if err := json.NewEncoder(w).Encode(page); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// encodeCursor returns the opaque form of a cursor given to clients.
+func encodeCursor(cursor pageCursor) string {
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(value string) (pageCursor, error) {
+	var cursor pageCursor
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return cursor, err
+	}
+
+	err = json.Unmarshal(data, &cursor)
+	return cursor, err
+}
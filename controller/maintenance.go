@@ -0,0 +1,175 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/sychonet/vdash-be/db/entity"
+	request "github.com/sychonet/vdash-be/dto/request"
+	response "github.com/sychonet/vdash-be/dto/response"
+	"github.com/sychonet/vdash-be/service"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// CordonServer marks a server as cordoned so that the scheduler no longer places new resources on it.
+func (c *ServerController) CordonServer(w http.ResponseWriter, r *http.Request) {
+	var req request.CordonServerRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	c.setCordoned(w, r, req.ID, true)
+}
+
+// UncordonServer makes a cordoned server available to the scheduler again.
+func (c *ServerController) UncordonServer(w http.ResponseWriter, r *http.Request) {
+	var req request.CordonServerRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	c.setCordoned(w, r, req.ID, false)
+}
+
+// setCordoned updates the cordon flag of the server.
+func (c *ServerController) setCordoned(w http.ResponseWriter, r *http.Request, id int, cordoned bool) {
+	// Validate the request
+	if id <= 0 {
+		http.Error(w, "Invalid id", http.StatusBadRequest)
+		return
+	}
+
+	err := c.dbService.SetServerCordoned(r.Context(), id, cordoned)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		http.Error(w, "Server not found", http.StatusNotFound)
+		return
+	}
+
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to update server: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// DrainServer cordons a server and starts moving all its domains to other servers. The drain runs in the background, its progress is returned by GetDrain.
+func (c *ServerController) DrainServer(w http.ResponseWriter, r *http.Request) {
+	var req request.DrainServerRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	c.drainServer(w, r, req)
+}
+
+// drainServer starts draining the server given in the request.
+func (c *ServerController) drainServer(w http.ResponseWriter, r *http.Request, req request.DrainServerRequest) {
+	// Validate the request
+	if req.ID <= 0 {
+		http.Error(w, "Invalid id", http.StatusBadRequest)
+		return
+	}
+
+	drain, err := c.drainService.Drain(r.Context(), req.ID, req.Live)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		http.Error(w, "Server not found", http.StatusNotFound)
+		return
+	}
+
+	if errors.Is(err, service.ErrDrainRunning) {
+		http.Error(w, "Server is already being drained", http.StatusConflict)
+		return
+	}
+
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to drain server: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	if err := json.NewEncoder(w).Encode(newDrainResponse(*drain)); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// GetDrain returns the progress of the latest drain of a server.
+func (c *ServerController) GetDrain(w http.ResponseWriter, r *http.Request) {
+	idParam := r.URL.Query().Get("id")
+	if idParam == "" {
+		http.Error(w, "Missing id query parameter", http.StatusBadRequest)
+		return
+	}
+
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		http.Error(w, "Invalid id query parameter", http.StatusBadRequest)
+		return
+	}
+
+	c.getDrain(w, r, id)
+}
+
+// getDrain writes the progress of the latest drain of the server.
+func (c *ServerController) getDrain(w http.ResponseWriter, r *http.Request, id int) {
+	drain, err := c.dbService.GetDrain(r.Context(), id)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		http.Error(w, "No drain found for server", http.StatusNotFound)
+		return
+	}
+
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to get drain: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(newDrainResponse(*drain)); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// newDrainResponse converts the progress of a drain into its response representation.
+func newDrainResponse(drain entity.DrainInfo) response.DrainResponse {
+	resp := response.DrainResponse{
+		ServerID:   drain.ServerID,
+		Live:       drain.Live,
+		Status:     drain.Status,
+		StartedAt:  drain.StartedAt,
+		Total:      len(drain.Domains),
+		Domains:    []response.DrainDomainResponse{},
+		Violations: []response.GroupViolationResponse{},
+		Error:      drain.Error,
+	}
+
+	if !drain.FinishedAt.IsZero() {
+		resp.FinishedAt = &drain.FinishedAt
+	}
+
+	for _, domain := range drain.Domains {
+		switch domain.Status {
+		case service.DrainDomainMigrated:
+			resp.Migrated++
+		case service.DrainDomainFailed:
+			resp.Failed++
+		}
+
+		resp.Domains = append(resp.Domains, response.DrainDomainResponse{
+			Name:           domain.Name,
+			TargetServerID: domain.TargetServerID,
+			Status:         domain.Status,
+			Error:          domain.Error,
+		})
+	}
+
+	resp.Violations = append(resp.Violations, newGroupViolationResponses(drain.Violations)...)
+
+	return resp
+}
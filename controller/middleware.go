@@ -0,0 +1,357 @@
+package controller
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/sychonet/vdash-be/db/entity"
+	"github.com/sychonet/vdash-be/service"
+)
+
+// Authenticate rejects requests without valid credentials and stores the identity of the caller in the request context. The credentials are read from the Authorization header as a bearer token or from the X-API-Key header.
+func (c *ServerController) Authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		identity, err := c.authService.Authenticate(r.Context(), credential(r))
+		if errors.Is(err, service.ErrUnauthenticated) {
+			slog.Warn("Rejected unauthenticated request", "method", r.Method, "path", r.URL.Path, "remote", r.RemoteAddr, "error", err)
+			w.Header().Set("WWW-Authenticate", `Bearer realm="vdash"`)
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		if err != nil {
+			http.Error(w, "Failed to authenticate request", http.StatusInternalServerError)
+			return
+		}
+
+		slog.Info("Authenticated request", "method", r.Method, "path", r.URL.Path, "subject", identity.Subject, "auth", identity.Method)
+		next.ServeHTTP(w, r.WithContext(service.WithIdentity(r.Context(), identity)))
+	})
+}
+
+// credential returns the API key or bearer token presented by the request.
+func credential(r *http.Request) string {
+	if key := r.Header.Get("X-API-Key"); key != "" {
+		return key
+	}
+
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return ""
+	}
+
+	return strings.TrimSpace(token)
+}
+
+// Require rejects requests whose caller is not granted the permission, written resource:verb. The permission of a project scoped route is required in the project of the request, the one of other routes in every project, so that a role bound in a project does not reach the servers, public IPs and access settings shared by every project. Requests without an identity are let through as they only reach the handlers when authentication is disabled.
+func (c *ServerController) Require(permission string, projectScoped bool) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			identity, ok := service.IdentityFromContext(r.Context())
+			if !ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			project := ""
+			if projectScoped {
+				project = projectOf(r)
+			}
+
+			if !c.authorize(w, r, identity, permission, project) {
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// authorize checks that the caller is granted the permission in the project, an empty project standing for every project. If it is not an error response is written and false is returned.
+func (c *ServerController) authorize(w http.ResponseWriter, r *http.Request, identity *service.Identity, permission, project string) bool {
+	err := c.rbacService.Authorize(r.Context(), identity, permission, project)
+	if errors.Is(err, service.ErrForbidden) {
+		slog.Warn("Rejected unauthorized request", "method", r.Method, "path", r.URL.Path, "subject", identity.Subject, "error", err)
+		http.Error(w, "Forbidden: "+err.Error(), http.StatusForbidden)
+		return false
+	}
+
+	if err != nil {
+		http.Error(w, "Failed to authorize request", http.StatusInternalServerError)
+		return false
+	}
+
+	return true
+}
+
+// authorizeGlobally checks that the caller is granted the permission in every project, for the requests of project scoped routes which change what every project shares. If it is not an error response is written and false is returned.
+func (c *ServerController) authorizeGlobally(w http.ResponseWriter, r *http.Request, permission string) bool {
+	identity, ok := service.IdentityFromContext(r.Context())
+	if !ok {
+		return true
+	}
+
+	return c.authorize(w, r, identity, permission, "")
+}
+
+// requestProject returns the project named by a request, given by the project of the path of v2 project routes, the X-Project header or the project query parameter. It is empty if the request names no project.
+func requestProject(r *http.Request) string {
+	if project := chi.URLParam(r, "project"); project != "" {
+		return project
+	}
+
+	if project := r.Header.Get("X-Project"); project != "" {
+		return project
+	}
+
+	return r.URL.Query().Get("project")
+}
+
+// projectOf returns the project owning the resources a request creates or looks up, the default project if the request names none.
+func projectOf(r *http.Request) string {
+	if project := requestProject(r); project != "" {
+		return project
+	}
+
+	return service.DefaultProject
+}
+
+// Audit records every POST, PUT, PATCH and DELETE request in the audit log once it has been handled, along with its caller, the resources it acted on and its outcome. A failure to record an entry is logged but does not fail the request.
+func (c *ServerController) Audit(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		default:
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		start := time.Now()
+
+		// Keep the beginning of the body for the audit entry and hand the whole body to the handler
+		body, err := io.ReadAll(io.LimitReader(r.Body, service.MaxAuditBody+1))
+		if err != nil {
+			http.Error(w, "Failed to read request body", http.StatusBadRequest)
+			return
+		}
+		truncated := len(body) > service.MaxAuditBody
+		r.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(body), r.Body), r.Body}
+
+		recorder := &responseRecorder{ResponseWriter: w, status: http.StatusOK, limit: service.MaxAuditBody}
+		next.ServeHTTP(recorder, r)
+
+		if truncated {
+			body = body[:service.MaxAuditBody]
+		}
+
+		entry := entity.AuditEntry{
+			Time:       start.UTC(),
+			SourceIP:   sourceIP(r),
+			Method:     r.Method,
+			Route:      r.URL.Path,
+			Path:       r.URL.Path,
+			Project:    requestProject(r),
+			Body:       service.SanitizeBody(body, truncated),
+			Status:     recorder.status,
+			Outcome:    service.AuditSuccess,
+			DurationMS: time.Since(start).Milliseconds(),
+		}
+
+		if identity, ok := service.IdentityFromContext(r.Context()); ok {
+			entry.Actor = identity.Subject
+			entry.AuthMethod = identity.Method
+		}
+
+		// The resources of v2 requests are named in the path, the ones of v1 requests in the body
+		var ids []string
+		if rctx := chi.RouteContext(r.Context()); rctx != nil {
+			if pattern := rctx.RoutePattern(); pattern != "" {
+				entry.Route = pattern
+			}
+
+			for i, key := range rctx.URLParams.Keys {
+				if key == "serverID" {
+					entry.ServerID, _ = strconv.Atoi(rctx.URLParams.Values[i])
+					continue
+				}
+
+				if key != "*" {
+					ids = append(ids, key+":"+rctx.URLParams.Values[i])
+				}
+			}
+		}
+
+		// Handlers on /v1/servers take the id of the server rather than a serverID
+		if entry.ServerID == 0 {
+			entry.ServerID = service.ServerIDOf(body, strings.HasPrefix(r.URL.Path, "/v1/servers"))
+		}
+
+		if entry.ServerID == 0 {
+			entry.ServerID = service.ServerIDOf(recorder.body.Bytes(), false)
+		}
+
+		ids = append(ids, service.ResourceIDs(body)...)
+		ids = append(ids, service.ResourceIDs(recorder.body.Bytes())...)
+		seen := map[string]bool{}
+		for _, id := range ids {
+			if !seen[id] {
+				seen[id] = true
+				entry.ResourceIDs = append(entry.ResourceIDs, id)
+			}
+		}
+
+		if recorder.status >= http.StatusBadRequest {
+			entry.Outcome = service.AuditFailure
+			entry.Error = strings.TrimSpace(recorder.body.String())
+		}
+
+		// Record the entry even if the caller went away, the request has been handled anyway
+		if err := c.auditService.Record(context.WithoutCancel(r.Context()), entry); err != nil {
+			slog.Error("Failed to record audit entry", "method", r.Method, "path", r.URL.Path, "error", err)
+		}
+	})
+}
+
+// responseRecorder captures the status and the first limit bytes of the body of a response while writing it. Truncated is set if the body is longer.
+type responseRecorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	limit       int
+	body        bytes.Buffer
+	truncated   bool
+}
+
+func (rr *responseRecorder) WriteHeader(status int) {
+	if !rr.wroteHeader {
+		rr.status = status
+		rr.wroteHeader = true
+	}
+	rr.ResponseWriter.WriteHeader(status)
+}
+
+func (rr *responseRecorder) Write(data []byte) (int, error) {
+	rr.wroteHeader = true
+	remaining := rr.limit - rr.body.Len()
+	if len(data) > remaining {
+		rr.truncated = true
+	}
+
+	if remaining > 0 {
+		rr.body.Write(data[:min(len(data), remaining)])
+	}
+
+	return rr.ResponseWriter.Write(data)
+}
+
+// sourceIP returns the address of the client which sent the request, without its port.
+func sourceIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+
+	return host
+}
+
+// Limits of the Idempotent middleware. maxIdempotentRequest is the size of the largest request body read to fingerprint a request, larger requests are rejected. maxIdempotentResponse is the size of the largest response kept to be replayed, the keys of requests with larger responses are released.
+const (
+	maxIdempotentRequest  = 1 << 20
+	maxIdempotentResponse = 1 << 20
+)
+
+// Idempotent replays the response to the first request made with the same Idempotency-Key header instead of handling a retry again. A key sent again with a different request is rejected with 422, and with 409 while its first request is still being handled. Only successful responses are kept, so that a request refused with a client or server error, such as one exceeding the quota of its project, can be retried once the problem is solved.
+func (c *ServerController) Idempotent(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get("Idempotency-Key")
+		if key == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if len(key) > service.MaxIdempotencyKey {
+			http.Error(w, fmt.Sprintf("Invalid Idempotency-Key header, it must not be longer than %d characters", service.MaxIdempotencyKey), http.StatusBadRequest)
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxIdempotentRequest))
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			http.Error(w, fmt.Sprintf("Request body is larger than %d bytes", tooLarge.Limit), http.StatusRequestEntityTooLarge)
+			return
+		}
+
+		if err != nil {
+			http.Error(w, "Failed to read request body", http.StatusBadRequest)
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		record, err := c.idempotencyService.Begin(r.Context(), service.Actor(r.Context()), key, r.Method, r.URL.Path, projectOf(r), body)
+		if errors.Is(err, service.ErrIdempotencyKeyReused) {
+			http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+			return
+		}
+
+		if errors.Is(err, service.ErrIdempotencyInProgress) {
+			w.Header().Set("Retry-After", "1")
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+
+		if err != nil {
+			http.Error(w, "Failed to check Idempotency-Key", http.StatusInternalServerError)
+			return
+		}
+
+		if record.Completed {
+			if record.ContentType != "" {
+				w.Header().Set("Content-Type", record.ContentType)
+			}
+			w.Header().Set("Idempotent-Replayed", "true")
+			w.WriteHeader(record.Status)
+			if _, err := w.Write(record.Body); err != nil {
+				slog.Error("Failed to replay response", "method", r.Method, "path", r.URL.Path, "error", err)
+			}
+			return
+		}
+
+		recorder := &responseRecorder{ResponseWriter: w, status: http.StatusOK, limit: maxIdempotentResponse}
+		next.ServeHTTP(recorder, r)
+
+		// The response has been sent, so the record is updated even if the caller went away
+		ctx := context.WithoutCancel(r.Context())
+		if recorder.status < 200 || recorder.status > 299 || recorder.truncated {
+			if err := c.idempotencyService.Release(ctx, record); err != nil {
+				slog.Error("Failed to release Idempotency-Key", "method", r.Method, "path", r.URL.Path, "error", err)
+			}
+			return
+		}
+
+		if err := c.idempotencyService.Complete(ctx, record, recorder.status, w.Header().Get("Content-Type"), recorder.body.Bytes()); err != nil {
+			slog.Error("Failed to store response of Idempotency-Key", "method", r.Method, "path", r.URL.Path, "error", err)
+		}
+	})
+}
+
+// Deprecated marks the responses of the v1 routes as deprecated. The v1 routes keep working while clients move to the v2 routes, which name the resources in the path rather than in the body of GET and DELETE requests.
+func Deprecated(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Deprecation", "true")
+		w.Header().Set("Link", `</v2>; rel="successor-version"`)
+		next.ServeHTTP(w, r)
+	})
+}
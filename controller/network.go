@@ -2,12 +2,19 @@ package controller
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"log/slog"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
+	"github.com/sychonet/vdash-be/db/entity"
 	request "github.com/sychonet/vdash-be/dto/request"
 	response "github.com/sychonet/vdash-be/dto/response"
+	"github.com/sychonet/vdash-be/service"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
 // CreateNetwork creates a new network on a server using the provided request.
@@ -18,12 +25,22 @@ func (c *ServerController) CreateNetwork(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	c.createNetwork(w, r, req)
+}
+
+// createNetwork creates the network described by the request.
+func (c *ServerController) createNetwork(w http.ResponseWriter, r *http.Request, req request.NetworkRequest) {
 	// Validate the request
 	if req.Name == "" {
 		http.Error(w, "Invalid name", http.StatusBadRequest)
 		return
 	}
 
+	if err := service.ValidateName(req.Name); err != nil {
+		http.Error(w, "Invalid name: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
 	if req.Bridge == "" {
 		http.Error(w, "Invalid bridge", http.StatusBadRequest)
 		return
@@ -34,21 +51,62 @@ func (c *ServerController) CreateNetwork(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	project, ok := c.project(w, r)
+	if !ok {
+		return
+	}
+
+	release, ok := c.reserveQuota(w, r, project, service.ResourceUsage{Networks: 1})
+	if !ok {
+		return
+	}
+	defer release()
+
 	// Get the libvirt URI from the database
-	serverDetail, err := c.dbService.GetServer(req.ServerID)
+	serverDetail, err := c.dbService.GetServer(r.Context(), req.ServerID)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Failed to get server details: %v", err), http.StatusInternalServerError)
 		return
 	}
 
-	c.libvirtService.URI = serverDetail.LibvirtURI
+	// Networks of different projects may share a name on a server as the project is part of the libvirt name
+	libvirtName := service.LibvirtName(project, req.Name)
+	existing, err := c.dbService.GetNetworkByLibvirtName(r.Context(), req.ServerID, libvirtName)
+	if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+		http.Error(w, fmt.Sprintf("Failed to check network name: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	if existing != nil {
+		http.Error(w, "Network already exists", http.StatusConflict)
+		return
+	}
+
+	libvirtService := c.libvirtService.Connect(serverDetail.LibvirtURI)
 
-	err = c.libvirtService.CreateNetwork(req.Name, req.Bridge)
+	err = libvirtService.CreateNetwork(r.Context(), libvirtName, req.Bridge, service.Owner{Project: project, Name: req.Name})
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Failed to create network: %v", err), http.StatusInternalServerError)
 		return
 	}
 
+	network := entity.NetworkInfo{
+		ServerID:    req.ServerID,
+		Name:        req.Name,
+		LibvirtName: libvirtName,
+		Project:     project,
+		Bridge:      req.Bridge,
+		CreatedAt:   time.Now().UTC(),
+	}
+	if err := c.dbService.AddNetwork(r.Context(), network); err != nil {
+		// A network without a record belongs to no project, so it is removed again
+		if err := libvirtService.DeleteNetwork(r.Context(), libvirtName); err != nil {
+			slog.Error("Failed to remove unrecorded network " + libvirtName + ": " + err.Error())
+		}
+		http.Error(w, fmt.Sprintf("Failed to record network: %v", err), http.StatusInternalServerError)
+		return
+	}
+
 	// Prepare the response
 	resp := response.NetworkResponse{
 		Name:   req.Name,
@@ -77,16 +135,43 @@ func (c *ServerController) GetNetworks(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	c.getNetworks(w, r, serverID)
+}
+
+// getNetworks lists the networks of the project of the request on the server. They can be filtered by a prefix of their name and their state, active or inactive, and sorted by name.
+func (c *ServerController) getNetworks(w http.ResponseWriter, r *http.Request, serverID int) {
+	filter, ok := parseListFilter(w, r)
+	if !ok {
+		return
+	}
+
+	project, ok := c.project(w, r)
+	if !ok {
+		return
+	}
+
 	// Get the libvirt URI from the database
-	serverDetail, err := c.dbService.GetServer(serverID)
+	serverDetail, err := c.dbService.GetServer(r.Context(), serverID)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Failed to get server details: %v", err), http.StatusInternalServerError)
 		return
 	}
 
-	c.libvirtService.URI = serverDetail.LibvirtURI
+	// Get the owners of the networks created through vdash on the server
+	records, err := c.dbService.GetNetworksOnServer(r.Context(), serverID)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to get network owners: %v", err), http.StatusInternalServerError)
+		return
+	}
 
-	networks, err := c.libvirtService.GetNetworks()
+	owners := map[string]service.Owner{}
+	for _, record := range records {
+		owners[record.LibvirtName] = service.Owner{Project: record.Project, Name: record.Name}
+	}
+
+	libvirtService := c.libvirtService.Connect(serverDetail.LibvirtURI)
+
+	networks, err := libvirtService.GetNetworks(r.Context())
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Failed to list networks: %v", err), http.StatusInternalServerError)
 		return
@@ -101,22 +186,47 @@ func (c *ServerController) GetNetworks(w http.ResponseWriter, r *http.Request) {
 	// Prepare the response
 	var resp []response.NetworkResponse
 	for _, network := range networks {
-		name, err := network.GetName()
+		libvirtName, err := network.GetName()
 		if err != nil {
 			http.Error(w, fmt.Sprintf("Failed to get network name: %v", err), http.StatusInternalServerError)
 			return
 		}
 
+		// Only show the networks of the project
+		name, ok := service.VisibleName(owners, libvirtName, project)
+		if !ok || !filter.matchName(name) {
+			continue
+		}
+
+		active, err := network.IsActive()
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Failed to get network state: %v", err), http.StatusInternalServerError)
+			return
+		}
+
+		state := "inactive"
+		if active {
+			state = "active"
+		}
+
+		if !filter.matchState(state) {
+			continue
+		}
+
 		resp = append(resp, response.NetworkResponse{
 			Name:   name,
 			Bridge: name,
+			State:  state,
 		})
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(resp); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-	}
+	writeList(w, r, resp, listOrder[response.NetworkResponse]{
+		key: func(n response.NetworkResponse) string { return n.Name },
+		fields: map[string]func(a, b response.NetworkResponse) int{
+			"name": func(a, b response.NetworkResponse) int { return strings.Compare(a.Name, b.Name) },
+		},
+		defaultSort: "name",
+	})
 }
 
 // DeleteNetwork deletes a network on a server using the provided request.
@@ -127,6 +237,11 @@ func (c *ServerController) DeleteNetwork(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	c.deleteNetwork(w, r, req)
+}
+
+// deleteNetwork deletes the network given in the request.
+func (c *ServerController) deleteNetwork(w http.ResponseWriter, r *http.Request, req request.NetworkRequest) {
 	// Validate the request
 	if req.Name == "" {
 		http.Error(w, "Invalid name", http.StatusBadRequest)
@@ -138,20 +253,46 @@ func (c *ServerController) DeleteNetwork(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	project, ok := c.project(w, r)
+	if !ok {
+		return
+	}
+
 	// Get the libvirt URI from the database
-	serverDetail, err := c.dbService.GetServer(req.ServerID)
+	serverDetail, err := c.dbService.GetServer(r.Context(), req.ServerID)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Failed to get server details: %v", err), http.StatusInternalServerError)
 		return
 	}
 
-	c.libvirtService.URI = serverDetail.LibvirtURI
+	// Refuse to delete a network of another project, or one without an ownership record unless allowed in every project
+	libvirtName := service.LibvirtName(project, req.Name)
+	record, err := c.dbService.GetNetworkByLibvirtName(r.Context(), req.ServerID, libvirtName)
+	if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+		http.Error(w, fmt.Sprintf("Failed to get network owner: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	var owner string
+	if record != nil {
+		owner = record.Project
+	}
+
+	if !c.checkOwner(w, r, owner, project, service.ResourceNetworks+":"+service.VerbDelete, "Network not found") {
+		return
+	}
+
+	libvirtService := c.libvirtService.Connect(serverDetail.LibvirtURI)
 
-	err = c.libvirtService.DeleteNetwork(req.Name)
+	err = libvirtService.DeleteNetwork(r.Context(), libvirtName)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Failed to delete network: %v", err), http.StatusInternalServerError)
 		return
 	}
 
+	if err := c.dbService.DeleteNetworkByName(r.Context(), req.ServerID, libvirtName); err != nil {
+		slog.Error("Failed to delete record of network " + libvirtName + ": " + err.Error())
+	}
+
 	w.WriteHeader(http.StatusNoContent)
 }
@@ -0,0 +1,64 @@
+package controller
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/sychonet/vdash-be/openapi"
+)
+
+//go:embed docs.html
+var docsPage []byte
+
+// NewOpenAPIDocument returns the OpenAPI document describing the routes.
+func NewOpenAPIDocument(routes []openapi.Route) *openapi.Document {
+	return openapi.New(openapi.Info{
+		Title:       "vdash API",
+		Description: "Manages libvirt servers and the domains, volumes, networks and public IPs running on them. Errors are returned as plain text.",
+		Version:     "2",
+	}, routes)
+}
+
+// ServeOpenAPI returns a handler writing the OpenAPI document.
+func ServeOpenAPI(document *openapi.Document) http.HandlerFunc {
+	// The document does not change once the routes are registered
+	data, err := json.MarshalIndent(document, "", "  ")
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(data)
+	}
+}
+
+// ServeDocs writes a page rendering the OpenAPI document with Redoc.
+func ServeDocs(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write(docsPage)
+}
+
+// Validate rejects the requests whose query parameters or body do not match the route in the OpenAPI document, before they reach the handler.
+func Validate(validator *openapi.Validator) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			err := validator.Validate(r)
+			var invalid *openapi.ValidationError
+			if errors.As(err, &invalid) {
+				http.Error(w, invalid.Error(), http.StatusBadRequest)
+				return
+			}
+
+			if err != nil {
+				http.Error(w, "Failed to read request body", http.StatusBadRequest)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,39 @@
+package controller
+
+import (
+	"github.com/sychonet/vdash-be/db/entity"
+	request "github.com/sychonet/vdash-be/dto/request"
+	"github.com/sychonet/vdash-be/service"
+)
+
+// newPlacement converts the placement fields of a create request into a validated scheduler placement.
+func newPlacement(nodeSelector map[string]string, required, preferred []request.PlacementConstraint) (service.Placement, error) {
+	placement := service.Placement{NodeSelector: nodeSelector}
+
+	for _, constraint := range required {
+		c := entity.PlacementConstraint{
+			Key:      constraint.Key,
+			Operator: constraint.Operator,
+			Values:   constraint.Values,
+		}
+		if err := service.ValidateConstraint(c); err != nil {
+			return placement, err
+		}
+		placement.Required = append(placement.Required, c)
+	}
+
+	for _, constraint := range preferred {
+		c := entity.PlacementConstraint{
+			Key:      constraint.Key,
+			Operator: constraint.Operator,
+			Values:   constraint.Values,
+			Weight:   constraint.Weight,
+		}
+		if err := service.ValidateConstraint(c); err != nil {
+			return placement, err
+		}
+		placement.Preferred = append(placement.Preferred, c)
+	}
+
+	return placement, nil
+}
@@ -0,0 +1,129 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/sychonet/vdash-be/db/entity"
+	request "github.com/sychonet/vdash-be/dto/request"
+	response "github.com/sychonet/vdash-be/dto/response"
+	"github.com/sychonet/vdash-be/service"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// BootServer powers on a server through the API of its provider.
+func (c *ServerController) BootServer(w http.ResponseWriter, r *http.Request) {
+	c.powerServer(w, r, service.PowerBoot)
+}
+
+// RebootServer reboots a server through the API of its provider.
+func (c *ServerController) RebootServer(w http.ResponseWriter, r *http.Request) {
+	c.powerServer(w, r, service.PowerReboot)
+}
+
+// ShutdownServer shuts down a server through the API of its provider.
+func (c *ServerController) ShutdownServer(w http.ResponseWriter, r *http.Request) {
+	c.powerServer(w, r, service.PowerShutdown)
+}
+
+// RescueServer boots a server in rescue mode through the API of its provider.
+func (c *ServerController) RescueServer(w http.ResponseWriter, r *http.Request) {
+	c.powerServer(w, r, service.PowerRescue)
+}
+
+// powerServer requests the power action for the server given in the URL. The request body with the reason of the action is optional.
+func (c *ServerController) powerServer(w http.ResponseWriter, r *http.Request, action string) {
+	id, err := strconv.Atoi(chi.URLParam(r, "serverID"))
+	if err != nil || id <= 0 {
+		http.Error(w, "Invalid serverID", http.StatusBadRequest)
+		return
+	}
+
+	var req request.PowerServerRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	record, err := c.powerService.Power(r.Context(), id, action, req.Reason, false)
+	var apiErr *service.APIError
+
+	switch {
+	case errors.Is(err, mongo.ErrNoDocuments):
+		http.Error(w, "Server not found", http.StatusNotFound)
+		return
+	case errors.Is(err, service.ErrUnmanagedServer):
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	case errors.Is(err, service.ErrNotSupported):
+		http.Error(w, err.Error(), http.StatusNotImplemented)
+		return
+	case errors.As(err, &apiErr):
+		http.Error(w, fmt.Sprintf("Failed to %s server: %v", action, err), http.StatusBadGateway)
+		return
+	case err != nil:
+		http.Error(w, fmt.Sprintf("Failed to %s server: %v", action, err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	if err := json.NewEncoder(w).Encode(newPowerActionResponse(*record)); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// GetPowerActions returns the latest power actions requested for the server given in the URL, newest first.
+func (c *ServerController) GetPowerActions(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "serverID"))
+	if err != nil || id <= 0 {
+		http.Error(w, "Invalid serverID", http.StatusBadRequest)
+		return
+	}
+
+	limit := 100
+	if limitParam := r.URL.Query().Get("limit"); limitParam != "" {
+		l, err := strconv.Atoi(limitParam)
+		if err != nil || l <= 0 {
+			http.Error(w, "Invalid limit query parameter", http.StatusBadRequest)
+			return
+		}
+		limit = l
+	}
+
+	actions, err := c.dbService.GetPowerActions(r.Context(), id, int64(limit))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to get power actions: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	// Prepare the response
+	resp := []response.PowerActionResponse{}
+	for _, action := range actions {
+		resp = append(resp, newPowerActionResponse(action))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// newPowerActionResponse converts a power action into its response representation.
+func newPowerActionResponse(action entity.PowerAction) response.PowerActionResponse {
+	return response.PowerActionResponse{
+		ServerID:  action.ServerID,
+		Provider:  action.Provider,
+		Action:    action.Action,
+		Reason:    action.Reason,
+		Automatic: action.Automatic,
+		Actor:     action.Actor,
+		Time:      action.Time,
+		Error:     action.Error,
+	}
+}
@@ -0,0 +1,229 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/sychonet/vdash-be/db/entity"
+	request "github.com/sychonet/vdash-be/dto/request"
+	response "github.com/sychonet/vdash-be/dto/response"
+	"github.com/sychonet/vdash-be/service"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// GetProjects returns every project including the default one.
+func (c *ServerController) GetProjects(w http.ResponseWriter, r *http.Request) {
+	projects, err := c.projectService.GetProjects(r.Context())
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to get projects: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	// Prepare the response
+	resp := []response.ProjectResponse{}
+	for _, project := range projects {
+		resp = append(resp, newProjectResponse(project))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// CreateProject creates a new project.
+func (c *ServerController) CreateProject(w http.ResponseWriter, r *http.Request) {
+	var req request.CreateProjectRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	project, err := c.projectService.CreateProject(r.Context(), req.Name, req.Description)
+
+	switch {
+	case errors.Is(err, service.ErrDefaultProject), errors.Is(err, service.ErrProjectExists):
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	case errors.Is(err, service.ErrInvalidProject):
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	case err != nil:
+		http.Error(w, fmt.Sprintf("Failed to create project: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(newProjectResponse(*project)); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// DeleteProject deletes a project which no longer owns any resource.
+func (c *ServerController) DeleteProject(w http.ResponseWriter, r *http.Request) {
+	var req request.DeleteProjectRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	c.deleteProject(w, r, req)
+}
+
+// deleteProject deletes the project given in the request.
+func (c *ServerController) deleteProject(w http.ResponseWriter, r *http.Request, req request.DeleteProjectRequest) {
+	err := c.projectService.DeleteProject(r.Context(), req.Name)
+
+	switch {
+	case errors.Is(err, mongo.ErrNoDocuments):
+		http.Error(w, "Project not found", http.StatusNotFound)
+		return
+	case errors.Is(err, service.ErrDefaultProject), errors.Is(err, service.ErrProjectInUse):
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	case err != nil:
+		http.Error(w, fmt.Sprintf("Failed to delete project: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetProjectQuota returns the usage of the project of the request against each limit of its quota.
+func (c *ServerController) GetProjectQuota(w http.ResponseWriter, r *http.Request) {
+	project, ok := c.project(w, r)
+	if !ok {
+		return
+	}
+
+	c.writeQuota(w, r, project)
+}
+
+// UpdateProjectQuota replaces the quota of the project of the request. Its route requires projects:update in every project, so that a project cannot raise its own quota.
+func (c *ServerController) UpdateProjectQuota(w http.ResponseWriter, r *http.Request) {
+	var req request.UpdateQuotaRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	project, ok := c.project(w, r)
+	if !ok {
+		return
+	}
+
+	quota := entity.Quota{
+		VCPU:      req.VCPU,
+		Memory:    req.Memory,
+		Disk:      req.Disk,
+		Domains:   req.Domains,
+		PublicIPs: req.PublicIPs,
+		Networks:  req.Networks,
+	}
+
+	err := c.projectService.SetQuota(r.Context(), project, quota)
+	if errors.Is(err, service.ErrInvalidQuota) {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to update quota: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	c.writeQuota(w, r, project)
+}
+
+// writeQuota writes the usage of the project against each limit of its quota.
+func (c *ServerController) writeQuota(w http.ResponseWriter, r *http.Request, project string) {
+	quota, usage, err := c.projectService.GetUsage(r.Context(), project)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to get quota: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	// Prepare the response
+	resp := response.QuotaResponse{
+		Project:   project,
+		VCPU:      response.QuotaLimitResponse{Used: usage.VCPU, Limit: quota.VCPU},
+		Memory:    response.QuotaLimitResponse{Used: usage.Memory, Limit: quota.Memory},
+		Disk:      response.QuotaLimitResponse{Used: usage.Disk, Limit: quota.Disk},
+		Domains:   response.QuotaLimitResponse{Used: usage.Domains, Limit: quota.Domains},
+		PublicIPs: response.QuotaLimitResponse{Used: usage.PublicIPs, Limit: quota.PublicIPs},
+		Networks:  response.QuotaLimitResponse{Used: usage.Networks, Limit: quota.Networks},
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// checkOwner checks that the project may delete an object found in libvirt, owner being the project of its ownership record or empty if it has none. Objects without a record were not created through vdash or their record failed to be written, so only the default project may delete them and only with the permission in every project. If the project may not an error response is written and false is returned.
+func (c *ServerController) checkOwner(w http.ResponseWriter, r *http.Request, owner, project, permission, notFound string) bool {
+	if owner != "" {
+		if owner != project {
+			http.Error(w, notFound, http.StatusNotFound)
+			return false
+		}
+		return true
+	}
+
+	if project != service.DefaultProject {
+		http.Error(w, notFound, http.StatusNotFound)
+		return false
+	}
+
+	return c.authorizeGlobally(w, r, permission)
+}
+
+// reserveQuota locks the project and checks that it can take the requested resources. The caller must release the project once the resources are recorded, or as soon as it gives up creating them. If the project cannot take them an error response is written and false is returned, in which case the project is already released.
+func (c *ServerController) reserveQuota(w http.ResponseWriter, r *http.Request, project string, requested service.ResourceUsage) (func(), bool) {
+	unlock := c.projectService.Lock(project)
+
+	err := c.projectService.CheckQuota(r.Context(), project, requested)
+	if errors.Is(err, service.ErrQuotaExceeded) {
+		unlock()
+		http.Error(w, "Forbidden: "+err.Error(), http.StatusForbidden)
+		return nil, false
+	}
+
+	if err != nil {
+		unlock()
+		http.Error(w, fmt.Sprintf("Failed to check quota: %v", err), http.StatusInternalServerError)
+		return nil, false
+	}
+
+	return unlock, true
+}
+
+// project returns the project the request acts in, the default project if it names none. If the project does not exist an error response is written and false is returned.
+func (c *ServerController) project(w http.ResponseWriter, r *http.Request) (string, bool) {
+	name := projectOf(r)
+
+	_, err := c.projectService.GetProject(r.Context(), name)
+	if errors.Is(err, service.ErrUnknownProject) {
+		http.Error(w, "Project not found", http.StatusNotFound)
+		return "", false
+	}
+
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to get project: %v", err), http.StatusInternalServerError)
+		return "", false
+	}
+
+	return name, true
+}
+
+func newProjectResponse(project entity.Project) response.ProjectResponse {
+	resp := response.ProjectResponse{Name: project.Name, Description: project.Description}
+	if !project.CreatedAt.IsZero() {
+		resp.CreatedAt = &project.CreatedAt
+	}
+
+	return resp
+}
@@ -0,0 +1,214 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/sychonet/vdash-be/db/entity"
+	request "github.com/sychonet/vdash-be/dto/request"
+	response "github.com/sychonet/vdash-be/dto/response"
+	"github.com/sychonet/vdash-be/service"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// GetRoles returns the built-in and custom roles along with their permissions.
+func (c *ServerController) GetRoles(w http.ResponseWriter, r *http.Request) {
+	roles, err := c.rbacService.GetRoles(r.Context())
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to get roles: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	// Prepare the response
+	resp := []response.RoleResponse{}
+	for _, role := range roles {
+		resp = append(resp, response.RoleResponse{
+			Name:        role.Name,
+			Description: role.Description,
+			Permissions: role.Permissions,
+			Builtin:     service.IsBuiltinRole(role.Name),
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// SaveRole creates or replaces a custom role.
+func (c *ServerController) SaveRole(w http.ResponseWriter, r *http.Request) {
+	var req request.SaveRoleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	role := entity.Role{Name: req.Name, Description: req.Description, Permissions: req.Permissions}
+	err := c.rbacService.SaveRole(r.Context(), role)
+
+	switch {
+	case errors.Is(err, service.ErrBuiltinRole):
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	case errors.Is(err, service.ErrInvalidRole):
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	case err != nil:
+		http.Error(w, fmt.Sprintf("Failed to save role: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(response.RoleResponse{Name: role.Name, Description: role.Description, Permissions: role.Permissions}); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// DeleteRole deletes a custom role which is not bound to any subject.
+func (c *ServerController) DeleteRole(w http.ResponseWriter, r *http.Request) {
+	var req request.DeleteRoleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	c.deleteRole(w, r, req)
+}
+
+// deleteRole deletes the custom role given in the request.
+func (c *ServerController) deleteRole(w http.ResponseWriter, r *http.Request, req request.DeleteRoleRequest) {
+	err := c.rbacService.DeleteRole(r.Context(), req.Name)
+
+	switch {
+	case errors.Is(err, mongo.ErrNoDocuments):
+		http.Error(w, "Role not found", http.StatusNotFound)
+		return
+	case errors.Is(err, service.ErrBuiltinRole), errors.Is(err, service.ErrRoleInUse):
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	case err != nil:
+		http.Error(w, fmt.Sprintf("Failed to delete role: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetRoleBindings returns the role bindings, filtered by the subject query parameter if given.
+func (c *ServerController) GetRoleBindings(w http.ResponseWriter, r *http.Request) {
+	bindings, err := c.rbacService.GetRoleBindings(r.Context(), r.URL.Query().Get("subject"))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to get role bindings: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	// Prepare the response
+	resp := []response.RoleBindingResponse{}
+	for _, binding := range bindings {
+		resp = append(resp, newRoleBindingResponse(binding))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// CreateRoleBinding grants a role to a subject in a project or in every project.
+func (c *ServerController) CreateRoleBinding(w http.ResponseWriter, r *http.Request) {
+	var req request.CreateRoleBindingRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if req.Subject == "" {
+		http.Error(w, "Invalid subject", http.StatusBadRequest)
+		return
+	}
+
+	// A binding in every project grants global access, so it needs the permission to grant roles in every project whatever the scope of the route
+	if req.Project == "" && !c.authorizeGlobally(w, r, service.ResourceRoles+":"+service.VerbCreate) {
+		return
+	}
+
+	if req.Project != "" {
+		_, err := c.projectService.GetProject(r.Context(), req.Project)
+		if errors.Is(err, service.ErrUnknownProject) {
+			http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+			return
+		}
+
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Failed to get project: %v", err), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	binding, err := c.rbacService.CreateRoleBinding(r.Context(), req.Subject, req.Role, req.Project, service.Actor(r.Context()))
+	if errors.Is(err, service.ErrUnknownRole) {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to create role binding: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(newRoleBindingResponse(*binding)); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// DeleteRoleBinding removes a role binding.
+func (c *ServerController) DeleteRoleBinding(w http.ResponseWriter, r *http.Request) {
+	var req request.DeleteRoleBindingRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	c.deleteRoleBinding(w, r, req)
+}
+
+// deleteRoleBinding removes the role binding given in the request.
+func (c *ServerController) deleteRoleBinding(w http.ResponseWriter, r *http.Request, req request.DeleteRoleBindingRequest) {
+	id, err := primitive.ObjectIDFromHex(req.ID)
+	if err != nil {
+		http.Error(w, "Invalid id", http.StatusBadRequest)
+		return
+	}
+
+	err = c.rbacService.DeleteRoleBinding(r.Context(), id)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		http.Error(w, "Role binding not found", http.StatusNotFound)
+		return
+	}
+
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to delete role binding: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// newRoleBindingResponse converts a role binding into its response representation.
+func newRoleBindingResponse(binding entity.RoleBinding) response.RoleBindingResponse {
+	return response.RoleBindingResponse{
+		ID:        binding.ID.Hex(),
+		Subject:   binding.Subject,
+		Role:      binding.Role,
+		Project:   binding.Project,
+		CreatedBy: binding.CreatedBy,
+		CreatedAt: binding.CreatedAt,
+	}
+}
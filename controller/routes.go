@@ -0,0 +1,214 @@
+package controller
+
+import (
+	"net/http"
+
+	request "github.com/sychonet/vdash-be/dto/request"
+	response "github.com/sychonet/vdash-be/dto/response"
+	"github.com/sychonet/vdash-be/openapi"
+	"github.com/sychonet/vdash-be/service"
+)
+
+// Parameters shared by several routes.
+var (
+	projectHeader = openapi.Parameter{Name: "X-Project", In: "header", Description: "Project the request acts in, the default project if not set. It can also be given with the project query parameter. The permission of the routes on domains, volumes, networks and quota usage is checked in this project, the one of the other routes in every project", Schema: &openapi.Schema{Type: "string"}}
+	nameQuery     = openapi.Parameter{Name: "name", In: "query", Description: "Only return the resources whose name starts with the value", Schema: &openapi.Schema{Type: "string"}}
+	stateQuery    = openapi.Parameter{Name: "state", In: "query", Description: "Only return the resources in the state", Schema: &openapi.Schema{Type: "string"}}
+	labelQuery    = openapi.Parameter{Name: "label", In: "query", Description: "Only return the resources with the label, given as key=value. It can be repeated", Schema: &openapi.Schema{Type: "array", Items: &openapi.Schema{Type: "string"}}}
+	serverIDQuery = openapi.Parameter{Name: "serverID", In: "query", Description: "Only return the resources of the server", Schema: &openapi.Schema{Type: "integer", Format: "int64"}}
+	limitQuery    = openapi.Parameter{Name: "limit", In: "query", Description: "Maximum number of entries to return", Schema: &openapi.Schema{Type: "integer", Format: "int64", Minimum: bound(1)}}
+)
+
+// bound returns a pointer to a bound of a schema.
+func bound(value float64) *float64 {
+	return &value
+}
+
+// requiredQuery describes a mandatory query parameter.
+func requiredQuery(name, description string, schema *openapi.Schema) openapi.Parameter {
+	return openapi.Parameter{Name: name, In: "query", Description: description, Required: true, Schema: schema}
+}
+
+// listQuery returns the query parameters of a list route: the filters it supports, the sort order and, for v2 routes, the page.
+func listQuery(v2 bool, filters ...openapi.Parameter) []openapi.Parameter {
+	query := append(filters, openapi.Parameter{Name: "sort", In: "query", Description: "Field to sort by, prefixed with - for a descending order", Schema: &openapi.Schema{Type: "string"}})
+	if v2 {
+		query = append(query,
+			openapi.Parameter{Name: "limit", In: "query", Description: "Maximum number of items in the page", Schema: &openapi.Schema{Type: "integer", Format: "int64", Minimum: bound(1), Maximum: bound(maxPageSize)}},
+			openapi.Parameter{Name: "cursor", In: "query", Description: "Cursor of the page, returned as nextCursor by the previous page", Schema: &openapi.Schema{Type: "string"}},
+		)
+	}
+
+	return query
+}
+
+// auditQuery returns the query parameters of the audit log routes.
+func auditQuery() []openapi.Parameter {
+	text := &openapi.Schema{Type: "string"}
+	return []openapi.Parameter{
+		{Name: "actor", In: "query", Description: "Only return the requests of the caller", Schema: text},
+		{Name: "method", In: "query", Description: "Only return the requests with the HTTP method", Schema: text},
+		{Name: "route", In: "query", Description: "Only return the requests to the route, such as /v2/servers/{serverID}", Schema: text},
+		{Name: "project", In: "query", Description: "Only return the requests in the project", Schema: text},
+		{Name: "outcome", In: "query", Description: "Only return the requests with the outcome", Schema: &openapi.Schema{Type: "string", Enum: []any{service.AuditSuccess, service.AuditFailure}}},
+		serverIDQuery,
+		{Name: "since", In: "query", Description: "Only return the requests made at or after the time", Schema: &openapi.Schema{Type: "string", Format: "date-time"}},
+		{Name: "until", In: "query", Description: "Only return the requests made before the time", Schema: &openapi.Schema{Type: "string", Format: "date-time"}},
+		{Name: "format", In: "query", Description: "json returns the latest entries, jsonl streams every matching entry as JSON lines", Schema: &openapi.Schema{Type: "string", Enum: []any{"json", "jsonl"}}},
+		{Name: "limit", In: "query", Description: "Maximum number of entries to return", Schema: &openapi.Schema{Type: "integer", Format: "int64", Minimum: bound(1), Maximum: bound(maxAuditLimit)}},
+	}
+}
+
+// Routes returns every route of the API. The routes are registered and documented from this list so that the OpenAPI document cannot drift from the handlers. The v1 routes are deprecated in favour of the v2 routes.
+func (c *ServerController) Routes() []openapi.Route {
+	var routes []openapi.Route
+	for _, route := range c.v1Routes() {
+		route.Deprecated = true
+		routes = append(routes, route)
+	}
+	routes = append(routes, c.v2Routes()...)
+
+	// The project of a request also decides which role bindings apply to the project scoped routes
+	for i := range routes {
+		routes[i].Headers = append(routes[i].Headers, projectHeader)
+	}
+
+	return routes
+}
+
+// v1Routes returns the routes which name the resources in the body of the request or in query parameters.
+func (c *ServerController) v1Routes() []openapi.Route {
+	return []openapi.Route{
+		{Method: http.MethodPost, Path: "/v1/servers", Summary: "Onboard a server", Permission: "servers:create", Handler: c.CreateServer, Body: request.CreateServerRequest{}, Status: http.StatusCreated, Response: response.CreateServerResponse{}, Idempotent: true},
+		{Method: http.MethodGet, Path: "/v1/servers", Summary: "List the servers", Permission: "servers:read", Handler: c.GetServers, Query: listQuery(false, nameQuery, stateQuery, labelQuery), Response: []response.GetServersResponse{}},
+		{Method: http.MethodDelete, Path: "/v1/servers", Summary: "Delete a server", Permission: "servers:delete", Handler: c.DeleteServer, Body: request.DeleteServerRequest{}, Status: http.StatusNoContent},
+		{Method: http.MethodPost, Path: "/v1/servers/sync", Summary: "Reconcile the servers with the provider inventories", Permission: "servers:sync", Handler: c.SyncServers, Query: []openapi.Parameter{{Name: "dryRun", In: "query", Description: "Only report the differences", Schema: &openapi.Schema{Type: "boolean"}}}, Response: response.SyncServersResponse{}},
+		{Method: http.MethodPut, Path: "/v1/servers/labels", Summary: "Replace the labels of a server", Permission: "servers:update", Handler: c.UpdateServerLabels, Body: request.UpdateServerLabelsRequest{}, Status: http.StatusNoContent},
+		{Method: http.MethodPut, Path: "/v1/servers/connection", Summary: "Replace the connection overrides of a server", Permission: "servers:update", Handler: c.UpdateServerConnection, Body: request.UpdateServerConnectionRequest{}, Response: response.UpdateServerConnectionResponse{}},
+		{Method: http.MethodPost, Path: "/v1/servers/discover", Summary: "Refresh the capabilities of a server", Permission: "servers:update", Handler: c.DiscoverServer, Body: request.DiscoverServerRequest{}, Response: response.NodeCapabilitiesResponse{}},
+		{Method: http.MethodPost, Path: "/v1/servers/cordon", Summary: "Cordon a server", Permission: "servers:update", Handler: c.CordonServer, Body: request.CordonServerRequest{}, Status: http.StatusNoContent},
+		{Method: http.MethodPost, Path: "/v1/servers/uncordon", Summary: "Uncordon a server", Permission: "servers:update", Handler: c.UncordonServer, Body: request.CordonServerRequest{}, Status: http.StatusNoContent},
+		{Method: http.MethodPost, Path: "/v1/servers/drain", Summary: "Drain a server", Permission: "servers:drain", Handler: c.DrainServer, Body: request.DrainServerRequest{}, Status: http.StatusAccepted, Response: response.DrainResponse{}},
+		{Method: http.MethodGet, Path: "/v1/servers/drain", Summary: "Get the latest drain of a server", Permission: "servers:read", Handler: c.GetDrain, Query: []openapi.Parameter{requiredQuery("id", "Server to get the drain of", &openapi.Schema{Type: "integer", Format: "int64"})}, Response: response.DrainResponse{}},
+		{Method: http.MethodGet, Path: "/v1/servers/events", Summary: "List the health status changes of the servers", Permission: "servers:read", Handler: c.GetServerEvents, Query: []openapi.Parameter{serverIDQuery, limitQuery}, Response: []response.ServerEventResponse{}},
+		{Method: http.MethodPost, Path: "/v1/servers/{serverID}/boot", Summary: "Boot a server", Permission: "servers:power", Handler: c.BootServer, Body: request.PowerServerRequest{}, OptionalBody: true, Status: http.StatusAccepted, Response: response.PowerActionResponse{}},
+		{Method: http.MethodPost, Path: "/v1/servers/{serverID}/reboot", Summary: "Reboot a server", Permission: "servers:power", Handler: c.RebootServer, Body: request.PowerServerRequest{}, OptionalBody: true, Status: http.StatusAccepted, Response: response.PowerActionResponse{}},
+		{Method: http.MethodPost, Path: "/v1/servers/{serverID}/shutdown", Summary: "Shut down a server", Permission: "servers:power", Handler: c.ShutdownServer, Body: request.PowerServerRequest{}, OptionalBody: true, Status: http.StatusAccepted, Response: response.PowerActionResponse{}},
+		{Method: http.MethodPost, Path: "/v1/servers/{serverID}/rescue", Summary: "Boot a server into the rescue system", Permission: "servers:power", Handler: c.RescueServer, Body: request.PowerServerRequest{}, OptionalBody: true, Status: http.StatusAccepted, Response: response.PowerActionResponse{}},
+		{Method: http.MethodGet, Path: "/v1/servers/{serverID}/power", Summary: "List the power actions of a server", Permission: "servers:read", Handler: c.GetPowerActions, Query: []openapi.Parameter{limitQuery}, Response: []response.PowerActionResponse{}},
+		{Method: http.MethodPost, Path: "/v1/ips", Summary: "Add a public IP", Permission: "ips:create", ProjectScoped: true, Handler: c.AddPublicIP, Body: request.AddPublicIPRequest{}, Status: http.StatusCreated, Response: response.AddIPResponse{}, Idempotent: true},
+		{Method: http.MethodGet, Path: "/v1/ips", Summary: "List the available public IPs", Permission: "ips:read", ProjectScoped: true, Handler: c.GetAvailablePublicIPs, Query: listQuery(false, nameQuery, serverIDQuery), Response: []response.GetAvailableIPsResponse{}},
+		{Method: http.MethodGet, Path: "/v1/ips/allocated", Summary: "List the public IPs allocated to domains", Permission: "ips:read", ProjectScoped: true, Handler: c.GetAllocatedPublicIPs, Response: []response.PublicIPResponse{}},
+		{Method: http.MethodPut, Path: "/v1/ips", Summary: "Update a public IP", Permission: "ips:update", ProjectScoped: true, Handler: c.UpdatePublicIP, Body: request.UpdatePublicIPRequest{}, Status: http.StatusNoContent},
+		{Method: http.MethodDelete, Path: "/v1/ips", Summary: "Delete a public IP", Permission: "ips:delete", Handler: c.DeletePublicIP, Body: request.DeletePublicIPRequest{}, Status: http.StatusNoContent},
+		{Method: http.MethodPost, Path: "/v1/ips/sync", Summary: "Reconcile the public IPs with the provider failover IPs", Permission: "ips:sync", Handler: c.SyncPublicIPs, Response: response.SyncPublicIPsResponse{}},
+		{Method: http.MethodPost, Path: "/v1/ips/route", Summary: "Route a failover IP to a server", Permission: "ips:update", Handler: c.RoutePublicIP, Body: request.RoutePublicIPRequest{}, Response: response.PublicIPResponse{}},
+		{Method: http.MethodPost, Path: "/v1/ips/mac", Summary: "Generate the virtual MAC of a failover IP", Permission: "ips:update", Handler: c.GeneratePublicIPMAC, Body: request.GeneratePublicIPMACRequest{}, Status: http.StatusCreated, Response: response.PublicIPResponse{}},
+		{Method: http.MethodDelete, Path: "/v1/ips/mac", Summary: "Delete the virtual MAC of a failover IP", Permission: "ips:update", Handler: c.DeletePublicIPMAC, Body: request.DeletePublicIPMACRequest{}, Status: http.StatusNoContent},
+		{Method: http.MethodPost, Path: "/v1/storage/pools", Summary: "Create a storage pool", Permission: "storage:create", Handler: c.CreateStoragePool, Body: request.StoragePoolRequest{}, Status: http.StatusCreated, Response: response.StoragePoolResponse{}, Idempotent: true},
+		{Method: http.MethodGet, Path: "/v1/storage/pools", Summary: "List the storage pools of a server", Permission: "storage:read", Handler: c.GetStoragePools, Query: append([]openapi.Parameter{requiredQuery("serverID", "Server to list the storage pools of", &openapi.Schema{Type: "integer", Format: "int64"})}, listQuery(false, nameQuery, stateQuery)...), Response: []response.StoragePoolListResponse{}},
+		{Method: http.MethodDelete, Path: "/v1/storage/pools", Summary: "Delete a storage pool", Permission: "storage:delete", Handler: c.DeleteStoragePool, Body: request.DeleteStoragePoolRequest{}, Status: http.StatusNoContent},
+		{Method: http.MethodPost, Path: "/v1/storage/volumes", Summary: "Create a volume, on the server picked by the scheduler if serverID is 0", Permission: "storage:create", ProjectScoped: true, Handler: c.CreateVolume, Body: request.CreateVolumeRequest{}, Status: http.StatusCreated, Response: response.CreateVolumeResponse{}, Idempotent: true},
+		{Method: http.MethodGet, Path: "/v1/storage/volumes", Summary: "List the volumes of a storage pool", Permission: "storage:read", ProjectScoped: true, Handler: c.GetVolumes, Query: append([]openapi.Parameter{requiredQuery("poolName", "Storage pool to list the volumes of", &openapi.Schema{Type: "string"}), requiredQuery("serverID", "Server of the storage pool", &openapi.Schema{Type: "integer", Format: "int64"})}, listQuery(false, nameQuery)...), Response: []response.CreateVolumeResponse{}},
+		{Method: http.MethodDelete, Path: "/v1/storage/volumes", Summary: "Delete a volume", Permission: "storage:delete", ProjectScoped: true, Handler: c.DeleteVolume, Body: request.DeleteVolumeRequest{}, Status: http.StatusNoContent},
+		{Method: http.MethodPost, Path: "/v1/storage/volumes/resize", Summary: "Grow a volume", Permission: "storage:update", ProjectScoped: true, Handler: c.ResizeVolume, Body: request.ResizeVolumeRequest{}, Status: http.StatusNoContent},
+		{Method: http.MethodPost, Path: "/v1/networks", Summary: "Create a network", Permission: "networks:create", ProjectScoped: true, Handler: c.CreateNetwork, Body: request.NetworkRequest{}, Status: http.StatusCreated, Response: response.NetworkResponse{}, Idempotent: true},
+		{Method: http.MethodGet, Path: "/v1/networks", Summary: "List the networks of a server", Permission: "networks:read", ProjectScoped: true, Handler: c.GetNetworks, Query: append([]openapi.Parameter{requiredQuery("serverID", "Server to list the networks of", &openapi.Schema{Type: "integer", Format: "int64"})}, listQuery(false, nameQuery, stateQuery)...), Response: []response.NetworkResponse{}},
+		{Method: http.MethodDelete, Path: "/v1/networks", Summary: "Delete a network", Permission: "networks:delete", ProjectScoped: true, Handler: c.DeleteNetwork, Body: request.NetworkRequest{}, Status: http.StatusNoContent},
+		{Method: http.MethodPost, Path: "/v1/domains", Summary: "Create a domain, on the server picked by the scheduler if serverID is 0", Permission: "domains:create", ProjectScoped: true, Handler: c.CreateDomain, Body: request.CreateDomainRequest{}, Status: http.StatusCreated, Response: response.CreateDomainResponse{}, Idempotent: true},
+		{Method: http.MethodGet, Path: "/v1/domains", Summary: "List the domains of a server", Permission: "domains:read", ProjectScoped: true, Handler: c.GetDomains, Query: append([]openapi.Parameter{requiredQuery("serverID", "Server to list the domains of", &openapi.Schema{Type: "integer", Format: "int64"})}, listQuery(false, nameQuery, stateQuery)...), Response: []response.GetDomainResponse{}},
+		{Method: http.MethodDelete, Path: "/v1/domains", Summary: "Delete a domain", Permission: "domains:delete", ProjectScoped: true, Handler: c.DeleteDomain, Body: request.DeleteDomainRequest{}, Status: http.StatusNoContent},
+		{Method: http.MethodPost, Path: "/v1/domains/resize", Summary: "Change the memory and vCPUs of a domain", Permission: "domains:update", ProjectScoped: true, Handler: c.ResizeDomain, Body: request.ResizeDomainRequest{}, Status: http.StatusNoContent},
+		{Method: http.MethodPost, Path: "/v1/groups", Summary: "Create a server group", Permission: "groups:create", Handler: c.CreateServerGroup, Body: request.CreateServerGroupRequest{}, Status: http.StatusCreated, Response: response.ServerGroupResponse{}, Idempotent: true},
+		{Method: http.MethodGet, Path: "/v1/groups", Summary: "List the server groups", Permission: "groups:read", Handler: c.GetServerGroups, Query: []openapi.Parameter{{Name: "name", In: "query", Description: "Only return the group with the name", Schema: &openapi.Schema{Type: "string"}}}, Response: []response.ServerGroupResponse{}},
+		{Method: http.MethodDelete, Path: "/v1/groups", Summary: "Delete a server group", Permission: "groups:delete", Handler: c.DeleteServerGroup, Body: request.DeleteServerGroupRequest{}, Status: http.StatusNoContent},
+		{Method: http.MethodGet, Path: "/v1/groups/violations", Summary: "List the domains breaking the policy of their server group", Permission: "groups:read", Handler: c.GetServerGroupViolations, Response: []response.GroupViolationResponse{}},
+		{Method: http.MethodPost, Path: "/v1/scheduler/explain", Summary: "Explain where the scheduler would place a domain or a volume", Permission: "scheduler:read", Handler: c.ExplainSchedule, Body: request.SchedulerExplainRequest{}, Response: response.SchedulerExplainResponse{}},
+		// Creating an API key does not support Idempotency-Key, replaying its response would need the key to be stored
+		{Method: http.MethodPost, Path: "/v1/keys", Summary: "Create an API key", Permission: "keys:create", Handler: c.CreateAPIKey, Body: request.CreateAPIKeyRequest{}, Status: http.StatusCreated, Response: response.CreateAPIKeyResponse{}},
+		{Method: http.MethodGet, Path: "/v1/keys", Summary: "List the API keys", Permission: "keys:read", Handler: c.GetAPIKeys, Response: []response.APIKeyResponse{}},
+		{Method: http.MethodDelete, Path: "/v1/keys", Summary: "Revoke an API key", Permission: "keys:delete", Handler: c.RevokeAPIKey, Body: request.RevokeAPIKeyRequest{}, Status: http.StatusNoContent},
+		{Method: http.MethodGet, Path: "/v1/roles", Summary: "List the roles", Permission: "roles:read", Handler: c.GetRoles, Response: []response.RoleResponse{}},
+		{Method: http.MethodPost, Path: "/v1/roles", Summary: "Create or replace a custom role", Permission: "roles:create", Handler: c.SaveRole, Body: request.SaveRoleRequest{}, Status: http.StatusCreated, Response: response.RoleResponse{}, Idempotent: true},
+		{Method: http.MethodDelete, Path: "/v1/roles", Summary: "Delete a custom role", Permission: "roles:delete", Handler: c.DeleteRole, Body: request.DeleteRoleRequest{}, Status: http.StatusNoContent},
+		{Method: http.MethodGet, Path: "/v1/rolebindings", Summary: "List the role bindings", Permission: "roles:read", Handler: c.GetRoleBindings, Query: []openapi.Parameter{{Name: "subject", In: "query", Description: "Only return the role bindings of the subject", Schema: &openapi.Schema{Type: "string"}}}, Response: []response.RoleBindingResponse{}},
+		{Method: http.MethodPost, Path: "/v1/rolebindings", Summary: "Grant a role to a subject", Permission: "roles:create", Handler: c.CreateRoleBinding, Body: request.CreateRoleBindingRequest{}, Status: http.StatusCreated, Response: response.RoleBindingResponse{}, Idempotent: true},
+		{Method: http.MethodDelete, Path: "/v1/rolebindings", Summary: "Remove a role binding", Permission: "roles:delete", Handler: c.DeleteRoleBinding, Body: request.DeleteRoleBindingRequest{}, Status: http.StatusNoContent},
+		{Method: http.MethodGet, Path: "/v1/projects", Summary: "List the projects", Permission: "projects:read", Handler: c.GetProjects, Response: []response.ProjectResponse{}},
+		{Method: http.MethodPost, Path: "/v1/projects", Summary: "Create a project", Permission: "projects:create", Handler: c.CreateProject, Body: request.CreateProjectRequest{}, Status: http.StatusCreated, Response: response.ProjectResponse{}, Idempotent: true},
+		{Method: http.MethodDelete, Path: "/v1/projects", Summary: "Delete a project", Permission: "projects:delete", Handler: c.DeleteProject, Body: request.DeleteProjectRequest{}, Status: http.StatusNoContent},
+		{Method: http.MethodGet, Path: "/v1/projects/quota", Summary: "Get the quota and usage of the project of the request", Permission: "projects:read", ProjectScoped: true, Handler: c.GetProjectQuota, Response: response.QuotaResponse{}},
+		{Method: http.MethodPut, Path: "/v1/projects/quota", Summary: "Replace the quota of the project of the request", Permission: "projects:update", Handler: c.UpdateProjectQuota, Body: request.UpdateQuotaRequest{}, Response: response.QuotaResponse{}},
+		{Method: http.MethodGet, Path: "/v1/audit", Summary: "List the entries of the audit log", Permission: "audit:read", Handler: c.GetAuditEntries, Query: auditQuery(), Response: []response.AuditEntryResponse{}},
+	}
+}
+
+// v2Routes returns the routes which name the resources in the path instead of in the body or in query parameters.
+func (c *ServerController) v2Routes() []openapi.Route {
+	return []openapi.Route{
+		{Method: http.MethodPost, Path: "/v2/servers", Summary: "Onboard a server", Permission: "servers:create", Handler: c.CreateServer, Body: request.CreateServerRequest{}, Status: http.StatusCreated, Response: response.CreateServerResponse{}, Idempotent: true},
+		{Method: http.MethodGet, Path: "/v2/servers", Summary: "List the servers", Permission: "servers:read", Handler: c.GetServers, Query: listQuery(true, nameQuery, stateQuery, labelQuery), Response: response.ListResponse[response.GetServersResponse]{}},
+		{Method: http.MethodPost, Path: "/v2/servers/sync", Summary: "Reconcile the servers with the provider inventories", Permission: "servers:sync", Handler: c.SyncServers, Query: []openapi.Parameter{{Name: "dryRun", In: "query", Description: "Only report the differences", Schema: &openapi.Schema{Type: "boolean"}}}, Response: response.SyncServersResponse{}},
+		{Method: http.MethodGet, Path: "/v2/servers/events", Summary: "List the health status changes of the servers", Permission: "servers:read", Handler: c.GetServerEvents, Query: []openapi.Parameter{serverIDQuery, limitQuery}, Response: []response.ServerEventResponse{}},
+		{Method: http.MethodDelete, Path: "/v2/servers/{serverID}", Summary: "Delete a server", Permission: "servers:delete", Handler: c.DeleteServerV2, Status: http.StatusNoContent},
+		{Method: http.MethodPut, Path: "/v2/servers/{serverID}/labels", Summary: "Replace the labels of a server", Permission: "servers:update", Handler: c.UpdateServerLabelsV2, Body: request.UpdateServerLabelsRequest{}, OptionalBody: true, PathFields: []string{"id"}, Status: http.StatusNoContent},
+		{Method: http.MethodPut, Path: "/v2/servers/{serverID}/connection", Summary: "Replace the connection overrides of a server", Permission: "servers:update", Handler: c.UpdateServerConnectionV2, Body: request.UpdateServerConnectionRequest{}, OptionalBody: true, PathFields: []string{"id"}, Response: response.UpdateServerConnectionResponse{}},
+		{Method: http.MethodPost, Path: "/v2/servers/{serverID}/discover", Summary: "Refresh the capabilities of a server", Permission: "servers:update", Handler: c.DiscoverServerV2, Response: response.NodeCapabilitiesResponse{}},
+		{Method: http.MethodPost, Path: "/v2/servers/{serverID}/cordon", Summary: "Cordon a server", Permission: "servers:update", Handler: c.CordonServerV2, Status: http.StatusNoContent},
+		{Method: http.MethodPost, Path: "/v2/servers/{serverID}/uncordon", Summary: "Uncordon a server", Permission: "servers:update", Handler: c.UncordonServerV2, Status: http.StatusNoContent},
+		{Method: http.MethodPost, Path: "/v2/servers/{serverID}/drain", Summary: "Drain a server", Permission: "servers:drain", Handler: c.DrainServerV2, Body: request.DrainServerRequest{}, OptionalBody: true, PathFields: []string{"id"}, Status: http.StatusAccepted, Response: response.DrainResponse{}},
+		{Method: http.MethodGet, Path: "/v2/servers/{serverID}/drain", Summary: "Get the latest drain of a server", Permission: "servers:read", Handler: c.GetDrainV2, Response: response.DrainResponse{}},
+		{Method: http.MethodGet, Path: "/v2/servers/{serverID}/events", Summary: "List the health status changes of a server", Permission: "servers:read", Handler: c.GetServerEventsV2, Query: []openapi.Parameter{limitQuery}, Response: []response.ServerEventResponse{}},
+		{Method: http.MethodPost, Path: "/v2/servers/{serverID}/boot", Summary: "Boot a server", Permission: "servers:power", Handler: c.BootServer, Body: request.PowerServerRequest{}, OptionalBody: true, Status: http.StatusAccepted, Response: response.PowerActionResponse{}},
+		{Method: http.MethodPost, Path: "/v2/servers/{serverID}/reboot", Summary: "Reboot a server", Permission: "servers:power", Handler: c.RebootServer, Body: request.PowerServerRequest{}, OptionalBody: true, Status: http.StatusAccepted, Response: response.PowerActionResponse{}},
+		{Method: http.MethodPost, Path: "/v2/servers/{serverID}/shutdown", Summary: "Shut down a server", Permission: "servers:power", Handler: c.ShutdownServer, Body: request.PowerServerRequest{}, OptionalBody: true, Status: http.StatusAccepted, Response: response.PowerActionResponse{}},
+		{Method: http.MethodPost, Path: "/v2/servers/{serverID}/rescue", Summary: "Boot a server into the rescue system", Permission: "servers:power", Handler: c.RescueServer, Body: request.PowerServerRequest{}, OptionalBody: true, Status: http.StatusAccepted, Response: response.PowerActionResponse{}},
+		{Method: http.MethodGet, Path: "/v2/servers/{serverID}/power", Summary: "List the power actions of a server", Permission: "servers:read", Handler: c.GetPowerActions, Query: []openapi.Parameter{limitQuery}, Response: []response.PowerActionResponse{}},
+		{Method: http.MethodPost, Path: "/v2/servers/{serverID}/pools", Summary: "Create a storage pool", Permission: "storage:create", Handler: c.CreateStoragePoolV2, Body: request.StoragePoolRequest{}, PathFields: []string{"serverID"}, Status: http.StatusCreated, Response: response.StoragePoolResponse{}, Idempotent: true},
+		{Method: http.MethodGet, Path: "/v2/servers/{serverID}/pools", Summary: "List the storage pools of a server", Permission: "storage:read", Handler: c.GetStoragePoolsV2, Query: listQuery(true, nameQuery, stateQuery), Response: response.ListResponse[response.StoragePoolListResponse]{}},
+		{Method: http.MethodDelete, Path: "/v2/servers/{serverID}/pools/{pool}", Summary: "Delete a storage pool", Permission: "storage:delete", Handler: c.DeleteStoragePoolV2, Status: http.StatusNoContent},
+		{Method: http.MethodPost, Path: "/v2/servers/{serverID}/pools/{pool}/volumes", Summary: "Create a volume", Permission: "storage:create", ProjectScoped: true, Handler: c.CreateVolumeV2, Body: request.CreateVolumeRequest{}, PathFields: []string{"serverID", "poolName"}, Status: http.StatusCreated, Response: response.CreateVolumeResponse{}, Idempotent: true},
+		{Method: http.MethodGet, Path: "/v2/servers/{serverID}/pools/{pool}/volumes", Summary: "List the volumes of a storage pool", Permission: "storage:read", ProjectScoped: true, Handler: c.GetVolumesV2, Query: listQuery(true, nameQuery), Response: response.ListResponse[response.CreateVolumeResponse]{}},
+		{Method: http.MethodDelete, Path: "/v2/servers/{serverID}/pools/{pool}/volumes/{volume}", Summary: "Delete a volume", Permission: "storage:delete", ProjectScoped: true, Handler: c.DeleteVolumeV2, Status: http.StatusNoContent},
+		{Method: http.MethodPost, Path: "/v2/servers/{serverID}/pools/{pool}/volumes/{volume}/resize", Summary: "Grow a volume", Permission: "storage:update", ProjectScoped: true, Handler: c.ResizeVolumeV2, Body: request.ResizeVolumeRequest{}, PathFields: []string{"serverID", "poolName", "name"}, Status: http.StatusNoContent},
+		{Method: http.MethodPost, Path: "/v2/servers/{serverID}/networks", Summary: "Create a network", Permission: "networks:create", ProjectScoped: true, Handler: c.CreateNetworkV2, Body: request.NetworkRequest{}, PathFields: []string{"serverID"}, Status: http.StatusCreated, Response: response.NetworkResponse{}, Idempotent: true},
+		{Method: http.MethodGet, Path: "/v2/servers/{serverID}/networks", Summary: "List the networks of a server", Permission: "networks:read", ProjectScoped: true, Handler: c.GetNetworksV2, Query: listQuery(true, nameQuery, stateQuery), Response: response.ListResponse[response.NetworkResponse]{}},
+		{Method: http.MethodDelete, Path: "/v2/servers/{serverID}/networks/{name}", Summary: "Delete a network", Permission: "networks:delete", ProjectScoped: true, Handler: c.DeleteNetworkV2, Status: http.StatusNoContent},
+		{Method: http.MethodPost, Path: "/v2/servers/{serverID}/domains", Summary: "Create a domain", Permission: "domains:create", ProjectScoped: true, Handler: c.CreateDomainV2, Body: request.CreateDomainRequest{}, PathFields: []string{"serverID"}, Status: http.StatusCreated, Response: response.CreateDomainResponse{}, Idempotent: true},
+		{Method: http.MethodGet, Path: "/v2/servers/{serverID}/domains", Summary: "List the domains of a server", Permission: "domains:read", ProjectScoped: true, Handler: c.GetDomainsV2, Query: listQuery(true, nameQuery, stateQuery), Response: response.ListResponse[response.GetDomainResponse]{}},
+		{Method: http.MethodDelete, Path: "/v2/servers/{serverID}/domains/{name}", Summary: "Delete a domain", Permission: "domains:delete", ProjectScoped: true, Handler: c.DeleteDomainV2, Status: http.StatusNoContent},
+		{Method: http.MethodPost, Path: "/v2/servers/{serverID}/domains/{name}/resize", Summary: "Change the memory and vCPUs of a domain", Permission: "domains:update", ProjectScoped: true, Handler: c.ResizeDomainV2, Body: request.ResizeDomainRequest{}, PathFields: []string{"serverID", "name"}, Status: http.StatusNoContent},
+		// Domains and volumes created without a server are placed by the scheduler
+		{Method: http.MethodPost, Path: "/v2/domains", Summary: "Create a domain on the server picked by the scheduler", Permission: "domains:create", ProjectScoped: true, Handler: c.CreateDomain, Body: request.CreateDomainRequest{}, Status: http.StatusCreated, Response: response.CreateDomainResponse{}, Idempotent: true},
+		{Method: http.MethodPost, Path: "/v2/volumes", Summary: "Create a volume on the server picked by the scheduler", Permission: "storage:create", ProjectScoped: true, Handler: c.CreateVolume, Body: request.CreateVolumeRequest{}, Status: http.StatusCreated, Response: response.CreateVolumeResponse{}, Idempotent: true},
+		{Method: http.MethodPost, Path: "/v2/ips", Summary: "Add a public IP", Permission: "ips:create", ProjectScoped: true, Handler: c.AddPublicIP, Body: request.AddPublicIPRequest{}, Status: http.StatusCreated, Response: response.AddIPResponse{}, Idempotent: true},
+		{Method: http.MethodGet, Path: "/v2/ips", Summary: "List the available public IPs", Permission: "ips:read", ProjectScoped: true, Handler: c.GetAvailablePublicIPs, Query: listQuery(true, nameQuery, serverIDQuery), Response: response.ListResponse[response.GetAvailableIPsResponse]{}},
+		{Method: http.MethodGet, Path: "/v2/ips/allocated", Summary: "List the public IPs allocated to domains", Permission: "ips:read", ProjectScoped: true, Handler: c.GetAllocatedPublicIPs, Response: []response.PublicIPResponse{}},
+		{Method: http.MethodPost, Path: "/v2/ips/sync", Summary: "Reconcile the public IPs with the provider failover IPs", Permission: "ips:sync", Handler: c.SyncPublicIPs, Response: response.SyncPublicIPsResponse{}},
+		{Method: http.MethodPut, Path: "/v2/ips/{ip}", Summary: "Update a public IP", Permission: "ips:update", ProjectScoped: true, Handler: c.UpdatePublicIPV2, Body: request.UpdatePublicIPRequest{}, PathFields: []string{"ip"}, Status: http.StatusNoContent},
+		{Method: http.MethodDelete, Path: "/v2/ips/{ip}", Summary: "Delete a public IP", Permission: "ips:delete", Handler: c.DeletePublicIPV2, Status: http.StatusNoContent},
+		{Method: http.MethodPost, Path: "/v2/ips/{ip}/route", Summary: "Route a failover IP to a server", Permission: "ips:update", Handler: c.RoutePublicIPV2, Body: request.RoutePublicIPRequest{}, PathFields: []string{"ip"}, Response: response.PublicIPResponse{}},
+		{Method: http.MethodPost, Path: "/v2/ips/{ip}/mac", Summary: "Generate the virtual MAC of a failover IP", Permission: "ips:update", Handler: c.GeneratePublicIPMACV2, Body: request.GeneratePublicIPMACRequest{}, OptionalBody: true, PathFields: []string{"ip"}, Status: http.StatusCreated, Response: response.PublicIPResponse{}},
+		{Method: http.MethodDelete, Path: "/v2/ips/{ip}/mac", Summary: "Delete the virtual MAC of a failover IP", Permission: "ips:update", Handler: c.DeletePublicIPMACV2, Status: http.StatusNoContent},
+		{Method: http.MethodPost, Path: "/v2/groups", Summary: "Create a server group", Permission: "groups:create", Handler: c.CreateServerGroup, Body: request.CreateServerGroupRequest{}, Status: http.StatusCreated, Response: response.ServerGroupResponse{}, Idempotent: true},
+		{Method: http.MethodGet, Path: "/v2/groups", Summary: "List the server groups", Permission: "groups:read", Handler: c.GetServerGroups, Query: []openapi.Parameter{{Name: "name", In: "query", Description: "Only return the group with the name", Schema: &openapi.Schema{Type: "string"}}}, Response: []response.ServerGroupResponse{}},
+		{Method: http.MethodGet, Path: "/v2/groups/violations", Summary: "List the domains breaking the policy of their server group", Permission: "groups:read", Handler: c.GetServerGroupViolations, Response: []response.GroupViolationResponse{}},
+		{Method: http.MethodDelete, Path: "/v2/groups/{name}", Summary: "Delete a server group", Permission: "groups:delete", Handler: c.DeleteServerGroupV2, Status: http.StatusNoContent},
+		{Method: http.MethodPost, Path: "/v2/scheduler/explain", Summary: "Explain where the scheduler would place a domain or a volume", Permission: "scheduler:read", Handler: c.ExplainSchedule, Body: request.SchedulerExplainRequest{}, Response: response.SchedulerExplainResponse{}},
+		{Method: http.MethodPost, Path: "/v2/keys", Summary: "Create an API key", Permission: "keys:create", Handler: c.CreateAPIKey, Body: request.CreateAPIKeyRequest{}, Status: http.StatusCreated, Response: response.CreateAPIKeyResponse{}},
+		{Method: http.MethodGet, Path: "/v2/keys", Summary: "List the API keys", Permission: "keys:read", Handler: c.GetAPIKeys, Response: []response.APIKeyResponse{}},
+		{Method: http.MethodDelete, Path: "/v2/keys/{id}", Summary: "Revoke an API key", Permission: "keys:delete", Handler: c.RevokeAPIKeyV2, Status: http.StatusNoContent},
+		{Method: http.MethodGet, Path: "/v2/roles", Summary: "List the roles", Permission: "roles:read", Handler: c.GetRoles, Response: []response.RoleResponse{}},
+		{Method: http.MethodPost, Path: "/v2/roles", Summary: "Create or replace a custom role", Permission: "roles:create", Handler: c.SaveRole, Body: request.SaveRoleRequest{}, Status: http.StatusCreated, Response: response.RoleResponse{}, Idempotent: true},
+		{Method: http.MethodDelete, Path: "/v2/roles/{name}", Summary: "Delete a custom role", Permission: "roles:delete", Handler: c.DeleteRoleV2, Status: http.StatusNoContent},
+		{Method: http.MethodGet, Path: "/v2/rolebindings", Summary: "List the role bindings", Permission: "roles:read", Handler: c.GetRoleBindings, Query: []openapi.Parameter{{Name: "subject", In: "query", Description: "Only return the role bindings of the subject", Schema: &openapi.Schema{Type: "string"}}}, Response: []response.RoleBindingResponse{}},
+		{Method: http.MethodPost, Path: "/v2/rolebindings", Summary: "Grant a role to a subject", Permission: "roles:create", Handler: c.CreateRoleBinding, Body: request.CreateRoleBindingRequest{}, Status: http.StatusCreated, Response: response.RoleBindingResponse{}, Idempotent: true},
+		{Method: http.MethodDelete, Path: "/v2/rolebindings/{id}", Summary: "Remove a role binding", Permission: "roles:delete", Handler: c.DeleteRoleBindingV2, Status: http.StatusNoContent},
+		{Method: http.MethodGet, Path: "/v2/projects", Summary: "List the projects", Permission: "projects:read", Handler: c.GetProjects, Response: []response.ProjectResponse{}},
+		{Method: http.MethodPost, Path: "/v2/projects", Summary: "Create a project", Permission: "projects:create", Handler: c.CreateProject, Body: request.CreateProjectRequest{}, Status: http.StatusCreated, Response: response.ProjectResponse{}, Idempotent: true},
+		{Method: http.MethodDelete, Path: "/v2/projects/{project}", Summary: "Delete a project", Permission: "projects:delete", Handler: c.DeleteProjectV2, Status: http.StatusNoContent},
+		{Method: http.MethodGet, Path: "/v2/projects/{project}/quota", Summary: "Get the quota and usage of a project", Permission: "projects:read", ProjectScoped: true, Handler: c.GetProjectQuota, Response: response.QuotaResponse{}},
+		{Method: http.MethodPut, Path: "/v2/projects/{project}/quota", Summary: "Replace the quota of a project", Permission: "projects:update", Handler: c.UpdateProjectQuota, Body: request.UpdateQuotaRequest{}, Response: response.QuotaResponse{}},
+		{Method: http.MethodGet, Path: "/v2/audit", Summary: "List the entries of the audit log", Permission: "audit:read", Handler: c.GetAuditEntries, Query: auditQuery(), Response: []response.AuditEntryResponse{}},
+	}
+}
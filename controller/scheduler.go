@@ -0,0 +1,112 @@
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	request "github.com/sychonet/vdash-be/dto/request"
+	response "github.com/sychonet/vdash-be/dto/response"
+	"github.com/sychonet/vdash-be/service"
+)
+
+// ExplainSchedule runs the scheduler for a domain or volume creation request without creating anything and returns every candidate server with the outcome of each filter. The serverID of the wrapped request is ignored since the scheduler is only consulted when it is not provided.
+func (c *ServerController) ExplainSchedule(w http.ResponseWriter, r *http.Request) {
+	var req request.SchedulerExplainRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if (req.Domain == nil) == (req.Volume == nil) {
+		http.Error(w, "Exactly one of domain or volume is required", http.StatusBadRequest)
+		return
+	}
+
+	var decision *service.Decision
+	if req.Domain != nil {
+		if req.Domain.Memory <= 0 {
+			http.Error(w, "Invalid memory", http.StatusBadRequest)
+			return
+		}
+
+		if req.Domain.VCPU <= 0 {
+			http.Error(w, "Invalid VCPU", http.StatusBadRequest)
+			return
+		}
+
+		placement, ok := c.domainPlacement(w, r, *req.Domain)
+		if !ok {
+			return
+		}
+
+		result, err := c.schedulerService.ScheduleDomain(r.Context(), req.Domain.Memory, req.Domain.VCPU, req.Domain.PublicIP, placement)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Failed to schedule domain: %v", err), http.StatusInternalServerError)
+			return
+		}
+
+		decision = result
+	} else {
+		if req.Volume.PoolName == "" {
+			http.Error(w, "Invalid poolName", http.StatusBadRequest)
+			return
+		}
+
+		if req.Volume.Size <= 0 {
+			http.Error(w, "Invalid size", http.StatusBadRequest)
+			return
+		}
+
+		placement, err := newPlacement(req.Volume.NodeSelector, req.Volume.RequiredConstraints, req.Volume.PreferredConstraints)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Invalid placement: %v", err), http.StatusBadRequest)
+			return
+		}
+
+		result, err := c.schedulerService.ScheduleVolume(r.Context(), req.Volume.PoolName, req.Volume.Size, placement)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Failed to schedule volume: %v", err), http.StatusInternalServerError)
+			return
+		}
+
+		decision = result
+	}
+
+	// Prepare the response
+	resp := response.SchedulerExplainResponse{
+		ServerID:   decision.ServerID,
+		Candidates: []response.SchedulerCandidateResponse{},
+	}
+
+	for _, candidate := range decision.Candidates {
+		candidateResponse := response.SchedulerCandidateResponse{
+			ServerID:   candidate.Server.ID,
+			Hostname:   candidate.Server.Hostname,
+			Eligible:   candidate.Eligible(),
+			FreeMemory: candidate.FreeMemory / 1024 / 1024,
+			FreeVCPU:   candidate.FreeVCPU,
+			FreeSpace:  candidate.FreeSpace / 1024 / 1024 / 1024,
+			Score:      candidate.Score,
+		}
+
+		if candidate.Error != nil {
+			candidateResponse.Error = candidate.Error.Error()
+		}
+
+		for _, filter := range candidate.Filters {
+			candidateResponse.Filters = append(candidateResponse.Filters, response.SchedulerFilterResponse{
+				Name:   filter.Name,
+				Passed: filter.Passed,
+				Reason: filter.Reason,
+			})
+		}
+
+		resp.Candidates = append(resp.Candidates, candidateResponse)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
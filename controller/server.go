@@ -1,17 +1,23 @@
 package controller
 
 import (
+	"cmp"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
 	"net/http"
+	"strconv"
+	"strings"
 
 	"github.com/sychonet/vdash-be/db/entity"
 	request "github.com/sychonet/vdash-be/dto/request"
 	response "github.com/sychonet/vdash-be/dto/response"
+	"github.com/sychonet/vdash-be/service"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
-// CreateServer creates a new server using the provided request in mongodb database. We want to save id, hostname, publicIP, and libvirtURI for each server.
+// CreateServer onboards a new server using the provided request. The server is only saved in mongodb database with its id, hostname, publicIP, libvirtURI and discovered capabilities if libvirtd on it passes the onboarding checks.
 func (c *ServerController) CreateServer(w http.ResponseWriter, r *http.Request) {
 	var req request.CreateServerRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -19,15 +25,43 @@ func (c *ServerController) CreateServer(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	// Insert the server
+	// Validate the request
+	if req.ID <= 0 {
+		http.Error(w, "Invalid id", http.StatusBadRequest)
+		return
+	}
+
+	if req.PublicIP == "" {
+		http.Error(w, "Invalid publicIP", http.StatusBadRequest)
+		return
+	}
+
+	// Onboard the server
 	server := entity.ServerInfo{
 		ID:         req.ID,
 		Hostname:   req.Hostname,
 		PublicIP:   req.PublicIP,
-		LibvirtURI: "qemu+libssh://root@" + req.PublicIP + "/system",
+		Labels:     req.Labels,
+		Connection: newConnectionConfig(req.Connection),
+	}
+
+	onboarded, err := c.onboardingService.Onboard(r.Context(), server)
+	if errors.Is(err, service.ErrInvalidConnection) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var rejected *service.RejectedError
+	if errors.As(err, &rejected) {
+		http.Error(w, rejected.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	if mongo.IsDuplicateKeyError(err) {
+		http.Error(w, "Server already exists", http.StatusConflict)
+		return
 	}
 
-	err := c.dbService.AddServer(server)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Failed to insert server: %v", err), http.StatusInternalServerError)
 		return
@@ -35,10 +69,13 @@ func (c *ServerController) CreateServer(w http.ResponseWriter, r *http.Request)
 
 	// Prepare the response
 	resp := response.CreateServerResponse{
-		ID:         req.ID,
-		Hostname:   req.Hostname,
-		PublicIP:   req.PublicIP,
-		LibvirtURI: "qemu+libssh://root@" + req.PublicIP + "/system",
+		ID:           onboarded.ID,
+		Hostname:     onboarded.Hostname,
+		PublicIP:     onboarded.PublicIP,
+		LibvirtURI:   onboarded.LibvirtURI,
+		Labels:       onboarded.Labels,
+		Connection:   newConnectionResponse(onboarded.Connection),
+		Capabilities: newNodeCapabilitiesResponse(onboarded.Capabilities),
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -48,9 +85,14 @@ func (c *ServerController) CreateServer(w http.ResponseWriter, r *http.Request)
 	}
 }
 
-// GetServers returns all servers from mongodb database.
+// GetServers returns the servers from mongodb database. They can be filtered by a prefix of their hostname, their health status and their labels, and sorted by id, hostname or publicIP.
 func (c *ServerController) GetServers(w http.ResponseWriter, r *http.Request) {
-	serversDetails, err := c.dbService.GetServers()
+	filter, ok := parseListFilter(w, r)
+	if !ok {
+		return
+	}
+
+	serversDetails, err := c.dbService.GetServers(r.Context())
 	if err != nil {
 		slog.Error(err.Error())
 		http.Error(w, fmt.Sprintf("Failed to get servers: %v", err), http.StatusInternalServerError)
@@ -60,19 +102,43 @@ func (c *ServerController) GetServers(w http.ResponseWriter, r *http.Request) {
 	// Prepare the response
 	var servers []response.GetServersResponse
 	for _, serverDetail := range serversDetails {
+		if !filter.matchName(serverDetail.Hostname) || !filter.matchLabels(serverDetail.Labels) {
+			continue
+		}
+
 		var serverResponse response.GetServersResponse
 
 		serverResponse.ID = serverDetail.ID
 		serverResponse.Hostname = serverDetail.Hostname
 		serverResponse.PublicIP = serverDetail.PublicIP
 		serverResponse.LibvirtURI = serverDetail.LibvirtURI
+		serverResponse.Labels = serverDetail.Labels
+		serverResponse.Cordoned = serverDetail.Cordoned
+		serverResponse.Health = newServerHealthResponse(serverDetail.Health)
+		serverResponse.Connection = newConnectionResponse(serverDetail.Connection)
+		serverResponse.Capabilities = newNodeCapabilitiesResponse(serverDetail.Capabilities)
+		serverResponse.Provider = serverDetail.Provider
+		serverResponse.ProviderID = serverDetail.ProviderID
+		serverResponse.Missing = serverDetail.Missing
+		if !serverDetail.MissingSince.IsZero() {
+			serverResponse.MissingSince = &serverDetail.MissingSince
+		}
+
+		if !filter.matchState(serverResponse.Health.Status) {
+			continue
+		}
 		servers = append(servers, serverResponse)
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(servers); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-	}
+	writeList(w, r, servers, listOrder[response.GetServersResponse]{
+		key: func(s response.GetServersResponse) string { return strconv.Itoa(s.ID) },
+		fields: map[string]func(a, b response.GetServersResponse) int{
+			"id":       func(a, b response.GetServersResponse) int { return cmp.Compare(a.ID, b.ID) },
+			"hostname": func(a, b response.GetServersResponse) int { return strings.Compare(a.Hostname, b.Hostname) },
+			"publicIP": func(a, b response.GetServersResponse) int { return strings.Compare(a.PublicIP, b.PublicIP) },
+		},
+		defaultSort: "id",
+	})
 }
 
 // DeleteServer deletes a server from mongodb database.
@@ -83,7 +149,12 @@ func (c *ServerController) DeleteServer(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	err := c.dbService.DeleteServer(req.ID)
+	c.deleteServer(w, r, req)
+}
+
+// deleteServer deletes the server given in the request.
+func (c *ServerController) deleteServer(w http.ResponseWriter, r *http.Request, req request.DeleteServerRequest) {
+	err := c.dbService.DeleteServer(r.Context(), req.ID)
 
 	if err != nil {
 		slog.Error(err.Error())
@@ -94,3 +165,353 @@ func (c *ServerController) DeleteServer(w http.ResponseWriter, r *http.Request)
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusNoContent)
 }
+
+// UpdateServerLabels replaces the labels of a server in mongodb database. Labels are used by the scheduler to honor node selectors and placement constraints.
+func (c *ServerController) UpdateServerLabels(w http.ResponseWriter, r *http.Request) {
+	var req request.UpdateServerLabelsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	c.updateServerLabels(w, r, req)
+}
+
+// updateServerLabels replaces the labels of the server given in the request.
+func (c *ServerController) updateServerLabels(w http.ResponseWriter, r *http.Request, req request.UpdateServerLabelsRequest) {
+	// Validate the request
+	if req.ID <= 0 {
+		http.Error(w, "Invalid id", http.StatusBadRequest)
+		return
+	}
+
+	for key := range req.Labels {
+		if key == "" {
+			http.Error(w, "Invalid label key", http.StatusBadRequest)
+			return
+		}
+	}
+
+	err := c.dbService.UpdateServerLabels(r.Context(), req.ID, req.Labels)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		http.Error(w, "Server not found", http.StatusNotFound)
+		return
+	}
+
+	if err != nil {
+		slog.Error(err.Error())
+		http.Error(w, fmt.Sprintf("Failed to update server labels: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetServerEvents returns the latest health status changes of the servers. If the serverID query parameter is provided only the events of that server are returned.
+func (c *ServerController) GetServerEvents(w http.ResponseWriter, r *http.Request) {
+	serverID := 0
+	if serverIDParam := r.URL.Query().Get("serverID"); serverIDParam != "" {
+		id, err := strconv.Atoi(serverIDParam)
+		if err != nil {
+			http.Error(w, "Invalid serverID query parameter", http.StatusBadRequest)
+			return
+		}
+		serverID = id
+	}
+
+	c.getServerEvents(w, r, serverID)
+}
+
+// getServerEvents lists the latest health status changes of the server, or of every server if serverID is 0.
+func (c *ServerController) getServerEvents(w http.ResponseWriter, r *http.Request, serverID int) {
+	limit := 100
+	if limitParam := r.URL.Query().Get("limit"); limitParam != "" {
+		l, err := strconv.Atoi(limitParam)
+		if err != nil || l <= 0 {
+			http.Error(w, "Invalid limit query parameter", http.StatusBadRequest)
+			return
+		}
+		limit = l
+	}
+
+	events, err := c.dbService.GetServerEvents(r.Context(), serverID, int64(limit))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to get server events: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	// Prepare the response
+	resp := []response.ServerEventResponse{}
+	for _, event := range events {
+		resp = append(resp, response.ServerEventResponse{
+			ServerID: event.ServerID,
+			Time:     event.Time,
+			From:     event.From,
+			To:       event.To,
+			Error:    event.Error,
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// newServerHealthResponse converts the health of a server into its response representation.
+func newServerHealthResponse(health entity.ServerHealth) response.ServerHealthResponse {
+	resp := response.ServerHealthResponse{
+		Status:         health.Status,
+		Failures:       health.Failures,
+		Error:          health.Error,
+		LibvirtVersion: health.LibvirtVersion,
+	}
+
+	if resp.Status == "" {
+		resp.Status = service.ServerStatusUnknown
+	}
+
+	if !health.LastSeen.IsZero() {
+		resp.LastSeen = &health.LastSeen
+	}
+
+	if !health.LastChecked.IsZero() {
+		resp.LastChecked = &health.LastChecked
+	}
+
+	if !health.StatusChangedAt.IsZero() {
+		resp.StatusChangedAt = &health.StatusChangedAt
+	}
+
+	if !health.LastPowerCycle.IsZero() {
+		resp.LastPowerCycle = &health.LastPowerCycle
+	}
+
+	return resp
+}
+
+// UpdateServerConnection replaces the connection overrides of a server and rebuilds its libvirt URI. Omitting the connection resets the server to the global settings.
+func (c *ServerController) UpdateServerConnection(w http.ResponseWriter, r *http.Request) {
+	var req request.UpdateServerConnectionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	c.updateServerConnection(w, r, req)
+}
+
+// updateServerConnection replaces the connection overrides of the server given in the request.
+func (c *ServerController) updateServerConnection(w http.ResponseWriter, r *http.Request, req request.UpdateServerConnectionRequest) {
+	// Validate the request
+	if req.ID <= 0 {
+		http.Error(w, "Invalid id", http.StatusBadRequest)
+		return
+	}
+
+	server, err := c.onboardingService.UpdateConnection(r.Context(), req.ID, newConnectionConfig(req.Connection))
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		http.Error(w, "Server not found", http.StatusNotFound)
+		return
+	}
+
+	if errors.Is(err, service.ErrInvalidConnection) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to update server: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	// Prepare the response
+	resp := response.UpdateServerConnectionResponse{
+		ID:         server.ID,
+		LibvirtURI: server.LibvirtURI,
+		Connection: newConnectionResponse(server.Connection),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// newConnectionConfig converts the connection settings of a request into their database representation.
+func newConnectionConfig(connection *request.ConnectionConfig) *entity.ConnectionConfig {
+	if connection == nil {
+		return nil
+	}
+
+	return &entity.ConnectionConfig{
+		Transport:        connection.Transport,
+		User:             connection.User,
+		Port:             connection.Port,
+		Path:             connection.Path,
+		KeyFile:          connection.KeyFile,
+		KnownHosts:       connection.KnownHosts,
+		KnownHostsVerify: connection.KnownHostsVerify,
+		NoVerify:         connection.NoVerify,
+		PKIPath:          connection.PKIPath,
+		Socket:           connection.Socket,
+	}
+}
+
+// newConnectionResponse converts the connection overrides of a server into their response representation. It returns nil if the server uses the global settings.
+func newConnectionResponse(connection *entity.ConnectionConfig) *response.ConnectionResponse {
+	if connection == nil {
+		return nil
+	}
+
+	return &response.ConnectionResponse{
+		Transport:        connection.Transport,
+		User:             connection.User,
+		Port:             connection.Port,
+		Path:             connection.Path,
+		KeyFile:          connection.KeyFile,
+		KnownHosts:       connection.KnownHosts,
+		KnownHostsVerify: connection.KnownHostsVerify,
+		NoVerify:         connection.NoVerify,
+		PKIPath:          connection.PKIPath,
+		Socket:           connection.Socket,
+	}
+}
+
+// SyncServers reconciles the servers with the inventory of every provider account right away and returns the differences which were applied. With the dryRun query parameter set to true the differences are only reported.
+func (c *ServerController) SyncServers(w http.ResponseWriter, r *http.Request) {
+	dryRun := false
+	if dryRunParam := r.URL.Query().Get("dryRun"); dryRunParam != "" {
+		value, err := strconv.ParseBool(dryRunParam)
+		if err != nil {
+			http.Error(w, "Invalid dryRun query parameter", http.StatusBadRequest)
+			return
+		}
+		dryRun = value
+	}
+
+	diff, err := c.reconcilerService.Sync(r.Context(), dryRun)
+	if errors.Is(err, service.ErrSyncRunning) {
+		http.Error(w, "Inventory sync is already running", http.StatusConflict)
+		return
+	}
+
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to sync servers: %v", err), http.StatusBadGateway)
+		return
+	}
+
+	// Prepare the response
+	resp := response.SyncServersResponse{
+		Added:      []response.SyncedServerResponse{},
+		Updated:    []response.InventoryChangeResponse{},
+		Missing:    []int{},
+		Returned:   []int{},
+		Errors:     []string{},
+		DryRun:     diff.DryRun,
+		StartedAt:  diff.StartedAt,
+		FinishedAt: diff.FinishedAt,
+	}
+
+	for _, server := range diff.Added {
+		resp.Added = append(resp.Added, response.SyncedServerResponse{
+			ID:         server.ID,
+			Hostname:   server.Hostname,
+			PublicIP:   server.PublicIP,
+			LibvirtURI: server.LibvirtURI,
+			Labels:     server.Labels,
+		})
+	}
+
+	for _, change := range diff.Updated {
+		resp.Updated = append(resp.Updated, response.InventoryChangeResponse{
+			ServerID: change.ServerID,
+			Field:    change.Field,
+			Old:      change.Old,
+			New:      change.New,
+		})
+	}
+
+	resp.Missing = append(resp.Missing, diff.Missing...)
+	resp.Returned = append(resp.Returned, diff.Returned...)
+	resp.Errors = append(resp.Errors, diff.Errors...)
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// DiscoverServer connects to an existing server, refreshes its capabilities and returns them. Servers imported from the cloud service provider have no capabilities until they are discovered.
+func (c *ServerController) DiscoverServer(w http.ResponseWriter, r *http.Request) {
+	var req request.DiscoverServerRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	c.discoverServer(w, r, req)
+}
+
+// discoverServer refreshes the capabilities of the server given in the request.
+func (c *ServerController) discoverServer(w http.ResponseWriter, r *http.Request, req request.DiscoverServerRequest) {
+	server, err := c.onboardingService.Rediscover(r.Context(), req.ID)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		http.Error(w, "Server not found", http.StatusNotFound)
+		return
+	}
+
+	var rejected *service.RejectedError
+	if errors.As(err, &rejected) {
+		http.Error(w, rejected.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to discover server: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(newNodeCapabilitiesResponse(server.Capabilities)); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// newNodeCapabilitiesResponse converts the capabilities of a server into their response representation. It returns nil if the capabilities were never discovered.
+func newNodeCapabilitiesResponse(capabilities *entity.NodeCapabilities) *response.NodeCapabilitiesResponse {
+	if capabilities == nil {
+		return nil
+	}
+
+	resp := &response.NodeCapabilitiesResponse{
+		Arch:              capabilities.Arch,
+		CPUVendor:         capabilities.CPUVendor,
+		CPUModel:          capabilities.CPUModel,
+		CPUs:              capabilities.CPUs,
+		Sockets:           capabilities.Sockets,
+		Cores:             capabilities.Cores,
+		Threads:           capabilities.Threads,
+		Memory:            capabilities.Memory / 1024,
+		NUMACells:         []response.NUMACellResponse{},
+		LibvirtVersion:    capabilities.LibvirtVersion,
+		HypervisorVersion: capabilities.HypervisorVersion,
+		KVM:               capabilities.KVM,
+		MachineTypes:      capabilities.MachineTypes,
+		DefaultMachine:    capabilities.DefaultMachine,
+		HostModel:         capabilities.HostModel,
+		HostPassthrough:   capabilities.HostPassthrough,
+		CPUModels:         capabilities.CPUModels,
+		DiscoveredAt:      capabilities.DiscoveredAt,
+	}
+
+	for _, cell := range capabilities.NUMACells {
+		resp.NUMACells = append(resp.NUMACells, response.NUMACellResponse{
+			ID:     cell.ID,
+			Memory: cell.Memory / 1024,
+			CPUs:   cell.CPUs,
+		})
+	}
+
+	return resp
+}
@@ -6,9 +6,11 @@ import (
 	"log/slog"
 	"net/http"
 	"strconv"
+	"strings"
 
 	request "github.com/sychonet/vdash-be/dto/request"
 	response "github.com/sychonet/vdash-be/dto/response"
+	"github.com/sychonet/vdash-be/service"
 )
 
 // CreateStoragePool creates a new storage pool using the provided request.
@@ -19,18 +21,23 @@ func (c *ServerController) CreateStoragePool(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
+	c.createStoragePool(w, r, req)
+}
+
+// createStoragePool creates the storage pool described by the request.
+func (c *ServerController) createStoragePool(w http.ResponseWriter, r *http.Request, req request.StoragePoolRequest) {
 	// Get libvirt URI from database
-	serverDetail, err := c.dbService.GetServer(req.ServerID)
+	serverDetail, err := c.dbService.GetServer(r.Context(), req.ServerID)
 	if err != nil {
 		slog.Error("Failed to get libvirt uri from database: " + err.Error())
 		http.Error(w, fmt.Sprintf("Failed to get server details: %v", err), http.StatusInternalServerError)
 		return
 	}
 
-	c.libvirtService.URI = serverDetail.LibvirtURI
+	libvirtService := c.libvirtService.Connect(serverDetail.LibvirtURI)
 
 	// Create storage pool
-	err = c.libvirtService.CreateStoragePool(req.Name, req.Path)
+	err = libvirtService.CreateStoragePool(r.Context(), req.Name, req.Path)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Failed to create storage pool: %v", err), http.StatusInternalServerError)
 		return
@@ -65,17 +72,27 @@ func (c *ServerController) GetStoragePools(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
+	c.getStoragePools(w, r, serverID)
+}
+
+// getStoragePools lists the storage pools of the server. They can be filtered by a prefix of their name and their state, and sorted by name or state.
+func (c *ServerController) getStoragePools(w http.ResponseWriter, r *http.Request, serverID int) {
+	filter, ok := parseListFilter(w, r)
+	if !ok {
+		return
+	}
+
 	// Get libvirt URI from database
-	serverDetail, err := c.dbService.GetServer(serverID)
+	serverDetail, err := c.dbService.GetServer(r.Context(), serverID)
 	if err != nil {
 		slog.Error("Failed to get libvirt uri from database: " + err.Error())
 		http.Error(w, fmt.Sprintf("Failed to get server details: %v", err), http.StatusInternalServerError)
 		return
 	}
 
-	c.libvirtService.URI = serverDetail.LibvirtURI
+	libvirtService := c.libvirtService.Connect(serverDetail.LibvirtURI)
 
-	pools, err := c.libvirtService.GetStoragePools()
+	pools, err := libvirtService.GetStoragePools(r.Context())
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Failed to fetch pools details on node: %v", err), http.StatusInternalServerError)
 		return
@@ -90,22 +107,36 @@ func (c *ServerController) GetStoragePools(w http.ResponseWriter, r *http.Reques
 			return
 		}
 
+		if !filter.matchName(name) {
+			continue
+		}
+
 		info, err := pool.GetInfo()
 		if err != nil {
 			http.Error(w, fmt.Sprintf("Failed to get pool info: %v", err), http.StatusInternalServerError)
 			return
 		}
 
+		state := service.StoragePoolStateName(info.State)
+		if !filter.matchState(state) {
+			continue
+		}
+
 		resp = append(resp, response.StoragePoolListResponse{
 			Name:      name,
 			Available: fmt.Sprintf("%dG", info.Available),
+			State:     state,
 		})
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(resp); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-	}
+	writeList(w, r, resp, listOrder[response.StoragePoolListResponse]{
+		key: func(p response.StoragePoolListResponse) string { return p.Name },
+		fields: map[string]func(a, b response.StoragePoolListResponse) int{
+			"name":  func(a, b response.StoragePoolListResponse) int { return strings.Compare(a.Name, b.Name) },
+			"state": func(a, b response.StoragePoolListResponse) int { return strings.Compare(a.State, b.State) },
+		},
+		defaultSort: "name",
+	})
 }
 
 // DeleteStoragePool deletes the specified storage pool on a node.
@@ -116,18 +147,23 @@ func (c *ServerController) DeleteStoragePool(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
+	c.deleteStoragePool(w, r, req)
+}
+
+// deleteStoragePool deletes the storage pool given in the request.
+func (c *ServerController) deleteStoragePool(w http.ResponseWriter, r *http.Request, req request.DeleteStoragePoolRequest) {
 	// Get libvirt uri from database
-	serverDetail, err := c.dbService.GetServer(req.ServerID)
+	serverDetail, err := c.dbService.GetServer(r.Context(), req.ServerID)
 	if err != nil {
 		slog.Error("Failed to get libvirt uri from database: " + err.Error())
 		http.Error(w, fmt.Sprintf("Failed to get server details: %v", err), http.StatusInternalServerError)
 		return
 	}
 
-	c.libvirtService.URI = serverDetail.LibvirtURI
+	libvirtService := c.libvirtService.Connect(serverDetail.LibvirtURI)
 
 	// Delete pool from node
-	err = c.libvirtService.DeleteStoragePool(req.Name)
+	err = libvirtService.DeleteStoragePool(r.Context(), req.Name)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Failed to delete storage pool on server: %v", err), http.StatusInternalServerError)
 		return
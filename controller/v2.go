@@ -0,0 +1,391 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	request "github.com/sychonet/vdash-be/dto/request"
+)
+
+// The v2 handlers name the resource they act on in the path, such as /v2/servers/{serverID}/domains/{name}, instead of in the body of the request or in query parameters. They share the logic of the v1 handlers, the fields given in the path take precedence over the ones in the body.
+
+// pathServerID returns the server given in the path. If it is invalid an error response is written and false is returned.
+func pathServerID(w http.ResponseWriter, r *http.Request) (int, bool) {
+	serverID, err := strconv.Atoi(chi.URLParam(r, "serverID"))
+	if err != nil || serverID <= 0 {
+		http.Error(w, "Invalid serverID", http.StatusBadRequest)
+		return 0, false
+	}
+
+	return serverID, true
+}
+
+// decodeBody reads the JSON body of a v2 request into req. The body is optional as the resource is named in the path. If the body is invalid an error response is written and false is returned.
+func decodeBody(w http.ResponseWriter, r *http.Request, req any) bool {
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil && !errors.Is(err, io.EOF) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return false
+	}
+
+	return true
+}
+
+// DeleteServerV2 deletes the server given in the path.
+func (c *ServerController) DeleteServerV2(w http.ResponseWriter, r *http.Request) {
+	serverID, ok := pathServerID(w, r)
+	if !ok {
+		return
+	}
+
+	c.deleteServer(w, r, request.DeleteServerRequest{ID: serverID})
+}
+
+// UpdateServerLabelsV2 replaces the labels of the server given in the path.
+func (c *ServerController) UpdateServerLabelsV2(w http.ResponseWriter, r *http.Request) {
+	serverID, ok := pathServerID(w, r)
+	if !ok {
+		return
+	}
+
+	var req request.UpdateServerLabelsRequest
+	if !decodeBody(w, r, &req) {
+		return
+	}
+	req.ID = serverID
+
+	c.updateServerLabels(w, r, req)
+}
+
+// UpdateServerConnectionV2 replaces the connection overrides of the server given in the path.
+func (c *ServerController) UpdateServerConnectionV2(w http.ResponseWriter, r *http.Request) {
+	serverID, ok := pathServerID(w, r)
+	if !ok {
+		return
+	}
+
+	var req request.UpdateServerConnectionRequest
+	if !decodeBody(w, r, &req) {
+		return
+	}
+	req.ID = serverID
+
+	c.updateServerConnection(w, r, req)
+}
+
+// DiscoverServerV2 refreshes the capabilities of the server given in the path.
+func (c *ServerController) DiscoverServerV2(w http.ResponseWriter, r *http.Request) {
+	serverID, ok := pathServerID(w, r)
+	if !ok {
+		return
+	}
+
+	c.discoverServer(w, r, request.DiscoverServerRequest{ID: serverID})
+}
+
+// CordonServerV2 cordons the server given in the path.
+func (c *ServerController) CordonServerV2(w http.ResponseWriter, r *http.Request) {
+	serverID, ok := pathServerID(w, r)
+	if !ok {
+		return
+	}
+
+	c.setCordoned(w, r, serverID, true)
+}
+
+// UncordonServerV2 uncordons the server given in the path.
+func (c *ServerController) UncordonServerV2(w http.ResponseWriter, r *http.Request) {
+	serverID, ok := pathServerID(w, r)
+	if !ok {
+		return
+	}
+
+	c.setCordoned(w, r, serverID, false)
+}
+
+// DrainServerV2 starts draining the server given in the path.
+func (c *ServerController) DrainServerV2(w http.ResponseWriter, r *http.Request) {
+	serverID, ok := pathServerID(w, r)
+	if !ok {
+		return
+	}
+
+	var req request.DrainServerRequest
+	if !decodeBody(w, r, &req) {
+		return
+	}
+	req.ID = serverID
+
+	c.drainServer(w, r, req)
+}
+
+// GetDrainV2 returns the progress of the latest drain of the server given in the path.
+func (c *ServerController) GetDrainV2(w http.ResponseWriter, r *http.Request) {
+	serverID, ok := pathServerID(w, r)
+	if !ok {
+		return
+	}
+
+	c.getDrain(w, r, serverID)
+}
+
+// GetServerEventsV2 returns the latest health status changes of the server given in the path.
+func (c *ServerController) GetServerEventsV2(w http.ResponseWriter, r *http.Request) {
+	serverID, ok := pathServerID(w, r)
+	if !ok {
+		return
+	}
+
+	c.getServerEvents(w, r, serverID)
+}
+
+// CreateStoragePoolV2 creates a storage pool on the server given in the path.
+func (c *ServerController) CreateStoragePoolV2(w http.ResponseWriter, r *http.Request) {
+	serverID, ok := pathServerID(w, r)
+	if !ok {
+		return
+	}
+
+	var req request.StoragePoolRequest
+	if !decodeBody(w, r, &req) {
+		return
+	}
+	req.ServerID = serverID
+
+	c.createStoragePool(w, r, req)
+}
+
+// GetStoragePoolsV2 returns the storage pools of the server given in the path.
+func (c *ServerController) GetStoragePoolsV2(w http.ResponseWriter, r *http.Request) {
+	serverID, ok := pathServerID(w, r)
+	if !ok {
+		return
+	}
+
+	c.getStoragePools(w, r, serverID)
+}
+
+// DeleteStoragePoolV2 deletes the storage pool given in the path.
+func (c *ServerController) DeleteStoragePoolV2(w http.ResponseWriter, r *http.Request) {
+	serverID, ok := pathServerID(w, r)
+	if !ok {
+		return
+	}
+
+	c.deleteStoragePool(w, r, request.DeleteStoragePoolRequest{ServerID: serverID, Name: chi.URLParam(r, "pool")})
+}
+
+// CreateVolumeV2 creates a storage volume in the storage pool given in the path.
+func (c *ServerController) CreateVolumeV2(w http.ResponseWriter, r *http.Request) {
+	serverID, ok := pathServerID(w, r)
+	if !ok {
+		return
+	}
+
+	var req request.CreateVolumeRequest
+	if !decodeBody(w, r, &req) {
+		return
+	}
+	req.ServerID = serverID
+	req.PoolName = chi.URLParam(r, "pool")
+
+	c.createVolume(w, r, req)
+}
+
+// GetVolumesV2 returns the storage volumes of the project of the request in the storage pool given in the path.
+func (c *ServerController) GetVolumesV2(w http.ResponseWriter, r *http.Request) {
+	serverID, ok := pathServerID(w, r)
+	if !ok {
+		return
+	}
+
+	c.getVolumes(w, r, serverID, chi.URLParam(r, "pool"))
+}
+
+// ResizeVolumeV2 grows the storage volume given in the path.
+func (c *ServerController) ResizeVolumeV2(w http.ResponseWriter, r *http.Request) {
+	serverID, ok := pathServerID(w, r)
+	if !ok {
+		return
+	}
+
+	var req request.ResizeVolumeRequest
+	if !decodeBody(w, r, &req) {
+		return
+	}
+	req.ServerID = serverID
+	req.PoolName = chi.URLParam(r, "pool")
+	req.Name = chi.URLParam(r, "volume")
+
+	c.resizeVolume(w, r, req)
+}
+
+// DeleteVolumeV2 deletes the storage volume given in the path.
+func (c *ServerController) DeleteVolumeV2(w http.ResponseWriter, r *http.Request) {
+	serverID, ok := pathServerID(w, r)
+	if !ok {
+		return
+	}
+
+	c.deleteVolume(w, r, request.DeleteVolumeRequest{ServerID: serverID, PoolName: chi.URLParam(r, "pool"), Name: chi.URLParam(r, "volume")})
+}
+
+// CreateDomainV2 creates a domain on the server given in the path. Domains placed by the scheduler are created with CreateDomain.
+func (c *ServerController) CreateDomainV2(w http.ResponseWriter, r *http.Request) {
+	serverID, ok := pathServerID(w, r)
+	if !ok {
+		return
+	}
+
+	var req request.CreateDomainRequest
+	if !decodeBody(w, r, &req) {
+		return
+	}
+	req.ServerID = serverID
+
+	c.createDomain(w, r, req)
+}
+
+// GetDomainsV2 returns the domains of the project of the request on the server given in the path.
+func (c *ServerController) GetDomainsV2(w http.ResponseWriter, r *http.Request) {
+	serverID, ok := pathServerID(w, r)
+	if !ok {
+		return
+	}
+
+	c.getDomains(w, r, serverID)
+}
+
+// ResizeDomainV2 changes the memory and vCPUs of the domain given in the path.
+func (c *ServerController) ResizeDomainV2(w http.ResponseWriter, r *http.Request) {
+	serverID, ok := pathServerID(w, r)
+	if !ok {
+		return
+	}
+
+	var req request.ResizeDomainRequest
+	if !decodeBody(w, r, &req) {
+		return
+	}
+	req.ServerID = serverID
+	req.Name = chi.URLParam(r, "name")
+
+	c.resizeDomain(w, r, req)
+}
+
+// DeleteDomainV2 deletes the domain given in the path.
+func (c *ServerController) DeleteDomainV2(w http.ResponseWriter, r *http.Request) {
+	serverID, ok := pathServerID(w, r)
+	if !ok {
+		return
+	}
+
+	c.deleteDomain(w, r, request.DeleteDomainRequest{ServerID: serverID, Name: chi.URLParam(r, "name")})
+}
+
+// CreateNetworkV2 creates a network on the server given in the path.
+func (c *ServerController) CreateNetworkV2(w http.ResponseWriter, r *http.Request) {
+	serverID, ok := pathServerID(w, r)
+	if !ok {
+		return
+	}
+
+	var req request.NetworkRequest
+	if !decodeBody(w, r, &req) {
+		return
+	}
+	req.ServerID = serverID
+
+	c.createNetwork(w, r, req)
+}
+
+// GetNetworksV2 returns the networks of the project of the request on the server given in the path.
+func (c *ServerController) GetNetworksV2(w http.ResponseWriter, r *http.Request) {
+	serverID, ok := pathServerID(w, r)
+	if !ok {
+		return
+	}
+
+	c.getNetworks(w, r, serverID)
+}
+
+// DeleteNetworkV2 deletes the network given in the path.
+func (c *ServerController) DeleteNetworkV2(w http.ResponseWriter, r *http.Request) {
+	serverID, ok := pathServerID(w, r)
+	if !ok {
+		return
+	}
+
+	c.deleteNetwork(w, r, request.NetworkRequest{ServerID: serverID, Name: chi.URLParam(r, "name")})
+}
+
+// UpdatePublicIPV2 updates the public IP given in the path.
+func (c *ServerController) UpdatePublicIPV2(w http.ResponseWriter, r *http.Request) {
+	var req request.UpdatePublicIPRequest
+	if !decodeBody(w, r, &req) {
+		return
+	}
+	req.IP = chi.URLParam(r, "ip")
+
+	c.updatePublicIP(w, r, req)
+}
+
+// DeletePublicIPV2 deletes the public IP given in the path.
+func (c *ServerController) DeletePublicIPV2(w http.ResponseWriter, r *http.Request) {
+	c.deletePublicIP(w, r, request.DeletePublicIPRequest{IP: chi.URLParam(r, "ip")})
+}
+
+// RoutePublicIPV2 routes the failover IP given in the path to another server.
+func (c *ServerController) RoutePublicIPV2(w http.ResponseWriter, r *http.Request) {
+	var req request.RoutePublicIPRequest
+	if !decodeBody(w, r, &req) {
+		return
+	}
+	req.IP = chi.URLParam(r, "ip")
+
+	c.routePublicIP(w, r, req)
+}
+
+// GeneratePublicIPMACV2 creates a virtual MAC for the failover IP given in the path.
+func (c *ServerController) GeneratePublicIPMACV2(w http.ResponseWriter, r *http.Request) {
+	var req request.GeneratePublicIPMACRequest
+	if !decodeBody(w, r, &req) {
+		return
+	}
+	req.IP = chi.URLParam(r, "ip")
+
+	c.generatePublicIPMAC(w, r, req)
+}
+
+// DeletePublicIPMACV2 deletes the virtual MAC of the failover IP given in the path.
+func (c *ServerController) DeletePublicIPMACV2(w http.ResponseWriter, r *http.Request) {
+	c.deletePublicIPMAC(w, r, request.DeletePublicIPMACRequest{IP: chi.URLParam(r, "ip")})
+}
+
+// DeleteServerGroupV2 deletes the server group given in the path.
+func (c *ServerController) DeleteServerGroupV2(w http.ResponseWriter, r *http.Request) {
+	c.deleteServerGroup(w, r, request.DeleteServerGroupRequest{Name: chi.URLParam(r, "name")})
+}
+
+// RevokeAPIKeyV2 revokes the API key given in the path.
+func (c *ServerController) RevokeAPIKeyV2(w http.ResponseWriter, r *http.Request) {
+	c.revokeAPIKey(w, r, request.RevokeAPIKeyRequest{ID: chi.URLParam(r, "id")})
+}
+
+// DeleteRoleV2 deletes the custom role given in the path.
+func (c *ServerController) DeleteRoleV2(w http.ResponseWriter, r *http.Request) {
+	c.deleteRole(w, r, request.DeleteRoleRequest{Name: chi.URLParam(r, "name")})
+}
+
+// DeleteRoleBindingV2 removes the role binding given in the path.
+func (c *ServerController) DeleteRoleBindingV2(w http.ResponseWriter, r *http.Request) {
+	c.deleteRoleBinding(w, r, request.DeleteRoleBindingRequest{ID: chi.URLParam(r, "id")})
+}
+
+// DeleteProjectV2 deletes the project given in the path.
+func (c *ServerController) DeleteProjectV2(w http.ResponseWriter, r *http.Request) {
+	c.deleteProject(w, r, request.DeleteProjectRequest{Name: chi.URLParam(r, "project")})
+}
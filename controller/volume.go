@@ -1,14 +1,21 @@
 package controller
 
 import (
+	"cmp"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
+	"github.com/sychonet/vdash-be/db/entity"
 	request "github.com/sychonet/vdash-be/dto/request"
 	response "github.com/sychonet/vdash-be/dto/response"
+	"github.com/sychonet/vdash-be/service"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
 // CreateVolume creates a new storage volume using the provided request.
@@ -19,6 +26,11 @@ func (c *ServerController) CreateVolume(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	c.createVolume(w, r, req)
+}
+
+// createVolume creates the storage volume described by the request.
+func (c *ServerController) createVolume(w http.ResponseWriter, r *http.Request, req request.CreateVolumeRequest) {
 	serverID := req.ServerID
 
 	if serverID < 0 {
@@ -31,9 +43,25 @@ func (c *ServerController) CreateVolume(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	if err := service.ValidateName(req.Name); err != nil {
+		http.Error(w, "Invalid name: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	project, ok := c.project(w, r)
+	if !ok {
+		return
+	}
+
+	placement, err := newPlacement(req.NodeSelector, req.RequiredConstraints, req.PreferredConstraints)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Invalid placement: %v", err), http.StatusBadRequest)
+		return
+	}
+
 	if serverID == 0 {
 		// Get serverID from volume scheduler with given storage pool name
-		serverID, err := c.schedulerService.GetServerIDForVolume(req.PoolName, req.Size)
+		serverID, err = c.schedulerService.GetServerIDForVolume(r.Context(), req.PoolName, req.Size, placement)
 
 		if err != nil {
 			http.Error(w, fmt.Sprintf("Failed to get serverID: %v", err), http.StatusInternalServerError)
@@ -47,29 +75,71 @@ func (c *ServerController) CreateVolume(w http.ResponseWriter, r *http.Request)
 	}
 
 	// Get the libvirt uri from database
-	serverDetail, err := c.dbService.GetServer(serverID)
+	serverDetail, err := c.dbService.GetServer(r.Context(), serverID)
 	if err != nil {
 		slog.Error("Failed to get libvirt uri from database: " + err.Error())
 		http.Error(w, fmt.Sprintf("Failed to get server details: %v", err), http.StatusInternalServerError)
 		return
 	}
 
-	c.libvirtService.URI = serverDetail.LibvirtURI
+	// The project is only locked once the server is picked, so that the probes of the scheduler do not hold up the other requests of the project
+	release, ok := c.reserveQuota(w, r, project, service.ResourceUsage{Disk: int64(req.Size)})
+	if !ok {
+		return
+	}
+	defer release()
+
+	// Volumes of different projects may share a name in a storage pool as the project is part of the libvirt name
+	libvirtName := service.LibvirtName(project, req.Name)
+	existing, err := c.dbService.GetVolumeByLibvirtName(r.Context(), serverID, req.PoolName, libvirtName)
+	if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+		http.Error(w, fmt.Sprintf("Failed to check storage volume name: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	if existing != nil {
+		http.Error(w, "Storage volume already exists", http.StatusConflict)
+		return
+	}
+
+	libvirtService := c.libvirtService.Connect(serverDetail.LibvirtURI)
 
 	// Create the storage volume
-	err = c.libvirtService.CreateStorageVolume(req.PoolName, req.Name, req.Format, req.Size)
+	path, err := libvirtService.CreateStorageVolume(r.Context(), req.PoolName, req.Format, libvirtName, req.Size)
 
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Failed to create storage volume: %v", err), http.StatusInternalServerError)
 		return
 	}
 
+	// Record the owner of the volume, libvirt keeps no metadata on volumes
+	volume := entity.VolumeInfo{
+		ServerID:    serverID,
+		Pool:        req.PoolName,
+		Name:        req.Name,
+		LibvirtName: libvirtName,
+		Project:     project,
+		Format:      req.Format,
+		Size:        req.Size,
+		Path:        path,
+		CreatedAt:   time.Now().UTC(),
+	}
+	if err := c.dbService.AddVolume(r.Context(), volume); err != nil {
+		// A volume without a record belongs to no project and escapes its quota, so it is removed again
+		if err := libvirtService.DeleteStorageVolume(r.Context(), req.PoolName, libvirtName); err != nil {
+			slog.Error("Failed to remove unrecorded storage volume " + libvirtName + ": " + err.Error())
+		}
+		http.Error(w, fmt.Sprintf("Failed to record storage volume: %v", err), http.StatusInternalServerError)
+		return
+	}
+
 	// Prepare the response
 	resp := response.CreateVolumeResponse{
 		ServerID: serverID,
 		Name:     req.Name,
 		Format:   req.Format,
 		Size:     uint64(req.Size),
+		Path:     path,
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -101,18 +171,47 @@ func (c *ServerController) GetVolumes(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	c.getVolumes(w, r, serverID, poolName)
+}
+
+// getVolumes lists the storage volumes of the project of the request in a storage pool of the server. They can be filtered by a prefix of their name and sorted by name or size.
+func (c *ServerController) getVolumes(w http.ResponseWriter, r *http.Request, serverID int, poolName string) {
+	filter, ok := parseListFilter(w, r)
+	if !ok {
+		return
+	}
+
+	project, ok := c.project(w, r)
+	if !ok {
+		return
+	}
+
 	// Get the libvirt uri from database
-	serverDetail, err := c.dbService.GetServer(serverID)
+	serverDetail, err := c.dbService.GetServer(r.Context(), serverID)
 	if err != nil {
 		slog.Error("Failed to get libvirt uri from database: " + err.Error())
 		http.Error(w, fmt.Sprintf("Failed to get server details: %v", err), http.StatusInternalServerError)
 		return
 	}
 
-	c.libvirtService.URI = serverDetail.LibvirtURI
+	// Get the owners of the volumes created through vdash in the storage pool
+	records, err := c.dbService.GetVolumesOnServer(r.Context(), serverID, poolName)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to get storage volume owners: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	owners := map[string]service.Owner{}
+	paths := map[string]string{}
+	for _, record := range records {
+		owners[record.LibvirtName] = service.Owner{Project: record.Project, Name: record.Name}
+		paths[record.LibvirtName] = record.Path
+	}
+
+	libvirtService := c.libvirtService.Connect(serverDetail.LibvirtURI)
 
 	// List all storage volumes on a given server under a given storage pool
-	volumes, err := c.libvirtService.GetStorageVolumesOnServer(poolName)
+	volumes, err := libvirtService.GetStorageVolumesOnServer(r.Context(), poolName)
 
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Failed to list storage volumes: %v", err), http.StatusInternalServerError)
@@ -127,12 +226,18 @@ func (c *ServerController) GetVolumes(w http.ResponseWriter, r *http.Request) {
 	// Prepare the response
 	var resp []response.CreateVolumeResponse
 	for _, volume := range volumes {
-		name, err := volume.GetName()
+		libvirtName, err := volume.GetName()
 		if err != nil {
 			http.Error(w, fmt.Sprintf("Failed to get volume name: %v", err), http.StatusInternalServerError)
 			return
 		}
 
+		// Only show the volumes of the project
+		name, ok := service.VisibleName(owners, libvirtName, project)
+		if !ok || !filter.matchName(name) {
+			continue
+		}
+
 		info, err := volume.GetInfo()
 		if err != nil {
 			http.Error(w, fmt.Sprintf("Failed to get volume info: %v", err), http.StatusInternalServerError)
@@ -143,13 +248,112 @@ func (c *ServerController) GetVolumes(w http.ResponseWriter, r *http.Request) {
 			Name:   name,
 			Format: fmt.Sprintf("%d", info.Type),
 			Size:   info.Capacity,
+			Path:   paths[libvirtName],
 		})
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(resp); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	writeList(w, r, resp, listOrder[response.CreateVolumeResponse]{
+		key: func(v response.CreateVolumeResponse) string { return v.Name },
+		fields: map[string]func(a, b response.CreateVolumeResponse) int{
+			"name": func(a, b response.CreateVolumeResponse) int { return strings.Compare(a.Name, b.Name) },
+			"size": func(a, b response.CreateVolumeResponse) int { return cmp.Compare(a.Size, b.Size) },
+		},
+		defaultSort: "name",
+	})
+}
+
+// ResizeVolume grows a storage volume. The growth counts against the quota of the project owning the volume.
+func (c *ServerController) ResizeVolume(w http.ResponseWriter, r *http.Request) {
+	var req request.ResizeVolumeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
 	}
+
+	c.resizeVolume(w, r, req)
+}
+
+// resizeVolume grows the storage volume given in the request.
+func (c *ServerController) resizeVolume(w http.ResponseWriter, r *http.Request, req request.ResizeVolumeRequest) {
+	// Validate the request
+	if req.ServerID <= 0 {
+		http.Error(w, "Invalid serverID", http.StatusBadRequest)
+		return
+	}
+
+	if req.PoolName == "" || req.Name == "" {
+		http.Error(w, "Invalid volume", http.StatusBadRequest)
+		return
+	}
+
+	if req.Size <= 0 {
+		http.Error(w, "Invalid size", http.StatusBadRequest)
+		return
+	}
+
+	project, ok := c.project(w, r)
+	if !ok {
+		return
+	}
+
+	// Get the libvirt uri from database
+	serverDetail, err := c.dbService.GetServer(r.Context(), req.ServerID)
+	if err != nil {
+		slog.Error("Failed to get libvirt uri from database: " + err.Error())
+		http.Error(w, fmt.Sprintf("Failed to get server details: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	libvirtName := service.LibvirtName(project, req.Name)
+	record, err := c.dbService.GetVolumeByLibvirtName(r.Context(), req.ServerID, req.PoolName, libvirtName)
+	if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+		http.Error(w, fmt.Sprintf("Failed to get storage volume owner: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	if record != nil && record.Project != project {
+		http.Error(w, "Storage volume not found", http.StatusNotFound)
+		return
+	}
+
+	libvirtService := c.libvirtService.Connect(serverDetail.LibvirtURI)
+
+	// The quota is charged with the difference to the current size of the volume
+	var size int
+	if record != nil {
+		size = record.Size
+	} else {
+		capacity, err := libvirtService.GetStorageVolumeSize(r.Context(), req.PoolName, libvirtName)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Failed to get storage volume: %v", err), http.StatusInternalServerError)
+			return
+		}
+		size = int((capacity + 1<<30 - 1) >> 30)
+	}
+
+	if req.Size < size {
+		http.Error(w, "Storage volumes can only grow", http.StatusUnprocessableEntity)
+		return
+	}
+
+	release, ok := c.reserveQuota(w, r, project, service.ResourceUsage{Disk: int64(req.Size - size)})
+	if !ok {
+		return
+	}
+	defer release()
+
+	if err := libvirtService.ResizeStorageVolume(r.Context(), req.PoolName, libvirtName, req.Size); err != nil {
+		http.Error(w, fmt.Sprintf("Failed to resize storage volume: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	if record != nil {
+		if err := c.dbService.UpdateVolumeSize(r.Context(), record.ID, req.Size); err != nil {
+			slog.Error("Failed to record size of storage volume " + libvirtName + ": " + err.Error())
+		}
+	}
+
+	w.WriteHeader(http.StatusNoContent)
 }
 
 // DeleteVolume deletes the specified disk image.
@@ -160,21 +364,52 @@ func (c *ServerController) DeleteVolume(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	c.deleteVolume(w, r, req)
+}
+
+// deleteVolume deletes the storage volume given in the request.
+func (c *ServerController) deleteVolume(w http.ResponseWriter, r *http.Request, req request.DeleteVolumeRequest) {
+	project, ok := c.project(w, r)
+	if !ok {
+		return
+	}
+
 	// Get the libvirt uri from database
-	serverDetail, err := c.dbService.GetServer(req.ServerID)
+	serverDetail, err := c.dbService.GetServer(r.Context(), req.ServerID)
 	if err != nil {
 		slog.Error("Failed to get libvirt uri from database: " + err.Error())
 		http.Error(w, fmt.Sprintf("Failed to get server details: %v", err), http.StatusInternalServerError)
 		return
 	}
 
-	c.libvirtService.URI = serverDetail.LibvirtURI
+	// Refuse to delete a volume of another project, or one without an ownership record unless allowed in every project
+	libvirtName := service.LibvirtName(project, req.Name)
+	record, err := c.dbService.GetVolumeByLibvirtName(r.Context(), req.ServerID, req.PoolName, libvirtName)
+	if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+		http.Error(w, fmt.Sprintf("Failed to get storage volume owner: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	var owner string
+	if record != nil {
+		owner = record.Project
+	}
+
+	if !c.checkOwner(w, r, owner, project, service.ResourceStorage+":"+service.VerbDelete, "Storage volume not found") {
+		return
+	}
 
-	err = c.libvirtService.DeleteStorageVolume(req.PoolName, req.Name)
+	libvirtService := c.libvirtService.Connect(serverDetail.LibvirtURI)
+
+	err = libvirtService.DeleteStorageVolume(r.Context(), req.PoolName, libvirtName)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Failed to delete storage volume: %v", err), http.StatusInternalServerError)
 		return
 	}
 
+	if err := c.dbService.DeleteVolumeByName(r.Context(), req.ServerID, req.PoolName, libvirtName); err != nil {
+		slog.Error("Failed to delete record of storage volume " + libvirtName + ": " + err.Error())
+	}
+
 	w.WriteHeader(http.StatusNoContent)
 }
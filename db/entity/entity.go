@@ -1,16 +1,291 @@
 package entity
 
-// ServerInfo represents a server information.
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ServerInfo represents a server information. ProviderID is the ID of the server in the inventory of its provider account, which differs from ID when another server already used it.
 type ServerInfo struct {
-	ID         int    `bson:"_id"`
-	Hostname   string `bson:"hostname"`
-	PublicIP   string `bson:"publicIP"`
-	LibvirtURI string `bson:"libvirtURI"`
+	ID           int               `bson:"_id"`
+	Hostname     string            `bson:"hostname"`
+	PublicIP     string            `bson:"publicIP"`
+	LibvirtURI   string            `bson:"libvirtURI"`
+	Labels       map[string]string `bson:"labels,omitempty"`
+	Cordoned     bool              `bson:"cordoned"`
+	Health       ServerHealth      `bson:"health"`
+	Capabilities *NodeCapabilities `bson:"capabilities,omitempty"`
+	Connection   *ConnectionConfig `bson:"connection,omitempty"`
+	Provider     string            `bson:"provider,omitempty"`
+	ProviderID   int               `bson:"providerID,omitempty"`
+	Missing      bool              `bson:"missing"`
+	MissingSince time.Time         `bson:"missingSince,omitempty"`
+}
+
+// ServerHealth represents the outcome of the latest health checks of a server.
+type ServerHealth struct {
+	Status          string    `bson:"status"`
+	LastSeen        time.Time `bson:"lastSeen,omitempty"`
+	LastChecked     time.Time `bson:"lastChecked,omitempty"`
+	StatusChangedAt time.Time `bson:"statusChangedAt,omitempty"`
+	Failures        int       `bson:"failures"`
+	Error           string    `bson:"error,omitempty"`
+	LibvirtVersion  string    `bson:"libvirtVersion,omitempty"`
+	LastPowerCycle  time.Time `bson:"lastPowerCycle,omitempty"`
+}
+
+// ServerEvent represents a change of the health status of a server.
+type ServerEvent struct {
+	ID       primitive.ObjectID `bson:"_id,omitempty"`
+	ServerID int                `bson:"serverID"`
+	Time     time.Time          `bson:"time"`
+	From     string             `bson:"from"`
+	To       string             `bson:"to"`
+	Error    string             `bson:"error,omitempty"`
 }
 
-// IPInfo represents public ip information associated with a server.
+// IPInfo represents public ip information associated with a server. Project is the project the IP is allocated to while it is not available.
 type IPInfo struct {
-	IP        string `bson:"_id"`
-	ServerID  int    `bson:"serverID"`
-	Available bool   `bson:"available"`
+	IP          string    `bson:"_id"`
+	ServerID    int       `bson:"serverID"`
+	Available   bool      `bson:"available"`
+	Provider    string    `bson:"provider,omitempty"`
+	Destination string    `bson:"destination,omitempty"`
+	MAC         string    `bson:"mac,omitempty"`
+	SyncedAt    time.Time `bson:"syncedAt,omitempty"`
+	Project     string    `bson:"project,omitempty"`
+}
+
+// PlacementConstraint represents a rule on server labels that the scheduler evaluates while picking a server for a resource.
+type PlacementConstraint struct {
+	Key      string   `bson:"key"`
+	Operator string   `bson:"operator"`
+	Values   []string `bson:"values,omitempty"`
+	Weight   int      `bson:"weight,omitempty"`
+}
+
+// ServerGroup represents a group of domains whose placement on servers is governed by an affinity policy.
+type ServerGroup struct {
+	Name    string              `bson:"_id"`
+	Policy  string              `bson:"policy"`
+	Members []ServerGroupMember `bson:"members"`
+}
+
+// ServerGroupMember represents a domain belonging to a server group along with the server it runs on.
+type ServerGroupMember struct {
+	Domain   string `bson:"domain"`
+	ServerID int    `bson:"serverID"`
+}
+
+// GroupViolation represents members of a server group which are placed against the policy of the group.
+type GroupViolation struct {
+	Group     string   `bson:"group"`
+	Policy    string   `bson:"policy"`
+	Soft      bool     `bson:"soft"`
+	Message   string   `bson:"message"`
+	Domains   []string `bson:"domains"`
+	ServerIDs []int    `bson:"serverIDs"`
+}
+
+// DomainInfo represents a domain created through vdash along with the project owning it and the placement requirements it was scheduled with. Name is the name known to the project and LibvirtName the one defined in libvirt. Memory is in MiB.
+type DomainInfo struct {
+	UUID         string                `bson:"_id"`
+	Name         string                `bson:"name"`
+	LibvirtName  string                `bson:"libvirtName"`
+	Project      string                `bson:"project"`
+	ServerID     int                   `bson:"serverID"`
+	Memory       uint64                `bson:"memory"`
+	VCPU         uint                  `bson:"vcpu"`
+	NodeSelector map[string]string     `bson:"nodeSelector,omitempty"`
+	Required     []PlacementConstraint `bson:"required,omitempty"`
+	Preferred    []PlacementConstraint `bson:"preferred,omitempty"`
+	Group        string                `bson:"group,omitempty"`
+}
+
+// VolumeInfo represents a storage volume created through vdash along with the project owning it. Size is in GB.
+type VolumeInfo struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty"`
+	ServerID    int                `bson:"serverID"`
+	Pool        string             `bson:"pool"`
+	Name        string             `bson:"name"`
+	LibvirtName string             `bson:"libvirtName"`
+	Project     string             `bson:"project"`
+	Format      string             `bson:"format"`
+	Size        int                `bson:"size"`
+	Path        string             `bson:"path,omitempty"`
+	CreatedAt   time.Time          `bson:"createdAt"`
+}
+
+// NetworkInfo represents a network created through vdash along with the project owning it.
+type NetworkInfo struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty"`
+	ServerID    int                `bson:"serverID"`
+	Name        string             `bson:"name"`
+	LibvirtName string             `bson:"libvirtName"`
+	Project     string             `bson:"project"`
+	Bridge      string             `bson:"bridge"`
+	CreatedAt   time.Time          `bson:"createdAt"`
+}
+
+// DrainInfo represents the progress of the latest drain of a server.
+type DrainInfo struct {
+	ServerID   int              `bson:"_id"`
+	Live       bool             `bson:"live"`
+	Status     string           `bson:"status"`
+	StartedAt  time.Time        `bson:"startedAt"`
+	FinishedAt time.Time        `bson:"finishedAt,omitempty"`
+	Domains    []DrainDomain    `bson:"domains"`
+	Violations []GroupViolation `bson:"violations,omitempty"`
+	Error      string           `bson:"error,omitempty"`
+}
+
+// DrainDomain represents a domain being moved off a server that is drained.
+type DrainDomain struct {
+	Name           string `bson:"name"`
+	TargetServerID int    `bson:"targetServerID,omitempty"`
+	Status         string `bson:"status"`
+	Error          string `bson:"error,omitempty"`
+}
+
+// NodeCapabilities represents the hardware and virtualization capabilities discovered on a server. Memory is in KiB.
+type NodeCapabilities struct {
+	Arch              string     `bson:"arch"`
+	CPUVendor         string     `bson:"cpuVendor"`
+	CPUModel          string     `bson:"cpuModel"`
+	CPUs              uint       `bson:"cpus"`
+	Sockets           uint32     `bson:"sockets"`
+	Cores             uint32     `bson:"cores"`
+	Threads           uint32     `bson:"threads"`
+	Memory            uint64     `bson:"memory"`
+	NUMACells         []NUMACell `bson:"numaCells"`
+	LibvirtVersion    string     `bson:"libvirtVersion"`
+	HypervisorVersion string     `bson:"hypervisorVersion"`
+	KVM               bool       `bson:"kvm"`
+	MachineTypes      []string   `bson:"machineTypes"`
+	DefaultMachine    string     `bson:"defaultMachine"`
+	HostModel         bool       `bson:"hostModel"`
+	HostPassthrough   bool       `bson:"hostPassthrough"`
+	CPUModels         []string   `bson:"cpuModels"`
+	DiscoveredAt      time.Time  `bson:"discoveredAt"`
+}
+
+// NUMACell represents a NUMA cell of a server. Memory is in KiB.
+type NUMACell struct {
+	ID     int    `bson:"id"`
+	Memory uint64 `bson:"memory"`
+	CPUs   []int  `bson:"cpus"`
+}
+
+// ConnectionConfig represents the settings used to build the libvirt connection URI of a server. Empty fields fall back to the global settings. Only paths to key files and certificates on the vdash host are stored, never their content.
+type ConnectionConfig struct {
+	Transport        string `bson:"transport,omitempty"`
+	User             string `bson:"user,omitempty"`
+	Port             string `bson:"port,omitempty"`
+	Path             string `bson:"path,omitempty"`
+	KeyFile          string `bson:"keyFile,omitempty"`
+	KnownHosts       string `bson:"knownHosts,omitempty"`
+	KnownHostsVerify string `bson:"knownHostsVerify,omitempty"`
+	NoVerify         *bool  `bson:"noVerify,omitempty"`
+	PKIPath          string `bson:"pkiPath,omitempty"`
+	Socket           string `bson:"socket,omitempty"`
+}
+
+// PowerAction represents a power action requested from the provider of a server, by an operator or automatically by the health checks.
+type PowerAction struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty"`
+	ServerID  int                `bson:"serverID"`
+	Provider  string             `bson:"provider"`
+	Action    string             `bson:"action"`
+	Reason    string             `bson:"reason,omitempty"`
+	Automatic bool               `bson:"automatic"`
+	Actor     string             `bson:"actor,omitempty"`
+	Time      time.Time          `bson:"time"`
+	Error     string             `bson:"error,omitempty"`
+}
+
+// APIKey represents a key used to authenticate API requests. Only the SHA-256 hash of the key is stored, Prefix is kept to tell keys apart.
+type APIKey struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty"`
+	Name       string             `bson:"name"`
+	Prefix     string             `bson:"prefix"`
+	Hash       string             `bson:"hash"`
+	Roles      []string           `bson:"roles,omitempty"`
+	CreatedBy  string             `bson:"createdBy,omitempty"`
+	CreatedAt  time.Time          `bson:"createdAt"`
+	ExpiresAt  time.Time          `bson:"expiresAt,omitempty"`
+	LastUsedAt time.Time          `bson:"lastUsedAt,omitempty"`
+	RevokedAt  time.Time          `bson:"revokedAt,omitempty"`
+}
+
+// Role represents a custom role, a named set of permissions of the form resource:verb.
+type Role struct {
+	Name        string   `bson:"_id"`
+	Description string   `bson:"description,omitempty"`
+	Permissions []string `bson:"permissions"`
+}
+
+// RoleBinding grants a role to a subject. A binding without a project applies to every project.
+type RoleBinding struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty"`
+	Subject   string             `bson:"subject"`
+	Role      string             `bson:"role"`
+	Project   string             `bson:"project,omitempty"`
+	CreatedBy string             `bson:"createdBy,omitempty"`
+	CreatedAt time.Time          `bson:"createdAt"`
+}
+
+// Project represents a tenant owning domains, volumes, networks and public IP allocations along with the quota limiting them.
+type Project struct {
+	Name        string    `bson:"_id"`
+	Description string    `bson:"description,omitempty"`
+	CreatedAt   time.Time `bson:"createdAt"`
+	Quota       Quota     `bson:"quota"`
+}
+
+// Quota represents the limits on the resources a project may use. A nil limit is unlimited. Memory is in MiB and Disk in GB.
+type Quota struct {
+	VCPU      *int64 `bson:"vcpu,omitempty"`
+	Memory    *int64 `bson:"memory,omitempty"`
+	Disk      *int64 `bson:"disk,omitempty"`
+	Domains   *int64 `bson:"domains,omitempty"`
+	PublicIPs *int64 `bson:"publicIPs,omitempty"`
+	Networks  *int64 `bson:"networks,omitempty"`
+}
+
+// AuditEntry represents a mutating API request recorded in the audit log. Body is the request body with sensitive fields redacted.
+type AuditEntry struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty"`
+	Time        time.Time          `bson:"time"`
+	Actor       string             `bson:"actor,omitempty"`
+	AuthMethod  string             `bson:"authMethod,omitempty"`
+	SourceIP    string             `bson:"sourceIP"`
+	Method      string             `bson:"method"`
+	Route       string             `bson:"route"`
+	Path        string             `bson:"path"`
+	Project     string             `bson:"project,omitempty"`
+	Body        string             `bson:"body,omitempty"`
+	ServerID    int                `bson:"serverID,omitempty"`
+	ResourceIDs []string           `bson:"resourceIDs,omitempty"`
+	Status      int                `bson:"status"`
+	Outcome     string             `bson:"outcome"`
+	Error       string             `bson:"error,omitempty"`
+	DurationMS  int64              `bson:"durationMs"`
+}
+
+// IdempotencyRecord represents a request made with an Idempotency-Key header. The record is reserved before the request is handled and completed with its response, which is replayed to the retries of the request until ExpiresAt.
+type IdempotencyRecord struct {
+	ID          string    `bson:"_id"`
+	Actor       string    `bson:"actor,omitempty"`
+	Key         string    `bson:"key"`
+	Method      string    `bson:"method"`
+	Path        string    `bson:"path"`
+	Project     string    `bson:"project"`
+	BodyHash    string    `bson:"bodyHash"`
+	Completed   bool      `bson:"completed"`
+	Status      int       `bson:"status,omitempty"`
+	ContentType string    `bson:"contentType,omitempty"`
+	Body        []byte    `bson:"body,omitempty"`
+	CreatedAt   time.Time `bson:"createdAt"`
+	ExpiresAt   time.Time `bson:"expiresAt"`
 }
@@ -1,37 +1,50 @@
 package request
 
+import "time"
+
 // CreateServerRequest represents a request to create a new server.
 type CreateServerRequest struct {
-	ID       int    `json:"id"`
-	Hostname string `json:"hostname"`
-	PublicIP string `json:"publicIP"`
+	ID         int               `json:"id" validate:"required,min=1"`
+	Hostname   string            `json:"hostname"`
+	PublicIP   string            `json:"publicIP" validate:"required,min=1"`
+	Labels     map[string]string `json:"labels"`
+	Connection *ConnectionConfig `json:"connection"`
+}
+
+// UpdateServerLabelsRequest represents a request to replace the labels of a server.
+type UpdateServerLabelsRequest struct {
+	ID     int               `json:"id" validate:"required,min=1"`
+	Labels map[string]string `json:"labels"`
 }
 
 // DeleteServerRequest represents a request to delete a server.
 type DeleteServerRequest struct {
-	ID int `json:"id"`
+	ID int `json:"id" validate:"required,min=1"`
 }
 
 // CreateVolumeRequest represents a request to create a new volume in a storage pool.
 type CreateVolumeRequest struct {
-	ServerID int    `json:"serverID"`
-	PoolName string `json:"poolName"`
-	Format   string `json:"format"`
-	Name     string `json:"name"`
-	Size     int    `json:"size"`
+	ServerID             int                   `json:"serverID" validate:"min=0"`
+	PoolName             string                `json:"poolName"`
+	Format               string                `json:"format" validate:"oneof=raw qcow2 qed vmdk vdi vpc vhdx iso"`
+	Name                 string                `json:"name"`
+	Size                 int                   `json:"size" validate:"required,min=1"`
+	NodeSelector         map[string]string     `json:"nodeSelector"`
+	RequiredConstraints  []PlacementConstraint `json:"requiredConstraints"`
+	PreferredConstraints []PlacementConstraint `json:"preferredConstraints"`
 }
 
 // DeleteVolumeRequest represents a request to delete a storage volume in a storage pool.
 type DeleteVolumeRequest struct {
-	ServerID int    `json:"serverID"`
-	PoolName string `json:"poolName"`
-	Name     string `json:"name"`
+	ServerID int    `json:"serverID" validate:"required,min=1"`
+	PoolName string `json:"poolName" validate:"required,min=1"`
+	Name     string `json:"name" validate:"required,min=1"`
 }
 
 // NetworkRequest represents a request to create a new network.
 type NetworkRequest struct {
-	ServerID int    `json:"serverID"`
-	Name     string `json:"name"`
+	ServerID int    `json:"serverID" validate:"required,min=1"`
+	Name     string `json:"name" validate:"required,min=1"`
 	Bridge   string `json:"bridge"`
 }
 
@@ -50,36 +63,198 @@ type DeleteStoragePoolRequest struct {
 
 // CreateDomainRequest represents a request to create a new domain.
 type CreateDomainRequest struct {
-	ServerID int      `json:"serverID"`
-	Name     string   `json:"name"`
-	Memory   uint64   `json:"memory"`
-	VCPU     uint     `json:"vcpu"`
-	Disks    []string `json:"disks"`
-	Networks []string `json:"networks"`
-	PublicIP bool     `json:"publicIP"`
+	ServerID             int                   `json:"serverID" validate:"min=0"`
+	Name                 string                `json:"name"`
+	Memory               uint64                `json:"memory" validate:"required,min=1"`
+	VCPU                 uint                  `json:"vcpu" validate:"required,min=1"`
+	Disks                []string              `json:"disks"`
+	Networks             []string              `json:"networks"`
+	PublicIP             bool                  `json:"publicIP"`
+	NodeSelector         map[string]string     `json:"nodeSelector"`
+	RequiredConstraints  []PlacementConstraint `json:"requiredConstraints"`
+	PreferredConstraints []PlacementConstraint `json:"preferredConstraints"`
+	Group                string                `json:"group"`
+	MachineType          string                `json:"machineType"`
+	CPUModel             string                `json:"cpuModel"`
+}
+
+// PlacementConstraint represents a rule on server labels used by the scheduler. Operator is one of In, NotIn, Exists or DoesNotExist. Weight is only used for preferred constraints.
+type PlacementConstraint struct {
+	Key      string   `json:"key" validate:"required,min=1"`
+	Operator string   `json:"operator" validate:"required,oneof=In NotIn Exists DoesNotExist"`
+	Values   []string `json:"values"`
+	Weight   int      `json:"weight"`
 }
 
 // AddPublicIPRequest represents a request to add a public IP for a server.
 type AddPublicIPRequest struct {
-	ServerID  int    `json:"serverID"`
-	IP        string `json:"ip"`
+	ServerID  int    `json:"serverID" validate:"required,min=1"`
+	IP        string `json:"ip" validate:"required,min=1"`
 	Available bool   `json:"available"`
 }
 
 // DeletePublicIPRequest represents a request to delete a public IP for a server.
 type DeletePublicIPRequest struct {
-	IP string `json:"ip"`
+	IP string `json:"ip" validate:"required,min=1"`
+}
+
+// RoutePublicIPRequest represents a request to route a failover IP to another server.
+type RoutePublicIPRequest struct {
+	IP       string `json:"ip" validate:"required,min=1"`
+	ServerID int    `json:"serverID" validate:"required,min=1"`
+}
+
+// GeneratePublicIPMACRequest represents a request to create a virtual MAC for a failover IP. Type is kvm, xen or vmware and defaults to kvm.
+type GeneratePublicIPMACRequest struct {
+	IP   string `json:"ip" validate:"required,min=1"`
+	Type string `json:"type" validate:"oneof='' kvm xen vmware"`
+}
+
+// DeletePublicIPMACRequest represents a request to delete the virtual MAC of a failover IP.
+type DeletePublicIPMACRequest struct {
+	IP string `json:"ip" validate:"required,min=1"`
 }
 
 // UpdatePublicIPRequest represents a request to update a public IP for a server.
 type UpdatePublicIPRequest struct {
-	ServerID  int    `json:"serverID"`
-	IP        string `json:"ip"`
+	ServerID  int    `json:"serverID" validate:"required,min=1"`
+	IP        string `json:"ip" validate:"required,min=1"`
 	Available bool   `json:"available"`
 }
 
 // DeleteDomainRequest represents a request to delete a domain.
 type DeleteDomainRequest struct {
-	ServerID int    `json:"serverID"`
-	Name     string `json:"name"`
+	ServerID int    `json:"serverID" validate:"required,min=1"`
+	Name     string `json:"name" validate:"required,min=1"`
+}
+
+// CreateServerGroupRequest represents a request to create a new server group.
+type CreateServerGroupRequest struct {
+	Name   string `json:"name" validate:"required,min=1"`
+	Policy string `json:"policy" validate:"required,oneof=affinity anti-affinity soft-affinity soft-anti-affinity"`
+}
+
+// DeleteServerGroupRequest represents a request to delete a server group.
+type DeleteServerGroupRequest struct {
+	Name string `json:"name"`
+}
+
+// SchedulerExplainRequest represents a request to explain where the scheduler would place a domain or a volume. Exactly one of Domain or Volume must be provided.
+type SchedulerExplainRequest struct {
+	Domain *CreateDomainRequest `json:"domain"`
+	Volume *CreateVolumeRequest `json:"volume"`
+}
+
+// CordonServerRequest represents a request to cordon or uncordon a server.
+type CordonServerRequest struct {
+	ID int `json:"id" validate:"required,min=1"`
+}
+
+// DrainServerRequest represents a request to move every domain off a server. Running domains are live migrated if Live is true and cold migrated otherwise.
+type DrainServerRequest struct {
+	ID   int  `json:"id" validate:"required,min=1"`
+	Live bool `json:"live"`
+}
+
+// CreateAPIKeyRequest represents a request to create an API key. The key does not expire if ExpiresAt is not set.
+type CreateAPIKeyRequest struct {
+	Name      string     `json:"name" validate:"required,min=1"`
+	Roles     []string   `json:"roles"`
+	ExpiresAt *time.Time `json:"expiresAt"`
+}
+
+// RevokeAPIKeyRequest represents a request to revoke an API key.
+type RevokeAPIKeyRequest struct {
+	ID string `json:"id"`
+}
+
+// SaveRoleRequest represents a request to create or replace a custom role.
+type SaveRoleRequest struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Permissions []string `json:"permissions"`
+}
+
+// DeleteRoleRequest represents a request to delete a custom role.
+type DeleteRoleRequest struct {
+	Name string `json:"name"`
+}
+
+// CreateRoleBindingRequest represents a request to grant a role to a subject, the sub claim of a token or apikey: followed by the ID of an API key. The role applies to every project if Project is empty.
+type CreateRoleBindingRequest struct {
+	Subject string `json:"subject" validate:"required,min=1"`
+	Role    string `json:"role"`
+	Project string `json:"project"`
+}
+
+// DeleteRoleBindingRequest represents a request to remove a role binding.
+type DeleteRoleBindingRequest struct {
+	ID string `json:"id"`
+}
+
+// CreateProjectRequest represents a request to create a project.
+type CreateProjectRequest struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// DeleteProjectRequest represents a request to delete a project.
+type DeleteProjectRequest struct {
+	Name string `json:"name"`
+}
+
+// UpdateQuotaRequest represents a request to replace the quota of a project. A missing or null limit is unlimited. Memory is in MiB and Disk in GB.
+type UpdateQuotaRequest struct {
+	VCPU      *int64 `json:"vcpu" validate:"min=0"`
+	Memory    *int64 `json:"memory" validate:"min=0"`
+	Disk      *int64 `json:"disk" validate:"min=0"`
+	Domains   *int64 `json:"domains" validate:"min=0"`
+	PublicIPs *int64 `json:"publicIPs" validate:"min=0"`
+	Networks  *int64 `json:"networks" validate:"min=0"`
+}
+
+// ResizeDomainRequest represents a request to change the memory and vCPUs of a domain. Memory is in MiB.
+type ResizeDomainRequest struct {
+	ServerID int    `json:"serverID" validate:"required,min=1"`
+	Name     string `json:"name" validate:"required,min=1"`
+	Memory   uint64 `json:"memory" validate:"required,min=1"`
+	VCPU     uint   `json:"vcpu" validate:"required,min=1"`
+}
+
+// ResizeVolumeRequest represents a request to grow a storage volume. Size is in GB.
+type ResizeVolumeRequest struct {
+	ServerID int    `json:"serverID" validate:"required,min=1"`
+	PoolName string `json:"poolName" validate:"required,min=1"`
+	Name     string `json:"name" validate:"required,min=1"`
+	Size     int    `json:"size" validate:"required,min=1"`
+}
+
+// PowerServerRequest represents the optional body of a power action request.
+type PowerServerRequest struct {
+	Reason string `json:"reason"`
+}
+
+// DiscoverServerRequest represents a request to refresh the capabilities of a server.
+type DiscoverServerRequest struct {
+	ID int `json:"id" validate:"required,min=1"`
+}
+
+// ConnectionConfig represents the settings used to build the libvirt connection URI of a server. Empty fields fall back to the global settings. Key files and certificates are given as paths on the vdash host.
+type ConnectionConfig struct {
+	Transport        string `json:"transport" validate:"oneof='' ssh libssh tls tcp unix"`
+	User             string `json:"user"`
+	Port             string `json:"port"`
+	Path             string `json:"path"`
+	KeyFile          string `json:"keyFile"`
+	KnownHosts       string `json:"knownHosts"`
+	KnownHostsVerify string `json:"knownHostsVerify" validate:"oneof='' normal auto ignore"`
+	NoVerify         *bool  `json:"noVerify"`
+	PKIPath          string `json:"pkiPath"`
+	Socket           string `json:"socket"`
+}
+
+// UpdateServerConnectionRequest represents a request to replace the connection overrides of a server. A missing connection resets the server to the global settings.
+type UpdateServerConnectionRequest struct {
+	ID         int               `json:"id" validate:"required,min=1"`
+	Connection *ConnectionConfig `json:"connection"`
 }
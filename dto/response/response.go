@@ -1,19 +1,206 @@
 package response
 
+import "time"
+
 // CreateServerResponse represents a response to a server creation request.
 type CreateServerResponse struct {
-	ID         int    `json:"id"`
-	Hostname   string `json:"hostname"`
-	PublicIP   string `json:"publicIP"`
-	LibvirtURI string `json:"libvirtURI"`
+	ID           int                       `json:"id"`
+	Hostname     string                    `json:"hostname"`
+	PublicIP     string                    `json:"publicIP"`
+	LibvirtURI   string                    `json:"libvirtURI"`
+	Labels       map[string]string         `json:"labels"`
+	Connection   *ConnectionResponse       `json:"connection,omitempty"`
+	Capabilities *NodeCapabilitiesResponse `json:"capabilities,omitempty"`
 }
 
 // GetServersResponse represents a response to a server list request.
 type GetServersResponse struct {
-	ID         int    `json:"id"`
-	Hostname   string `json:"hostname"`
-	PublicIP   string `json:"publicIP"`
-	LibvirtURI string `json:"libvirtURI"`
+	ID           int                       `json:"id"`
+	Hostname     string                    `json:"hostname"`
+	PublicIP     string                    `json:"publicIP"`
+	LibvirtURI   string                    `json:"libvirtURI"`
+	Labels       map[string]string         `json:"labels"`
+	Cordoned     bool                      `json:"cordoned"`
+	Health       ServerHealthResponse      `json:"health"`
+	Connection   *ConnectionResponse       `json:"connection,omitempty"`
+	Capabilities *NodeCapabilitiesResponse `json:"capabilities,omitempty"`
+	Provider     string                    `json:"provider,omitempty"`
+	ProviderID   int                       `json:"providerID,omitempty"`
+	Missing      bool                      `json:"missing"`
+	MissingSince *time.Time                `json:"missingSince,omitempty"`
+}
+
+// SyncServersResponse represents the differences applied by a sync with the provider inventory.
+type SyncServersResponse struct {
+	Added      []SyncedServerResponse    `json:"added"`
+	Updated    []InventoryChangeResponse `json:"updated"`
+	Missing    []int                     `json:"missing"`
+	Returned   []int                     `json:"returned"`
+	Errors     []string                  `json:"errors"`
+	DryRun     bool                      `json:"dryRun"`
+	StartedAt  time.Time                 `json:"startedAt"`
+	FinishedAt time.Time                 `json:"finishedAt"`
+}
+
+// SyncedServerResponse represents a server added by a sync with the provider inventory.
+type SyncedServerResponse struct {
+	ID         int               `json:"id"`
+	Hostname   string            `json:"hostname"`
+	PublicIP   string            `json:"publicIP"`
+	LibvirtURI string            `json:"libvirtURI"`
+	Labels     map[string]string `json:"labels"`
+}
+
+// InventoryChangeResponse represents a field of a server which changed in the provider inventory.
+type InventoryChangeResponse struct {
+	ServerID int    `json:"serverID"`
+	Field    string `json:"field"`
+	Old      string `json:"old"`
+	New      string `json:"new"`
+}
+
+// ConnectionResponse represents the connection overrides of a server.
+type ConnectionResponse struct {
+	Transport        string `json:"transport,omitempty"`
+	User             string `json:"user,omitempty"`
+	Port             string `json:"port,omitempty"`
+	Path             string `json:"path,omitempty"`
+	KeyFile          string `json:"keyFile,omitempty"`
+	KnownHosts       string `json:"knownHosts,omitempty"`
+	KnownHostsVerify string `json:"knownHostsVerify,omitempty"`
+	NoVerify         *bool  `json:"noVerify,omitempty"`
+	PKIPath          string `json:"pkiPath,omitempty"`
+	Socket           string `json:"socket,omitempty"`
+}
+
+// UpdateServerConnectionResponse represents a response to a connection update request.
+type UpdateServerConnectionResponse struct {
+	ID         int                 `json:"id"`
+	LibvirtURI string              `json:"libvirtURI"`
+	Connection *ConnectionResponse `json:"connection,omitempty"`
+}
+
+// NodeCapabilitiesResponse represents the capabilities discovered on a server. Memory is in MiB.
+type NodeCapabilitiesResponse struct {
+	Arch              string             `json:"arch"`
+	CPUVendor         string             `json:"cpuVendor"`
+	CPUModel          string             `json:"cpuModel"`
+	CPUs              uint               `json:"cpus"`
+	Sockets           uint32             `json:"sockets"`
+	Cores             uint32             `json:"cores"`
+	Threads           uint32             `json:"threads"`
+	Memory            uint64             `json:"memory"`
+	NUMACells         []NUMACellResponse `json:"numaCells"`
+	LibvirtVersion    string             `json:"libvirtVersion"`
+	HypervisorVersion string             `json:"hypervisorVersion"`
+	KVM               bool               `json:"kvm"`
+	MachineTypes      []string           `json:"machineTypes"`
+	DefaultMachine    string             `json:"defaultMachine"`
+	HostModel         bool               `json:"hostModel"`
+	HostPassthrough   bool               `json:"hostPassthrough"`
+	CPUModels         []string           `json:"cpuModels"`
+	DiscoveredAt      time.Time          `json:"discoveredAt"`
+}
+
+// NUMACellResponse represents a NUMA cell of a server. Memory is in MiB.
+type NUMACellResponse struct {
+	ID     int    `json:"id"`
+	Memory uint64 `json:"memory"`
+	CPUs   []int  `json:"cpus"`
+}
+
+// ServerHealthResponse represents the health of a server as recorded by the health checker.
+type ServerHealthResponse struct {
+	Status          string     `json:"status"`
+	LastSeen        *time.Time `json:"lastSeen,omitempty"`
+	LastChecked     *time.Time `json:"lastChecked,omitempty"`
+	StatusChangedAt *time.Time `json:"statusChangedAt,omitempty"`
+	Failures        int        `json:"failures"`
+	Error           string     `json:"error,omitempty"`
+	LibvirtVersion  string     `json:"libvirtVersion,omitempty"`
+	LastPowerCycle  *time.Time `json:"lastPowerCycle,omitempty"`
+}
+
+// PowerActionResponse represents a power action requested from the provider of a server.
+type PowerActionResponse struct {
+	ServerID  int       `json:"serverID"`
+	Provider  string    `json:"provider"`
+	Action    string    `json:"action"`
+	Reason    string    `json:"reason,omitempty"`
+	Automatic bool      `json:"automatic"`
+	Actor     string    `json:"actor,omitempty"`
+	Time      time.Time `json:"time"`
+	Error     string    `json:"error,omitempty"`
+}
+
+// APIKeyResponse represents an API key without the key itself.
+type APIKeyResponse struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Roles      []string   `json:"roles"`
+	CreatedBy  string     `json:"createdBy,omitempty"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	ExpiresAt  *time.Time `json:"expiresAt,omitempty"`
+	LastUsedAt *time.Time `json:"lastUsedAt,omitempty"`
+	RevokedAt  *time.Time `json:"revokedAt,omitempty"`
+}
+
+// RoleResponse represents a built-in or custom role.
+type RoleResponse struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description,omitempty"`
+	Permissions []string `json:"permissions"`
+	Builtin     bool     `json:"builtin"`
+}
+
+// RoleBindingResponse represents a role granted to a subject.
+type RoleBindingResponse struct {
+	ID        string    `json:"id"`
+	Subject   string    `json:"subject"`
+	Role      string    `json:"role"`
+	Project   string    `json:"project,omitempty"`
+	CreatedBy string    `json:"createdBy,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// ProjectResponse represents a project owning domains, volumes, networks and public IP allocations.
+type ProjectResponse struct {
+	Name        string     `json:"name"`
+	Description string     `json:"description,omitempty"`
+	CreatedAt   *time.Time `json:"createdAt,omitempty"`
+}
+
+// QuotaResponse represents the usage of a project against each limit of its quota. Memory is in MiB and Disk in GB.
+type QuotaResponse struct {
+	Project   string             `json:"project"`
+	VCPU      QuotaLimitResponse `json:"vcpu"`
+	Memory    QuotaLimitResponse `json:"memory"`
+	Disk      QuotaLimitResponse `json:"disk"`
+	Domains   QuotaLimitResponse `json:"domains"`
+	PublicIPs QuotaLimitResponse `json:"publicIPs"`
+	Networks  QuotaLimitResponse `json:"networks"`
+}
+
+// QuotaLimitResponse represents the usage of a resource and its limit, which is null when unlimited.
+type QuotaLimitResponse struct {
+	Used  int64  `json:"used"`
+	Limit *int64 `json:"limit"`
+}
+
+// CreateAPIKeyResponse represents a newly created API key. The key is only returned once.
+type CreateAPIKeyResponse struct {
+	APIKeyResponse
+	Key string `json:"key"`
+}
+
+// ServerEventResponse represents a change of the health status of a server.
+type ServerEventResponse struct {
+	ServerID int       `json:"serverID"`
+	Time     time.Time `json:"time"`
+	From     string    `json:"from"`
+	To       string    `json:"to"`
+	Error    string    `json:"error,omitempty"`
 }
 
 // CreateVolumeResponse represents a response to a storage volume creation request.
@@ -22,12 +209,14 @@ type CreateVolumeResponse struct {
 	Name     string `json:"name"`
 	Format   string `json:"format"`
 	Size     uint64 `json:"size"`
+	Path     string `json:"path,omitempty"`
 }
 
 // NetworkResponse represents a response to a network creation request.
 type NetworkResponse struct {
 	Name   string `json:"name"`
 	Bridge string `json:"bridge"`
+	State  string `json:"state,omitempty"`
 }
 
 // StoragePoolResponse represents a response to a storage pool creation request.
@@ -40,6 +229,7 @@ type StoragePoolResponse struct {
 type StoragePoolListResponse struct {
 	Name      string `json:"name"`
 	Available string `json:"available"`
+	State     string `json:"state"`
 }
 
 // CreateDomainResponse represents a response to a domain creation request.
@@ -53,9 +243,10 @@ type CreateDomainResponse struct {
 
 // ScalewayServerResponse represents a response from the Scaleway API GET https://api.online.net/api/v1/server/{server_id}.
 type ScalewayServerResponse struct {
-	ID       int                        `json:"id"`
-	Hostname string                     `json:"hostname"`
-	IP       []ScalewayServerResponseIP `json:"ip"`
+	ID       int                            `json:"id"`
+	Hostname string                         `json:"hostname"`
+	IP       []ScalewayServerResponseIP     `json:"ip"`
+	Location ScalewayServerResponseLocation `json:"location"`
 }
 
 // ScalewayServerResponseIP represents the ip object in the ScalewayServerResponse.
@@ -64,6 +255,52 @@ type ScalewayServerResponseIP struct {
 	Type    string `json:"type"`
 }
 
+// ScalewayServerResponseLocation represents the location object in the ScalewayServerResponse.
+type ScalewayServerResponseLocation struct {
+	Datacenter string `json:"datacenter"`
+	Room       string `json:"room"`
+	Zone       string `json:"zone"`
+	Line       string `json:"line"`
+	Column     string `json:"column"`
+	Block      string `json:"block"`
+	Rack       string `json:"rack"`
+	Position   int    `json:"position"`
+}
+
+// ScalewayFailoverResponse represents a failover IP in the response from the Scaleway API GET https://api.online.net/api/v1/server/failover.
+type ScalewayFailoverResponse struct {
+	Source      string `json:"source"`
+	Destination string `json:"destination"`
+}
+
+// ScalewayIPResponse represents a response from the Scaleway API GET https://api.online.net/api/v1/server/ip/{address}.
+type ScalewayIPResponse struct {
+	Address string `json:"address"`
+	MAC     string `json:"mac"`
+	Reverse string `json:"reverse"`
+}
+
+// HetznerServerResponse represents a server in the responses from the Hetzner Robot API GET /server and GET /server/{server_number}.
+type HetznerServerResponse struct {
+	Server struct {
+		ServerNumber int    `json:"server_number"`
+		ServerName   string `json:"server_name"`
+		ServerIP     string `json:"server_ip"`
+		DC           string `json:"dc"`
+		Status       string `json:"status"`
+	} `json:"server"`
+}
+
+// HetznerFailoverResponse represents a failover IP in the response from the Hetzner Robot API GET /failover.
+type HetznerFailoverResponse struct {
+	Failover struct {
+		IP             string `json:"ip"`
+		ServerIP       string `json:"server_ip"`
+		ServerNumber   int    `json:"server_number"`
+		ActiveServerIP string `json:"active_server_ip"`
+	} `json:"failover"`
+}
+
 // AddIPResponse represents a response to an IP addition request.
 type AddIPResponse struct {
 	IP string `json:"publicIP"`
@@ -71,8 +308,34 @@ type AddIPResponse struct {
 
 // GetAvailableIPsResponse represents a response to an IP list request.
 type GetAvailableIPsResponse struct {
-	IP       string `json:"publicIP"`
-	ServerID int    `json:"serverID"`
+	IP          string `json:"publicIP"`
+	ServerID    int    `json:"serverID"`
+	Provider    string `json:"provider,omitempty"`
+	Destination string `json:"destination,omitempty"`
+	MAC         string `json:"mac,omitempty"`
+}
+
+// PublicIPResponse represents a public IP along with its routing, virtual MAC and the project it is allocated to.
+type PublicIPResponse struct {
+	IP          string     `json:"publicIP"`
+	ServerID    int        `json:"serverID"`
+	Available   bool       `json:"available"`
+	Provider    string     `json:"provider,omitempty"`
+	Destination string     `json:"destination,omitempty"`
+	MAC         string     `json:"mac,omitempty"`
+	Project     string     `json:"project,omitempty"`
+	SyncedAt    *time.Time `json:"syncedAt,omitempty"`
+}
+
+// SyncPublicIPsResponse represents the differences applied by a sync with the failover IPs of the provider accounts.
+type SyncPublicIPsResponse struct {
+	Added      []PublicIPResponse `json:"added"`
+	Updated    []PublicIPResponse `json:"updated"`
+	Removed    []string           `json:"removed"`
+	Orphaned   []string           `json:"orphaned"`
+	Errors     []string           `json:"errors"`
+	StartedAt  time.Time          `json:"startedAt"`
+	FinishedAt time.Time          `json:"finishedAt"`
 }
 
 // GetDomainResponse represents a response to a domain list request.
@@ -80,4 +343,104 @@ type GetDomainResponse struct {
 	Name   string `json:"name"`
 	Memory uint64 `json:"memory"`
 	VCPU   uint   `json:"vcpu"`
+	State  string `json:"state"`
+}
+
+// ServerGroupResponse represents a response to a server group creation or list request.
+type ServerGroupResponse struct {
+	Name    string                      `json:"name"`
+	Policy  string                      `json:"policy"`
+	Members []ServerGroupMemberResponse `json:"members"`
+}
+
+// ServerGroupMemberResponse represents a domain belonging to a server group.
+type ServerGroupMemberResponse struct {
+	Domain   string `json:"domain"`
+	ServerID int    `json:"serverID"`
+}
+
+// GroupViolationResponse represents a server group whose members are placed against its policy.
+type GroupViolationResponse struct {
+	Group     string   `json:"group"`
+	Policy    string   `json:"policy"`
+	Soft      bool     `json:"soft"`
+	Message   string   `json:"message"`
+	Domains   []string `json:"domains"`
+	ServerIDs []int    `json:"serverIDs"`
+}
+
+// SchedulerExplainResponse represents a response to a scheduler explain request. ServerID is the server the scheduler would pick, 0 if none is available.
+type SchedulerExplainResponse struct {
+	ServerID   int                          `json:"serverID"`
+	Candidates []SchedulerCandidateResponse `json:"candidates"`
+}
+
+// SchedulerCandidateResponse represents a server evaluated by the scheduler. FreeMemory is in MiB and FreeSpace in GB.
+type SchedulerCandidateResponse struct {
+	ServerID   int                       `json:"serverID"`
+	Hostname   string                    `json:"hostname"`
+	Eligible   bool                      `json:"eligible"`
+	Filters    []SchedulerFilterResponse `json:"filters"`
+	FreeMemory uint64                    `json:"freeMemory"`
+	FreeVCPU   uint                      `json:"freeVCPU"`
+	FreeSpace  uint64                    `json:"freeSpace"`
+	Score      int                       `json:"score"`
+	Error      string                    `json:"error,omitempty"`
+}
+
+// SchedulerFilterResponse represents the outcome of a scheduler filter for a server.
+type SchedulerFilterResponse struct {
+	Name   string `json:"name"`
+	Passed bool   `json:"passed"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// DrainResponse represents the progress of the drain of a server.
+type DrainResponse struct {
+	ServerID   int                      `json:"serverID"`
+	Live       bool                     `json:"live"`
+	Status     string                   `json:"status"`
+	StartedAt  time.Time                `json:"startedAt"`
+	FinishedAt *time.Time               `json:"finishedAt,omitempty"`
+	Total      int                      `json:"total"`
+	Migrated   int                      `json:"migrated"`
+	Failed     int                      `json:"failed"`
+	Domains    []DrainDomainResponse    `json:"domains"`
+	Violations []GroupViolationResponse `json:"violations"`
+	Error      string                   `json:"error,omitempty"`
+}
+
+// DrainDomainResponse represents a domain being moved off a server that is drained.
+type DrainDomainResponse struct {
+	Name           string `json:"name"`
+	TargetServerID int    `json:"targetServerID,omitempty"`
+	Status         string `json:"status"`
+	Error          string `json:"error,omitempty"`
+}
+
+// AuditEntryResponse represents a mutating request recorded in the audit log.
+type AuditEntryResponse struct {
+	ID          string    `json:"id"`
+	Time        time.Time `json:"time"`
+	Actor       string    `json:"actor,omitempty"`
+	AuthMethod  string    `json:"authMethod,omitempty"`
+	SourceIP    string    `json:"sourceIP"`
+	Method      string    `json:"method"`
+	Route       string    `json:"route"`
+	Path        string    `json:"path"`
+	Project     string    `json:"project,omitempty"`
+	Body        string    `json:"body,omitempty"`
+	ServerID    int       `json:"serverID,omitempty"`
+	ResourceIDs []string  `json:"resourceIDs"`
+	Status      int       `json:"status"`
+	Outcome     string    `json:"outcome"`
+	Error       string    `json:"error,omitempty"`
+	DurationMS  int64     `json:"durationMs"`
+}
+
+// ListResponse represents a page of a v2 list. Total is the number of items matching the filters across every page, NextCursor is empty on the last page.
+type ListResponse[T any] struct {
+	Items      []T    `json:"items"`
+	NextCursor string `json:"nextCursor,omitempty"`
+	Total      int    `json:"total"`
 }
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/sychonet/vdash-be/service"
+)
+
+// createKey creates an API key and prints it. It is meant to create the first key of an installation, further keys can be created through the API.
+func createKey(args []string) int {
+	fs := flag.NewFlagSet("key create", flag.ContinueOnError)
+	name := fs.String("name", "", "name of the key (required)")
+	roles := fs.String("roles", service.RoleAdmin, "comma separated roles granted to the key in every project")
+	expiresIn := fs.Duration("expires-in", 0, "lifetime of the key, it does not expire if zero")
+	if code, ok := loadConfig(fs, args); !ok {
+		return code
+	}
+
+	if *name == "" || *expiresIn < 0 {
+		fmt.Fprintln(os.Stderr, "a name is required and the lifetime must not be negative")
+		fs.Usage()
+		return exitUsage
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	databaseService := newDatabaseService()
+	if err := databaseService.Ping(ctx); err != nil {
+		slog.Error("Database unavailable", "error", err)
+		return exitUnavailable
+	}
+
+	var grants []string
+	for _, role := range strings.Split(*roles, ",") {
+		if role = strings.TrimSpace(role); role != "" {
+			grants = append(grants, role)
+		}
+	}
+
+	if err := service.NewRBACService(databaseService).ValidateRoles(ctx, grants); err != nil {
+		slog.Error("Invalid roles", "error", err)
+		return exitUsage
+	}
+
+	var expiresAt time.Time
+	if *expiresIn > 0 {
+		expiresAt = time.Now().UTC().Add(*expiresIn)
+	}
+
+	credential, key, err := newAuthService(databaseService).CreateAPIKey(ctx, *name, grants, expiresAt, "cli")
+	if err != nil {
+		slog.Error("Failed to create API key", "error", err)
+		return exitFailure
+	}
+
+	fmt.Fprintf(os.Stderr, "Created API key %s (%s), store it now as it cannot be shown again\n", key.Name, key.ID.Hex())
+	fmt.Fprintln(os.Stdout, credential)
+
+	return exitOK
+}
@@ -1,139 +1,173 @@
 package main
 
 import (
+	"errors"
+	"flag"
 	"fmt"
 	"log/slog"
-	"net/http"
+	"os"
 	"strings"
-	"sync"
+	"time"
 
-	"github.com/go-chi/chi/v5"
-	"github.com/go-chi/chi/v5/middleware"
 	"github.com/sychonet/vdash-be/config"
-	controller "github.com/sychonet/vdash-be/controller"
 	entity "github.com/sychonet/vdash-be/db/entity"
 	"github.com/sychonet/vdash-be/service"
 )
 
-// init is called before the main function. It initializes the application by fetching the servers from the cloud service provider Scaleway and inserting them into the database if the database has got no entry in it.
-func init() {
-	config.LoadConfig()
+// Exit codes of the commands.
+const (
+	exitOK          = 0
+	exitFailure     = 1
+	exitUsage       = 2
+	exitUnavailable = 3
+)
 
-	scalewayService := service.NewScalewayService(config.AppConfig.Scaleway.BaseURL, config.AppConfig.Scaleway.Token)
-	databaseService := service.NewDatabaseService(config.AppConfig.Database.Host, config.AppConfig.Database.Port, config.AppConfig.Database.Username, config.AppConfig.Database.Password, config.AppConfig.Database.Name, config.AppConfig.Database.ServersCollection, config.AppConfig.Database.IPsCollection)
+const usage = `Usage: vdash <command> [flags]
 
-	count, err := databaseService.CountServers()
+Commands:
+  serve        Run the API server (default)
+  bootstrap    Import the servers of the provider accounts once
+  db migrate   Apply the database schema and index migrations
+  check        Validate the configuration and the connectivity to the database and every node
+  key create   Create an API key, used to get the first key of a new installation
+  openapi      Print the OpenAPI document of the API
 
-	if count > 0 {
-		slog.Info("Servers already present in the database")
-		return
-	}
+Run vdash <command> -h for the flags of a command.
+`
 
-	var wg sync.WaitGroup
+// main is the entrypoint for the application.
+func main() {
+	os.Exit(run(os.Args[1:]))
+}
 
-	// Get servers from cloud service provider Scaleway
-	servers, err := scalewayService.GetServers()
-	if err != nil {
-		panic(err)
+// run runs the command given by the arguments and returns its exit code.
+func run(args []string) int {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		return serve(args)
 	}
 
-	serverDetailsChan := make(chan entity.ServerInfo, len(servers))
-
-	// For each node save id, public ip, hostname (only with prefix "pr"). For this we need to make API requests in parallel using goroutines but we need to make sure that we don't breach API quota contract.
-	for _, server := range servers {
-		wg.Add(1)
-		// Get server details
-		go func(server string) {
-			defer wg.Done()
-			serverDetails, err := scalewayService.GetServerDetails(server)
+	switch args[0] {
+	case "serve":
+		return serve(args[1:])
+	case "bootstrap":
+		return bootstrap(args[1:])
+	case "db":
+		if len(args) > 1 && args[1] == "migrate" {
+			return migrate(args[2:])
+		}
+	case "check":
+		return check(args[1:])
+	case "key":
+		if len(args) > 1 && args[1] == "create" {
+			return createKey(args[2:])
+		}
+	case "openapi":
+		return printOpenAPI(args[1:])
+	case "help", "-h", "-help", "--help":
+		fmt.Fprint(os.Stdout, usage)
+		return exitOK
+	}
 
-			fmt.Println(serverDetails)
-			if err != nil {
-				panic(err)
-			}
+	fmt.Fprintf(os.Stderr, "unknown command %q\n\n%s", strings.Join(args, " "), usage)
+	return exitUsage
+}
 
-			if strings.HasPrefix(serverDetails.Hostname, "pr") {
-				// Find public IP of the server and generate URI for libvirt connection from it
-				var publicIP string
-				var libvirtURI string
-
-				for _, ip := range serverDetails.IP {
-					if ip.Type == "public" {
-						publicIP = ip.Address
-						// URI for libvirt connection for each node will be "qemu+libssh://root@{public ip}/system"
-						libvirtURI = "qemu+libssh://root@" + publicIP + "/system"
-						break
-					}
-				}
-
-				entity := entity.ServerInfo{
-					ID:         serverDetails.ID,
-					Hostname:   serverDetails.Hostname,
-					PublicIP:   publicIP,
-					LibvirtURI: libvirtURI,
-				}
-
-				serverDetailsChan <- entity
-			}
-		}(server)
+// loadConfig parses the flags of the command and loads the configuration. It returns false along with the exit code if the command should stop.
+func loadConfig(fs *flag.FlagSet, args []string) (int, bool) {
+	flags := config.RegisterFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOK, false
+		}
+		return exitUsage, false
 	}
 
-	wg.Wait()
-	var serverDetails []interface{}
+	if fs.NArg() > 0 {
+		fmt.Fprintf(os.Stderr, "unexpected arguments %q\n", fs.Args())
+		fs.Usage()
+		return exitUsage, false
+	}
 
-	for serverDetail := range serverDetailsChan {
-		serverDetails = append(serverDetails, serverDetail)
+	if err := config.Load(flags); err != nil {
+		slog.Error("Invalid configuration", "error", err)
+		return exitUsage, false
 	}
-	close(serverDetailsChan)
 
-	// Insert the servers in the database
-	err = databaseService.AddServers(serverDetails)
-	if err != nil {
-		panic(err)
+	return exitOK, true
+}
+
+// duration parses a duration of the configuration, which has been validated when it was loaded. An empty duration is zero.
+func duration(value string) time.Duration {
+	if value == "" {
+		return 0
 	}
 
-	slog.Info("Servers inserted in the database")
+	d, _ := time.ParseDuration(value)
+	return d
 }
 
-// main is the entrypoint for the application.
-func main() {
-	config.LoadConfig()
-
-	scalewayService := service.NewScalewayService(config.AppConfig.Scaleway.BaseURL, config.AppConfig.Scaleway.Token)
-	databaseService := service.NewDatabaseService(config.AppConfig.Database.Host, config.AppConfig.Database.Port, config.AppConfig.Database.Username, config.AppConfig.Database.Password, config.AppConfig.Database.Name, config.AppConfig.Database.ServersCollection, config.AppConfig.Database.IPsCollection)
-	libvirtService := service.NewLibvirtService("")
-	schedulerService := service.NewSchedulerService(databaseService, libvirtService)
-
-	serverController := controller.NewServerController(scalewayService, databaseService, libvirtService, schedulerService)
-
-	r := chi.NewRouter()
-	r.Use(middleware.Logger)
-
-	// While creating a resource such as disk, network, storage pool, or virtual machine, the user may provide the node hostname. If the hostname is provided, the application will connect to the node using the URI and create the resource on that node. If the hostname is not provided, the application will use the scheduler to decide which node should be picked.
-	// Scheduler -> CPU, Memory, Disks, Networks, Public IP (if required)
-	// Public IP = False -> Scheduler will pick a node based on CPU, Memory, Disks
-	// Public IP = True -> Scheduler will first fetch list of nodes where failover IPs are available pick a node based on CPU, Memory, Disks
-
-	// Define routes
-	r.Post("/v1/servers", serverController.CreateServer)
-	r.Get("/v1/servers", serverController.GetServers)
-	r.Delete("/v1/servers", serverController.DeleteServer)
-	r.Post("/v1/ips", serverController.AddPublicIP)
-	r.Get("/v1/ips", serverController.GetAvailablePublicIPs)
-	r.Put("/v1/ips", serverController.UpdatePublicIP)
-	r.Delete("/v1/ips", serverController.DeletePublicIP)
-	r.Post("/v1/storage/pools", serverController.CreateStoragePool)
-	r.Get("/v1/storage/pools", serverController.GetStoragePools)
-	r.Delete("/v1/storage/pools", serverController.DeleteStoragePool)
-	r.Post("/v1/storage/volumes", serverController.CreateVolume)
-	r.Get("/v1/storage/volumes", serverController.GetVolumes)
-	r.Delete("/v1/storage/volumes", serverController.DeleteVolume)
-	r.Post("/v1/networks", serverController.CreateNetwork)
-	r.Get("/v1/networks", serverController.GetNetworks)
-	r.Delete("/v1/networks", serverController.DeleteNetwork)
-	r.Post("/v1/domains", serverController.CreateDomain)
-	r.Get("/v1/domains", serverController.GetDomains)
-	r.Delete("/v1/domains", serverController.DeleteDomain)
-
-	http.ListenAndServe(":"+config.AppConfig.Application.Port, r)
+// newAuthService returns the authentication service from the configuration.
+func newAuthService(databaseService *service.DatabaseService) *service.AuthService {
+	jwt := config.AppConfig.Auth.JWT
+	return service.NewAuthService(databaseService, jwt.Secret, jwt.JWKSURL, jwt.Issuer, jwt.Audience, jwt.RolesClaim)
+}
+
+// newDatabaseService returns the database service from the configuration.
+func newDatabaseService() *service.DatabaseService {
+	database := config.AppConfig.Database
+	return service.NewDatabaseService(database.MongoURI(), database.Name, database.ServersCollection, database.IPsCollection, database.GroupsCollection, database.DomainsCollection, database.DrainsCollection, database.EventsCollection, database.PowerCollection, database.MigrationsCollection, database.KeysCollection, database.RolesCollection, database.BindingsCollection, database.ProjectsCollection, database.VolumesCollection, database.NetworksCollection, database.AuditCollection, database.IdempotencyCollection)
+}
+
+// libvirtConnection returns the global libvirt connection settings from the configuration.
+func libvirtConnection() entity.ConnectionConfig {
+	noVerify := config.AppConfig.Libvirt.NoVerify
+
+	return entity.ConnectionConfig{
+		Transport:        config.AppConfig.Libvirt.Transport,
+		User:             config.AppConfig.Libvirt.User,
+		Port:             config.AppConfig.Libvirt.Port,
+		Path:             config.AppConfig.Libvirt.Path,
+		KeyFile:          config.AppConfig.Libvirt.KeyFile,
+		KnownHosts:       config.AppConfig.Libvirt.KnownHosts,
+		KnownHostsVerify: config.AppConfig.Libvirt.KnownHostsVerify,
+		NoVerify:         &noVerify,
+		PKIPath:          config.AppConfig.Libvirt.PKIPath,
+		Socket:           config.AppConfig.Libvirt.Socket,
+	}
+}
+
+// newProviders returns the provider accounts from the configuration.
+func newProviders() (*service.Providers, error) {
+	var providers []service.Provider
+	for _, provider := range config.AppConfig.Providers {
+		var timeout time.Duration
+		if provider.Timeout != "" {
+			var err error
+			timeout, err = time.ParseDuration(provider.Timeout)
+			if err != nil {
+				return nil, fmt.Errorf("provider %s: invalid timeout: %w", provider.Name, err)
+			}
+		}
+
+		options := service.APIOptions{
+			RateLimit:   provider.RateLimit,
+			Burst:       provider.Burst,
+			MaxRetries:  provider.MaxRetries,
+			Concurrency: provider.Concurrency,
+			Timeout:     timeout,
+		}
+
+		switch provider.Type {
+		case service.ProviderTypeScaleway:
+			providers = append(providers, service.NewScalewayService(provider.Name, provider.BaseURL, provider.Token, provider.HostnamePrefix, provider.RescueImage, options))
+		case service.ProviderTypeHetzner:
+			providers = append(providers, service.NewHetznerService(provider.Name, provider.BaseURL, provider.Username, provider.Password, provider.HostnamePrefix, provider.RescueImage, options))
+		case service.ProviderTypeStatic:
+			providers = append(providers, service.NewInventoryFileService(provider.Name, provider.File, provider.HostnamePrefix))
+		default:
+			return nil, fmt.Errorf("provider %s: unknown type %q", provider.Name, provider.Type)
+		}
+	}
+
+	return service.NewProviders(providers...)
 }
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// migrate applies the pending database migrations and prints the status of every migration.
+func migrate(args []string) int {
+	fs := flag.NewFlagSet("db migrate", flag.ContinueOnError)
+	dryRun := fs.Bool("dry-run", false, "list the pending migrations without applying them")
+	if code, ok := loadConfig(fs, args); !ok {
+		return code
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	databaseService := newDatabaseService()
+	if err := databaseService.Ping(ctx); err != nil {
+		slog.Error("Database unavailable", "error", err)
+		return exitUnavailable
+	}
+
+	statuses, err := databaseService.Migrate(ctx, *dryRun)
+	for _, status := range statuses {
+		state := "applied"
+		if !status.Applied {
+			state = "pending"
+		}
+		fmt.Fprintf(os.Stdout, "%-8s %s  %s\n", state, status.ID, status.Description)
+	}
+
+	if err != nil {
+		slog.Error("Failed to apply the migrations", "error", err)
+		return exitFailure
+	}
+
+	return exitOK
+}
@@ -0,0 +1,36 @@
+package main
+
+//go:generate sh -c "go run . openapi > docs/openapi.json"
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"log/slog"
+	"os"
+
+	controller "github.com/sychonet/vdash-be/controller"
+)
+
+// printOpenAPI writes the OpenAPI document of the API to the standard output, docs/openapi.json is generated with it. The document is built from the routes alone, so it needs neither the configuration nor the database.
+func printOpenAPI(args []string) int {
+	fs := flag.NewFlagSet("openapi", flag.ContinueOnError)
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOK
+		}
+		return exitUsage
+	}
+
+	// The handlers are not called, so the controller needs no services
+	document := controller.NewOpenAPIDocument((&controller.ServerController{}).Routes())
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(document); err != nil {
+		slog.Error("Failed to write the OpenAPI document", "error", err)
+		return exitFailure
+	}
+
+	return exitOK
+}
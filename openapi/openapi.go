@@ -0,0 +1,263 @@
+// Package openapi describes the API as an OpenAPI 3 document built from its routes and from the request and response types, and validates the requests against that document.
+package openapi
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Route represents an endpoint of the API along with everything needed to document it. Body and Response are zero values of the request and response types, nil if the endpoint has none. PathFields are the json names of the body fields which are taken from the path instead. The Permission of a ProjectScoped route is required in the project of the request, the one of other routes in every project.
+type Route struct {
+	Method        string
+	Path          string
+	Summary       string
+	Permission    string
+	ProjectScoped bool
+	Handler       http.HandlerFunc
+	Body          any
+	OptionalBody  bool
+	PathFields    []string
+	Query         []Parameter
+	Headers       []Parameter
+	Status        int
+	Response      any
+	Idempotent    bool
+	Deprecated    bool
+}
+
+// Document represents an OpenAPI 3 document.
+type Document struct {
+	OpenAPI    string                `json:"openapi"`
+	Info       Info                  `json:"info"`
+	Security   []map[string][]string `json:"security"`
+	Paths      map[string]PathItem   `json:"paths"`
+	Components Components            `json:"components"`
+
+	// validators holds the validator of every route, keyed by method and path
+	validators map[string]*Validator
+}
+
+// Info represents the metadata of the API.
+type Info struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+// PathItem maps the lowercase HTTP methods of a path to their operation.
+type PathItem map[string]*Operation
+
+// Operation represents an OpenAPI operation. Permission is the resource:verb permission required to call it.
+type Operation struct {
+	OperationID string               `json:"operationId"`
+	Summary     string               `json:"summary,omitempty"`
+	Description string               `json:"description,omitempty"`
+	Tags        []string             `json:"tags,omitempty"`
+	Deprecated  bool                 `json:"deprecated,omitempty"`
+	Permission  string               `json:"x-permission,omitempty"`
+	Parameters  []Parameter          `json:"parameters,omitempty"`
+	RequestBody *RequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*Response `json:"responses"`
+}
+
+// Parameter represents a path, query or header parameter.
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+// RequestBody represents the body of a request.
+type RequestBody struct {
+	Required bool                 `json:"required,omitempty"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+// Response represents a response of an operation.
+type Response struct {
+	Description string               `json:"description"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+// MediaType represents the schema of a body for a content type.
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// Components holds the schemas and the security schemes referenced by the operations.
+type Components struct {
+	Schemas         map[string]*Schema         `json:"schemas"`
+	SecuritySchemes map[string]*SecurityScheme `json:"securitySchemes"`
+}
+
+// SecurityScheme represents a way to authenticate requests.
+type SecurityScheme struct {
+	Type        string `json:"type"`
+	Scheme      string `json:"scheme,omitempty"`
+	In          string `json:"in,omitempty"`
+	Name        string `json:"name,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+// New returns the document describing the routes. It panics if the validate tag of a type is invalid, which is a programming error.
+func New(info Info, routes []Route) *Document {
+	registry := newSchemaRegistry()
+	d := &Document{
+		OpenAPI:  "3.0.3",
+		Info:     info,
+		Security: []map[string][]string{{"bearerAuth": {}}, {"apiKey": {}}},
+		Paths:    map[string]PathItem{},
+		Components: Components{
+			Schemas: registry.schemas,
+			SecuritySchemes: map[string]*SecurityScheme{
+				"bearerAuth": {Type: "http", Scheme: "bearer", Description: "A JWT bearer token or an API key in the Authorization header"},
+				"apiKey":     {Type: "apiKey", In: "header", Name: "X-API-Key", Description: "An API key"},
+			},
+		},
+		validators: map[string]*Validator{},
+	}
+
+	for _, route := range routes {
+		operation := &Operation{
+			OperationID: operationID(route.Method, route.Path),
+			Summary:     route.Summary,
+			Deprecated:  route.Deprecated,
+			Permission:  route.Permission,
+			Responses:   map[string]*Response{},
+		}
+
+		if route.Permission != "" {
+			resource, _, _ := strings.Cut(route.Permission, ":")
+			operation.Tags = []string{resource}
+			operation.Description = "Requires the " + route.Permission + " permission in every project."
+			if route.ProjectScoped {
+				operation.Description = "Requires the " + route.Permission + " permission in the project of the request."
+			}
+		}
+
+		for _, name := range pathParameters(route.Path) {
+			operation.Parameters = append(operation.Parameters, pathParameter(name))
+		}
+		operation.Parameters = append(operation.Parameters, route.Query...)
+		operation.Parameters = append(operation.Parameters, route.Headers...)
+
+		if route.Idempotent {
+			operation.Parameters = append(operation.Parameters, Parameter{
+				Name:        "Idempotency-Key",
+				In:          "header",
+				Description: "Makes retries of the request safe, a request repeated with the same key gets the response of the first one if it succeeded",
+				Schema:      &Schema{Type: "string", MaxLength: ptr(255)},
+			})
+		}
+
+		var body *Schema
+		if route.Body != nil {
+			body = withoutFields(registry, registry.schemaOf(route.Body), route.PathFields)
+			operation.RequestBody = &RequestBody{
+				Required: !route.OptionalBody,
+				Content:  map[string]MediaType{"application/json": {Schema: body}},
+			}
+		}
+
+		status := route.Status
+		if status == 0 {
+			status = http.StatusOK
+		}
+
+		success := &Response{Description: http.StatusText(status)}
+		if route.Response != nil {
+			success.Content = map[string]MediaType{"application/json": {Schema: registry.schemaOf(route.Response)}}
+		}
+		operation.Responses[strconv.Itoa(status)] = success
+		operation.Responses["default"] = &Response{
+			Description: "Error",
+			Content:     map[string]MediaType{"text/plain": {Schema: &Schema{Type: "string"}}},
+		}
+
+		item, ok := d.Paths[route.Path]
+		if !ok {
+			item = PathItem{}
+			d.Paths[route.Path] = item
+		}
+		item[strings.ToLower(route.Method)] = operation
+
+		d.validators[route.Method+" "+route.Path] = &Validator{
+			query:      route.Query,
+			body:       body,
+			components: registry.schemas,
+		}
+	}
+
+	return d
+}
+
+// Validator returns the validator of the requests of a route.
+func (d *Document) Validator(route Route) *Validator {
+	return d.validators[route.Method+" "+route.Path]
+}
+
+// withoutFields returns the schema of a body without the fields taken from the path. The schema is copied inline so that the component of the type is left untouched.
+func withoutFields(registry *schemaRegistry, schema *Schema, fields []string) *Schema {
+	if len(fields) == 0 {
+		return schema
+	}
+
+	resolved := *schema
+	if schema.Ref != "" {
+		resolved = *registry.schemas[strings.TrimPrefix(schema.Ref, refPrefix)]
+	}
+
+	properties := resolved.Properties
+	resolved.Properties = map[string]*Schema{}
+	for name, property := range properties {
+		if !slices.Contains(fields, name) {
+			resolved.Properties[name] = property
+		}
+	}
+
+	resolved.Required = slices.DeleteFunc(slices.Clone(resolved.Required), func(name string) bool {
+		return slices.Contains(fields, name)
+	})
+
+	return &resolved
+}
+
+// pathParameters returns the names of the parameters of a path, such as serverID in /v2/servers/{serverID}.
+func pathParameters(path string) []string {
+	var names []string
+	for _, segment := range strings.Split(path, "/") {
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+			names = append(names, strings.TrimSuffix(strings.TrimPrefix(segment, "{"), "}"))
+		}
+	}
+
+	return names
+}
+
+// pathParameter describes a path parameter. Parameters naming a server are positive integers, the other ones are strings.
+func pathParameter(name string) Parameter {
+	schema := &Schema{Type: "string"}
+	if name == "serverID" {
+		schema = &Schema{Type: "integer", Format: "int64", Minimum: ptr(1.0)}
+	}
+
+	return Parameter{Name: name, In: "path", Required: true, Schema: schema}
+}
+
+// operationID derives a unique id for an operation from its method and path, such as postV2ServersServerIDDomains.
+func operationID(method, path string) string {
+	id := strings.ToLower(method)
+	for _, segment := range strings.Split(path, "/") {
+		segment = strings.Trim(segment, "{}")
+		if segment == "" {
+			continue
+		}
+		id += strings.ToUpper(segment[:1]) + segment[1:]
+	}
+
+	return id
+}
@@ -0,0 +1,269 @@
+package openapi
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schema represents an OpenAPI schema object. Only the keywords used to describe the request and response types are supported.
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	AllOf                []*Schema          `json:"allOf,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Nullable             bool               `json:"nullable,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Enum                 []any              `json:"enum,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	MinItems             *int               `json:"minItems,omitempty"`
+	MaxItems             *int               `json:"maxItems,omitempty"`
+}
+
+// refPrefix starts the references to the schemas of the components.
+const refPrefix = "#/components/schemas/"
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// schemaRegistry turns Go types into schemas. Named structs are registered as components and referenced, so that every type is described once.
+type schemaRegistry struct {
+	schemas map[string]*Schema
+	names   map[reflect.Type]string
+}
+
+func newSchemaRegistry() *schemaRegistry {
+	return &schemaRegistry{schemas: map[string]*Schema{}, names: map[reflect.Type]string{}}
+}
+
+// schemaOf returns the schema of the type of the value, or nil if value is nil.
+func (s *schemaRegistry) schemaOf(value any) *Schema {
+	if value == nil {
+		return nil
+	}
+
+	return s.schema(reflect.TypeOf(value))
+}
+
+// schema returns the schema of a type as encoding/json would encode it.
+func (s *schemaRegistry) schema(t reflect.Type) *Schema {
+	if t == timeType {
+		return &Schema{Type: "string", Format: "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		schema := s.schema(t.Elem())
+		if schema.Ref != "" {
+			// A reference cannot have siblings in OpenAPI 3.0
+			return &Schema{AllOf: []*Schema{schema}, Nullable: true}
+		}
+		schema.Nullable = true
+		return schema
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int8, reflect.Int16, reflect.Int32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int, reflect.Int64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer", Format: "int32", Minimum: ptr(0.0)}
+	case reflect.Uint, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64", Minimum: ptr(0.0)}
+	case reflect.Float32:
+		return &Schema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: s.schema(t.Elem()), Nullable: t.Kind() == reflect.Slice}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: s.schema(t.Elem()), Nullable: true}
+	case reflect.Struct:
+		return s.structSchema(t)
+	default:
+		// Interfaces accept any value
+		return &Schema{}
+	}
+}
+
+// structSchema registers a named struct as a component and returns a reference to it. Anonymous structs are described inline.
+func (s *schemaRegistry) structSchema(t reflect.Type) *Schema {
+	if t.Name() == "" {
+		return s.objectSchema(t)
+	}
+
+	if name, ok := s.names[t]; ok {
+		return &Schema{Ref: refPrefix + name}
+	}
+
+	name := s.componentName(t)
+	s.names[t] = name
+	// Register the name before describing the fields so that recursive types end
+	s.schemas[name] = &Schema{}
+	*s.schemas[name] = *s.objectSchema(t)
+
+	return &Schema{Ref: refPrefix + name}
+}
+
+// componentName returns the name of the component of a struct. Instances of generic types are named after the type and its arguments, such as ListResponseOfDomainResponse, and names used by types of another package are prefixed with their package.
+func (s *schemaRegistry) componentName(t reflect.Type) string {
+	name := t.Name()
+	if base, args, ok := strings.Cut(name, "["); ok {
+		name = base + "Of"
+		for _, arg := range strings.Split(strings.TrimSuffix(args, "]"), ",") {
+			name += arg[strings.LastIndex(arg, ".")+1:]
+		}
+	}
+
+	if _, taken := s.schemas[name]; taken {
+		pkg := t.PkgPath()[strings.LastIndex(t.PkgPath(), "/")+1:]
+		name = strings.ToUpper(pkg[:1]) + pkg[1:] + name
+	}
+
+	return name
+}
+
+// objectSchema describes the exported fields of a struct, following the json tags. The fields of embedded structs are promoted as encoding/json does.
+func (s *schemaRegistry) objectSchema(t reflect.Type) *Schema {
+	schema := &Schema{Type: "object", Properties: map[string]*Schema{}}
+
+	for i := range t.NumField() {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+
+		name, options, _ := strings.Cut(tag, ",")
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+
+			if embedded.Kind() == reflect.Struct {
+				promoted := s.objectSchema(embedded)
+				for key, value := range promoted.Properties {
+					schema.Properties[key] = value
+				}
+				schema.Required = append(schema.Required, promoted.Required...)
+				continue
+			}
+		}
+
+		if !field.IsExported() {
+			continue
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+
+		property := s.schema(field.Type)
+		if strings.Contains(options, "string") && (property.Type == "integer" || property.Type == "number" || property.Type == "boolean") {
+			property = &Schema{Type: "string"}
+		}
+
+		required, err := applyRules(property, field.Tag.Get("validate"))
+		if err != nil {
+			panic(fmt.Sprintf("openapi: field %s of %s: %v", field.Name, t, err))
+		}
+
+		if required {
+			schema.Required = append(schema.Required, name)
+		}
+		schema.Properties[name] = property
+	}
+
+	return schema
+}
+
+// applyRules adds the rules of a validate tag to the schema of a field and reports whether the field is required. The rules are separated by commas:
+//
+//	required       the field must be present
+//	min=N, max=N   bounds of a number, of the length of a string or of the number of items of an array
+//	oneof=A B ''   the allowed values, '' standing for the empty string
+func applyRules(schema *Schema, tag string) (bool, error) {
+	if tag == "" {
+		return false, nil
+	}
+
+	target := schema
+	if len(schema.AllOf) > 0 {
+		target = schema.AllOf[0]
+	}
+
+	required := false
+	for _, rule := range strings.Split(tag, ",") {
+		name, value, _ := strings.Cut(rule, "=")
+		switch name {
+		case "required":
+			required = true
+		case "min", "max":
+			bound, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return false, fmt.Errorf("invalid %s rule %q", name, value)
+			}
+
+			switch target.Type {
+			case "integer", "number":
+				if name == "min" {
+					target.Minimum = &bound
+				} else {
+					target.Maximum = &bound
+				}
+			case "string":
+				if name == "min" {
+					target.MinLength = ptr(int(bound))
+				} else {
+					target.MaxLength = ptr(int(bound))
+				}
+			case "array":
+				if name == "min" {
+					target.MinItems = ptr(int(bound))
+				} else {
+					target.MaxItems = ptr(int(bound))
+				}
+			default:
+				return false, fmt.Errorf("%s rule on a field of type %q", name, target.Type)
+			}
+		case "oneof":
+			for _, option := range strings.Fields(value) {
+				if option == "''" {
+					option = ""
+				}
+
+				if target.Type == "integer" {
+					number, err := strconv.ParseInt(option, 10, 64)
+					if err != nil {
+						return false, fmt.Errorf("invalid oneof value %q", option)
+					}
+					target.Enum = append(target.Enum, number)
+					continue
+				}
+				target.Enum = append(target.Enum, option)
+			}
+		default:
+			return false, fmt.Errorf("unknown rule %q", name)
+		}
+	}
+
+	return required, nil
+}
+
+func ptr[T any](value T) *T {
+	return &value
+}
@@ -0,0 +1,249 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Validator checks the query parameters and the body of the requests of a route against the document.
+type Validator struct {
+	query      []Parameter
+	body       *Schema
+	components map[string]*Schema
+}
+
+// ValidationError is returned when a request does not match the document. Problems lists every mismatch.
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "Invalid request: " + strings.Join(e.Problems, "; ")
+}
+
+// Validate returns a ValidationError if the query parameters or the body of the request do not match the route. The body is read and replaced so that the handler can decode it again. Empty query parameters are ignored as the handlers treat them as missing, and an empty body is checked as an empty object.
+func (v *Validator) Validate(r *http.Request) error {
+	var problems []string
+
+	query := r.URL.Query()
+	for _, parameter := range v.query {
+		values := slices.DeleteFunc(slices.Clone(query[parameter.Name]), func(value string) bool { return value == "" })
+		if len(values) == 0 {
+			if parameter.Required {
+				problems = append(problems, fmt.Sprintf("missing %s query parameter", parameter.Name))
+			}
+			continue
+		}
+
+		schema := parameter.Schema
+		if schema.Type == "array" {
+			schema = schema.Items
+		} else {
+			values = values[:1]
+		}
+
+		for _, value := range values {
+			if problem := v.checkParameter(schema, value); problem != "" {
+				problems = append(problems, fmt.Sprintf("%s query parameter %s", parameter.Name, problem))
+			}
+		}
+	}
+
+	if v.body != nil {
+		data, err := io.ReadAll(r.Body)
+		if err != nil {
+			return fmt.Errorf("failed to read request body: %w", err)
+		}
+		r.Body = io.NopCloser(bytes.NewReader(data))
+
+		var body any = map[string]any{}
+		if len(bytes.TrimSpace(data)) > 0 {
+			decoder := json.NewDecoder(bytes.NewReader(data))
+			decoder.UseNumber()
+			if err := decoder.Decode(&body); err != nil {
+				return &ValidationError{Problems: []string{"body is not valid JSON: " + err.Error()}}
+			}
+		}
+
+		problems = append(problems, v.check(v.body, body, "body")...)
+	}
+
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+
+	return nil
+}
+
+// checkParameter returns why a query parameter does not match its schema, or an empty string if it does.
+func (v *Validator) checkParameter(schema *Schema, value string) string {
+	var decoded any = value
+	switch schema.Type {
+	case "integer", "number":
+		decoded = json.Number(value)
+	case "boolean":
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return "must be true or false"
+		}
+		decoded = b
+	}
+
+	if problems := v.check(schema, decoded, ""); len(problems) > 0 {
+		return problems[0]
+	}
+
+	return ""
+}
+
+// check returns the mismatches between a decoded JSON value and a schema. path locates the value in the body, such as requiredConstraints[0].operator, and is "body" for the body itself.
+func (v *Validator) check(schema *Schema, value any, path string) []string {
+	if schema.Ref != "" {
+		return v.check(v.components[strings.TrimPrefix(schema.Ref, refPrefix)], value, path)
+	}
+
+	if value == nil {
+		if schema.Nullable || (schema.Type == "" && len(schema.AllOf) == 0) {
+			return nil
+		}
+		return []string{describe(path, "must not be null")}
+	}
+
+	var problems []string
+	for _, sub := range schema.AllOf {
+		problems = append(problems, v.check(sub, value, path)...)
+	}
+
+	switch schema.Type {
+	case "object":
+		object, ok := value.(map[string]any)
+		if !ok {
+			return append(problems, describe(path, "must be an object"))
+		}
+
+		for _, name := range schema.Required {
+			if _, ok := object[name]; !ok {
+				problems = append(problems, describe(join(path, name), "is required"))
+			}
+		}
+
+		// Unknown fields are ignored like encoding/json does
+		for _, name := range slices.Sorted(maps.Keys(object)) {
+			field := object[name]
+			if property, ok := schema.Properties[name]; ok {
+				problems = append(problems, v.check(property, field, join(path, name))...)
+			} else if schema.AdditionalProperties != nil {
+				problems = append(problems, v.check(schema.AdditionalProperties, field, join(path, name))...)
+			}
+		}
+	case "array":
+		array, ok := value.([]any)
+		if !ok {
+			return append(problems, describe(path, "must be an array"))
+		}
+
+		if schema.MinItems != nil && len(array) < *schema.MinItems {
+			problems = append(problems, describe(path, fmt.Sprintf("must have at least %d items", *schema.MinItems)))
+		}
+
+		if schema.MaxItems != nil && len(array) > *schema.MaxItems {
+			problems = append(problems, describe(path, fmt.Sprintf("must have at most %d items", *schema.MaxItems)))
+		}
+
+		for i, item := range array {
+			problems = append(problems, v.check(schema.Items, item, fmt.Sprintf("%s[%d]", path, i))...)
+		}
+	case "string":
+		s, ok := value.(string)
+		if !ok {
+			return append(problems, describe(path, "must be a string"))
+		}
+
+		length := utf8.RuneCountInString(s)
+		if schema.MinLength != nil && length < *schema.MinLength {
+			if *schema.MinLength == 1 {
+				problems = append(problems, describe(path, "must not be empty"))
+			} else {
+				problems = append(problems, describe(path, fmt.Sprintf("must be at least %d characters long", *schema.MinLength)))
+			}
+		}
+
+		if schema.MaxLength != nil && length > *schema.MaxLength {
+			problems = append(problems, describe(path, fmt.Sprintf("must be at most %d characters long", *schema.MaxLength)))
+		}
+
+		if schema.Format == "date-time" {
+			if _, err := time.Parse(time.RFC3339, s); err != nil {
+				problems = append(problems, describe(path, "must be an RFC 3339 time"))
+			}
+		}
+	case "integer", "number":
+		expected := "must be a number"
+		if schema.Type == "integer" {
+			expected = "must be an integer"
+		}
+
+		number, ok := value.(json.Number)
+		if !ok {
+			return append(problems, describe(path, expected))
+		}
+
+		f, err := number.Float64()
+		if err != nil {
+			return append(problems, describe(path, expected))
+		}
+
+		if _, err := number.Int64(); err != nil && schema.Type == "integer" {
+			return append(problems, describe(path, expected))
+		}
+
+		if schema.Minimum != nil && f < *schema.Minimum {
+			problems = append(problems, describe(path, fmt.Sprintf("must be at least %v", *schema.Minimum)))
+		}
+
+		if schema.Maximum != nil && f > *schema.Maximum {
+			problems = append(problems, describe(path, fmt.Sprintf("must be at most %v", *schema.Maximum)))
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return append(problems, describe(path, "must be true or false"))
+		}
+	}
+
+	if len(schema.Enum) > 0 && !slices.ContainsFunc(schema.Enum, func(option any) bool { return fmt.Sprint(option) == fmt.Sprint(value) }) {
+		var options []string
+		for _, option := range schema.Enum {
+			options = append(options, fmt.Sprintf("%q", option))
+		}
+		problems = append(problems, describe(path, "must be one of "+strings.Join(options, ", ")))
+	}
+
+	return problems
+}
+
+// join returns the path of a field of an object.
+func join(path, name string) string {
+	if path == "" || path == "body" {
+		return name
+	}
+
+	return path + "." + name
+}
+
+// describe prefixes a problem with the path of the value it concerns.
+func describe(path, problem string) string {
+	if path == "" {
+		return problem
+	}
+
+	return path + " " + problem
+}
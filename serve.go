@@ -0,0 +1,166 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/sychonet/vdash-be/config"
+	controller "github.com/sychonet/vdash-be/controller"
+	"github.com/sychonet/vdash-be/service"
+)
+
+// serve runs the API server along with the background workers until it receives SIGINT or SIGTERM. It then stops accepting requests, stops the workers and gives the in-flight requests and drains until the shutdown timeout to finish.
+func serve(args []string) int {
+	fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+	if code, ok := loadConfig(fs, args); !ok {
+		return code
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	providers, err := newProviders()
+	if err != nil {
+		slog.Error("Invalid provider configuration", "error", err)
+		return exitUsage
+	}
+	databaseService := newDatabaseService()
+	libvirtService := service.NewLibvirtService("")
+	schedulerService := service.NewSchedulerService(databaseService, libvirtService)
+	drainService := service.NewDrainService(ctx, databaseService, libvirtService, schedulerService)
+
+	onboardingService := service.NewOnboardingService(databaseService, libvirtService, config.AppConfig.Onboarding.MinLibvirtVersion, config.AppConfig.Onboarding.MinHypervisorVersion, libvirtConnection())
+
+	powerService := service.NewPowerService(databaseService, providers)
+
+	var workers sync.WaitGroup
+	startWorker := func(run func(ctx context.Context)) {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			run(ctx)
+		}()
+	}
+
+	// Check the health of the servers in the background
+	healthService := service.NewHealthService(databaseService, libvirtService, powerService, duration(config.AppConfig.Health.Interval), config.AppConfig.Health.FailureThreshold, duration(config.AppConfig.Health.PowerCycleAfter))
+	startWorker(healthService.Run)
+
+	// Keep the servers in sync with the provider inventories in the background
+	reconcilerService := service.NewReconcilerService(databaseService, providers, onboardingService, duration(config.AppConfig.Reconcile.Interval))
+	startWorker(reconcilerService.Run)
+
+	// Keep the public IPs in sync with the failover IPs of the provider accounts in the background
+	failoverService := service.NewFailoverService(databaseService, providers, duration(config.AppConfig.Reconcile.Interval))
+	startWorker(failoverService.Run)
+
+	authService := newAuthService(databaseService)
+	rbacService := service.NewRBACService(databaseService)
+	projectService := service.NewProjectService(databaseService)
+	auditService := service.NewAuditService(databaseService)
+	idempotencyService := service.NewIdempotencyService(databaseService, duration(config.AppConfig.Idempotency.TTL))
+
+	serverController := controller.NewServerController(providers, databaseService, libvirtService, schedulerService, drainService, onboardingService, reconcilerService, failoverService, powerService, authService, rbacService, projectService, auditService, idempotencyService)
+
+	routes := serverController.Routes()
+	document := controller.NewOpenAPIDocument(routes)
+
+	r := chi.NewRouter()
+	r.Use(middleware.Logger)
+
+	// The OpenAPI document and its rendering are public
+	r.Get("/openapi.json", controller.ServeOpenAPI(document))
+	r.Get("/docs", controller.ServeDocs)
+
+	r.Group(func(r chi.Router) {
+		if config.AppConfig.Auth.Enabled {
+			r.Use(serverController.Authenticate)
+		} else {
+			slog.Warn("Authentication is disabled, the API is open to anyone who can reach it")
+		}
+		r.Use(serverController.Audit)
+
+		// While creating a resource such as disk, network, storage pool, or virtual machine, the user may provide the node hostname. If the hostname is provided, the application will connect to the node using the URI and create the resource on that node. If the hostname is not provided, the application will use the scheduler to decide which node should be picked.
+		// Scheduler -> CPU, Memory, Disks, Networks, Public IP (if required)
+		// Public IP = False -> Scheduler will pick a node based on CPU, Memory, Disks
+		// Public IP = True -> Scheduler will first fetch list of nodes where failover IPs are available pick a node based on CPU, Memory, Disks
+
+		// Define routes, requests are checked against the OpenAPI document before reaching the handlers and before their Idempotency-Key is reserved
+		for _, route := range routes {
+			middlewares := chi.Middlewares{serverController.Require(route.Permission, route.ProjectScoped), controller.Validate(document.Validator(route))}
+			if route.Deprecated {
+				middlewares = append(chi.Middlewares{controller.Deprecated}, middlewares...)
+			}
+
+			if route.Idempotent {
+				middlewares = append(middlewares, serverController.Idempotent)
+			}
+
+			r.With(middlewares...).Method(route.Method, route.Path, route.Handler)
+		}
+	})
+
+	server := &http.Server{
+		Addr:              ":" + config.AppConfig.Application.Port,
+		Handler:           r,
+		ReadHeaderTimeout: duration(config.AppConfig.Application.ReadHeaderTimeout),
+		ReadTimeout:       duration(config.AppConfig.Application.ReadTimeout),
+		WriteTimeout:      duration(config.AppConfig.Application.WriteTimeout),
+		IdleTimeout:       duration(config.AppConfig.Application.IdleTimeout),
+	}
+
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- server.ListenAndServe()
+	}()
+
+	code := exitOK
+	select {
+	case err := <-serveErr:
+		slog.Error("Failed to serve the API", "error", err)
+		stop()
+		code = exitUnavailable
+	case <-ctx.Done():
+		slog.Info("Shutting down")
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), duration(config.AppConfig.Application.ShutdownTimeout))
+	defer cancel()
+
+	// Stop accepting requests and wait for the in-flight ones, the connections left after the timeout are closed which cancels their requests
+	if err := server.Shutdown(shutdownCtx); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		slog.Error("Failed to finish in-flight requests", "error", err)
+		server.Close()
+		code = exitFailure
+	}
+
+	if err := drainService.Wait(shutdownCtx); err != nil {
+		slog.Error("Failed to finish running drains", "error", err)
+		code = exitFailure
+	}
+
+	// Libvirt calls cannot be interrupted, so a worker probing a node may outlive the timeout
+	stopped := make(chan struct{})
+	go func() {
+		workers.Wait()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-shutdownCtx.Done():
+		slog.Error("Failed to stop background workers", "error", shutdownCtx.Err())
+		code = exitFailure
+	}
+
+	return code
+}
@@ -0,0 +1,214 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Defaults of the provider API clients used when the configuration leaves them unset.
+const (
+	apiDefaultTimeout     = 10 * time.Second
+	apiDefaultConcurrency = 4
+	apiRetryBaseDelay     = 500 * time.Millisecond
+	apiRetryMaxDelay      = 30 * time.Second
+)
+
+// APIError is returned when the API of a provider answers with an unsuccessful status code.
+type APIError struct {
+	Provider   string
+	Method     string
+	Path       string
+	StatusCode int
+	Code       string
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	message := e.Message
+	if message == "" {
+		message = http.StatusText(e.StatusCode)
+	}
+
+	return fmt.Sprintf("%s: %s %s: %d %s", e.Provider, e.Method, e.Path, e.StatusCode, message)
+}
+
+// Temporary reports whether the request may succeed when retried.
+func (e *APIError) Temporary() bool {
+	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= http.StatusInternalServerError
+}
+
+// APIOptions holds the settings of a provider API client. RateLimit is the number of requests per second allowed by the API quota and Burst the number of requests which may be sent at once. Concurrency bounds the number of requests in flight when fetching many servers.
+type APIOptions struct {
+	RateLimit   float64
+	Burst       int
+	MaxRetries  int
+	Concurrency int
+	Timeout     time.Duration
+}
+
+// apiClient sends rate limited requests to the API of a provider and retries them with exponential backoff when the API is throttling or failing.
+type apiClient struct {
+	provider    string
+	baseURL     string
+	client      *http.Client
+	limiter     *tokenBucket
+	maxRetries  int
+	concurrency int
+	authorize   func(req *http.Request)
+	decodeError func(data []byte, apiErr *APIError)
+}
+
+func newAPIClient(provider, baseURL string, options APIOptions, authorize func(req *http.Request), decodeError func(data []byte, apiErr *APIError)) *apiClient {
+	if options.Timeout <= 0 {
+		options.Timeout = apiDefaultTimeout
+	}
+
+	if options.Concurrency <= 0 {
+		options.Concurrency = apiDefaultConcurrency
+	}
+
+	return &apiClient{
+		provider: provider,
+		baseURL:  strings.TrimSuffix(baseURL, "/"),
+		// The client and its transport are shared so that connections to the API are reused
+		client:      &http.Client{Timeout: options.Timeout},
+		limiter:     newTokenBucket(options.RateLimit, options.Burst),
+		maxRetries:  max(options.MaxRetries, 0),
+		concurrency: options.Concurrency,
+		authorize:   authorize,
+		decodeError: decodeError,
+	}
+}
+
+// do sends a request with the optional form to the API and decodes the JSON response into out.
+func (c *apiClient) do(ctx context.Context, method, path string, form url.Values, out any) error {
+	for attempt := 0; ; attempt++ {
+		retryAfter, err := c.send(ctx, method, path, form, out)
+		if err == nil {
+			return nil
+		}
+
+		if attempt >= c.maxRetries || !retryable(method, err) {
+			return err
+		}
+
+		delay := backoff(attempt, retryAfter)
+		slog.Warn(fmt.Sprintf("Retrying %s request %s %s in %s: %v", c.provider, method, path, delay, err))
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// send performs a single attempt of a request. It returns the delay requested by the Retry-After header, if any.
+func (c *apiClient) send(ctx context.Context, method, path string, form url.Values, out any) (time.Duration, error) {
+	if err := c.limiter.Wait(ctx); err != nil {
+		return 0, err
+	}
+
+	var body io.Reader
+	if form != nil {
+		body = strings.NewReader(form.Encode())
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, body)
+	if err != nil {
+		return 0, err
+	}
+
+	c.authorize(req)
+	req.Header.Set("Accept", "application/json")
+	if form != nil {
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		apiErr := &APIError{Provider: c.provider, Method: method, Path: path, StatusCode: resp.StatusCode}
+
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+		if c.decodeError != nil {
+			c.decodeError(data, apiErr)
+		}
+
+		return retryAfterDelay(resp.Header.Get("Retry-After")), apiErr
+	}
+
+	// parse the response
+	if out == nil {
+		_, err = io.Copy(io.Discard, resp.Body)
+		return 0, err
+	}
+
+	return 0, json.NewDecoder(resp.Body).Decode(out)
+}
+
+// retryable reports whether a failed request should be retried. Requests with other methods than the idempotent ones, such as the POST actions rebooting a server, are only retried when throttled since the API may have processed them.
+func retryable(method string, err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusTooManyRequests {
+		return true
+	}
+
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete:
+		if apiErr != nil {
+			return apiErr.Temporary()
+		}
+		return true
+	}
+
+	return false
+}
+
+// backoff returns the delay before the next attempt. The delay doubles with every attempt, is jittered and honors the delay requested by the API.
+func backoff(attempt int, retryAfter time.Duration) time.Duration {
+	delay := apiRetryBaseDelay << attempt
+	if delay <= 0 || delay > apiRetryMaxDelay {
+		delay = apiRetryMaxDelay
+	}
+	delay = delay/2 + rand.N(delay/2+1)
+
+	return max(delay, min(retryAfter, apiRetryMaxDelay))
+}
+
+// retryAfterDelay parses the Retry-After header given either in seconds or as an HTTP date.
+func retryAfterDelay(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return time.Duration(seconds) * time.Second
+	}
+
+	if date, err := http.ParseTime(value); err == nil {
+		return time.Until(date)
+	}
+
+	return 0
+}
@@ -0,0 +1,279 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// bodyTracker counts the response bodies which were opened and not closed yet.
+type bodyTracker struct {
+	transport http.RoundTripper
+	open      atomic.Int64
+}
+
+func (t *bodyTracker) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	t.open.Add(1)
+	resp.Body = &trackedBody{ReadCloser: resp.Body, tracker: t}
+	return resp, nil
+}
+
+type trackedBody struct {
+	io.ReadCloser
+	tracker *bodyTracker
+	once    sync.Once
+}
+
+func (b *trackedBody) Close() error {
+	b.once.Do(func() { b.tracker.open.Add(-1) })
+	return b.ReadCloser.Close()
+}
+
+// newTestAPIClient returns a client of a test server serving handler, whose response bodies are tracked.
+func newTestAPIClient(t *testing.T, handler http.HandlerFunc, options APIOptions) (*apiClient, *bodyTracker) {
+	t.Helper()
+
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	client := newAPIClient("test", server.URL, options, func(req *http.Request) {}, func(data []byte, apiErr *APIError) {
+		apiErr.Message = string(data)
+	})
+
+	tracker := &bodyTracker{transport: http.DefaultTransport}
+	client.client.Transport = tracker
+	t.Cleanup(func() {
+		if open := tracker.open.Load(); open != 0 {
+			t.Errorf("%d response bodies were not closed", open)
+		}
+	})
+
+	return client, tracker
+}
+
+func TestAPIClientRetriesTemporaryErrors(t *testing.T) {
+	for _, status := range []int{http.StatusTooManyRequests, http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable} {
+		t.Run(http.StatusText(status), func(t *testing.T) {
+			var attempts atomic.Int64
+			client, _ := newTestAPIClient(t, func(w http.ResponseWriter, r *http.Request) {
+				if attempts.Add(1) < 3 {
+					http.Error(w, "try again", status)
+					return
+				}
+				w.Write([]byte(`{"name":"ok"}`))
+			}, APIOptions{MaxRetries: 3})
+
+			var out struct{ Name string }
+			if err := client.do(context.Background(), http.MethodGet, "/thing", nil, &out); err != nil {
+				t.Fatalf("do() error = %v", err)
+			}
+
+			if attempts.Load() != 3 {
+				t.Errorf("attempts = %d, want 3", attempts.Load())
+			}
+
+			if out.Name != "ok" {
+				t.Errorf("decoded name = %q, want ok", out.Name)
+			}
+		})
+	}
+}
+
+func TestAPIClientGivesUpAfterMaxRetries(t *testing.T) {
+	var attempts atomic.Int64
+	client, _ := newTestAPIClient(t, func(w http.ResponseWriter, r *http.Request) {
+		attempts.Add(1)
+		http.Error(w, "down", http.StatusServiceUnavailable)
+	}, APIOptions{MaxRetries: 2})
+
+	err := client.do(context.Background(), http.MethodGet, "/thing", nil, nil)
+
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusServiceUnavailable || !apiErr.Temporary() {
+		t.Fatalf("do() error = %v, want a temporary 503 APIError", err)
+	}
+
+	if attempts.Load() != 3 {
+		t.Errorf("attempts = %d, want 3", attempts.Load())
+	}
+}
+
+func TestAPIClientDoesNotRetryClientErrors(t *testing.T) {
+	var attempts atomic.Int64
+	client, _ := newTestAPIClient(t, func(w http.ResponseWriter, r *http.Request) {
+		attempts.Add(1)
+		http.Error(w, "no such thing", http.StatusNotFound)
+	}, APIOptions{MaxRetries: 3})
+
+	err := client.do(context.Background(), http.MethodGet, "/thing", nil, nil)
+
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("do() error = %v, want an APIError", err)
+	}
+
+	if apiErr.Provider != "test" || apiErr.Method != http.MethodGet || apiErr.Path != "/thing" || apiErr.StatusCode != http.StatusNotFound || apiErr.Temporary() {
+		t.Errorf("APIError = %+v, want a permanent 404 of GET /thing", apiErr)
+	}
+
+	if attempts.Load() != 1 {
+		t.Errorf("attempts = %d, want 1", attempts.Load())
+	}
+}
+
+func TestAPIClientDoesNotRetryFailedActions(t *testing.T) {
+	var attempts atomic.Int64
+	client, _ := newTestAPIClient(t, func(w http.ResponseWriter, r *http.Request) {
+		attempts.Add(1)
+		http.Error(w, "bad gateway", http.StatusBadGateway)
+	}, APIOptions{MaxRetries: 3})
+
+	err := client.do(context.Background(), http.MethodPost, "/server/reboot/1", url.Values{}, nil)
+
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadGateway {
+		t.Fatalf("do() error = %v, want the 502 APIError", err)
+	}
+
+	if attempts.Load() != 1 {
+		t.Errorf("attempts = %d, want 1 since the API may have rebooted the server", attempts.Load())
+	}
+}
+
+func TestAPIClientHonorsRetryAfter(t *testing.T) {
+	var attempts atomic.Int64
+	client, _ := newTestAPIClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if attempts.Add(1) == 1 {
+			w.Header().Set("Retry-After", "1")
+			http.Error(w, "slow down", http.StatusTooManyRequests)
+			return
+		}
+		w.Write([]byte(`{}`))
+	}, APIOptions{MaxRetries: 1})
+
+	start := time.Now()
+	if err := client.do(context.Background(), http.MethodGet, "/thing", nil, nil); err != nil {
+		t.Fatalf("do() error = %v", err)
+	}
+
+	if elapsed := time.Since(start); elapsed < time.Second {
+		t.Errorf("retried after %s, want at least the 1s asked by Retry-After", elapsed)
+	}
+}
+
+func TestAPIClientStopsWhenContextIsCanceled(t *testing.T) {
+	client, _ := newTestAPIClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "30")
+		http.Error(w, "slow down", http.StatusTooManyRequests)
+	}, APIOptions{MaxRetries: 5})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	err := client.do(ctx, http.MethodGet, "/thing", nil, nil)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("do() error = %v, want context.DeadlineExceeded", err)
+	}
+
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("do() returned after %s, want it to stop waiting once the context is done", elapsed)
+	}
+}
+
+func TestAPIClientSendsForms(t *testing.T) {
+	client, _ := newTestAPIClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/x-www-form-urlencoded" {
+			t.Errorf("request = %s with Content-Type %q, want a form POST", r.Method, r.Header.Get("Content-Type"))
+		}
+
+		if err := r.ParseForm(); err != nil || r.PostForm.Get("source") != "192.0.2.1" {
+			t.Errorf("form = %v (%v), want source=192.0.2.1", r.PostForm, err)
+		}
+	}, APIOptions{})
+
+	if err := client.do(context.Background(), http.MethodPost, "/edit", url.Values{"source": {"192.0.2.1"}}, nil); err != nil {
+		t.Fatalf("do() error = %v", err)
+	}
+}
+
+func TestRetryable(t *testing.T) {
+	transportErr := errors.New("connection reset")
+	tests := []struct {
+		name   string
+		method string
+		err    error
+		want   bool
+	}{
+		{"temporary API error of a GET", http.MethodGet, &APIError{StatusCode: http.StatusBadGateway}, true},
+		{"temporary API error of a POST", http.MethodPost, &APIError{StatusCode: http.StatusBadGateway}, false},
+		{"throttled POST", http.MethodPost, &APIError{StatusCode: http.StatusTooManyRequests}, true},
+		{"permanent API error", http.MethodGet, &APIError{StatusCode: http.StatusBadRequest}, false},
+		{"transport error of a GET", http.MethodGet, transportErr, true},
+		{"transport error of a DELETE", http.MethodDelete, transportErr, true},
+		{"transport error of a POST", http.MethodPost, transportErr, false},
+		{"canceled context", http.MethodGet, context.Canceled, false},
+		{"expired context", http.MethodGet, context.DeadlineExceeded, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := retryable(tt.method, tt.err); got != tt.want {
+				t.Errorf("retryable(%s, %v) = %v, want %v", tt.method, tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	for attempt := range 5 {
+		delay := backoff(attempt, 0)
+		full := apiRetryBaseDelay << attempt
+		if delay < full/2 || delay > full {
+			t.Errorf("backoff(%d, 0) = %s, want between %s and %s", attempt, delay, full/2, full)
+		}
+	}
+
+	if delay := backoff(40, 0); delay > apiRetryMaxDelay {
+		t.Errorf("backoff(40, 0) = %s, want at most %s", delay, apiRetryMaxDelay)
+	}
+
+	if delay := backoff(0, 5*time.Second); delay != 5*time.Second {
+		t.Errorf("backoff(0, 5s) = %s, want the 5s asked by the API", delay)
+	}
+
+	if delay := backoff(0, time.Hour); delay != apiRetryMaxDelay {
+		t.Errorf("backoff(0, 1h) = %s, want it capped to %s", delay, apiRetryMaxDelay)
+	}
+}
+
+func TestRetryAfterDelay(t *testing.T) {
+	if got := retryAfterDelay(""); got != 0 {
+		t.Errorf("retryAfterDelay(\"\") = %s, want 0", got)
+	}
+
+	if got := retryAfterDelay("7"); got != 7*time.Second {
+		t.Errorf("retryAfterDelay(\"7\") = %s, want 7s", got)
+	}
+
+	if got := retryAfterDelay("soon"); got != 0 {
+		t.Errorf("retryAfterDelay(\"soon\") = %s, want 0", got)
+	}
+
+	date := time.Now().Add(10 * time.Second).UTC().Format(http.TimeFormat)
+	if got := retryAfterDelay(date); got <= 8*time.Second || got > 10*time.Second {
+		t.Errorf("retryAfterDelay(%q) = %s, want about 10s", date, got)
+	}
+}
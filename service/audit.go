@@ -0,0 +1,164 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sychonet/vdash-be/db/entity"
+)
+
+// Outcomes of an audited request.
+const (
+	AuditSuccess = "success"
+	AuditFailure = "failure"
+)
+
+// Limits of the audit log.
+const (
+	// MaxAuditBody is the size of the request and response bodies kept for an audit entry, larger bodies are cut.
+	MaxAuditBody = 64 << 10
+	// maxAuditError is the length of the error message kept for a failed request.
+	maxAuditError = 1024
+)
+
+// redacted replaces the values of sensitive fields in the request bodies kept by the audit log.
+const redacted = "[REDACTED]"
+
+// sensitiveFields are the parts of field names whose values are never written to the audit log.
+var sensitiveFields = []string{"password", "secret", "token", "key", "credential", "passphrase", "authorization"}
+
+// resourceIDFields are the fields of request and response bodies which identify the resources a request acts on.
+var resourceIDFields = []string{"id", "uuid", "name", "publicIP", "ip"}
+
+// AuditFilter selects audit entries. Empty fields match every entry.
+type AuditFilter struct {
+	Actor    string
+	Method   string
+	Route    string
+	Project  string
+	Outcome  string
+	ServerID int
+	Since    time.Time
+	Until    time.Time
+}
+
+// AuditService is a service that records every mutating API request in an append-only collection. Entries are never updated nor deleted through vdash.
+type AuditService struct {
+	databaseService *DatabaseService
+}
+
+func NewAuditService(databaseService *DatabaseService) *AuditService {
+	return &AuditService{databaseService: databaseService}
+}
+
+// Record appends an entry to the audit log. The error message of a failed request is cut to a reasonable length.
+func (a *AuditService) Record(ctx context.Context, entry entity.AuditEntry) error {
+	if len(entry.Error) > maxAuditError {
+		entry.Error = entry.Error[:maxAuditError]
+	}
+
+	return a.databaseService.AddAuditEntry(ctx, entry)
+}
+
+// GetEntries returns the latest entries matching the filter, most recent first.
+func (a *AuditService) GetEntries(ctx context.Context, filter AuditFilter, limit int64) ([]entity.AuditEntry, error) {
+	return a.databaseService.GetAuditEntries(ctx, filter, limit)
+}
+
+// Export calls fn with every entry matching the filter, oldest first, without loading them all in memory.
+func (a *AuditService) Export(ctx context.Context, filter AuditFilter, fn func(entity.AuditEntry) error) error {
+	return a.databaseService.ForEachAuditEntry(ctx, filter, fn)
+}
+
+// SanitizeBody returns a JSON request body with the values of sensitive fields redacted, ready to be kept in the audit log. Bodies which are not JSON are only described by their size.
+func SanitizeBody(body []byte, truncated bool) string {
+	if len(body) == 0 {
+		return ""
+	}
+
+	var value any
+	if truncated || json.Unmarshal(body, &value) != nil {
+		return fmt.Sprintf("<%d bytes not recorded>", len(body))
+	}
+
+	sanitized, err := json.Marshal(redact(value))
+	if err != nil {
+		return fmt.Sprintf("<%d bytes not recorded>", len(body))
+	}
+
+	return string(sanitized)
+}
+
+// redact replaces the values of sensitive fields found at any depth of a decoded JSON value.
+func redact(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		for field, child := range v {
+			if isSensitive(field) {
+				v[field] = redacted
+				continue
+			}
+			v[field] = redact(child)
+		}
+	case []any:
+		for i, child := range v {
+			v[i] = redact(child)
+		}
+	}
+
+	return value
+}
+
+func isSensitive(field string) bool {
+	field = strings.ToLower(field)
+	for _, sensitive := range sensitiveFields {
+		if strings.Contains(field, sensitive) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ResourceIDs returns the identifiers found in the top level fields of a JSON object, such as the name of a created domain or the id of a created API key.
+func ResourceIDs(body []byte) []string {
+	var fields map[string]any
+	if json.Unmarshal(body, &fields) != nil {
+		return nil
+	}
+
+	var ids []string
+	for _, field := range resourceIDFields {
+		switch v := fields[field].(type) {
+		case string:
+			if v != "" {
+				ids = append(ids, field+":"+v)
+			}
+		case float64:
+			ids = append(ids, fmt.Sprintf("%s:%d", field, int64(v)))
+		}
+	}
+
+	return ids
+}
+
+// ServerIDOf returns the server named by the serverID field of a JSON object, or by its id field if idIsServer is true, and 0 if there is none.
+func ServerIDOf(body []byte, idIsServer bool) int {
+	var fields map[string]any
+	if json.Unmarshal(body, &fields) != nil {
+		return 0
+	}
+
+	if id, ok := fields["serverID"].(float64); ok {
+		return int(id)
+	}
+
+	if id, ok := fields["id"].(float64); ok && idIsServer {
+		return int(id)
+	}
+
+	return 0
+}
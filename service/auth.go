@@ -0,0 +1,250 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/sychonet/vdash-be/db/entity"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Methods used by callers to authenticate.
+const (
+	AuthAPIKey = "apikey"
+	AuthJWT    = "jwt"
+)
+
+// APIKeyPrefix starts every API key so that keys can be told apart from JWT bearer tokens.
+const APIKeyPrefix = "vdk_"
+
+// APIKeySubjectPrefix starts the subject of the callers authenticated with an API key, which is followed by the ID of the key. Tokens claiming such a subject are rejected so that they never get the role bindings of a key.
+const APIKeySubjectPrefix = "apikey:"
+
+// apiKeyLastUsedInterval is how often the last use of an API key is recorded, so that busy keys do not cause a write on every request.
+const apiKeyLastUsedInterval = time.Minute
+
+// ErrUnauthenticated is returned when the credentials of a request are missing or invalid.
+var ErrUnauthenticated = errors.New("invalid credentials")
+
+// Identity represents the caller of an API request. KeyID is set for callers authenticated with an API key. Roles are the roles granted by the API key or token in every project.
+type Identity struct {
+	Subject string
+	Method  string
+	KeyID   string
+	Roles   []string
+}
+
+type identityKey struct{}
+
+// WithIdentity returns a copy of the context carrying the identity of the caller.
+func WithIdentity(ctx context.Context, identity *Identity) context.Context {
+	return context.WithValue(ctx, identityKey{}, identity)
+}
+
+// IdentityFromContext returns the identity of the caller carried by the context.
+func IdentityFromContext(ctx context.Context) (*Identity, bool) {
+	identity, ok := ctx.Value(identityKey{}).(*Identity)
+	return identity, ok
+}
+
+// Actor returns the subject of the caller carried by the context, or an empty string if the request is not authenticated.
+func Actor(ctx context.Context) string {
+	if identity, ok := IdentityFromContext(ctx); ok {
+		return identity.Subject
+	}
+
+	return ""
+}
+
+// AuthService is a service that authenticates API requests with API keys stored in the database or with JWT bearer tokens signed with a shared secret or with a key published at a JWKS endpoint.
+type AuthService struct {
+	databaseService *DatabaseService
+	secret          []byte
+	jwks            *jwksCache
+	issuer          string
+	audience        string
+	rolesClaim      string
+}
+
+func NewAuthService(databaseService *DatabaseService, secret, jwksURL, issuer, audience, rolesClaim string) *AuthService {
+	a := &AuthService{
+		databaseService: databaseService,
+		issuer:          issuer,
+		audience:        audience,
+		rolesClaim:      rolesClaim,
+	}
+
+	if secret != "" {
+		a.secret = []byte(secret)
+	}
+
+	if jwksURL != "" {
+		a.jwks = newJWKSCache(jwksURL)
+	}
+
+	return a
+}
+
+// Authenticate returns the identity of the caller presenting the credential, which is either an API key or a JWT bearer token.
+func (a *AuthService) Authenticate(ctx context.Context, credential string) (*Identity, error) {
+	if credential == "" {
+		return nil, ErrUnauthenticated
+	}
+
+	if strings.HasPrefix(credential, APIKeyPrefix) {
+		return a.authenticateAPIKey(ctx, credential)
+	}
+
+	return a.authenticateToken(ctx, credential)
+}
+
+// authenticateAPIKey looks up the API key by its hash and rejects it if it has been revoked or has expired.
+func (a *AuthService) authenticateAPIKey(ctx context.Context, credential string) (*Identity, error) {
+	key, err := a.databaseService.GetAPIKeyByHash(ctx, hashAPIKey(credential))
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrUnauthenticated
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	if !key.RevokedAt.IsZero() {
+		return nil, fmt.Errorf("%w: API key has been revoked", ErrUnauthenticated)
+	}
+
+	if !key.ExpiresAt.IsZero() && now.After(key.ExpiresAt) {
+		return nil, fmt.Errorf("%w: API key has expired", ErrUnauthenticated)
+	}
+
+	if now.Sub(key.LastUsedAt) > apiKeyLastUsedInterval {
+		// A failure to record the last use does not reject the request, the error is logged by the database service
+		_ = a.databaseService.UpdateAPIKeyLastUsed(ctx, key.ID, now)
+	}
+
+	return &Identity{Subject: APIKeySubjectPrefix + key.ID.Hex(), Method: AuthAPIKey, KeyID: key.ID.Hex(), Roles: key.Roles}, nil
+}
+
+// authenticateToken verifies the signature, expiry, issuer and audience of a JWT bearer token. Tokens signed with HMAC are verified with the shared secret and the other ones with the JWKS endpoint.
+func (a *AuthService) authenticateToken(ctx context.Context, credential string) (*Identity, error) {
+	if a.secret == nil && a.jwks == nil {
+		return nil, fmt.Errorf("%w: bearer tokens are not accepted", ErrUnauthenticated)
+	}
+
+	options := []jwt.ParserOption{jwt.WithExpirationRequired()}
+	if a.issuer != "" {
+		options = append(options, jwt.WithIssuer(a.issuer))
+	}
+
+	if a.audience != "" {
+		options = append(options, jwt.WithAudience(a.audience))
+	}
+
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(credential, claims, func(token *jwt.Token) (any, error) {
+		switch token.Method.(type) {
+		case *jwt.SigningMethodHMAC:
+			if a.secret == nil {
+				return nil, errors.New("HMAC signed tokens are not accepted")
+			}
+			return a.secret, nil
+		case *jwt.SigningMethodRSA, *jwt.SigningMethodRSAPSS, *jwt.SigningMethodECDSA, *jwt.SigningMethodEd25519:
+			if a.jwks == nil {
+				return nil, errors.New("tokens signed with a public key are not accepted")
+			}
+			kid, _ := token.Header["kid"].(string)
+			return a.jwks.key(ctx, kid)
+		default:
+			return nil, fmt.Errorf("unexpected signing method %s", token.Method.Alg())
+		}
+	}, options...)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnauthenticated, err)
+	}
+
+	subject, err := claims.GetSubject()
+	if err != nil || subject == "" {
+		return nil, fmt.Errorf("%w: token has no subject", ErrUnauthenticated)
+	}
+
+	if strings.HasPrefix(subject, APIKeySubjectPrefix) {
+		return nil, fmt.Errorf("%w: token subject is reserved for API keys", ErrUnauthenticated)
+	}
+
+	return &Identity{Subject: subject, Method: AuthJWT, Roles: a.tokenRoles(claims)}, nil
+}
+
+// tokenRoles returns the roles listed in the roles claim of a token, given either as an array or as a space separated string.
+func (a *AuthService) tokenRoles(claims jwt.MapClaims) []string {
+	if a.rolesClaim == "" {
+		return nil
+	}
+
+	switch value := claims[a.rolesClaim].(type) {
+	case string:
+		return strings.Fields(value)
+	case []any:
+		var roles []string
+		for _, role := range value {
+			if name, ok := role.(string); ok {
+				roles = append(roles, name)
+			}
+		}
+		return roles
+	default:
+		return nil
+	}
+}
+
+// CreateAPIKey generates a new API key granted the roles in every project and stores its hash. The key itself is only returned here and cannot be retrieved later. A zero expiresAt creates a key which does not expire.
+func (a *AuthService) CreateAPIKey(ctx context.Context, name string, roles []string, expiresAt time.Time, createdBy string) (string, *entity.APIKey, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", nil, err
+	}
+	credential := APIKeyPrefix + base64.RawURLEncoding.EncodeToString(secret)
+
+	key := entity.APIKey{
+		Name:      name,
+		Prefix:    credential[:len(APIKeyPrefix)+8],
+		Hash:      hashAPIKey(credential),
+		Roles:     roles,
+		CreatedBy: createdBy,
+		CreatedAt: time.Now().UTC(),
+		ExpiresAt: expiresAt,
+	}
+
+	id, err := a.databaseService.AddAPIKey(ctx, key)
+	if err != nil {
+		return "", nil, err
+	}
+	key.ID = id
+
+	return credential, &key, nil
+}
+
+// GetAPIKeys returns every API key including the revoked ones.
+func (a *AuthService) GetAPIKeys(ctx context.Context) ([]entity.APIKey, error) {
+	return a.databaseService.GetAPIKeys(ctx)
+}
+
+// RevokeAPIKey revokes an API key, requests presenting it are rejected from now on.
+func (a *AuthService) RevokeAPIKey(ctx context.Context, id primitive.ObjectID) error {
+	return a.databaseService.RevokeAPIKey(ctx, id, time.Now().UTC())
+}
+
+// hashAPIKey returns the hash under which an API key is stored. API keys are random, so a plain SHA-256 is enough to keep them from being recovered from the database.
+func hashAPIKey(credential string) string {
+	sum := sha256.Sum256([]byte(credential))
+	return hex.EncodeToString(sum[:])
+}
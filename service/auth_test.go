@@ -0,0 +1,54 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func TestAuthenticateToken(t *testing.T) {
+	auth := NewAuthService(nil, "secret", "", "", "", "roles")
+
+	tests := []struct {
+		name    string
+		subject string
+		wantErr bool
+	}{
+		{"user", "alice", false},
+		{"subject of an API key", APIKeySubjectPrefix + "0123456789abcdef01234567", true},
+		{"no subject", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims := jwt.MapClaims{"exp": time.Now().Add(time.Hour).Unix(), "roles": "viewer"}
+			if tt.subject != "" {
+				claims["sub"] = tt.subject
+			}
+
+			token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte("secret"))
+			if err != nil {
+				t.Fatalf("SignedString() error = %v", err)
+			}
+
+			identity, err := auth.Authenticate(context.Background(), token)
+			if tt.wantErr {
+				if !errors.Is(err, ErrUnauthenticated) {
+					t.Errorf("Authenticate() error = %v, want ErrUnauthenticated", err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Authenticate() error = %v", err)
+			}
+
+			if identity.Subject != tt.subject || identity.Method != AuthJWT || len(identity.Roles) != 1 || identity.Roles[0] != "viewer" {
+				t.Errorf("Authenticate() = %+v, want the subject and roles of the token", identity)
+			}
+		})
+	}
+}
@@ -0,0 +1,265 @@
+package service
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sychonet/vdash-be/db/entity"
+)
+
+// domainArch is the guest architecture of the domains created by vdash.
+const domainArch = "x86_64"
+
+// capabilitiesXML is the subset of the libvirt host capabilities document used by vdash.
+type capabilitiesXML struct {
+	Host struct {
+		CPU struct {
+			Arch   string `xml:"arch"`
+			Model  string `xml:"model"`
+			Vendor string `xml:"vendor"`
+		} `xml:"cpu"`
+		Cells []struct {
+			ID     int `xml:"id,attr"`
+			Memory struct {
+				Unit  string `xml:"unit,attr"`
+				Value uint64 `xml:",chardata"`
+			} `xml:"memory"`
+			CPUs []struct {
+				ID int `xml:"id,attr"`
+			} `xml:"cpus>cpu"`
+		} `xml:"topology>cells>cell"`
+	} `xml:"host"`
+	Guests []struct {
+		OSType string `xml:"os_type"`
+		Arch   struct {
+			Name     string `xml:"name,attr"`
+			Machines []struct {
+				Canonical string `xml:"canonical,attr"`
+				Name      string `xml:",chardata"`
+			} `xml:"machine"`
+			Domains []struct {
+				Type string `xml:"type,attr"`
+			} `xml:"domain"`
+		} `xml:"arch"`
+	} `xml:"guest"`
+}
+
+// domainCapabilitiesXML is the subset of the libvirt domain capabilities document used by vdash.
+type domainCapabilitiesXML struct {
+	Machine string `xml:"machine"`
+	CPU     struct {
+		Modes []struct {
+			Name      string `xml:"name,attr"`
+			Supported string `xml:"supported,attr"`
+			Models    []struct {
+				Usable string `xml:"usable,attr"`
+				Name   string `xml:",chardata"`
+			} `xml:"model"`
+		} `xml:"mode"`
+	} `xml:"cpu"`
+}
+
+// DiscoverCapabilities connects to the libvirt host with the given URI and reads its hardware, NUMA topology, versions and the machine types and CPU models available to KVM guests.
+func (l *LibvirtService) DiscoverCapabilities(ctx context.Context, libvirtURI string) (*entity.NodeCapabilities, error) {
+	// Connect to libvirtd
+	conn, err := connect(ctx, libvirtURI)
+	if err != nil {
+		slog.Error("Failed to connect to libvirt: " + err.Error())
+		return nil, err
+	}
+	defer conn.Close()
+
+	nodeInfo, err := conn.GetNodeInfo()
+	if err != nil {
+		slog.Error("Failed to get node info: " + err.Error())
+		return nil, err
+	}
+
+	libVersion, err := conn.GetLibVersion()
+	if err != nil {
+		slog.Error("Failed to get libvirt version: " + err.Error())
+		return nil, err
+	}
+
+	hypervisorVersion, err := conn.GetVersion()
+	if err != nil {
+		slog.Error("Failed to get hypervisor version: " + err.Error())
+		return nil, err
+	}
+
+	capabilities := &entity.NodeCapabilities{
+		Arch:              nodeInfo.Model,
+		CPUs:              nodeInfo.Cpus,
+		Sockets:           nodeInfo.Sockets,
+		Cores:             nodeInfo.Cores,
+		Threads:           nodeInfo.Threads,
+		Memory:            nodeInfo.Memory,
+		LibvirtVersion:    formatVersion(libVersion),
+		HypervisorVersion: formatVersion(hypervisorVersion),
+		DiscoveredAt:      time.Now().UTC(),
+	}
+
+	// Read the host CPU, the NUMA topology and the guest machine types
+	capsXML, err := conn.GetCapabilities()
+	if err != nil {
+		slog.Error("Failed to get capabilities: " + err.Error())
+		return nil, err
+	}
+
+	var caps capabilitiesXML
+	if err := xml.Unmarshal([]byte(capsXML), &caps); err != nil {
+		return nil, fmt.Errorf("failed to parse capabilities: %w", err)
+	}
+
+	capabilities.CPUVendor = caps.Host.CPU.Vendor
+	capabilities.CPUModel = caps.Host.CPU.Model
+	if caps.Host.CPU.Arch != "" {
+		capabilities.Arch = caps.Host.CPU.Arch
+	}
+
+	for _, cell := range caps.Host.Cells {
+		numaCell := entity.NUMACell{ID: cell.ID, Memory: cell.Memory.Value}
+		for _, cpu := range cell.CPUs {
+			numaCell.CPUs = append(numaCell.CPUs, cpu.ID)
+		}
+		capabilities.NUMACells = append(capabilities.NUMACells, numaCell)
+	}
+
+	for _, guest := range caps.Guests {
+		if guest.OSType != "hvm" || guest.Arch.Name != domainArch {
+			continue
+		}
+
+		for _, domain := range guest.Arch.Domains {
+			if domain.Type == "kvm" {
+				capabilities.KVM = true
+			}
+		}
+
+		for _, machine := range guest.Arch.Machines {
+			if !slices.Contains(capabilities.MachineTypes, machine.Name) {
+				capabilities.MachineTypes = append(capabilities.MachineTypes, machine.Name)
+			}
+		}
+	}
+
+	if !capabilities.KVM {
+		return capabilities, nil
+	}
+
+	// Read the default machine type and the CPU models usable by KVM guests
+	domainCapsXML, err := conn.GetDomainCapabilities("", domainArch, "", "kvm", 0)
+	if err != nil {
+		slog.Error("Failed to get domain capabilities: " + err.Error())
+		return nil, err
+	}
+
+	var domainCaps domainCapabilitiesXML
+	if err := xml.Unmarshal([]byte(domainCapsXML), &domainCaps); err != nil {
+		return nil, fmt.Errorf("failed to parse domain capabilities: %w", err)
+	}
+
+	capabilities.DefaultMachine = domainCaps.Machine
+	for _, mode := range domainCaps.CPU.Modes {
+		if mode.Supported != "yes" {
+			continue
+		}
+
+		switch mode.Name {
+		case "host-model":
+			capabilities.HostModel = true
+		case "host-passthrough":
+			capabilities.HostPassthrough = true
+		case "custom":
+			for _, model := range mode.Models {
+				if model.Usable == "yes" {
+					capabilities.CPUModels = append(capabilities.CPUModels, model.Name)
+				}
+			}
+		}
+	}
+
+	return capabilities, nil
+}
+
+// ValidateCapabilities returns the reasons why a server with the given capabilities cannot host vdash domains. The minimum versions are ignored when empty.
+func ValidateCapabilities(capabilities *entity.NodeCapabilities, minLibvirtVersion, minHypervisorVersion string) ([]string, error) {
+	var problems []string
+
+	if capabilities.Arch != domainArch {
+		problems = append(problems, fmt.Sprintf("architecture %s is not supported, %s is required", capabilities.Arch, domainArch))
+	}
+
+	if !capabilities.KVM {
+		problems = append(problems, "KVM is not available for "+domainArch+" guests")
+	}
+
+	checks := []struct {
+		name     string
+		version  string
+		minimum  string
+		required bool
+	}{
+		{"libvirt", capabilities.LibvirtVersion, minLibvirtVersion, minLibvirtVersion != ""},
+		{"hypervisor", capabilities.HypervisorVersion, minHypervisorVersion, minHypervisorVersion != ""},
+	}
+
+	for _, check := range checks {
+		if !check.required {
+			continue
+		}
+
+		older, err := versionOlder(check.version, check.minimum)
+		if err != nil {
+			return nil, err
+		}
+
+		if older {
+			problems = append(problems, fmt.Sprintf("%s version %s is older than the required %s", check.name, check.version, check.minimum))
+		}
+	}
+
+	return problems, nil
+}
+
+// versionOlder reports whether the dotted version is older than the minimum one.
+func versionOlder(version, minimum string) (bool, error) {
+	v, err := parseVersion(version)
+	if err != nil {
+		return false, err
+	}
+
+	m, err := parseVersion(minimum)
+	if err != nil {
+		return false, err
+	}
+
+	return v < m, nil
+}
+
+// parseVersion converts a dotted version into the number encoding used by libvirt.
+func parseVersion(version string) (uint32, error) {
+	parts := strings.Split(version, ".")
+	if len(parts) > 3 {
+		return 0, fmt.Errorf("invalid version %q", version)
+	}
+
+	var encoded uint32
+	multiplier := uint32(1000000)
+	for _, part := range parts {
+		n, err := strconv.ParseUint(part, 10, 32)
+		if err != nil || n >= 1000 {
+			return 0, fmt.Errorf("invalid version %q", version)
+		}
+		encoded += uint32(n) * multiplier
+		multiplier /= 1000
+	}
+
+	return encoded, nil
+}
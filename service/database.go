@@ -2,40 +2,80 @@ package service
 
 import (
 	"context"
+	"errors"
 	"log/slog"
+	"time"
 
 	"github.com/sychonet/vdash-be/db"
 	"github.com/sychonet/vdash-be/db/entity"
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type DatabaseService struct {
-	Host              string
-	Port              string
-	Username          string
-	Password          string
-	Name              string
-	ServersCollection string
-	IPsCollection     string
+	URI                   string
+	Name                  string
+	ServersCollection     string
+	IPsCollection         string
+	GroupsCollection      string
+	DomainsCollection     string
+	DrainsCollection      string
+	EventsCollection      string
+	PowerCollection       string
+	MigrationsCollection  string
+	KeysCollection        string
+	RolesCollection       string
+	BindingsCollection    string
+	ProjectsCollection    string
+	VolumesCollection     string
+	NetworksCollection    string
+	AuditCollection       string
+	IdempotencyCollection string
 }
 
-func NewDatabaseService(host, port, username, password, name, serversCollection, ipsCollection string) *DatabaseService {
+func NewDatabaseService(uri, name, serversCollection, ipsCollection, groupsCollection, domainsCollection, drainsCollection, eventsCollection, powerCollection, migrationsCollection, keysCollection, rolesCollection, bindingsCollection, projectsCollection, volumesCollection, networksCollection, auditCollection, idempotencyCollection string) *DatabaseService {
 	return &DatabaseService{
-		Host:              host,
-		Port:              port,
-		Username:          username,
-		Password:          password,
-		Name:              name,
-		ServersCollection: serversCollection,
-		IPsCollection:     ipsCollection,
+		URI:                   uri,
+		Name:                  name,
+		ServersCollection:     serversCollection,
+		IPsCollection:         ipsCollection,
+		GroupsCollection:      groupsCollection,
+		DomainsCollection:     domainsCollection,
+		DrainsCollection:      drainsCollection,
+		EventsCollection:      eventsCollection,
+		PowerCollection:       powerCollection,
+		MigrationsCollection:  migrationsCollection,
+		KeysCollection:        keysCollection,
+		RolesCollection:       rolesCollection,
+		BindingsCollection:    bindingsCollection,
+		ProjectsCollection:    projectsCollection,
+		VolumesCollection:     volumesCollection,
+		NetworksCollection:    networksCollection,
+		AuditCollection:       auditCollection,
+		IdempotencyCollection: idempotencyCollection,
 	}
 }
 
 func (d *DatabaseService) GetURI() string {
-	return "mongodb://" + d.Username + ":" + d.Password + "@" + d.Host + ":" + d.Port
+	return d.URI
 }
 
-func (d *DatabaseService) AddServer(server entity.ServerInfo) error {
+// Ping checks that the database can be reached.
+func (d *DatabaseService) Ping(ctx context.Context) error {
+	// Connect to the database
+	client := db.GetClient(d.GetURI())
+	defer func() {
+		if err := client.Disconnect(context.Background()); err != nil {
+			slog.Error(err.Error())
+		}
+	}()
+
+	return client.Ping(ctx, nil)
+}
+
+func (d *DatabaseService) AddServer(ctx context.Context, server entity.ServerInfo) error {
 	// Connect to the database
 	client := db.GetClient(d.GetURI())
 	defer func() {
@@ -48,7 +88,7 @@ func (d *DatabaseService) AddServer(server entity.ServerInfo) error {
 	collection := client.Database(d.Name).Collection(d.ServersCollection)
 
 	// Insert the server information in database
-	_, err := collection.InsertOne(context.Background(), server)
+	_, err := collection.InsertOne(ctx, server)
 	if err != nil {
 		slog.Error(err.Error())
 	}
@@ -56,7 +96,7 @@ func (d *DatabaseService) AddServer(server entity.ServerInfo) error {
 	return err
 }
 
-func (d *DatabaseService) AddServers(servers []interface{}) error {
+func (d *DatabaseService) AddServers(ctx context.Context, servers []interface{}) error {
 	// Connect to the database
 	client := db.GetClient(d.GetURI())
 	defer func() {
@@ -69,7 +109,7 @@ func (d *DatabaseService) AddServers(servers []interface{}) error {
 	collection := client.Database(d.Name).Collection(d.ServersCollection)
 
 	// Insert the server information in database
-	_, err := collection.InsertMany(context.Background(), servers)
+	_, err := collection.InsertMany(ctx, servers)
 	if err != nil {
 		slog.Error(err.Error())
 	}
@@ -77,7 +117,7 @@ func (d *DatabaseService) AddServers(servers []interface{}) error {
 	return err
 }
 
-func (d *DatabaseService) CountServers() (int64, error) {
+func (d *DatabaseService) CountServers(ctx context.Context) (int64, error) {
 	// Connect to the database
 	client := db.GetClient(d.GetURI())
 	defer func() {
@@ -90,7 +130,7 @@ func (d *DatabaseService) CountServers() (int64, error) {
 	collection := client.Database(d.Name).Collection(d.ServersCollection)
 
 	// Count number of servers in database
-	count, err := collection.CountDocuments(context.Background(), bson.D{})
+	count, err := collection.CountDocuments(ctx, bson.D{})
 	if err != nil {
 		slog.Error(err.Error())
 	}
@@ -98,7 +138,7 @@ func (d *DatabaseService) CountServers() (int64, error) {
 	return count, err
 }
 
-func (d *DatabaseService) GetServers() ([]entity.ServerInfo, error) {
+func (d *DatabaseService) GetServers(ctx context.Context) ([]entity.ServerInfo, error) {
 	// Connect to the database
 	client := db.GetClient(d.GetURI())
 	defer func() {
@@ -111,16 +151,16 @@ func (d *DatabaseService) GetServers() ([]entity.ServerInfo, error) {
 	collection := client.Database(d.Name).Collection(d.ServersCollection)
 
 	// Get all servers from the database
-	cursor, err := collection.Find(context.Background(), nil)
+	cursor, err := collection.Find(ctx, bson.D{})
 	if err != nil {
 		slog.Error(err.Error())
 		return nil, err
 	}
-	defer cursor.Close(context.Background())
+	defer cursor.Close(ctx)
 
 	// Decode the servers
 	var servers []entity.ServerInfo
-	if err := cursor.All(context.Background(), &servers); err != nil {
+	if err := cursor.All(ctx, &servers); err != nil {
 		slog.Error(err.Error())
 		return nil, err
 	}
@@ -128,7 +168,7 @@ func (d *DatabaseService) GetServers() ([]entity.ServerInfo, error) {
 	return servers, nil
 }
 
-func (d *DatabaseService) GetServer(id int) (*entity.ServerInfo, error) {
+func (d *DatabaseService) GetServer(ctx context.Context, id int) (*entity.ServerInfo, error) {
 	// Connect to the database
 	client := db.GetClient(d.GetURI())
 	defer func() {
@@ -142,7 +182,7 @@ func (d *DatabaseService) GetServer(id int) (*entity.ServerInfo, error) {
 
 	// Get the server from the database
 	var server entity.ServerInfo
-	if err := collection.FindOne(context.Background(), entity.ServerInfo{ID: id}).Decode(&server); err != nil {
+	if err := collection.FindOne(ctx, bson.D{{Key: "_id", Value: id}}).Decode(&server); err != nil {
 		slog.Error(err.Error())
 		return nil, err
 	}
@@ -150,7 +190,7 @@ func (d *DatabaseService) GetServer(id int) (*entity.ServerInfo, error) {
 	return &server, nil
 }
 
-func (d *DatabaseService) DeleteServer(id int) error {
+func (d *DatabaseService) DeleteServer(ctx context.Context, id int) error {
 	// Connect to the database
 	client := db.GetClient(d.GetURI())
 	defer func() {
@@ -163,7 +203,7 @@ func (d *DatabaseService) DeleteServer(id int) error {
 	collection := client.Database(d.Name).Collection(d.ServersCollection)
 
 	// Delete the server from the database
-	_, err := collection.DeleteOne(context.Background(), entity.ServerInfo{ID: id})
+	_, err := collection.DeleteOne(ctx, bson.D{{Key: "_id", Value: id}})
 	if err != nil {
 		slog.Error(err.Error())
 	}
@@ -171,7 +211,33 @@ func (d *DatabaseService) DeleteServer(id int) error {
 	return err
 }
 
-func (d *DatabaseService) AddIP(ip entity.IPInfo) error {
+func (d *DatabaseService) UpdateServerLabels(ctx context.Context, id int, labels map[string]string) error {
+	// Connect to the database
+	client := db.GetClient(d.GetURI())
+	defer func() {
+		if err := client.Disconnect(context.Background()); err != nil {
+			slog.Error(err.Error())
+		}
+	}()
+
+	// Get the collection
+	collection := client.Database(d.Name).Collection(d.ServersCollection)
+
+	// Replace the labels of the server
+	result, err := collection.UpdateOne(ctx, bson.D{{Key: "_id", Value: id}}, bson.D{{Key: "$set", Value: bson.D{{Key: "labels", Value: labels}}}})
+	if err != nil {
+		slog.Error(err.Error())
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+
+	return nil
+}
+
+func (d *DatabaseService) AddIP(ctx context.Context, ip entity.IPInfo) error {
 	// Connect to the database
 	client := db.GetClient(d.GetURI())
 	defer func() {
@@ -184,7 +250,7 @@ func (d *DatabaseService) AddIP(ip entity.IPInfo) error {
 	collection := client.Database(d.Name).Collection(d.IPsCollection)
 
 	// Insert the IP information in database
-	_, err := collection.InsertOne(context.Background(), ip)
+	_, err := collection.InsertOne(ctx, ip)
 	if err != nil {
 		slog.Error(err.Error())
 	}
@@ -192,7 +258,7 @@ func (d *DatabaseService) AddIP(ip entity.IPInfo) error {
 	return err
 }
 
-func (d *DatabaseService) GetAvailablePublicIPs() ([]entity.IPInfo, error) {
+func (d *DatabaseService) GetAvailablePublicIPs(ctx context.Context) ([]entity.IPInfo, error) {
 	// Connect to the database
 	client := db.GetClient(d.GetURI())
 	defer func() {
@@ -205,16 +271,16 @@ func (d *DatabaseService) GetAvailablePublicIPs() ([]entity.IPInfo, error) {
 	collection := client.Database(d.Name).Collection(d.IPsCollection)
 
 	// Get all available public IPs from the database
-	cursor, err := collection.Find(context.Background(), bson.D{{Key: "available", Value: true}})
+	cursor, err := collection.Find(ctx, bson.D{{Key: "available", Value: true}})
 	if err != nil {
 		slog.Error(err.Error())
 		return nil, err
 	}
-	defer cursor.Close(context.Background())
+	defer cursor.Close(ctx)
 
 	// Decode the IPs
 	var ips []entity.IPInfo
-	if err := cursor.All(context.Background(), &ips); err != nil {
+	if err := cursor.All(ctx, &ips); err != nil {
 		slog.Error(err.Error())
 		return nil, err
 	}
@@ -222,7 +288,7 @@ func (d *DatabaseService) GetAvailablePublicIPs() ([]entity.IPInfo, error) {
 	return ips, nil
 }
 
-func (d *DatabaseService) DeletePublicIP(ip string) error {
+func (d *DatabaseService) DeletePublicIP(ctx context.Context, ip string) error {
 	// Connect to the database
 	client := db.GetClient(d.GetURI())
 	defer func() {
@@ -235,7 +301,7 @@ func (d *DatabaseService) DeletePublicIP(ip string) error {
 	collection := client.Database(d.Name).Collection(d.IPsCollection)
 
 	// Delete the IP from the database
-	_, err := collection.DeleteOne(context.Background(), entity.IPInfo{IP: ip})
+	_, err := collection.DeleteOne(ctx, bson.D{{Key: "_id", Value: ip}})
 	if err != nil {
 		slog.Error(err.Error())
 	}
@@ -243,7 +309,8 @@ func (d *DatabaseService) DeletePublicIP(ip string) error {
 	return err
 }
 
-func (d *DatabaseService) UpdatePublicIP(ip string, serverID int, available bool) error {
+// UpdatePublicIP records the server and availability of a public IP along with the project it is allocated to, which is empty for available IPs.
+func (d *DatabaseService) UpdatePublicIP(ctx context.Context, ip string, serverID int, available bool, project string) error {
 	// Connect to the database
 	client := db.GetClient(d.GetURI())
 	defer func() {
@@ -256,7 +323,7 @@ func (d *DatabaseService) UpdatePublicIP(ip string, serverID int, available bool
 	collection := client.Database(d.Name).Collection(d.IPsCollection)
 
 	// Update the IP information in database
-	_, err := collection.UpdateOne(context.Background(), entity.IPInfo{IP: ip}, bson.D{{Key: "$set", Value: bson.D{{Key: "serverID", Value: serverID}, {Key: "available", Value: available}}}})
+	_, err := collection.UpdateOne(ctx, bson.D{{Key: "_id", Value: ip}}, bson.D{{Key: "$set", Value: bson.D{{Key: "serverID", Value: serverID}, {Key: "available", Value: available}, {Key: "project", Value: project}}}})
 	if err != nil {
 		slog.Error(err.Error())
 	}
@@ -264,7 +331,7 @@ func (d *DatabaseService) UpdatePublicIP(ip string, serverID int, available bool
 	return err
 }
 
-func (d *DatabaseService) CheckPublicIPAvailable(serverID int) (string, error) {
+func (d *DatabaseService) CheckPublicIPAvailable(ctx context.Context, serverID int) (string, error) {
 	// Connect to the database
 	client := db.GetClient(d.GetURI())
 	defer func() {
@@ -278,7 +345,7 @@ func (d *DatabaseService) CheckPublicIPAvailable(serverID int) (string, error) {
 
 	// Get the public IP associated with the server from the database
 	var ip entity.IPInfo
-	if err := collection.FindOne(context.Background(), entity.IPInfo{ServerID: serverID, Available: true}).Decode(&ip); err != nil {
+	if err := collection.FindOne(ctx, bson.D{{Key: "serverID", Value: serverID}, {Key: "available", Value: true}}).Decode(&ip); err != nil {
 		slog.Error(err.Error())
 		return "", err
 	}
@@ -286,7 +353,7 @@ func (d *DatabaseService) CheckPublicIPAvailable(serverID int) (string, error) {
 	return ip.IP, nil
 }
 
-func (d *DatabaseService) GetServersWithIDs(serverIDs []int) ([]entity.ServerInfo, error) {
+func (d *DatabaseService) GetServersWithIDs(ctx context.Context, serverIDs []int) ([]entity.ServerInfo, error) {
 	// Connect to the database
 	client := db.GetClient(d.GetURI())
 	defer func() {
@@ -299,19 +366,1725 @@ func (d *DatabaseService) GetServersWithIDs(serverIDs []int) ([]entity.ServerInf
 	collection := client.Database(d.Name).Collection(d.ServersCollection)
 
 	// Get the servers from the database
-	cursor, err := collection.Find(context.Background(), bson.D{{Key: "_id", Value: bson.D{{Key: "$in", Value: serverIDs}}}})
+	cursor, err := collection.Find(ctx, bson.D{{Key: "_id", Value: bson.D{{Key: "$in", Value: serverIDs}}}})
 	if err != nil {
 		slog.Error(err.Error())
 		return nil, err
 	}
-	defer cursor.Close(context.Background())
+	defer cursor.Close(ctx)
 
 	// Decode the servers
 	var servers []entity.ServerInfo
-	if err := cursor.All(context.Background(), &servers); err != nil {
+	if err := cursor.All(ctx, &servers); err != nil {
 		slog.Error(err.Error())
 		return nil, err
 	}
 
 	return servers, nil
 }
+
+func (d *DatabaseService) AddServerGroup(ctx context.Context, group entity.ServerGroup) error {
+	// Connect to the database
+	client := db.GetClient(d.GetURI())
+	defer func() {
+		if err := client.Disconnect(context.Background()); err != nil {
+			slog.Error(err.Error())
+		}
+	}()
+
+	// Get the collection
+	collection := client.Database(d.Name).Collection(d.GroupsCollection)
+
+	// Insert the server group in database
+	_, err := collection.InsertOne(ctx, group)
+	if err != nil {
+		slog.Error(err.Error())
+	}
+
+	return err
+}
+
+func (d *DatabaseService) GetServerGroups(ctx context.Context) ([]entity.ServerGroup, error) {
+	// Connect to the database
+	client := db.GetClient(d.GetURI())
+	defer func() {
+		if err := client.Disconnect(context.Background()); err != nil {
+			slog.Error(err.Error())
+		}
+	}()
+
+	// Get the collection
+	collection := client.Database(d.Name).Collection(d.GroupsCollection)
+
+	// Get all server groups from the database
+	cursor, err := collection.Find(ctx, bson.D{})
+	if err != nil {
+		slog.Error(err.Error())
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	// Decode the server groups
+	var groups []entity.ServerGroup
+	if err := cursor.All(ctx, &groups); err != nil {
+		slog.Error(err.Error())
+		return nil, err
+	}
+
+	return groups, nil
+}
+
+func (d *DatabaseService) GetServerGroup(ctx context.Context, name string) (*entity.ServerGroup, error) {
+	// Connect to the database
+	client := db.GetClient(d.GetURI())
+	defer func() {
+		if err := client.Disconnect(context.Background()); err != nil {
+			slog.Error(err.Error())
+		}
+	}()
+
+	// Get the collection
+	collection := client.Database(d.Name).Collection(d.GroupsCollection)
+
+	// Get the server group from the database
+	var group entity.ServerGroup
+	if err := collection.FindOne(ctx, bson.D{{Key: "_id", Value: name}}).Decode(&group); err != nil {
+		slog.Error(err.Error())
+		return nil, err
+	}
+
+	return &group, nil
+}
+
+func (d *DatabaseService) DeleteServerGroup(ctx context.Context, name string) error {
+	// Connect to the database
+	client := db.GetClient(d.GetURI())
+	defer func() {
+		if err := client.Disconnect(context.Background()); err != nil {
+			slog.Error(err.Error())
+		}
+	}()
+
+	// Get the collection
+	collection := client.Database(d.Name).Collection(d.GroupsCollection)
+
+	// Delete the server group from the database
+	_, err := collection.DeleteOne(ctx, bson.D{{Key: "_id", Value: name}})
+	if err != nil {
+		slog.Error(err.Error())
+	}
+
+	return err
+}
+
+func (d *DatabaseService) AddServerGroupMember(ctx context.Context, name string, member entity.ServerGroupMember) error {
+	// Connect to the database
+	client := db.GetClient(d.GetURI())
+	defer func() {
+		if err := client.Disconnect(context.Background()); err != nil {
+			slog.Error(err.Error())
+		}
+	}()
+
+	// Get the collection
+	collection := client.Database(d.Name).Collection(d.GroupsCollection)
+
+	// Add the member to the server group
+	_, err := collection.UpdateOne(ctx, bson.D{{Key: "_id", Value: name}}, bson.D{{Key: "$addToSet", Value: bson.D{{Key: "members", Value: member}}}})
+	if err != nil {
+		slog.Error(err.Error())
+	}
+
+	return err
+}
+
+func (d *DatabaseService) RemoveServerGroupMember(ctx context.Context, member entity.ServerGroupMember) error {
+	// Connect to the database
+	client := db.GetClient(d.GetURI())
+	defer func() {
+		if err := client.Disconnect(context.Background()); err != nil {
+			slog.Error(err.Error())
+		}
+	}()
+
+	// Get the collection
+	collection := client.Database(d.Name).Collection(d.GroupsCollection)
+
+	// Remove the member from every server group it belongs to
+	_, err := collection.UpdateMany(ctx, bson.D{{Key: "members", Value: member}}, bson.D{{Key: "$pull", Value: bson.D{{Key: "members", Value: member}}}})
+	if err != nil {
+		slog.Error(err.Error())
+	}
+
+	return err
+}
+
+func (d *DatabaseService) MoveServerGroupMember(ctx context.Context, member entity.ServerGroupMember, serverID int) error {
+	// Connect to the database
+	client := db.GetClient(d.GetURI())
+	defer func() {
+		if err := client.Disconnect(context.Background()); err != nil {
+			slog.Error(err.Error())
+		}
+	}()
+
+	// Get the collection
+	collection := client.Database(d.Name).Collection(d.GroupsCollection)
+
+	// Record the new server of the member in every server group it belongs to
+	_, err := collection.UpdateMany(ctx, bson.D{{Key: "members", Value: member}}, bson.D{{Key: "$set", Value: bson.D{{Key: "members.$.serverID", Value: serverID}}}})
+	if err != nil {
+		slog.Error(err.Error())
+	}
+
+	return err
+}
+
+func (d *DatabaseService) SetServerCordoned(ctx context.Context, id int, cordoned bool) error {
+	// Connect to the database
+	client := db.GetClient(d.GetURI())
+	defer func() {
+		if err := client.Disconnect(context.Background()); err != nil {
+			slog.Error(err.Error())
+		}
+	}()
+
+	// Get the collection
+	collection := client.Database(d.Name).Collection(d.ServersCollection)
+
+	// Mark the server as cordoned or schedulable
+	result, err := collection.UpdateOne(ctx, bson.D{{Key: "_id", Value: id}}, bson.D{{Key: "$set", Value: bson.D{{Key: "cordoned", Value: cordoned}}}})
+	if err != nil {
+		slog.Error(err.Error())
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+
+	return nil
+}
+
+func (d *DatabaseService) AddDomain(ctx context.Context, domain entity.DomainInfo) error {
+	// Connect to the database
+	client := db.GetClient(d.GetURI())
+	defer func() {
+		if err := client.Disconnect(context.Background()); err != nil {
+			slog.Error(err.Error())
+		}
+	}()
+
+	// Get the collection
+	collection := client.Database(d.Name).Collection(d.DomainsCollection)
+
+	// Insert the domain information in database
+	_, err := collection.InsertOne(ctx, domain)
+	if err != nil {
+		slog.Error(err.Error())
+	}
+
+	return err
+}
+
+func (d *DatabaseService) GetDomain(ctx context.Context, uuid string) (*entity.DomainInfo, error) {
+	// Connect to the database
+	client := db.GetClient(d.GetURI())
+	defer func() {
+		if err := client.Disconnect(context.Background()); err != nil {
+			slog.Error(err.Error())
+		}
+	}()
+
+	// Get the collection
+	collection := client.Database(d.Name).Collection(d.DomainsCollection)
+
+	// Get the domain from the database
+	var domain entity.DomainInfo
+	if err := collection.FindOne(ctx, bson.D{{Key: "_id", Value: uuid}}).Decode(&domain); err != nil {
+		slog.Error(err.Error())
+		return nil, err
+	}
+
+	return &domain, nil
+}
+
+// DeleteDomainByName deletes the record of the domain defined in libvirt with the given name on the server.
+func (d *DatabaseService) DeleteDomainByName(ctx context.Context, serverID int, libvirtName string) error {
+	// Connect to the database
+	client := db.GetClient(d.GetURI())
+	defer func() {
+		if err := client.Disconnect(context.Background()); err != nil {
+			slog.Error(err.Error())
+		}
+	}()
+
+	// Get the collection
+	collection := client.Database(d.Name).Collection(d.DomainsCollection)
+
+	// Delete the domain from the database
+	_, err := collection.DeleteOne(ctx, bson.D{{Key: "serverID", Value: serverID}, {Key: "libvirtName", Value: libvirtName}})
+	if err != nil {
+		slog.Error(err.Error())
+	}
+
+	return err
+}
+
+func (d *DatabaseService) UpdateDomainServer(ctx context.Context, uuid string, serverID int) error {
+	// Connect to the database
+	client := db.GetClient(d.GetURI())
+	defer func() {
+		if err := client.Disconnect(context.Background()); err != nil {
+			slog.Error(err.Error())
+		}
+	}()
+
+	// Get the collection
+	collection := client.Database(d.Name).Collection(d.DomainsCollection)
+
+	// Record the server the domain runs on
+	_, err := collection.UpdateOne(ctx, bson.D{{Key: "_id", Value: uuid}}, bson.D{{Key: "$set", Value: bson.D{{Key: "serverID", Value: serverID}}}})
+	if err != nil {
+		slog.Error(err.Error())
+	}
+
+	return err
+}
+
+func (d *DatabaseService) SaveDrain(ctx context.Context, drain entity.DrainInfo) error {
+	// Connect to the database
+	client := db.GetClient(d.GetURI())
+	defer func() {
+		if err := client.Disconnect(context.Background()); err != nil {
+			slog.Error(err.Error())
+		}
+	}()
+
+	// Get the collection
+	collection := client.Database(d.Name).Collection(d.DrainsCollection)
+
+	// Insert or replace the drain progress of the server
+	_, err := collection.ReplaceOne(ctx, bson.D{{Key: "_id", Value: drain.ServerID}}, drain, options.Replace().SetUpsert(true))
+	if err != nil {
+		slog.Error(err.Error())
+	}
+
+	return err
+}
+
+func (d *DatabaseService) GetDrain(ctx context.Context, serverID int) (*entity.DrainInfo, error) {
+	// Connect to the database
+	client := db.GetClient(d.GetURI())
+	defer func() {
+		if err := client.Disconnect(context.Background()); err != nil {
+			slog.Error(err.Error())
+		}
+	}()
+
+	// Get the collection
+	collection := client.Database(d.Name).Collection(d.DrainsCollection)
+
+	// Get the drain progress of the server from the database
+	var drain entity.DrainInfo
+	if err := collection.FindOne(ctx, bson.D{{Key: "_id", Value: serverID}}).Decode(&drain); err != nil {
+		slog.Error(err.Error())
+		return nil, err
+	}
+
+	return &drain, nil
+}
+
+func (d *DatabaseService) UpdateServerHealth(ctx context.Context, id int, health entity.ServerHealth) error {
+	// Connect to the database
+	client := db.GetClient(d.GetURI())
+	defer func() {
+		if err := client.Disconnect(context.Background()); err != nil {
+			slog.Error(err.Error())
+		}
+	}()
+
+	// Get the collection
+	collection := client.Database(d.Name).Collection(d.ServersCollection)
+
+	// Update the health of the server
+	_, err := collection.UpdateOne(ctx, bson.D{{Key: "_id", Value: id}}, bson.D{{Key: "$set", Value: bson.D{{Key: "health", Value: health}}}})
+	if err != nil {
+		slog.Error(err.Error())
+	}
+
+	return err
+}
+
+func (d *DatabaseService) AddServerEvent(ctx context.Context, event entity.ServerEvent) error {
+	// Connect to the database
+	client := db.GetClient(d.GetURI())
+	defer func() {
+		if err := client.Disconnect(context.Background()); err != nil {
+			slog.Error(err.Error())
+		}
+	}()
+
+	// Get the collection
+	collection := client.Database(d.Name).Collection(d.EventsCollection)
+
+	// Insert the server event in database
+	_, err := collection.InsertOne(ctx, event)
+	if err != nil {
+		slog.Error(err.Error())
+	}
+
+	return err
+}
+
+func (d *DatabaseService) GetServerEvents(ctx context.Context, serverID int, limit int64) ([]entity.ServerEvent, error) {
+	// Connect to the database
+	client := db.GetClient(d.GetURI())
+	defer func() {
+		if err := client.Disconnect(context.Background()); err != nil {
+			slog.Error(err.Error())
+		}
+	}()
+
+	// Get the collection
+	collection := client.Database(d.Name).Collection(d.EventsCollection)
+
+	// Get the latest events, of a single server if serverID is set
+	filter := bson.D{}
+	if serverID > 0 {
+		filter = bson.D{{Key: "serverID", Value: serverID}}
+	}
+
+	cursor, err := collection.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "time", Value: -1}}).SetLimit(limit))
+	if err != nil {
+		slog.Error(err.Error())
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	// Decode the events
+	var events []entity.ServerEvent
+	if err := cursor.All(ctx, &events); err != nil {
+		slog.Error(err.Error())
+		return nil, err
+	}
+
+	return events, nil
+}
+
+func (d *DatabaseService) UpdateServerCapabilities(ctx context.Context, id int, capabilities entity.NodeCapabilities) error {
+	// Connect to the database
+	client := db.GetClient(d.GetURI())
+	defer func() {
+		if err := client.Disconnect(context.Background()); err != nil {
+			slog.Error(err.Error())
+		}
+	}()
+
+	// Get the collection
+	collection := client.Database(d.Name).Collection(d.ServersCollection)
+
+	// Replace the capabilities of the server
+	_, err := collection.UpdateOne(ctx, bson.D{{Key: "_id", Value: id}}, bson.D{{Key: "$set", Value: bson.D{{Key: "capabilities", Value: capabilities}}}})
+	if err != nil {
+		slog.Error(err.Error())
+	}
+
+	return err
+}
+
+// UpdateServerConnection replaces the connection settings of a server along with the libvirt URI built from them.
+func (d *DatabaseService) UpdateServerConnection(ctx context.Context, id int, connection *entity.ConnectionConfig, libvirtURI string) error {
+	// Connect to the database
+	client := db.GetClient(d.GetURI())
+	defer func() {
+		if err := client.Disconnect(context.Background()); err != nil {
+			slog.Error(err.Error())
+		}
+	}()
+
+	// Get the collection
+	collection := client.Database(d.Name).Collection(d.ServersCollection)
+
+	update := bson.D{{Key: "$set", Value: bson.D{{Key: "connection", Value: connection}, {Key: "libvirtURI", Value: libvirtURI}}}}
+	if connection == nil {
+		update = bson.D{
+			{Key: "$set", Value: bson.D{{Key: "libvirtURI", Value: libvirtURI}}},
+			{Key: "$unset", Value: bson.D{{Key: "connection", Value: ""}}},
+		}
+	}
+
+	result, err := collection.UpdateOne(ctx, bson.D{{Key: "_id", Value: id}}, update)
+	if err != nil {
+		slog.Error(err.Error())
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+
+	return nil
+}
+
+// UpdateServerInventory replaces the fields of a server which are synchronized with the inventory of its provider.
+func (d *DatabaseService) UpdateServerInventory(ctx context.Context, server entity.ServerInfo) error {
+	// Connect to the database
+	client := db.GetClient(d.GetURI())
+	defer func() {
+		if err := client.Disconnect(context.Background()); err != nil {
+			slog.Error(err.Error())
+		}
+	}()
+
+	// Get the collection
+	collection := client.Database(d.Name).Collection(d.ServersCollection)
+
+	update := bson.D{
+		{Key: "hostname", Value: server.Hostname},
+		{Key: "publicIP", Value: server.PublicIP},
+		{Key: "libvirtURI", Value: server.LibvirtURI},
+		{Key: "provider", Value: server.Provider},
+		{Key: "missing", Value: server.Missing},
+		{Key: "missingSince", Value: server.MissingSince},
+	}
+
+	result, err := collection.UpdateOne(ctx, bson.D{{Key: "_id", Value: server.ID}}, bson.D{{Key: "$set", Value: update}})
+	if err != nil {
+		slog.Error(err.Error())
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+
+	return nil
+}
+
+func (d *DatabaseService) GetIPs(ctx context.Context) ([]entity.IPInfo, error) {
+	// Connect to the database
+	client := db.GetClient(d.GetURI())
+	defer func() {
+		if err := client.Disconnect(context.Background()); err != nil {
+			slog.Error(err.Error())
+		}
+	}()
+
+	// Get the collection
+	collection := client.Database(d.Name).Collection(d.IPsCollection)
+
+	// Get all public IPs from the database
+	cursor, err := collection.Find(ctx, bson.D{})
+	if err != nil {
+		slog.Error(err.Error())
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	// Decode the IPs
+	var ips []entity.IPInfo
+	if err := cursor.All(ctx, &ips); err != nil {
+		slog.Error(err.Error())
+		return nil, err
+	}
+
+	return ips, nil
+}
+
+func (d *DatabaseService) GetIP(ctx context.Context, ip string) (*entity.IPInfo, error) {
+	// Connect to the database
+	client := db.GetClient(d.GetURI())
+	defer func() {
+		if err := client.Disconnect(context.Background()); err != nil {
+			slog.Error(err.Error())
+		}
+	}()
+
+	// Get the collection
+	collection := client.Database(d.Name).Collection(d.IPsCollection)
+
+	var ipInfo entity.IPInfo
+	if err := collection.FindOne(ctx, bson.D{{Key: "_id", Value: ip}}).Decode(&ipInfo); err != nil {
+		slog.Error(err.Error())
+		return nil, err
+	}
+
+	return &ipInfo, nil
+}
+
+// SaveIP inserts or replaces the information of a public IP.
+func (d *DatabaseService) SaveIP(ctx context.Context, ip entity.IPInfo) error {
+	// Connect to the database
+	client := db.GetClient(d.GetURI())
+	defer func() {
+		if err := client.Disconnect(context.Background()); err != nil {
+			slog.Error(err.Error())
+		}
+	}()
+
+	// Get the collection
+	collection := client.Database(d.Name).Collection(d.IPsCollection)
+
+	_, err := collection.ReplaceOne(ctx, bson.D{{Key: "_id", Value: ip.IP}}, ip, options.Replace().SetUpsert(true))
+	if err != nil {
+		slog.Error(err.Error())
+	}
+
+	return err
+}
+
+func (d *DatabaseService) AddPowerAction(ctx context.Context, action entity.PowerAction) error {
+	// Connect to the database
+	client := db.GetClient(d.GetURI())
+	defer func() {
+		if err := client.Disconnect(context.Background()); err != nil {
+			slog.Error(err.Error())
+		}
+	}()
+
+	// Get the collection
+	collection := client.Database(d.Name).Collection(d.PowerCollection)
+
+	// Insert the power action in database
+	_, err := collection.InsertOne(ctx, action)
+	if err != nil {
+		slog.Error(err.Error())
+	}
+
+	return err
+}
+
+func (d *DatabaseService) GetPowerActions(ctx context.Context, serverID int, limit int64) ([]entity.PowerAction, error) {
+	// Connect to the database
+	client := db.GetClient(d.GetURI())
+	defer func() {
+		if err := client.Disconnect(context.Background()); err != nil {
+			slog.Error(err.Error())
+		}
+	}()
+
+	// Get the collection
+	collection := client.Database(d.Name).Collection(d.PowerCollection)
+
+	// Get the latest power actions of the server
+	cursor, err := collection.Find(ctx, bson.D{{Key: "serverID", Value: serverID}}, options.Find().SetSort(bson.D{{Key: "time", Value: -1}}).SetLimit(limit))
+	if err != nil {
+		slog.Error(err.Error())
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	// Decode the power actions
+	var actions []entity.PowerAction
+	if err := cursor.All(ctx, &actions); err != nil {
+		slog.Error(err.Error())
+		return nil, err
+	}
+
+	return actions, nil
+}
+
+func (d *DatabaseService) AddAPIKey(ctx context.Context, key entity.APIKey) (primitive.ObjectID, error) {
+	// Connect to the database
+	client := db.GetClient(d.GetURI())
+	defer func() {
+		if err := client.Disconnect(context.Background()); err != nil {
+			slog.Error(err.Error())
+		}
+	}()
+
+	// Get the collection
+	collection := client.Database(d.Name).Collection(d.KeysCollection)
+
+	// Insert the API key in database
+	result, err := collection.InsertOne(ctx, key)
+	if err != nil {
+		slog.Error(err.Error())
+		return primitive.NilObjectID, err
+	}
+
+	return result.InsertedID.(primitive.ObjectID), nil
+}
+
+func (d *DatabaseService) GetAPIKeys(ctx context.Context) ([]entity.APIKey, error) {
+	// Connect to the database
+	client := db.GetClient(d.GetURI())
+	defer func() {
+		if err := client.Disconnect(context.Background()); err != nil {
+			slog.Error(err.Error())
+		}
+	}()
+
+	// Get the collection
+	collection := client.Database(d.Name).Collection(d.KeysCollection)
+
+	// Get all API keys from the database
+	cursor, err := collection.Find(ctx, bson.D{}, options.Find().SetSort(bson.D{{Key: "createdAt", Value: 1}}))
+	if err != nil {
+		slog.Error(err.Error())
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	// Decode the API keys
+	var keys []entity.APIKey
+	if err := cursor.All(ctx, &keys); err != nil {
+		slog.Error(err.Error())
+		return nil, err
+	}
+
+	return keys, nil
+}
+
+func (d *DatabaseService) GetAPIKeyByHash(ctx context.Context, hash string) (*entity.APIKey, error) {
+	// Connect to the database
+	client := db.GetClient(d.GetURI())
+	defer func() {
+		if err := client.Disconnect(context.Background()); err != nil {
+			slog.Error(err.Error())
+		}
+	}()
+
+	// Get the collection
+	collection := client.Database(d.Name).Collection(d.KeysCollection)
+
+	// Get the API key from the database
+	var key entity.APIKey
+	if err := collection.FindOne(ctx, bson.D{{Key: "hash", Value: hash}}).Decode(&key); err != nil {
+		return nil, err
+	}
+
+	return &key, nil
+}
+
+func (d *DatabaseService) RevokeAPIKey(ctx context.Context, id primitive.ObjectID, revokedAt time.Time) error {
+	// Connect to the database
+	client := db.GetClient(d.GetURI())
+	defer func() {
+		if err := client.Disconnect(context.Background()); err != nil {
+			slog.Error(err.Error())
+		}
+	}()
+
+	// Get the collection
+	collection := client.Database(d.Name).Collection(d.KeysCollection)
+
+	// Mark the API key as revoked
+	result, err := collection.UpdateOne(ctx, bson.D{{Key: "_id", Value: id}}, bson.D{{Key: "$set", Value: bson.D{{Key: "revokedAt", Value: revokedAt}}}})
+	if err != nil {
+		slog.Error(err.Error())
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+
+	return nil
+}
+
+func (d *DatabaseService) UpdateAPIKeyLastUsed(ctx context.Context, id primitive.ObjectID, lastUsedAt time.Time) error {
+	// Connect to the database
+	client := db.GetClient(d.GetURI())
+	defer func() {
+		if err := client.Disconnect(context.Background()); err != nil {
+			slog.Error(err.Error())
+		}
+	}()
+
+	// Get the collection
+	collection := client.Database(d.Name).Collection(d.KeysCollection)
+
+	// Record when the API key was last used
+	_, err := collection.UpdateOne(ctx, bson.D{{Key: "_id", Value: id}}, bson.D{{Key: "$set", Value: bson.D{{Key: "lastUsedAt", Value: lastUsedAt}}}})
+	if err != nil {
+		slog.Error(err.Error())
+	}
+
+	return err
+}
+
+func (d *DatabaseService) SaveRole(ctx context.Context, role entity.Role) error {
+	// Connect to the database
+	client := db.GetClient(d.GetURI())
+	defer func() {
+		if err := client.Disconnect(context.Background()); err != nil {
+			slog.Error(err.Error())
+		}
+	}()
+
+	// Get the collection
+	collection := client.Database(d.Name).Collection(d.RolesCollection)
+
+	// Create or replace the role
+	_, err := collection.ReplaceOne(ctx, bson.D{{Key: "_id", Value: role.Name}}, role, options.Replace().SetUpsert(true))
+	if err != nil {
+		slog.Error(err.Error())
+	}
+
+	return err
+}
+
+func (d *DatabaseService) GetRoles(ctx context.Context) ([]entity.Role, error) {
+	// Connect to the database
+	client := db.GetClient(d.GetURI())
+	defer func() {
+		if err := client.Disconnect(context.Background()); err != nil {
+			slog.Error(err.Error())
+		}
+	}()
+
+	// Get the collection
+	collection := client.Database(d.Name).Collection(d.RolesCollection)
+
+	// Get all roles from the database
+	cursor, err := collection.Find(ctx, bson.D{}, options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}))
+	if err != nil {
+		slog.Error(err.Error())
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	// Decode the roles
+	var roles []entity.Role
+	if err := cursor.All(ctx, &roles); err != nil {
+		slog.Error(err.Error())
+		return nil, err
+	}
+
+	return roles, nil
+}
+
+func (d *DatabaseService) GetRolesWithNames(ctx context.Context, names []string) ([]entity.Role, error) {
+	// Connect to the database
+	client := db.GetClient(d.GetURI())
+	defer func() {
+		if err := client.Disconnect(context.Background()); err != nil {
+			slog.Error(err.Error())
+		}
+	}()
+
+	// Get the collection
+	collection := client.Database(d.Name).Collection(d.RolesCollection)
+
+	// Get the roles from the database
+	cursor, err := collection.Find(ctx, bson.D{{Key: "_id", Value: bson.D{{Key: "$in", Value: names}}}})
+	if err != nil {
+		slog.Error(err.Error())
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	// Decode the roles
+	var roles []entity.Role
+	if err := cursor.All(ctx, &roles); err != nil {
+		slog.Error(err.Error())
+		return nil, err
+	}
+
+	return roles, nil
+}
+
+func (d *DatabaseService) DeleteRole(ctx context.Context, name string) error {
+	// Connect to the database
+	client := db.GetClient(d.GetURI())
+	defer func() {
+		if err := client.Disconnect(context.Background()); err != nil {
+			slog.Error(err.Error())
+		}
+	}()
+
+	// Get the collection
+	collection := client.Database(d.Name).Collection(d.RolesCollection)
+
+	// Delete the role from the database
+	result, err := collection.DeleteOne(ctx, bson.D{{Key: "_id", Value: name}})
+	if err != nil {
+		slog.Error(err.Error())
+		return err
+	}
+
+	if result.DeletedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+
+	return nil
+}
+
+func (d *DatabaseService) AddRoleBinding(ctx context.Context, binding entity.RoleBinding) (primitive.ObjectID, error) {
+	// Connect to the database
+	client := db.GetClient(d.GetURI())
+	defer func() {
+		if err := client.Disconnect(context.Background()); err != nil {
+			slog.Error(err.Error())
+		}
+	}()
+
+	// Get the collection
+	collection := client.Database(d.Name).Collection(d.BindingsCollection)
+
+	// Insert the role binding in database
+	result, err := collection.InsertOne(ctx, binding)
+	if err != nil {
+		slog.Error(err.Error())
+		return primitive.NilObjectID, err
+	}
+
+	return result.InsertedID.(primitive.ObjectID), nil
+}
+
+// GetRoleBindings returns the role bindings of the subject, or every role binding if subject is empty.
+func (d *DatabaseService) GetRoleBindings(ctx context.Context, subject string) ([]entity.RoleBinding, error) {
+	// Connect to the database
+	client := db.GetClient(d.GetURI())
+	defer func() {
+		if err := client.Disconnect(context.Background()); err != nil {
+			slog.Error(err.Error())
+		}
+	}()
+
+	// Get the collection
+	collection := client.Database(d.Name).Collection(d.BindingsCollection)
+
+	filter := bson.D{}
+	if subject != "" {
+		filter = bson.D{{Key: "subject", Value: subject}}
+	}
+
+	// Get the role bindings from the database
+	cursor, err := collection.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "createdAt", Value: 1}}))
+	if err != nil {
+		slog.Error(err.Error())
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	// Decode the role bindings
+	var bindings []entity.RoleBinding
+	if err := cursor.All(ctx, &bindings); err != nil {
+		slog.Error(err.Error())
+		return nil, err
+	}
+
+	return bindings, nil
+}
+
+func (d *DatabaseService) CountRoleBindings(ctx context.Context, role string) (int64, error) {
+	// Connect to the database
+	client := db.GetClient(d.GetURI())
+	defer func() {
+		if err := client.Disconnect(context.Background()); err != nil {
+			slog.Error(err.Error())
+		}
+	}()
+
+	// Get the collection
+	collection := client.Database(d.Name).Collection(d.BindingsCollection)
+
+	// Count the bindings of the role
+	count, err := collection.CountDocuments(ctx, bson.D{{Key: "role", Value: role}})
+	if err != nil {
+		slog.Error(err.Error())
+	}
+
+	return count, err
+}
+
+func (d *DatabaseService) DeleteRoleBinding(ctx context.Context, id primitive.ObjectID) error {
+	// Connect to the database
+	client := db.GetClient(d.GetURI())
+	defer func() {
+		if err := client.Disconnect(context.Background()); err != nil {
+			slog.Error(err.Error())
+		}
+	}()
+
+	// Get the collection
+	collection := client.Database(d.Name).Collection(d.BindingsCollection)
+
+	// Delete the role binding from the database
+	result, err := collection.DeleteOne(ctx, bson.D{{Key: "_id", Value: id}})
+	if err != nil {
+		slog.Error(err.Error())
+		return err
+	}
+
+	if result.DeletedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+
+	return nil
+}
+
+// GetDomainsOnServer returns the records of the domains created through vdash on the server.
+func (d *DatabaseService) GetDomainsOnServer(ctx context.Context, serverID int) ([]entity.DomainInfo, error) {
+	// Connect to the database
+	client := db.GetClient(d.GetURI())
+	defer func() {
+		if err := client.Disconnect(context.Background()); err != nil {
+			slog.Error(err.Error())
+		}
+	}()
+
+	// Get the collection
+	collection := client.Database(d.Name).Collection(d.DomainsCollection)
+
+	// Get the domains of the server from the database
+	cursor, err := collection.Find(ctx, bson.D{{Key: "serverID", Value: serverID}})
+	if err != nil {
+		slog.Error(err.Error())
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	// Decode the domains
+	var domains []entity.DomainInfo
+	if err := cursor.All(ctx, &domains); err != nil {
+		slog.Error(err.Error())
+		return nil, err
+	}
+
+	return domains, nil
+}
+
+// GetDomainByLibvirtName returns the record of the domain defined in libvirt with the given name on the server.
+func (d *DatabaseService) GetDomainByLibvirtName(ctx context.Context, serverID int, libvirtName string) (*entity.DomainInfo, error) {
+	// Connect to the database
+	client := db.GetClient(d.GetURI())
+	defer func() {
+		if err := client.Disconnect(context.Background()); err != nil {
+			slog.Error(err.Error())
+		}
+	}()
+
+	// Get the collection
+	collection := client.Database(d.Name).Collection(d.DomainsCollection)
+
+	// Get the domain from the database
+	var domain entity.DomainInfo
+	if err := collection.FindOne(ctx, bson.D{{Key: "serverID", Value: serverID}, {Key: "libvirtName", Value: libvirtName}}).Decode(&domain); err != nil {
+		slog.Error(err.Error())
+		return nil, err
+	}
+
+	return &domain, nil
+}
+
+func (d *DatabaseService) AddVolume(ctx context.Context, volume entity.VolumeInfo) error {
+	// Connect to the database
+	client := db.GetClient(d.GetURI())
+	defer func() {
+		if err := client.Disconnect(context.Background()); err != nil {
+			slog.Error(err.Error())
+		}
+	}()
+
+	// Get the collection
+	collection := client.Database(d.Name).Collection(d.VolumesCollection)
+
+	// Insert the volume information in database
+	_, err := collection.InsertOne(ctx, volume)
+	if err != nil {
+		slog.Error(err.Error())
+	}
+
+	return err
+}
+
+// GetVolumesOnServer returns the records of the volumes created through vdash in a storage pool of the server.
+func (d *DatabaseService) GetVolumesOnServer(ctx context.Context, serverID int, pool string) ([]entity.VolumeInfo, error) {
+	// Connect to the database
+	client := db.GetClient(d.GetURI())
+	defer func() {
+		if err := client.Disconnect(context.Background()); err != nil {
+			slog.Error(err.Error())
+		}
+	}()
+
+	// Get the collection
+	collection := client.Database(d.Name).Collection(d.VolumesCollection)
+
+	// Get the volumes of the storage pool from the database
+	cursor, err := collection.Find(ctx, bson.D{{Key: "serverID", Value: serverID}, {Key: "pool", Value: pool}})
+	if err != nil {
+		slog.Error(err.Error())
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	// Decode the volumes
+	var volumes []entity.VolumeInfo
+	if err := cursor.All(ctx, &volumes); err != nil {
+		slog.Error(err.Error())
+		return nil, err
+	}
+
+	return volumes, nil
+}
+
+// GetVolumeByLibvirtName returns the record of the volume defined in libvirt with the given name in a storage pool of the server.
+func (d *DatabaseService) GetVolumeByLibvirtName(ctx context.Context, serverID int, pool, libvirtName string) (*entity.VolumeInfo, error) {
+	// Connect to the database
+	client := db.GetClient(d.GetURI())
+	defer func() {
+		if err := client.Disconnect(context.Background()); err != nil {
+			slog.Error(err.Error())
+		}
+	}()
+
+	// Get the collection
+	collection := client.Database(d.Name).Collection(d.VolumesCollection)
+
+	// Get the volume from the database
+	var volume entity.VolumeInfo
+	if err := collection.FindOne(ctx, bson.D{{Key: "serverID", Value: serverID}, {Key: "pool", Value: pool}, {Key: "libvirtName", Value: libvirtName}}).Decode(&volume); err != nil {
+		slog.Error(err.Error())
+		return nil, err
+	}
+
+	return &volume, nil
+}
+
+// DeleteVolumeByName deletes the record of the volume defined in libvirt with the given name in a storage pool of the server.
+func (d *DatabaseService) DeleteVolumeByName(ctx context.Context, serverID int, pool, libvirtName string) error {
+	// Connect to the database
+	client := db.GetClient(d.GetURI())
+	defer func() {
+		if err := client.Disconnect(context.Background()); err != nil {
+			slog.Error(err.Error())
+		}
+	}()
+
+	// Get the collection
+	collection := client.Database(d.Name).Collection(d.VolumesCollection)
+
+	// Delete the volume from the database
+	_, err := collection.DeleteOne(ctx, bson.D{{Key: "serverID", Value: serverID}, {Key: "pool", Value: pool}, {Key: "libvirtName", Value: libvirtName}})
+	if err != nil {
+		slog.Error(err.Error())
+	}
+
+	return err
+}
+
+func (d *DatabaseService) AddNetwork(ctx context.Context, network entity.NetworkInfo) error {
+	// Connect to the database
+	client := db.GetClient(d.GetURI())
+	defer func() {
+		if err := client.Disconnect(context.Background()); err != nil {
+			slog.Error(err.Error())
+		}
+	}()
+
+	// Get the collection
+	collection := client.Database(d.Name).Collection(d.NetworksCollection)
+
+	// Insert the network information in database
+	_, err := collection.InsertOne(ctx, network)
+	if err != nil {
+		slog.Error(err.Error())
+	}
+
+	return err
+}
+
+// GetNetworksOnServer returns the records of the networks created through vdash on the server.
+func (d *DatabaseService) GetNetworksOnServer(ctx context.Context, serverID int) ([]entity.NetworkInfo, error) {
+	// Connect to the database
+	client := db.GetClient(d.GetURI())
+	defer func() {
+		if err := client.Disconnect(context.Background()); err != nil {
+			slog.Error(err.Error())
+		}
+	}()
+
+	// Get the collection
+	collection := client.Database(d.Name).Collection(d.NetworksCollection)
+
+	// Get the networks of the server from the database
+	cursor, err := collection.Find(ctx, bson.D{{Key: "serverID", Value: serverID}})
+	if err != nil {
+		slog.Error(err.Error())
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	// Decode the networks
+	var networks []entity.NetworkInfo
+	if err := cursor.All(ctx, &networks); err != nil {
+		slog.Error(err.Error())
+		return nil, err
+	}
+
+	return networks, nil
+}
+
+// GetNetworkByLibvirtName returns the record of the network defined in libvirt with the given name on the server.
+func (d *DatabaseService) GetNetworkByLibvirtName(ctx context.Context, serverID int, libvirtName string) (*entity.NetworkInfo, error) {
+	// Connect to the database
+	client := db.GetClient(d.GetURI())
+	defer func() {
+		if err := client.Disconnect(context.Background()); err != nil {
+			slog.Error(err.Error())
+		}
+	}()
+
+	// Get the collection
+	collection := client.Database(d.Name).Collection(d.NetworksCollection)
+
+	// Get the network from the database
+	var network entity.NetworkInfo
+	if err := collection.FindOne(ctx, bson.D{{Key: "serverID", Value: serverID}, {Key: "libvirtName", Value: libvirtName}}).Decode(&network); err != nil {
+		slog.Error(err.Error())
+		return nil, err
+	}
+
+	return &network, nil
+}
+
+// DeleteNetworkByName deletes the record of the network defined in libvirt with the given name on the server.
+func (d *DatabaseService) DeleteNetworkByName(ctx context.Context, serverID int, libvirtName string) error {
+	// Connect to the database
+	client := db.GetClient(d.GetURI())
+	defer func() {
+		if err := client.Disconnect(context.Background()); err != nil {
+			slog.Error(err.Error())
+		}
+	}()
+
+	// Get the collection
+	collection := client.Database(d.Name).Collection(d.NetworksCollection)
+
+	// Delete the network from the database
+	_, err := collection.DeleteOne(ctx, bson.D{{Key: "serverID", Value: serverID}, {Key: "libvirtName", Value: libvirtName}})
+	if err != nil {
+		slog.Error(err.Error())
+	}
+
+	return err
+}
+
+// GetProjectIPs returns the public IPs allocated to the project.
+func (d *DatabaseService) GetProjectIPs(ctx context.Context, project string) ([]entity.IPInfo, error) {
+	// Connect to the database
+	client := db.GetClient(d.GetURI())
+	defer func() {
+		if err := client.Disconnect(context.Background()); err != nil {
+			slog.Error(err.Error())
+		}
+	}()
+
+	// Get the collection
+	collection := client.Database(d.Name).Collection(d.IPsCollection)
+
+	// Get the public IPs allocated to the project from the database
+	cursor, err := collection.Find(ctx, bson.D{{Key: "available", Value: false}, {Key: "project", Value: project}})
+	if err != nil {
+		slog.Error(err.Error())
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	// Decode the IPs
+	var ips []entity.IPInfo
+	if err := cursor.All(ctx, &ips); err != nil {
+		slog.Error(err.Error())
+		return nil, err
+	}
+
+	return ips, nil
+}
+
+func (d *DatabaseService) AddProject(ctx context.Context, project entity.Project) error {
+	// Connect to the database
+	client := db.GetClient(d.GetURI())
+	defer func() {
+		if err := client.Disconnect(context.Background()); err != nil {
+			slog.Error(err.Error())
+		}
+	}()
+
+	// Get the collection
+	collection := client.Database(d.Name).Collection(d.ProjectsCollection)
+
+	// Insert the project in database
+	_, err := collection.InsertOne(ctx, project)
+	if err != nil {
+		slog.Error(err.Error())
+	}
+
+	return err
+}
+
+func (d *DatabaseService) GetProjects(ctx context.Context) ([]entity.Project, error) {
+	// Connect to the database
+	client := db.GetClient(d.GetURI())
+	defer func() {
+		if err := client.Disconnect(context.Background()); err != nil {
+			slog.Error(err.Error())
+		}
+	}()
+
+	// Get the collection
+	collection := client.Database(d.Name).Collection(d.ProjectsCollection)
+
+	// Get the projects from the database
+	cursor, err := collection.Find(ctx, bson.D{}, options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}))
+	if err != nil {
+		slog.Error(err.Error())
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	// Decode the projects
+	var projects []entity.Project
+	if err := cursor.All(ctx, &projects); err != nil {
+		slog.Error(err.Error())
+		return nil, err
+	}
+
+	return projects, nil
+}
+
+func (d *DatabaseService) GetProject(ctx context.Context, name string) (*entity.Project, error) {
+	// Connect to the database
+	client := db.GetClient(d.GetURI())
+	defer func() {
+		if err := client.Disconnect(context.Background()); err != nil {
+			slog.Error(err.Error())
+		}
+	}()
+
+	// Get the collection
+	collection := client.Database(d.Name).Collection(d.ProjectsCollection)
+
+	// Get the project from the database
+	var project entity.Project
+	if err := collection.FindOne(ctx, bson.D{{Key: "_id", Value: name}}).Decode(&project); err != nil {
+		// The default project is looked up on most requests and only stored once its quota is set
+		if !errors.Is(err, mongo.ErrNoDocuments) {
+			slog.Error(err.Error())
+		}
+		return nil, err
+	}
+
+	return &project, nil
+}
+
+func (d *DatabaseService) DeleteProject(ctx context.Context, name string) error {
+	// Connect to the database
+	client := db.GetClient(d.GetURI())
+	defer func() {
+		if err := client.Disconnect(context.Background()); err != nil {
+			slog.Error(err.Error())
+		}
+	}()
+
+	// Get the collection
+	collection := client.Database(d.Name).Collection(d.ProjectsCollection)
+
+	// Delete the project from the database
+	result, err := collection.DeleteOne(ctx, bson.D{{Key: "_id", Value: name}})
+	if err != nil {
+		slog.Error(err.Error())
+		return err
+	}
+
+	if result.DeletedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+
+	return nil
+}
+
+// CountProjectResources returns the number of domains, volumes, networks and public IPs owned by the project.
+func (d *DatabaseService) CountProjectResources(ctx context.Context, project string) (int64, error) {
+	// Connect to the database
+	client := db.GetClient(d.GetURI())
+	defer func() {
+		if err := client.Disconnect(context.Background()); err != nil {
+			slog.Error(err.Error())
+		}
+	}()
+
+	database := client.Database(d.Name)
+
+	var total int64
+	for _, name := range []string{d.DomainsCollection, d.VolumesCollection, d.NetworksCollection, d.IPsCollection} {
+		// Count the resources of the project in the collection
+		count, err := database.Collection(name).CountDocuments(ctx, bson.D{{Key: "project", Value: project}})
+		if err != nil {
+			slog.Error(err.Error())
+			return 0, err
+		}
+		total += count
+	}
+
+	return total, nil
+}
+
+// SetProjectQuota replaces the quota of the project. The default project is stored the first time its quota is set.
+func (d *DatabaseService) SetProjectQuota(ctx context.Context, name string, quota entity.Quota) error {
+	// Connect to the database
+	client := db.GetClient(d.GetURI())
+	defer func() {
+		if err := client.Disconnect(context.Background()); err != nil {
+			slog.Error(err.Error())
+		}
+	}()
+
+	// Get the collection
+	collection := client.Database(d.Name).Collection(d.ProjectsCollection)
+
+	// Update the quota of the project in database
+	_, err := collection.UpdateOne(ctx, bson.D{{Key: "_id", Value: name}}, bson.D{{Key: "$set", Value: bson.D{{Key: "quota", Value: quota}}}}, options.Update().SetUpsert(true))
+	if err != nil {
+		slog.Error(err.Error())
+	}
+
+	return err
+}
+
+// GetProjectUsage returns the resources used by the domains, volumes, networks and public IPs of the project.
+func (d *DatabaseService) GetProjectUsage(ctx context.Context, project string) (*ResourceUsage, error) {
+	// Connect to the database
+	client := db.GetClient(d.GetURI())
+	defer func() {
+		if err := client.Disconnect(context.Background()); err != nil {
+			slog.Error(err.Error())
+		}
+	}()
+
+	database := client.Database(d.Name)
+
+	// Sum the resources of the domains of the project
+	var usage ResourceUsage
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.D{{Key: "project", Value: project}}}},
+		{{Key: "$group", Value: bson.D{
+			{Key: "_id", Value: nil},
+			{Key: "vcpu", Value: bson.D{{Key: "$sum", Value: "$vcpu"}}},
+			{Key: "memory", Value: bson.D{{Key: "$sum", Value: "$memory"}}},
+			{Key: "domains", Value: bson.D{{Key: "$sum", Value: 1}}},
+		}}},
+	}
+	if err := d.aggregateUsage(ctx, database.Collection(d.DomainsCollection), pipeline, &usage); err != nil {
+		return nil, err
+	}
+
+	// Sum the size of the volumes of the project
+	pipeline = mongo.Pipeline{
+		{{Key: "$match", Value: bson.D{{Key: "project", Value: project}}}},
+		{{Key: "$group", Value: bson.D{{Key: "_id", Value: nil}, {Key: "disk", Value: bson.D{{Key: "$sum", Value: "$size"}}}}}},
+	}
+	if err := d.aggregateUsage(ctx, database.Collection(d.VolumesCollection), pipeline, &usage); err != nil {
+		return nil, err
+	}
+
+	// Count the networks and allocated public IPs of the project
+	networks, err := database.Collection(d.NetworksCollection).CountDocuments(ctx, bson.D{{Key: "project", Value: project}})
+	if err != nil {
+		slog.Error(err.Error())
+		return nil, err
+	}
+	usage.Networks = networks
+
+	ips, err := database.Collection(d.IPsCollection).CountDocuments(ctx, bson.D{{Key: "project", Value: project}, {Key: "available", Value: false}})
+	if err != nil {
+		slog.Error(err.Error())
+		return nil, err
+	}
+	usage.PublicIPs = ips
+
+	return &usage, nil
+}
+
+// aggregateUsage runs a pipeline grouping documents into a single result and decodes the sums it computes into usage. The other fields of usage are left untouched.
+func (d *DatabaseService) aggregateUsage(ctx context.Context, collection *mongo.Collection, pipeline mongo.Pipeline, usage *ResourceUsage) error {
+	cursor, err := collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		slog.Error(err.Error())
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	// A project without documents in the collection has no result
+	if cursor.Next(ctx) {
+		if err := cursor.Decode(usage); err != nil {
+			slog.Error(err.Error())
+			return err
+		}
+	}
+
+	return cursor.Err()
+}
+
+// UpdateDomainResources records the memory and number of vCPUs of a resized domain.
+func (d *DatabaseService) UpdateDomainResources(ctx context.Context, uuid string, memory uint64, vcpu uint) error {
+	// Connect to the database
+	client := db.GetClient(d.GetURI())
+	defer func() {
+		if err := client.Disconnect(context.Background()); err != nil {
+			slog.Error(err.Error())
+		}
+	}()
+
+	// Get the collection
+	collection := client.Database(d.Name).Collection(d.DomainsCollection)
+
+	// Record the resources of the domain
+	_, err := collection.UpdateOne(ctx, bson.D{{Key: "_id", Value: uuid}}, bson.D{{Key: "$set", Value: bson.D{{Key: "memory", Value: memory}, {Key: "vcpu", Value: vcpu}}}})
+	if err != nil {
+		slog.Error(err.Error())
+	}
+
+	return err
+}
+
+// UpdateVolumeSize records the size of a resized volume.
+func (d *DatabaseService) UpdateVolumeSize(ctx context.Context, id primitive.ObjectID, size int) error {
+	// Connect to the database
+	client := db.GetClient(d.GetURI())
+	defer func() {
+		if err := client.Disconnect(context.Background()); err != nil {
+			slog.Error(err.Error())
+		}
+	}()
+
+	// Get the collection
+	collection := client.Database(d.Name).Collection(d.VolumesCollection)
+
+	// Record the size of the volume
+	_, err := collection.UpdateOne(ctx, bson.D{{Key: "_id", Value: id}}, bson.D{{Key: "$set", Value: bson.D{{Key: "size", Value: size}}}})
+	if err != nil {
+		slog.Error(err.Error())
+	}
+
+	return err
+}
+
+func (d *DatabaseService) AddAuditEntry(ctx context.Context, entry entity.AuditEntry) error {
+	// Connect to the database
+	client := db.GetClient(d.GetURI())
+	defer func() {
+		if err := client.Disconnect(context.Background()); err != nil {
+			slog.Error(err.Error())
+		}
+	}()
+
+	// Get the collection
+	collection := client.Database(d.Name).Collection(d.AuditCollection)
+
+	// Append the entry to the audit log
+	_, err := collection.InsertOne(ctx, entry)
+	if err != nil {
+		slog.Error(err.Error())
+	}
+
+	return err
+}
+
+// GetAuditEntries returns the latest audit entries matching the filter, most recent first.
+func (d *DatabaseService) GetAuditEntries(ctx context.Context, filter AuditFilter, limit int64) ([]entity.AuditEntry, error) {
+	// Connect to the database
+	client := db.GetClient(d.GetURI())
+	defer func() {
+		if err := client.Disconnect(context.Background()); err != nil {
+			slog.Error(err.Error())
+		}
+	}()
+
+	// Get the collection
+	collection := client.Database(d.Name).Collection(d.AuditCollection)
+
+	// Get the audit entries from the database
+	cursor, err := collection.Find(ctx, auditQuery(filter), options.Find().SetSort(bson.D{{Key: "time", Value: -1}}).SetLimit(limit))
+	if err != nil {
+		slog.Error(err.Error())
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	// Decode the audit entries
+	var entries []entity.AuditEntry
+	if err := cursor.All(ctx, &entries); err != nil {
+		slog.Error(err.Error())
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+// ForEachAuditEntry calls fn with every audit entry matching the filter, oldest first. It stops at the first error returned by fn.
+func (d *DatabaseService) ForEachAuditEntry(ctx context.Context, filter AuditFilter, fn func(entity.AuditEntry) error) error {
+	// Connect to the database
+	client := db.GetClient(d.GetURI())
+	defer func() {
+		if err := client.Disconnect(context.Background()); err != nil {
+			slog.Error(err.Error())
+		}
+	}()
+
+	// Get the collection
+	collection := client.Database(d.Name).Collection(d.AuditCollection)
+
+	// Get the audit entries from the database
+	cursor, err := collection.Find(ctx, auditQuery(filter), options.Find().SetSort(bson.D{{Key: "time", Value: 1}}))
+	if err != nil {
+		slog.Error(err.Error())
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var entry entity.AuditEntry
+		if err := cursor.Decode(&entry); err != nil {
+			slog.Error(err.Error())
+			return err
+		}
+
+		if err := fn(entry); err != nil {
+			return err
+		}
+	}
+
+	return cursor.Err()
+}
+
+// auditQuery builds the query selecting the audit entries matching the filter.
+func auditQuery(filter AuditFilter) bson.D {
+	query := bson.D{}
+	for _, field := range []struct {
+		key   string
+		value string
+	}{
+		{"actor", filter.Actor},
+		{"method", filter.Method},
+		{"route", filter.Route},
+		{"project", filter.Project},
+		{"outcome", filter.Outcome},
+	} {
+		if field.value != "" {
+			query = append(query, bson.E{Key: field.key, Value: field.value})
+		}
+	}
+
+	if filter.ServerID > 0 {
+		query = append(query, bson.E{Key: "serverID", Value: filter.ServerID})
+	}
+
+	period := bson.D{}
+	if !filter.Since.IsZero() {
+		period = append(period, bson.E{Key: "$gte", Value: filter.Since})
+	}
+
+	if !filter.Until.IsZero() {
+		period = append(period, bson.E{Key: "$lt", Value: filter.Until})
+	}
+
+	if len(period) > 0 {
+		query = append(query, bson.E{Key: "time", Value: period})
+	}
+
+	return query
+}
+
+func (d *DatabaseService) AddIdempotencyRecord(ctx context.Context, record entity.IdempotencyRecord) error {
+	// Connect to the database
+	client := db.GetClient(d.GetURI())
+	defer func() {
+		if err := client.Disconnect(context.Background()); err != nil {
+			slog.Error(err.Error())
+		}
+	}()
+
+	// Get the collection
+	collection := client.Database(d.Name).Collection(d.IdempotencyCollection)
+
+	// Insert the record into the database
+	_, err := collection.InsertOne(ctx, record)
+	// A duplicate key means the key is already in use, which the caller handles
+	if err != nil && !mongo.IsDuplicateKeyError(err) {
+		slog.Error(err.Error())
+	}
+
+	return err
+}
+
+func (d *DatabaseService) GetIdempotencyRecord(ctx context.Context, id string) (*entity.IdempotencyRecord, error) {
+	// Connect to the database
+	client := db.GetClient(d.GetURI())
+	defer func() {
+		if err := client.Disconnect(context.Background()); err != nil {
+			slog.Error(err.Error())
+		}
+	}()
+
+	// Get the collection
+	collection := client.Database(d.Name).Collection(d.IdempotencyCollection)
+
+	// Get the record from the database
+	var record entity.IdempotencyRecord
+	if err := collection.FindOne(ctx, bson.D{{Key: "_id", Value: id}}).Decode(&record); err != nil {
+		slog.Error(err.Error())
+		return nil, err
+	}
+
+	return &record, nil
+}
+
+// CompleteIdempotencyRecord stores the response to the request of a reserved record.
+func (d *DatabaseService) CompleteIdempotencyRecord(ctx context.Context, id string, status int, contentType string, body []byte, expiresAt time.Time) error {
+	// Connect to the database
+	client := db.GetClient(d.GetURI())
+	defer func() {
+		if err := client.Disconnect(context.Background()); err != nil {
+			slog.Error(err.Error())
+		}
+	}()
+
+	// Get the collection
+	collection := client.Database(d.Name).Collection(d.IdempotencyCollection)
+
+	// Update the record in the database
+	update := bson.D{{Key: "$set", Value: bson.D{
+		{Key: "completed", Value: true},
+		{Key: "status", Value: status},
+		{Key: "contentType", Value: contentType},
+		{Key: "body", Value: body},
+		{Key: "expiresAt", Value: expiresAt},
+	}}}
+	result, err := collection.UpdateOne(ctx, bson.D{{Key: "_id", Value: id}}, update)
+	if err != nil {
+		slog.Error(err.Error())
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+
+	return nil
+}
+
+func (d *DatabaseService) DeleteIdempotencyRecord(ctx context.Context, id string) error {
+	// Connect to the database
+	client := db.GetClient(d.GetURI())
+	defer func() {
+		if err := client.Disconnect(context.Background()); err != nil {
+			slog.Error(err.Error())
+		}
+	}()
+
+	// Get the collection
+	collection := client.Database(d.Name).Collection(d.IdempotencyCollection)
+
+	// Delete the record from the database
+	_, err := collection.DeleteOne(ctx, bson.D{{Key: "_id", Value: id}})
+	if err != nil {
+		slog.Error(err.Error())
+	}
+
+	return err
+}
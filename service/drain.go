@@ -0,0 +1,265 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/sychonet/vdash-be/db/entity"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Statuses of a drain and of the domains it moves.
+const (
+	DrainRunning   = "running"
+	DrainCompleted = "completed"
+	DrainFailed    = "failed"
+
+	DrainDomainPending  = "pending"
+	DrainDomainMigrated = "migrated"
+	DrainDomainFailed   = "failed"
+)
+
+// ErrDrainRunning is returned when a drain is requested for a server which is already being drained.
+var ErrDrainRunning = errors.New("server is already being drained")
+
+// DrainService is a service that moves every domain off a server so that it can be taken into maintenance. It uses the SchedulerService to pick a target for each domain so that placement constraints and server group policies are respected.
+type DrainService struct {
+	databaseService  *DatabaseService
+	libvirtService   *LibvirtService
+	schedulerService *SchedulerService
+
+	// ctx stops the drains running in the background when it is done
+	ctx context.Context
+	wg  sync.WaitGroup
+
+	mu      sync.Mutex
+	running map[int]bool
+}
+
+func NewDrainService(ctx context.Context, databaseService *DatabaseService, libvirtService *LibvirtService, schedulerService *SchedulerService) *DrainService {
+	return &DrainService{
+		ctx:              ctx,
+		databaseService:  databaseService,
+		libvirtService:   libvirtService,
+		schedulerService: schedulerService,
+		running:          map[int]bool{},
+	}
+}
+
+// Drain cordons the server and starts moving its domains to other servers in the background. Running domains are live migrated if live is true and cold migrated otherwise. The progress is recorded in the database after every domain.
+func (d *DrainService) Drain(ctx context.Context, serverID int, live bool) (*entity.DrainInfo, error) {
+	server, err := d.databaseService.GetServer(ctx, serverID)
+	if err != nil {
+		return nil, err
+	}
+
+	d.mu.Lock()
+	if d.running[serverID] {
+		d.mu.Unlock()
+		return nil, ErrDrainRunning
+	}
+	d.running[serverID] = true
+	d.mu.Unlock()
+
+	drain, err := d.start(ctx, *server, live)
+	if err != nil {
+		d.finish(serverID)
+		return nil, err
+	}
+
+	return drain, nil
+}
+
+// start cordons the server, records the domains to move and launches the drain.
+func (d *DrainService) start(ctx context.Context, server entity.ServerInfo, live bool) (*entity.DrainInfo, error) {
+	// Keep the scheduler from placing anything new on the server
+	if err := d.databaseService.SetServerCordoned(ctx, server.ID, true); err != nil {
+		return nil, err
+	}
+
+	specs, err := d.libvirtService.GetDomainSpecs(ctx, server.LibvirtURI)
+	if err != nil {
+		return nil, err
+	}
+
+	drain := entity.DrainInfo{
+		ServerID:  server.ID,
+		Live:      live,
+		Status:    DrainRunning,
+		StartedAt: time.Now().UTC(),
+		Domains:   []entity.DrainDomain{},
+	}
+
+	for _, spec := range specs {
+		drain.Domains = append(drain.Domains, entity.DrainDomain{Name: spec.Name, Status: DrainDomainPending})
+	}
+
+	if err := d.databaseService.SaveDrain(ctx, drain); err != nil {
+		return nil, err
+	}
+
+	d.wg.Add(1)
+	go d.run(server, specs, drain)
+
+	return &drain, nil
+}
+
+// run moves the domains one after another and records the outcome of the drain. When the context of the service is done the domain being moved is left to finish and the remaining ones are reported as failed.
+func (d *DrainService) run(server entity.ServerInfo, specs []DomainSpec, drain entity.DrainInfo) {
+	defer d.wg.Done()
+	defer d.finish(server.ID)
+
+	// The outcome is recorded even when the drain is stopped
+	ctx := context.WithoutCancel(d.ctx)
+
+	groups := map[string]bool{}
+	failed := false
+	for i, spec := range specs {
+		if err := d.ctx.Err(); err != nil {
+			drain.Domains[i].Status = DrainDomainFailed
+			drain.Domains[i].Error = "drain interrupted: " + err.Error()
+			failed = true
+			continue
+		}
+
+		targetServerID, group, err := d.migrate(ctx, server, spec, drain.Live)
+		if err != nil {
+			slog.Error("Failed to move domain " + spec.Name + " off server " + server.Hostname + ": " + err.Error())
+			drain.Domains[i].Status = DrainDomainFailed
+			drain.Domains[i].Error = err.Error()
+			failed = true
+		} else {
+			drain.Domains[i].Status = DrainDomainMigrated
+			drain.Domains[i].TargetServerID = targetServerID
+		}
+
+		if group != "" {
+			groups[group] = true
+		}
+
+		if err := d.databaseService.SaveDrain(ctx, drain); err != nil {
+			slog.Error("Failed to record drain progress: " + err.Error())
+		}
+	}
+
+	// Report the server groups whose policy is broken after the domains moved
+	for name := range groups {
+		group, err := d.databaseService.GetServerGroup(ctx, name)
+		if err != nil {
+			slog.Error("Failed to get server group " + name + ": " + err.Error())
+			continue
+		}
+
+		drain.Violations = append(drain.Violations, GroupViolations(*group)...)
+	}
+
+	drain.Status = DrainCompleted
+	if failed {
+		drain.Status = DrainFailed
+		drain.Error = "some domains could not be moved, the server stays cordoned"
+	}
+	drain.FinishedAt = time.Now().UTC()
+
+	if err := d.databaseService.SaveDrain(ctx, drain); err != nil {
+		slog.Error("Failed to record drain progress: " + err.Error())
+	}
+
+	slog.Info(fmt.Sprintf("Drain of server %s finished with status %s", server.Hostname, drain.Status))
+}
+
+// migrate schedules a target for a domain with the placement requirements it was created with and moves it there. It returns the target server id and the server group of the domain.
+func (d *DrainService) migrate(ctx context.Context, server entity.ServerInfo, spec DomainSpec, live bool) (int, string, error) {
+	var placement Placement
+
+	domain, err := d.databaseService.GetDomain(ctx, spec.UUID)
+	if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+		return 0, "", err
+	}
+
+	groupName := ""
+	if domain != nil {
+		placement.NodeSelector = domain.NodeSelector
+		placement.Required = domain.Required
+		placement.Preferred = domain.Preferred
+
+		if domain.Group != "" {
+			group, err := d.databaseService.GetServerGroup(ctx, domain.Group)
+			if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+				return 0, "", err
+			}
+
+			if group != nil {
+				// Members still on the drained server are moved as well, so they must neither pin nor repel the target
+				var members []entity.ServerGroupMember
+				for _, member := range group.Members {
+					if member.ServerID != server.ID {
+						members = append(members, member)
+					}
+				}
+				group.Members = members
+
+				placement.Group = group
+				groupName = group.Name
+			}
+		}
+	}
+
+	decision, err := d.schedulerService.ScheduleDomain(ctx, spec.Memory, spec.VCPU, false, placement)
+	if err != nil {
+		return 0, groupName, err
+	}
+
+	if decision.ServerID == 0 {
+		return 0, groupName, errors.New("no server available")
+	}
+
+	target, err := d.databaseService.GetServer(ctx, decision.ServerID)
+	if err != nil {
+		return 0, groupName, err
+	}
+
+	if err := d.libvirtService.MigrateDomain(ctx, server.LibvirtURI, target.LibvirtURI, spec.Name, live); err != nil {
+		return 0, groupName, err
+	}
+
+	// Record the new location of the domain
+	if domain != nil {
+		if err := d.databaseService.UpdateDomainServer(ctx, domain.UUID, target.ID); err != nil {
+			slog.Error("Failed to update server of domain " + spec.Name + ": " + err.Error())
+		}
+	}
+
+	member := entity.ServerGroupMember{Domain: spec.Name, ServerID: server.ID}
+	if err := d.databaseService.MoveServerGroupMember(ctx, member, target.ID); err != nil {
+		slog.Error("Failed to update server group membership of domain " + spec.Name + ": " + err.Error())
+	}
+
+	return target.ID, groupName, nil
+}
+
+// Wait blocks until the drains running in the background have stopped or the context is done.
+func (d *DrainService) Wait(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		d.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// finish marks the drain of the server as no longer running.
+func (d *DrainService) finish(serverID int) {
+	d.mu.Lock()
+	delete(d.running, serverID)
+	d.mu.Unlock()
+}
@@ -0,0 +1,285 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/sychonet/vdash-be/db/entity"
+)
+
+// Types of virtual MACs which can be generated for a failover IP.
+const (
+	MACTypeKVM    = "kvm"
+	MACTypeXen    = "xen"
+	MACTypeVMware = "vmware"
+)
+
+// Errors returned by the FailoverService.
+var (
+	ErrIPSyncRunning    = errors.New("failover IP sync is already running")
+	ErrUnmanagedIP      = errors.New("public IP is not managed by a provider")
+	ErrProviderMismatch = errors.New("server and public IP belong to different providers")
+)
+
+// FailoverDiff describes the outcome of a reconciliation of the public IPs with the failover IPs of the provider accounts. Orphaned lists the allocated IPs which disappeared from their account, which are kept until they are released.
+type FailoverDiff struct {
+	Added      []entity.IPInfo
+	Updated    []entity.IPInfo
+	Removed    []string
+	Orphaned   []string
+	Errors     []string
+	StartedAt  time.Time
+	FinishedAt time.Time
+}
+
+// failoverStore holds the servers and public IPs the FailoverService reconciles with the provider accounts. It is implemented by the DatabaseService.
+type failoverStore interface {
+	GetServers(ctx context.Context) ([]entity.ServerInfo, error)
+	GetServer(ctx context.Context, id int) (*entity.ServerInfo, error)
+	GetIPs(ctx context.Context) ([]entity.IPInfo, error)
+	GetIP(ctx context.Context, ip string) (*entity.IPInfo, error)
+	SaveIP(ctx context.Context, ip entity.IPInfo) error
+	DeletePublicIP(ctx context.Context, ip string) error
+}
+
+// FailoverService is a service that imports the failover IPs of every provider account into the public IPs, routes them to servers and manages their virtual MACs.
+type FailoverService struct {
+	databaseService failoverStore
+	providers       *Providers
+	interval        time.Duration
+
+	mu sync.Mutex
+}
+
+func NewFailoverService(databaseService *DatabaseService, providers *Providers, interval time.Duration) *FailoverService {
+	return &FailoverService{
+		databaseService: databaseService,
+		providers:       providers,
+		interval:        interval,
+	}
+}
+
+// Run reconciles the failover IPs right away and then on every interval until the context is done.
+func (f *FailoverService) Run(ctx context.Context) {
+	ticker := time.NewTicker(f.interval)
+	defer ticker.Stop()
+
+	for {
+		diff, err := f.Sync(ctx)
+		if err != nil {
+			slog.Error("Failed to sync failover IPs: " + err.Error())
+		} else {
+			slog.Info(fmt.Sprintf("Failover IPs synced: %d added, %d updated, %d removed, %d orphaned, %d errors", len(diff.Added), len(diff.Updated), len(diff.Removed), len(diff.Orphaned), len(diff.Errors)))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Sync imports the failover IPs of every provider account along with the server they are routed to and their virtual MAC. Free failover IPs which disappeared from their account are removed, allocated ones are reported as orphaned so that their domain is not left with an address nobody tracks. Public IPs added by hand and the IPs of accounts whose inventory cannot be read are left untouched.
+func (f *FailoverService) Sync(ctx context.Context) (*FailoverDiff, error) {
+	if !f.mu.TryLock() {
+		return nil, ErrIPSyncRunning
+	}
+	defer f.mu.Unlock()
+
+	diff := &FailoverDiff{StartedAt: time.Now().UTC()}
+
+	servers, err := f.databaseService.GetServers(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ips, err := f.databaseService.GetIPs(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	known := map[string]entity.IPInfo{}
+	for _, ip := range ips {
+		known[ip.IP] = ip
+	}
+
+	for _, provider := range f.providers.All() {
+		failovers, err := provider.ListFailoverIPs(ctx)
+		if err != nil {
+			diff.Errors = append(diff.Errors, fmt.Sprintf("%s: failed to list failover IPs: %v", provider.Name(), err))
+			continue
+		}
+
+		// Failover IPs are routed to the main IP of a server of the same account
+		serverIDs := map[string]int{}
+		for _, server := range servers {
+			if server.Provider == provider.Name() {
+				serverIDs[server.PublicIP] = server.ID
+			}
+		}
+
+		listed := map[string]bool{}
+		for _, failover := range failovers {
+			listed[failover.IP] = true
+			f.syncIP(ctx, provider, failover, serverIDs, known, diff)
+		}
+
+		for _, ip := range ips {
+			if ip.Provider != provider.Name() || listed[ip.IP] {
+				continue
+			}
+
+			if !ip.Available {
+				slog.Warn("Allocated failover IP " + ip.IP + " is missing from the inventory of " + provider.Name())
+				diff.Orphaned = append(diff.Orphaned, ip.IP)
+				continue
+			}
+
+			if err := f.databaseService.DeletePublicIP(ctx, ip.IP); err != nil {
+				diff.Errors = append(diff.Errors, fmt.Sprintf("%s: failed to remove failover IP %s: %v", provider.Name(), ip.IP, err))
+				continue
+			}
+
+			diff.Removed = append(diff.Removed, ip.IP)
+		}
+	}
+
+	diff.FinishedAt = time.Now().UTC()
+	return diff, nil
+}
+
+// syncIP applies the differences between a public IP and a failover IP of a provider account. New failover IPs are available for domains right away.
+func (f *FailoverService) syncIP(ctx context.Context, provider Provider, failover FailoverIP, serverIDs map[string]int, known map[string]entity.IPInfo, diff *FailoverDiff) {
+	ip, exists := known[failover.IP]
+	if exists && ip.Provider != "" && ip.Provider != provider.Name() {
+		diff.Errors = append(diff.Errors, fmt.Sprintf("%s: failover IP %s is already managed by %s", provider.Name(), failover.IP, ip.Provider))
+		return
+	}
+
+	updated := ip
+	if !exists {
+		updated = entity.IPInfo{IP: failover.IP, Available: true}
+	}
+	updated.Provider = provider.Name()
+	updated.Destination = failover.Destination
+	updated.ServerID = serverIDs[failover.Destination]
+	updated.MAC = failover.MAC
+
+	if exists && updated.Provider == ip.Provider && updated.Destination == ip.Destination && updated.ServerID == ip.ServerID && updated.MAC == ip.MAC {
+		return
+	}
+
+	updated.SyncedAt = time.Now().UTC()
+	if err := f.databaseService.SaveIP(ctx, updated); err != nil {
+		diff.Errors = append(diff.Errors, fmt.Sprintf("%s: failed to save failover IP %s: %v", provider.Name(), failover.IP, err))
+		return
+	}
+
+	if exists {
+		diff.Updated = append(diff.Updated, updated)
+	} else {
+		diff.Added = append(diff.Added, updated)
+	}
+}
+
+// Route routes a failover IP to a server of the same provider account and records the new destination.
+func (f *FailoverService) Route(ctx context.Context, address string, serverID int) (*entity.IPInfo, error) {
+	ip, manager, err := f.manager(ctx, address)
+	if err != nil {
+		return nil, err
+	}
+
+	server, err := f.databaseService.GetServer(ctx, serverID)
+	if err != nil {
+		return nil, err
+	}
+
+	if server.Provider != ip.Provider {
+		return nil, ErrProviderMismatch
+	}
+
+	if err := manager.RouteFailoverIP(ctx, ip.IP, server.PublicIP); err != nil {
+		return nil, err
+	}
+
+	ip.ServerID = server.ID
+	ip.Destination = server.PublicIP
+	ip.SyncedAt = time.Now().UTC()
+	if err := f.databaseService.SaveIP(ctx, *ip); err != nil {
+		return nil, err
+	}
+
+	return ip, nil
+}
+
+// GenerateMAC creates a virtual MAC of the given type for a failover IP and records it.
+func (f *FailoverService) GenerateMAC(ctx context.Context, address, macType string) (*entity.IPInfo, error) {
+	switch macType {
+	case MACTypeKVM, MACTypeXen, MACTypeVMware:
+	default:
+		return nil, fmt.Errorf("unknown MAC type %q", macType)
+	}
+
+	ip, manager, err := f.manager(ctx, address)
+	if err != nil {
+		return nil, err
+	}
+
+	mac, err := manager.GenerateMAC(ctx, ip.IP, macType)
+	if err != nil {
+		return nil, err
+	}
+
+	ip.MAC = mac
+	ip.SyncedAt = time.Now().UTC()
+	if err := f.databaseService.SaveIP(ctx, *ip); err != nil {
+		return nil, err
+	}
+
+	return ip, nil
+}
+
+// DeleteMAC deletes the virtual MAC of a failover IP.
+func (f *FailoverService) DeleteMAC(ctx context.Context, address string) error {
+	ip, manager, err := f.manager(ctx, address)
+	if err != nil {
+		return err
+	}
+
+	if err := manager.DeleteMAC(ctx, ip.IP); err != nil {
+		return err
+	}
+
+	ip.MAC = ""
+	ip.SyncedAt = time.Now().UTC()
+	return f.databaseService.SaveIP(ctx, *ip)
+}
+
+// manager returns a public IP along with the provider account managing it.
+func (f *FailoverService) manager(ctx context.Context, address string) (*entity.IPInfo, FailoverManager, error) {
+	ip, err := f.databaseService.GetIP(ctx, address)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if ip.Provider == "" {
+		return nil, nil, ErrUnmanagedIP
+	}
+
+	provider, ok := f.providers.Get(ip.Provider)
+	if !ok {
+		return nil, nil, fmt.Errorf("%w: unknown provider %s", ErrUnmanagedIP, ip.Provider)
+	}
+
+	manager, ok := provider.(FailoverManager)
+	if !ok {
+		return nil, nil, ErrNotSupported
+	}
+
+	return ip, manager, nil
+}
@@ -0,0 +1,345 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/sychonet/vdash-be/db/entity"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// memoryStore is a failoverStore keeping the servers and public IPs in memory.
+type memoryStore struct {
+	mu      sync.Mutex
+	servers []entity.ServerInfo
+	ips     map[string]entity.IPInfo
+}
+
+func newMemoryStore(servers []entity.ServerInfo, ips ...entity.IPInfo) *memoryStore {
+	store := &memoryStore{servers: servers, ips: map[string]entity.IPInfo{}}
+	for _, ip := range ips {
+		store.ips[ip.IP] = ip
+	}
+
+	return store
+}
+
+func (m *memoryStore) GetServers(ctx context.Context) ([]entity.ServerInfo, error) {
+	return m.servers, nil
+}
+
+func (m *memoryStore) GetServer(ctx context.Context, id int) (*entity.ServerInfo, error) {
+	for _, server := range m.servers {
+		if server.ID == id {
+			return &server, nil
+		}
+	}
+
+	return nil, mongo.ErrNoDocuments
+}
+
+func (m *memoryStore) GetIPs(ctx context.Context) ([]entity.IPInfo, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var ips []entity.IPInfo
+	for _, ip := range m.ips {
+		ips = append(ips, ip)
+	}
+
+	return ips, nil
+}
+
+func (m *memoryStore) GetIP(ctx context.Context, address string) (*entity.IPInfo, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	ip, ok := m.ips[address]
+	if !ok {
+		return nil, mongo.ErrNoDocuments
+	}
+
+	return &ip, nil
+}
+
+func (m *memoryStore) SaveIP(ctx context.Context, ip entity.IPInfo) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.ips[ip.IP] = ip
+	return nil
+}
+
+func (m *memoryStore) DeletePublicIP(ctx context.Context, address string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.ips, address)
+	return nil
+}
+
+// newTestFailoverService returns a failover service reconciling the store with the providers.
+func newTestFailoverService(t *testing.T, store *memoryStore, providers ...Provider) *FailoverService {
+	t.Helper()
+
+	all, err := NewProviders(providers...)
+	if err != nil {
+		t.Fatalf("NewProviders() error = %v", err)
+	}
+
+	return &FailoverService{databaseService: store, providers: all}
+}
+
+// newTestHetzner returns a Hetzner provider using a test server serving handler as its Robot webservice.
+func newTestHetzner(t *testing.T, handler http.HandlerFunc) *HetznerService {
+	t.Helper()
+
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	return NewHetznerService("hetzner", server.URL, "robot", "secret", "", "", APIOptions{})
+}
+
+func TestFailoverSyncImportsFailoverIPs(t *testing.T) {
+	scaleway := newTestScaleway(t, "", func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/server/failover":
+			writeJSON(t, w, []map[string]string{
+				{"source": "198.51.100.1", "destination": "192.0.2.10"},
+				{"source": "198.51.100.2", "destination": "192.0.2.10"},
+			})
+		case "/api/v1/server/ip/198.51.100.1":
+			writeJSON(t, w, map[string]string{"address": "198.51.100.1", "mac": "52:54:00:00:00:01"})
+		case "/api/v1/server/ip/198.51.100.2":
+			writeJSON(t, w, map[string]string{"address": "198.51.100.2"})
+		default:
+			http.NotFound(w, r)
+		}
+	})
+
+	hetzner := newTestHetzner(t, func(w http.ResponseWriter, r *http.Request) {
+		if user, password, ok := r.BasicAuth(); !ok || user != "robot" || password != "secret" {
+			t.Errorf("basic auth = %q, %q, want the configured credentials", user, password)
+		}
+
+		writeJSON(t, w, []map[string]any{{"failover": map[string]any{"ip": "203.0.113.7", "active_server_ip": "192.0.2.20"}}})
+	})
+
+	store := newMemoryStore(
+		[]entity.ServerInfo{
+			{ID: 1, Provider: "scaleway", PublicIP: "192.0.2.10"},
+			{ID: 2, Provider: "hetzner", PublicIP: "192.0.2.20"},
+		},
+		entity.IPInfo{IP: "198.51.100.2", Provider: "scaleway", Destination: "192.0.2.99", Available: false, Project: "team-a"},
+		entity.IPInfo{IP: "198.51.100.9", Provider: "scaleway", Destination: "192.0.2.10", Available: true},
+		entity.IPInfo{IP: "198.51.100.8", Provider: "scaleway", Destination: "192.0.2.10", ServerID: 1, Project: "team-b"},
+		entity.IPInfo{IP: "203.0.113.50", ServerID: 2, Available: true},
+	)
+
+	diff, err := newTestFailoverService(t, store, scaleway, hetzner).Sync(context.Background())
+	if err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+
+	if len(diff.Errors) > 0 {
+		t.Errorf("Sync() errors = %v, want none", diff.Errors)
+	}
+
+	var added []string
+	for _, ip := range diff.Added {
+		added = append(added, ip.IP)
+	}
+	slices.Sort(added)
+	if !slices.Equal(added, []string{"198.51.100.1", "203.0.113.7"}) {
+		t.Errorf("added = %v, want 198.51.100.1 and 203.0.113.7", added)
+	}
+
+	if len(diff.Updated) != 1 || diff.Updated[0].IP != "198.51.100.2" {
+		t.Errorf("updated = %+v, want 198.51.100.2", diff.Updated)
+	}
+
+	if !slices.Equal(diff.Removed, []string{"198.51.100.9"}) {
+		t.Errorf("removed = %v, want 198.51.100.9", diff.Removed)
+	}
+
+	if !slices.Equal(diff.Orphaned, []string{"198.51.100.8"}) {
+		t.Errorf("orphaned = %v, want the allocated 198.51.100.8", diff.Orphaned)
+	}
+
+	want := map[string]entity.IPInfo{
+		"198.51.100.1": {IP: "198.51.100.1", Provider: "scaleway", Destination: "192.0.2.10", ServerID: 1, MAC: "52:54:00:00:00:01", Available: true},
+		"198.51.100.2": {IP: "198.51.100.2", Provider: "scaleway", Destination: "192.0.2.10", ServerID: 1, Project: "team-a"},
+		"203.0.113.7":  {IP: "203.0.113.7", Provider: "hetzner", Destination: "192.0.2.20", ServerID: 2, Available: true},
+		"203.0.113.50": {IP: "203.0.113.50", ServerID: 2, Available: true},
+		"198.51.100.8": {IP: "198.51.100.8", Provider: "scaleway", Destination: "192.0.2.10", ServerID: 1, Project: "team-b"},
+	}
+	if len(store.ips) != len(want) {
+		t.Errorf("stored IPs = %+v, want %+v", store.ips, want)
+	}
+
+	for address, wantIP := range want {
+		ip := store.ips[address]
+		ip.SyncedAt = wantIP.SyncedAt
+		if ip != wantIP {
+			t.Errorf("stored IP %s = %+v, want %+v", address, ip, wantIP)
+		}
+	}
+}
+
+func TestFailoverSyncKeepsIPsOfUnreadableAccounts(t *testing.T) {
+	scaleway := newTestScaleway(t, "", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"error":"Access denied","code":7}`, http.StatusForbidden)
+	})
+
+	store := newMemoryStore(nil, entity.IPInfo{IP: "198.51.100.1", Provider: "scaleway", Available: true})
+
+	diff, err := newTestFailoverService(t, store, scaleway).Sync(context.Background())
+	if err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+
+	if len(diff.Errors) != 1 || !strings.Contains(diff.Errors[0], "Access denied") {
+		t.Errorf("Sync() errors = %v, want the error of the account", diff.Errors)
+	}
+
+	if _, ok := store.ips["198.51.100.1"]; !ok || len(diff.Removed) > 0 {
+		t.Errorf("failover IP of the unreadable account was removed")
+	}
+}
+
+func TestFailoverRoute(t *testing.T) {
+	var source, destination string
+	scaleway := newTestScaleway(t, "", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/api/v1/server/failover/edit" {
+			t.Errorf("request = %s %s, want POST /api/v1/server/failover/edit", r.Method, r.URL.Path)
+		}
+
+		source = r.PostFormValue("source")
+		destination = r.PostFormValue("destination")
+	})
+
+	store := newMemoryStore(
+		[]entity.ServerInfo{{ID: 1, Provider: "scaleway", PublicIP: "192.0.2.10"}, {ID: 2, Provider: "scaleway", PublicIP: "192.0.2.20"}},
+		entity.IPInfo{IP: "198.51.100.1", Provider: "scaleway", Destination: "192.0.2.10", ServerID: 1},
+	)
+
+	ip, err := newTestFailoverService(t, store, scaleway).Route(context.Background(), "198.51.100.1", 2)
+	if err != nil {
+		t.Fatalf("Route() error = %v", err)
+	}
+
+	if source != "198.51.100.1" || destination != "192.0.2.20" {
+		t.Errorf("routed %q to %q, want 198.51.100.1 to 192.0.2.20", source, destination)
+	}
+
+	if ip.ServerID != 2 || ip.Destination != "192.0.2.20" || store.ips["198.51.100.1"].ServerID != 2 {
+		t.Errorf("Route() = %+v, want the IP recorded on server 2", ip)
+	}
+}
+
+func TestFailoverRouteToServerOfAnotherProvider(t *testing.T) {
+	scaleway := newTestScaleway(t, "", func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+	})
+
+	store := newMemoryStore(
+		[]entity.ServerInfo{{ID: 3, Provider: "hetzner", PublicIP: "192.0.2.30"}},
+		entity.IPInfo{IP: "198.51.100.1", Provider: "scaleway"},
+	)
+
+	if _, err := newTestFailoverService(t, store, scaleway).Route(context.Background(), "198.51.100.1", 3); !errors.Is(err, ErrProviderMismatch) {
+		t.Errorf("Route() error = %v, want ErrProviderMismatch", err)
+	}
+}
+
+func TestFailoverMAC(t *testing.T) {
+	var requests []string
+	scaleway := newTestScaleway(t, "", func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.URL.Path+" "+r.PostFormValue("address")+" "+r.PostFormValue("type"))
+		if r.URL.Path == "/api/v1/server/failover/generateMac" {
+			writeJSON(t, w, "52:54:00:00:00:02")
+		}
+	})
+
+	store := newMemoryStore(nil, entity.IPInfo{IP: "198.51.100.1", Provider: "scaleway"})
+	failover := newTestFailoverService(t, store, scaleway)
+
+	ip, err := failover.GenerateMAC(context.Background(), "198.51.100.1", MACTypeKVM)
+	if err != nil {
+		t.Fatalf("GenerateMAC() error = %v", err)
+	}
+
+	if ip.MAC != "52:54:00:00:00:02" || store.ips["198.51.100.1"].MAC != "52:54:00:00:00:02" {
+		t.Errorf("GenerateMAC() = %+v, want the generated MAC recorded", ip)
+	}
+
+	if err := failover.DeleteMAC(context.Background(), "198.51.100.1"); err != nil {
+		t.Fatalf("DeleteMAC() error = %v", err)
+	}
+
+	if store.ips["198.51.100.1"].MAC != "" {
+		t.Errorf("MAC = %q after DeleteMAC(), want none", store.ips["198.51.100.1"].MAC)
+	}
+
+	want := []string{
+		"/api/v1/server/failover/generateMac 198.51.100.1 kvm",
+		"/api/v1/server/failover/deleteMac 198.51.100.1 ",
+	}
+	if !slices.Equal(requests, want) {
+		t.Errorf("requests = %q, want %q", requests, want)
+	}
+}
+
+func TestFailoverErrors(t *testing.T) {
+	scaleway := newTestScaleway(t, "", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"error":"Failover IP not found","code":404}`, http.StatusNotFound)
+	})
+
+	hetzner := newTestHetzner(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+	})
+
+	store := newMemoryStore(
+		[]entity.ServerInfo{{ID: 1, Provider: "scaleway", PublicIP: "192.0.2.10"}},
+		entity.IPInfo{IP: "198.51.100.1", Provider: "scaleway", MAC: "52:54:00:00:00:01"},
+		entity.IPInfo{IP: "203.0.113.7", Provider: "hetzner"},
+		entity.IPInfo{IP: "203.0.113.50"},
+	)
+	failover := newTestFailoverService(t, store, scaleway, hetzner)
+	ctx := context.Background()
+
+	var apiErr *APIError
+	if _, err := failover.Route(ctx, "198.51.100.1", 1); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound || apiErr.Message != "Failover IP not found" {
+		t.Errorf("Route() error = %v, want the 404 APIError of the provider", err)
+	}
+
+	if err := failover.DeleteMAC(ctx, "198.51.100.1"); !errors.As(err, &apiErr) {
+		t.Errorf("DeleteMAC() error = %v, want the APIError of the provider", err)
+	}
+
+	if store.ips["198.51.100.1"].MAC == "" {
+		t.Errorf("MAC was removed although the provider refused to delete it")
+	}
+
+	if _, err := failover.GenerateMAC(ctx, "203.0.113.7", MACTypeKVM); !errors.Is(err, ErrNotSupported) {
+		t.Errorf("GenerateMAC() of a Hetzner IP error = %v, want ErrNotSupported", err)
+	}
+
+	if _, err := failover.GenerateMAC(ctx, "203.0.113.50", MACTypeKVM); !errors.Is(err, ErrUnmanagedIP) {
+		t.Errorf("GenerateMAC() of an IP added by hand error = %v, want ErrUnmanagedIP", err)
+	}
+
+	if _, err := failover.GenerateMAC(ctx, "198.51.100.1", "qemu"); err == nil {
+		t.Errorf("GenerateMAC() of an unknown type succeeded")
+	}
+
+	if _, err := failover.Route(ctx, "192.0.2.254", 1); !errors.Is(err, mongo.ErrNoDocuments) {
+		t.Errorf("Route() of an unknown IP error = %v, want mongo.ErrNoDocuments", err)
+	}
+}
@@ -0,0 +1,162 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/sychonet/vdash-be/db/entity"
+)
+
+// Health statuses of a server. A server is unknown until it has been checked successfully or has failed enough consecutive checks.
+const (
+	ServerStatusUnknown   = "unknown"
+	ServerStatusHealthy   = "healthy"
+	ServerStatusUnhealthy = "unhealthy"
+)
+
+// HealthService is a service that periodically probes the libvirt connection of every server and records its health in the database.
+type HealthService struct {
+	databaseService  *DatabaseService
+	libvirtService   *LibvirtService
+	powerService     *PowerService
+	interval         time.Duration
+	failureThreshold int
+	powerCycleAfter  time.Duration
+}
+
+// NewHealthService returns a HealthService. If powerCycleAfter is positive, servers which have been unreachable for that long are rebooted through their provider, at most once per powerCycleAfter.
+func NewHealthService(databaseService *DatabaseService, libvirtService *LibvirtService, powerService *PowerService, interval time.Duration, failureThreshold int, powerCycleAfter time.Duration) *HealthService {
+	if failureThreshold <= 0 {
+		failureThreshold = 1
+	}
+
+	return &HealthService{
+		databaseService:  databaseService,
+		libvirtService:   libvirtService,
+		powerService:     powerService,
+		interval:         interval,
+		failureThreshold: failureThreshold,
+		powerCycleAfter:  powerCycleAfter,
+	}
+}
+
+// Run checks the health of every server right away and then on every interval until the context is done.
+func (h *HealthService) Run(ctx context.Context) {
+	ticker := time.NewTicker(h.interval)
+	defer ticker.Stop()
+
+	for {
+		h.CheckServers(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// CheckServers probes every server in parallel and records the outcome.
+func (h *HealthService) CheckServers(ctx context.Context) {
+	servers, err := h.databaseService.GetServers(ctx)
+	if err != nil {
+		slog.Error("Failed to get servers for health check: " + err.Error())
+		return
+	}
+
+	var wg sync.WaitGroup
+	for _, server := range servers {
+		wg.Add(1)
+		go func(server entity.ServerInfo) {
+			defer wg.Done()
+			h.checkServer(ctx, server)
+		}(server)
+	}
+
+	wg.Wait()
+}
+
+// checkServer probes a single server, updates its health and records an event if its status changed. Nothing is recorded if the context is done, as the probe did not get to reach the server.
+func (h *HealthService) checkServer(ctx context.Context, server entity.ServerInfo) {
+	health := server.Health
+	if health.Status == "" {
+		health.Status = ServerStatusUnknown
+	}
+	previousStatus := health.Status
+
+	now := time.Now().UTC()
+	health.LastChecked = now
+
+	version, err := h.libvirtService.ProbeNode(ctx, server.LibvirtURI)
+	if ctx.Err() != nil {
+		// The probe was cut short by the shutdown, which says nothing about the server
+		return
+	}
+
+	if err != nil {
+		health.Failures++
+		health.Error = err.Error()
+		if health.Failures >= h.failureThreshold {
+			health.Status = ServerStatusUnhealthy
+		}
+	} else {
+		health.Failures = 0
+		health.Error = ""
+		health.LastSeen = now
+		health.LibvirtVersion = version
+		health.Status = ServerStatusHealthy
+	}
+
+	if h.shouldPowerCycle(server, health, now) {
+		health.LastPowerCycle = now
+		reason := "unreachable since " + unreachableSince(health).Format(time.RFC3339)
+		if _, err := h.powerService.Power(ctx, server.ID, PowerReboot, reason, true); err != nil {
+			slog.Error("Failed to power cycle server " + server.Hostname + ": " + err.Error())
+		}
+	}
+
+	if health.Status != previousStatus {
+		health.StatusChangedAt = now
+
+		slog.Info("Server " + server.Hostname + " is now " + health.Status)
+		event := entity.ServerEvent{
+			ServerID: server.ID,
+			Time:     now,
+			From:     previousStatus,
+			To:       health.Status,
+			Error:    health.Error,
+		}
+		if err := h.databaseService.AddServerEvent(ctx, event); err != nil {
+			slog.Error("Failed to record status change of server " + server.Hostname + ": " + err.Error())
+		}
+	}
+
+	if err := h.databaseService.UpdateServerHealth(ctx, server.ID, health); err != nil {
+		slog.Error("Failed to record health of server " + server.Hostname + ": " + err.Error())
+	}
+}
+
+// shouldPowerCycle reports whether an unhealthy server has been unreachable long enough to be rebooted automatically. Cordoned servers are left alone since they may be under maintenance.
+func (h *HealthService) shouldPowerCycle(server entity.ServerInfo, health entity.ServerHealth, now time.Time) bool {
+	if h.powerCycleAfter <= 0 || health.Status != ServerStatusUnhealthy || server.Cordoned || server.Missing || server.Provider == "" {
+		return false
+	}
+
+	since := unreachableSince(health)
+	if health.LastPowerCycle.After(since) {
+		since = health.LastPowerCycle
+	}
+
+	return !since.IsZero() && now.Sub(since) >= h.powerCycleAfter
+}
+
+// unreachableSince returns the time since which a server has not been reachable.
+func unreachableSince(health entity.ServerHealth) time.Time {
+	if !health.LastSeen.IsZero() {
+		return health.LastSeen
+	}
+
+	return health.StatusChangedAt
+}
@@ -0,0 +1,161 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/sychonet/vdash-be/dto/response"
+)
+
+// Defaults of the Hetzner provider used when the configuration leaves them unset.
+const (
+	hetznerDefaultBaseURL  = "https://robot-ws.your-server.de"
+	hetznerDefaultRescueOS = "linux"
+)
+
+// HetznerService is a provider for a Hetzner dedicated server account using the Robot webservice.
+type HetznerService struct {
+	name           string
+	hostnamePrefix string
+	rescueOS       string
+	client         *apiClient
+}
+
+func NewHetznerService(name, baseURL, username, password, hostnamePrefix, rescueOS string, options APIOptions) *HetznerService {
+	if baseURL == "" {
+		baseURL = hetznerDefaultBaseURL
+	}
+
+	if rescueOS == "" {
+		rescueOS = hetznerDefaultRescueOS
+	}
+
+	authorize := func(req *http.Request) {
+		req.SetBasicAuth(username, password)
+	}
+
+	// The webservice describes errors as {"error": {"status": ..., "code": "...", "message": "..."}}
+	decodeError := func(data []byte, apiErr *APIError) {
+		var body struct {
+			Error struct {
+				Code    string `json:"code"`
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		if json.Unmarshal(data, &body) == nil {
+			apiErr.Code = body.Error.Code
+			apiErr.Message = body.Error.Message
+		}
+	}
+
+	return &HetznerService{
+		name:           name,
+		hostnamePrefix: hostnamePrefix,
+		rescueOS:       rescueOS,
+		client:         newAPIClient(name, baseURL, options, authorize, decodeError),
+	}
+}
+
+func (h *HetznerService) Name() string {
+	return h.name
+}
+
+func (h *HetznerService) HostnamePrefix() string {
+	return h.hostnamePrefix
+}
+
+// ListServers returns every server of the account using GET /server.
+func (h *HetznerService) ListServers(ctx context.Context) ([]ProviderServer, error) {
+	var details []response.HetznerServerResponse
+	if err := h.client.do(ctx, http.MethodGet, "/server", nil, &details); err != nil {
+		return nil, err
+	}
+
+	servers := make([]ProviderServer, 0, len(details))
+	for _, server := range details {
+		servers = append(servers, newHetznerProviderServer(server))
+	}
+
+	return servers, nil
+}
+
+// GetServer returns a server of the account using GET /server/{server_number}.
+func (h *HetznerService) GetServer(ctx context.Context, id int) (*ProviderServer, error) {
+	var details response.HetznerServerResponse
+	if err := h.client.do(ctx, http.MethodGet, fmt.Sprintf("/server/%d", id), nil, &details); err != nil {
+		return nil, err
+	}
+
+	server := newHetznerProviderServer(details)
+	return &server, nil
+}
+
+// ListFailoverIPs returns the failover IPs of the account along with the server they are routed to using GET /failover.
+func (h *HetznerService) ListFailoverIPs(ctx context.Context) ([]FailoverIP, error) {
+	var failovers []response.HetznerFailoverResponse
+	if err := h.client.do(ctx, http.MethodGet, "/failover", nil, &failovers); err != nil {
+		return nil, err
+	}
+
+	ips := make([]FailoverIP, 0, len(failovers))
+	for _, failover := range failovers {
+		ips = append(ips, FailoverIP{IP: failover.Failover.IP, Destination: failover.Failover.ActiveServerIP})
+	}
+
+	return ips, nil
+}
+
+// RouteFailoverIP routes a failover IP to the server with the given main IP using POST /failover/{ip}.
+func (h *HetznerService) RouteFailoverIP(ctx context.Context, ip, destination string) error {
+	form := url.Values{"active_server_ip": {destination}}
+	return h.client.do(ctx, http.MethodPost, "/failover/"+ip, form, nil)
+}
+
+// GenerateMAC is not supported, virtual MACs of Hetzner failover IPs are not managed through the webservice.
+func (h *HetznerService) GenerateMAC(ctx context.Context, ip, macType string) (string, error) {
+	return "", ErrNotSupported
+}
+
+// DeleteMAC is not supported, virtual MACs of Hetzner failover IPs are not managed through the webservice.
+func (h *HetznerService) DeleteMAC(ctx context.Context, ip string) error {
+	return ErrNotSupported
+}
+
+// Power boots a server with Wake on LAN, resets it, presses its power button or activates the rescue system and resets it.
+func (h *HetznerService) Power(ctx context.Context, id int, action string) error {
+	if err := ValidatePowerAction(action); err != nil {
+		return err
+	}
+
+	switch action {
+	case PowerBoot:
+		return h.client.do(ctx, http.MethodPost, fmt.Sprintf("/wol/%d", id), url.Values{}, nil)
+	case PowerShutdown:
+		return h.reset(ctx, id, "power")
+	case PowerRescue:
+		form := url.Values{"os": {h.rescueOS}}
+		if err := h.client.do(ctx, http.MethodPost, fmt.Sprintf("/boot/%d/rescue", id), form, nil); err != nil {
+			return err
+		}
+	}
+
+	return h.reset(ctx, id, "hw")
+}
+
+// reset sends a reset of the given type to a server using POST /reset/{server_number}.
+func (h *HetznerService) reset(ctx context.Context, id int, resetType string) error {
+	return h.client.do(ctx, http.MethodPost, fmt.Sprintf("/reset/%d", id), url.Values{"type": {resetType}}, nil)
+}
+
+// newHetznerProviderServer converts a Hetzner server into a provider server.
+func newHetznerProviderServer(details response.HetznerServerResponse) ProviderServer {
+	return ProviderServer{
+		ID:         details.Server.ServerNumber,
+		Hostname:   details.Server.ServerName,
+		PublicIP:   details.Server.ServerIP,
+		Datacenter: details.Server.DC,
+	}
+}
@@ -0,0 +1,113 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/sychonet/vdash-be/db/entity"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// MaxIdempotencyKey is the longest Idempotency-Key accepted.
+const MaxIdempotencyKey = 255
+
+// idempotencyLockTimeout is how long a reserved key blocks its retries if the request handling it never completes, for instance because the server stopped.
+const idempotencyLockTimeout = 10 * time.Minute
+
+var (
+	// ErrIdempotencyKeyReused is returned when an idempotency key is sent again with a different request.
+	ErrIdempotencyKeyReused = errors.New("idempotency key was already used with a different request")
+	// ErrIdempotencyInProgress is returned when an idempotency key is sent again while its first request is still being handled.
+	ErrIdempotencyInProgress = errors.New("a request with this idempotency key is still in progress")
+)
+
+// IdempotencyService is a service that keeps the responses to requests made with an Idempotency-Key header, so that retries of a request get its first response instead of being handled again. Keys are scoped to their caller.
+type IdempotencyService struct {
+	databaseService *DatabaseService
+	ttl             time.Duration
+}
+
+func NewIdempotencyService(databaseService *DatabaseService, ttl time.Duration) *IdempotencyService {
+	return &IdempotencyService{databaseService: databaseService, ttl: ttl}
+}
+
+// Begin reserves the key of a request. It returns the new record if the key is unknown and the completed record of the first request if the request is a retry, in which case its response must be replayed. A key sent with another method, path, project or body returns ErrIdempotencyKeyReused.
+func (s *IdempotencyService) Begin(ctx context.Context, actor, key, method, path, project string, body []byte) (*entity.IdempotencyRecord, error) {
+	now := time.Now().UTC()
+	record := entity.IdempotencyRecord{
+		ID:        idempotencyID(actor, key),
+		Actor:     actor,
+		Key:       key,
+		Method:    method,
+		Path:      path,
+		Project:   project,
+		BodyHash:  hashBody(body),
+		CreatedAt: now,
+		ExpiresAt: now.Add(idempotencyLockTimeout),
+	}
+
+	// Records are removed by a TTL index which runs about every minute, so an expired record may still be found once
+	for attempt := 0; attempt < 2; attempt++ {
+		err := s.databaseService.AddIdempotencyRecord(ctx, record)
+		if err == nil {
+			return &record, nil
+		}
+
+		if !mongo.IsDuplicateKeyError(err) {
+			return nil, err
+		}
+
+		existing, err := s.databaseService.GetIdempotencyRecord(ctx, record.ID)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			continue
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		if existing.ExpiresAt.Before(now) {
+			if err := s.databaseService.DeleteIdempotencyRecord(ctx, existing.ID); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		if existing.Method != record.Method || existing.Path != record.Path || existing.Project != record.Project || existing.BodyHash != record.BodyHash {
+			return nil, ErrIdempotencyKeyReused
+		}
+
+		if !existing.Completed {
+			return nil, ErrIdempotencyInProgress
+		}
+
+		return existing, nil
+	}
+
+	return nil, ErrIdempotencyInProgress
+}
+
+// Complete stores the response to the request of a reserved record so that it is replayed to the retries of the request.
+func (s *IdempotencyService) Complete(ctx context.Context, record *entity.IdempotencyRecord, status int, contentType string, body []byte) error {
+	return s.databaseService.CompleteIdempotencyRecord(ctx, record.ID, status, contentType, body, time.Now().UTC().Add(s.ttl))
+}
+
+// Release frees the key of a reserved record whose response is not kept, so that the request can be retried.
+func (s *IdempotencyService) Release(ctx context.Context, record *entity.IdempotencyRecord) error {
+	return s.databaseService.DeleteIdempotencyRecord(ctx, record.ID)
+}
+
+// idempotencyID returns the id of the record of a key, which scopes the key to its caller.
+func idempotencyID(actor, key string) string {
+	sum := sha256.Sum256([]byte(actor + "\n" + key))
+	return hex.EncodeToString(sum[:])
+}
+
+// hashBody returns the hash used to tell whether a retry sends the same body as the first request.
+func hashBody(body []byte) string {
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,128 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// inventoryFile is the format of a static inventory file. It can be written in JSON or YAML.
+type inventoryFile struct {
+	Servers []struct {
+		ID         int    `json:"id" yaml:"id"`
+		Hostname   string `json:"hostname" yaml:"hostname"`
+		PublicIP   string `json:"publicIP" yaml:"publicIP"`
+		Datacenter string `json:"datacenter" yaml:"datacenter"`
+	} `json:"servers" yaml:"servers"`
+	FailoverIPs []struct {
+		IP          string `json:"ip" yaml:"ip"`
+		Destination string `json:"destination" yaml:"destination"`
+		MAC         string `json:"mac" yaml:"mac"`
+	} `json:"failoverIPs" yaml:"failoverIPs"`
+}
+
+// InventoryFileService is a provider for servers listed in a static JSON or YAML file, for fleets without a provider API. The file is read on every call so that changes are picked up without a restart. Power actions are not supported.
+type InventoryFileService struct {
+	name           string
+	path           string
+	hostnamePrefix string
+}
+
+func NewInventoryFileService(name, path, hostnamePrefix string) *InventoryFileService {
+	return &InventoryFileService{
+		name:           name,
+		path:           path,
+		hostnamePrefix: hostnamePrefix,
+	}
+}
+
+func (i *InventoryFileService) Name() string {
+	return i.name
+}
+
+func (i *InventoryFileService) HostnamePrefix() string {
+	return i.hostnamePrefix
+}
+
+// ListServers returns every server listed in the file.
+func (i *InventoryFileService) ListServers(ctx context.Context) ([]ProviderServer, error) {
+	inventory, err := i.read()
+	if err != nil {
+		return nil, err
+	}
+
+	servers := make([]ProviderServer, 0, len(inventory.Servers))
+	for _, server := range inventory.Servers {
+		servers = append(servers, ProviderServer{
+			ID:         server.ID,
+			Hostname:   server.Hostname,
+			PublicIP:   server.PublicIP,
+			Datacenter: server.Datacenter,
+		})
+	}
+
+	return servers, nil
+}
+
+// GetServer returns a server listed in the file.
+func (i *InventoryFileService) GetServer(ctx context.Context, id int) (*ProviderServer, error) {
+	servers, err := i.ListServers(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, server := range servers {
+		if server.ID == id {
+			return &server, nil
+		}
+	}
+
+	return nil, fmt.Errorf("server %d not found in %s", id, i.path)
+}
+
+// ListFailoverIPs returns every failover IP listed in the file.
+func (i *InventoryFileService) ListFailoverIPs(ctx context.Context) ([]FailoverIP, error) {
+	inventory, err := i.read()
+	if err != nil {
+		return nil, err
+	}
+
+	ips := make([]FailoverIP, 0, len(inventory.FailoverIPs))
+	for _, ip := range inventory.FailoverIPs {
+		ips = append(ips, FailoverIP{IP: ip.IP, Destination: ip.Destination, MAC: ip.MAC})
+	}
+
+	return ips, nil
+}
+
+// Power is not supported for servers listed in a file.
+func (i *InventoryFileService) Power(ctx context.Context, id int, action string) error {
+	return ErrNotSupported
+}
+
+// read parses the inventory file as YAML or JSON depending on its extension.
+func (i *InventoryFileService) read() (*inventoryFile, error) {
+	data, err := os.ReadFile(i.path)
+	if err != nil {
+		return nil, err
+	}
+
+	var inventory inventoryFile
+	switch strings.ToLower(filepath.Ext(i.path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &inventory)
+	default:
+		err = json.Unmarshal(data, &inventory)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse inventory file %s: %w", i.path, err)
+	}
+
+	return &inventory, nil
+}
@@ -0,0 +1,209 @@
+package service
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	// jwksRefreshInterval is how long the keys of a JWKS endpoint are used before they are fetched again.
+	jwksRefreshInterval = time.Hour
+	// jwksMinRefreshInterval keeps tokens signed with unknown keys from causing a fetch on every request.
+	jwksMinRefreshInterval = time.Minute
+)
+
+// jsonWebKey represents a public key published at a JWKS endpoint.
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// jwksCache keeps the public keys of a JWKS endpoint. The keys are fetched again when they are older than jwksRefreshInterval or when a token is signed with an unknown key, so that rotated keys are picked up.
+type jwksCache struct {
+	url    string
+	client *http.Client
+
+	mu        sync.Mutex
+	keys      map[string]any
+	fetchedAt time.Time
+}
+
+func newJWKSCache(url string) *jwksCache {
+	return &jwksCache{
+		url:    url,
+		client: &http.Client{Timeout: 10 * time.Second},
+		keys:   map[string]any{},
+	}
+}
+
+// key returns the public key with the given id. A token without a key id is accepted if the endpoint publishes a single key.
+func (j *jwksCache) key(ctx context.Context, kid string) (any, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	key, ok := j.lookup(kid)
+	if ok && time.Since(j.fetchedAt) < jwksRefreshInterval {
+		return key, nil
+	}
+
+	if time.Since(j.fetchedAt) >= jwksMinRefreshInterval {
+		if err := j.fetch(ctx); err != nil {
+			slog.Error("Failed to fetch JWKS: " + err.Error())
+			// Keep using the keys fetched before if the endpoint is unavailable
+			if ok {
+				return key, nil
+			}
+			return nil, err
+		}
+		key, ok = j.lookup(kid)
+	}
+
+	if !ok {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+
+	return key, nil
+}
+
+// lookup returns the cached key with the given id.
+func (j *jwksCache) lookup(kid string) (any, bool) {
+	if kid == "" && len(j.keys) == 1 {
+		for _, key := range j.keys {
+			return key, true
+		}
+	}
+
+	key, ok := j.keys[kid]
+	return key, ok
+}
+
+// fetch replaces the cached keys with the signing keys published at the endpoint. Keys of an unsupported type are skipped.
+func (j *jwksCache) fetch(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, j.url, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := j.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	var set struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&set); err != nil {
+		return err
+	}
+
+	keys := map[string]any{}
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+
+		key, err := jwk.publicKey()
+		if err != nil {
+			slog.Warn("Skipping JWKS key " + jwk.Kid + ": " + err.Error())
+			continue
+		}
+		keys[jwk.Kid] = key
+	}
+
+	j.keys = keys
+	j.fetchedAt = time.Now()
+
+	return nil
+}
+
+// publicKey converts the JSON web key into an RSA, ECDSA or Ed25519 public key.
+func (k jsonWebKey) publicKey() (any, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	case "OKP":
+		if k.Crv != "Ed25519" {
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid Ed25519 key size")
+		}
+
+		return ed25519.PublicKey(x), nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+	}
+}
+
+// decodeBigInt decodes a base64url encoded big-endian integer.
+func decodeBigInt(value string) (*big.Int, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, err
+	}
+
+	return new(big.Int).SetBytes(data), nil
+}
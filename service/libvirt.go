@@ -1,27 +1,44 @@
 package service
 
 import (
+	"context"
 	"fmt"
 	"log/slog"
 	"sync"
+	"time"
 
 	"libvirt.org/go/libvirt"
 )
 
+// shutdownTimeout is how long a guest is given to shut down before its domain is destroyed.
+const shutdownTimeout = 2 * time.Minute
+
 type LibvirtService struct {
 	URI string
 }
 
 type PoolCheckResult struct {
-	LibvirtURI string
-	PoolName   string
-	HasSpace   bool
-	Error      error
+	LibvirtURI     string
+	PoolName       string
+	HasSpace       bool
+	AvailableSpace uint64
+	Error          error
+}
+
+// DomainSpec represents the resources of a domain defined on a libvirt host. Memory is in MiB.
+type DomainSpec struct {
+	UUID   string
+	Name   string
+	Active bool
+	Memory uint64
+	VCPU   uint
 }
 
 type ResourceCheckResult struct {
 	LibvirtURI   string
 	HasResources bool
+	FreeMemory   uint64
+	FreeVCPU     uint
 	Error        error
 }
 
@@ -29,10 +46,24 @@ func NewLibvirtService(uri string) *LibvirtService {
 	return &LibvirtService{URI: uri}
 }
 
+// Connect returns a service acting on the libvirt host with the given URI. Handlers serving concurrent requests take their own service this way rather than changing the URI of the shared one, which would let a request act on the host of another.
+func (l *LibvirtService) Connect(uri string) *LibvirtService {
+	return &LibvirtService{URI: uri}
+}
+
+// connect opens a connection to the libvirt host with the given URI unless the context is done. Calls to libvirt cannot be interrupted, the context is therefore checked before every connection so that abandoned requests stop before reaching the next host.
+func connect(ctx context.Context, libvirtURI string) (*libvirt.Connect, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	return libvirt.NewConnect(libvirtURI)
+}
+
 // CreateStoragePool creates a new storage pool on a libvirt host.
-func (l *LibvirtService) CreateStoragePool(name, path string) error {
+func (l *LibvirtService) CreateStoragePool(ctx context.Context, name, path string) error {
 	// Connect to libvirtd
-	conn, err := libvirt.NewConnect(l.URI)
+	conn, err := connect(ctx, l.URI)
 	if err != nil {
 		slog.Error("Failed to connect to libvirt: " + err.Error())
 		return err
@@ -66,9 +97,9 @@ func (l *LibvirtService) CreateStoragePool(name, path string) error {
 }
 
 // GetStoragePools returns a list of all storage pools on a libvirt host.
-func (l *LibvirtService) GetStoragePools() ([]libvirt.StoragePool, error) {
+func (l *LibvirtService) GetStoragePools(ctx context.Context) ([]libvirt.StoragePool, error) {
 	// Connect to libvirtd
-	conn, err := libvirt.NewConnect(l.URI)
+	conn, err := connect(ctx, l.URI)
 	if err != nil {
 		slog.Error("Failed to connect to libvirt: " + err.Error())
 		return nil, err
@@ -90,9 +121,9 @@ func (l *LibvirtService) GetStoragePools() ([]libvirt.StoragePool, error) {
 	return pools, nil
 }
 
-func (l *LibvirtService) DeleteStoragePool(name string) error {
+func (l *LibvirtService) DeleteStoragePool(ctx context.Context, name string) error {
 	// Connect to libvirtd
-	conn, err := libvirt.NewConnect(l.URI)
+	conn, err := connect(ctx, l.URI)
 	if err != nil {
 		slog.Error("Failed to connect to libvirt: " + err.Error())
 		return err
@@ -122,12 +153,13 @@ func (l *LibvirtService) DeleteStoragePool(name string) error {
 	return nil
 }
 
-func (l *LibvirtService) CreateStorageVolume(poolName, format, name string, size int) error {
+// CreateStorageVolume creates a storage volume of the given size in GB and returns its path on the libvirt host.
+func (l *LibvirtService) CreateStorageVolume(ctx context.Context, poolName, format, name string, size int) (string, error) {
 	// Connect to libvirtd
-	conn, err := libvirt.NewConnect(l.URI)
+	conn, err := connect(ctx, l.URI)
 	if err != nil {
 		slog.Error("Failed to connect to libvirt: " + err.Error())
-		return err
+		return "", err
 	}
 	defer conn.Close()
 
@@ -135,7 +167,7 @@ func (l *LibvirtService) CreateStorageVolume(poolName, format, name string, size
 	pool, err := conn.LookupStoragePoolByName(poolName)
 	if err != nil {
 		slog.Error("Failed to find storage pool: " + err.Error())
-		return err
+		return "", err
 	}
 	defer pool.Free()
 
@@ -153,20 +185,26 @@ func (l *LibvirtService) CreateStorageVolume(poolName, format, name string, size
 	volume, err := pool.StorageVolCreateXML(volumeXML, 0)
 	if err != nil {
 		slog.Error("Failed to create storage volume: " + err.Error())
-		return err
+		return "", err
 	}
 	defer volume.Free()
 
-	return nil
+	path, err := volume.GetPath()
+	if err != nil {
+		slog.Error("Failed to get storage volume path: " + err.Error())
+		return "", err
+	}
+
+	return path, nil
 }
 
-func (l *LibvirtService) CheckPoolsForSpace(libvirtURIs []string, poolName string, requiredSpace uint64) []PoolCheckResult {
+func (l *LibvirtService) CheckPoolsForSpace(ctx context.Context, libvirtURIs []string, poolName string, requiredSpace uint64) []PoolCheckResult {
 	var wg sync.WaitGroup
 	results := make(chan PoolCheckResult, len(libvirtURIs))
 
 	for _, uri := range libvirtURIs {
 		wg.Add(1)
-		go checkPoolForSpace(uri, poolName, requiredSpace, &wg, results)
+		go checkPoolForSpace(ctx, uri, poolName, requiredSpace, &wg, results)
 	}
 
 	wg.Wait()
@@ -180,10 +218,10 @@ func (l *LibvirtService) CheckPoolsForSpace(libvirtURIs []string, poolName strin
 	return checkResults
 }
 
-func checkPoolForSpace(libvirtURI, poolName string, requiredSpace uint64, wg *sync.WaitGroup, results chan<- PoolCheckResult) {
+func checkPoolForSpace(ctx context.Context, libvirtURI, poolName string, requiredSpace uint64, wg *sync.WaitGroup, results chan<- PoolCheckResult) {
 	defer wg.Done()
 
-	conn, err := libvirt.NewConnect(libvirtURI)
+	conn, err := connect(ctx, libvirtURI)
 	if err != nil {
 		slog.Error("Failed to connect to libvirt: " + err.Error())
 		results <- PoolCheckResult{LibvirtURI: libvirtURI, PoolName: poolName, HasSpace: false, Error: err}
@@ -209,12 +247,12 @@ func checkPoolForSpace(libvirtURI, poolName string, requiredSpace uint64, wg *sy
 	availableSpace := info.Available
 	hasSpace := availableSpace >= requiredSpace
 
-	results <- PoolCheckResult{LibvirtURI: libvirtURI, PoolName: poolName, HasSpace: hasSpace, Error: nil}
+	results <- PoolCheckResult{LibvirtURI: libvirtURI, PoolName: poolName, HasSpace: hasSpace, AvailableSpace: availableSpace, Error: nil}
 }
 
-func (l *LibvirtService) GetStorageVolumesOnServer(poolName string) ([]libvirt.StorageVol, error) {
+func (l *LibvirtService) GetStorageVolumesOnServer(ctx context.Context, poolName string) ([]libvirt.StorageVol, error) {
 	// Connect to libvirtd
-	conn, err := libvirt.NewConnect(l.URI)
+	conn, err := connect(ctx, l.URI)
 	if err != nil {
 		slog.Error("Failed to connect to libvirt: " + err.Error())
 		return nil, err
@@ -239,9 +277,9 @@ func (l *LibvirtService) GetStorageVolumesOnServer(poolName string) ([]libvirt.S
 	return volumes, nil
 }
 
-func (l *LibvirtService) DeleteStorageVolume(poolName, volumeName string) error {
+func (l *LibvirtService) DeleteStorageVolume(ctx context.Context, poolName, volumeName string) error {
 	// Connect to libvirtd
-	conn, err := libvirt.NewConnect(l.URI)
+	conn, err := connect(ctx, l.URI)
 	if err != nil {
 		slog.Error("Failed to connect to libvirt: " + err.Error())
 		return err
@@ -273,9 +311,80 @@ func (l *LibvirtService) DeleteStorageVolume(poolName, volumeName string) error
 	return nil
 }
 
-func (l *LibvirtService) CreateNetwork(name, bridge string) error {
+// GetStorageVolumeSize returns the capacity in bytes of a storage volume.
+func (l *LibvirtService) GetStorageVolumeSize(ctx context.Context, poolName, volumeName string) (uint64, error) {
+	// Connect to libvirtd
+	conn, err := connect(ctx, l.URI)
+	if err != nil {
+		slog.Error("Failed to connect to libvirt: " + err.Error())
+		return 0, err
+	}
+	defer conn.Close()
+
+	// Lookup the storage pool
+	pool, err := conn.LookupStoragePoolByName(poolName)
+	if err != nil {
+		slog.Error("Failed to find storage pool: " + err.Error())
+		return 0, err
+	}
+	defer pool.Free()
+
+	// Lookup the storage volume
+	volume, err := pool.LookupStorageVolByName(volumeName)
+	if err != nil {
+		slog.Error("Failed to find storage volume: " + err.Error())
+		return 0, err
+	}
+	defer volume.Free()
+
+	info, err := volume.GetInfo()
+	if err != nil {
+		slog.Error("Failed to get storage volume info: " + err.Error())
+		return 0, err
+	}
+
+	return info.Capacity, nil
+}
+
+// ResizeStorageVolume grows a storage volume to the given size in GB.
+func (l *LibvirtService) ResizeStorageVolume(ctx context.Context, poolName, volumeName string, size int) error {
+	// Connect to libvirtd
+	conn, err := connect(ctx, l.URI)
+	if err != nil {
+		slog.Error("Failed to connect to libvirt: " + err.Error())
+		return err
+	}
+	defer conn.Close()
+
+	// Lookup the storage pool
+	pool, err := conn.LookupStoragePoolByName(poolName)
+	if err != nil {
+		slog.Error("Failed to find storage pool: " + err.Error())
+		return err
+	}
+	defer pool.Free()
+
+	// Lookup the storage volume
+	volume, err := pool.LookupStorageVolByName(volumeName)
+	if err != nil {
+		slog.Error("Failed to find storage volume: " + err.Error())
+		return err
+	}
+	defer volume.Free()
+
+	// Resize the storage volume
+	if err := volume.Resize(uint64(size)*1024*1024*1024, 0); err != nil {
+		slog.Error("Failed to resize storage volume: " + err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// CreateNetwork defines and starts a network on a libvirt host. The project owning the network is recorded in its metadata.
+func (l *LibvirtService) CreateNetwork(ctx context.Context, name, bridge string, owner Owner) error {
 	// Connect to libvirtd
-	conn, err := libvirt.NewConnect(l.URI)
+	conn, err := connect(ctx, l.URI)
 	if err != nil {
 		slog.Error("Failed to connect to libvirt: " + err.Error())
 		return err
@@ -285,9 +394,9 @@ func (l *LibvirtService) CreateNetwork(name, bridge string) error {
 	// Define the network XML
 	networkXML := fmt.Sprintf(`
 	<network>
-		<name>%s</name>
+		<name>%s</name>%s
 		<bridge name='%s'/>
-	</network>`, name, bridge)
+	</network>`, name, OwnerMetadata(owner.Project, owner.Name), bridge)
 
 	// Create the network
 	network, err := conn.NetworkDefineXML(networkXML)
@@ -306,9 +415,9 @@ func (l *LibvirtService) CreateNetwork(name, bridge string) error {
 	return nil
 }
 
-func (l *LibvirtService) GetNetworks() ([]libvirt.Network, error) {
+func (l *LibvirtService) GetNetworks(ctx context.Context) ([]libvirt.Network, error) {
 	// Connect to libvirtd
-	conn, err := libvirt.NewConnect(l.URI)
+	conn, err := connect(ctx, l.URI)
 	if err != nil {
 		slog.Error("Failed to connect to libvirt: " + err.Error())
 		return nil, err
@@ -325,9 +434,9 @@ func (l *LibvirtService) GetNetworks() ([]libvirt.Network, error) {
 	return networks, nil
 }
 
-func (l *LibvirtService) DeleteNetwork(name string) error {
+func (l *LibvirtService) DeleteNetwork(ctx context.Context, name string) error {
 	// Connect to libvirtd
-	conn, err := libvirt.NewConnect(l.URI)
+	conn, err := connect(ctx, l.URI)
 	if err != nil {
 		slog.Error("Failed to connect to libvirt: " + err.Error())
 		return err
@@ -357,12 +466,13 @@ func (l *LibvirtService) DeleteNetwork(name string) error {
 	return nil
 }
 
-func (l *LibvirtService) CreateDomain(name, xml string) error {
+// CreateDomain defines and starts a domain on a libvirt host and returns its UUID.
+func (l *LibvirtService) CreateDomain(ctx context.Context, name, xml string) (string, error) {
 	// Connect to libvirtd
-	conn, err := libvirt.NewConnect(l.URI)
+	conn, err := connect(ctx, l.URI)
 	if err != nil {
 		slog.Error("Failed to connect to libvirt: " + err.Error())
-		return err
+		return "", err
 	}
 	defer conn.Close()
 
@@ -370,22 +480,28 @@ func (l *LibvirtService) CreateDomain(name, xml string) error {
 	domain, err := conn.DomainDefineXML(xml)
 	if err != nil {
 		slog.Error("Failed to define domain: " + err.Error())
-		return err
+		return "", err
 	}
 	defer domain.Free()
 
 	// Start the domain
 	if err := domain.Create(); err != nil {
 		slog.Error(("Failed to start domain: " + err.Error()))
-		return err
+		return "", err
 	}
 
-	return nil
+	uuid, err := domain.GetUUIDString()
+	if err != nil {
+		slog.Error("Failed to get domain uuid: " + err.Error())
+		return "", err
+	}
+
+	return uuid, nil
 }
 
-func (l *LibvirtService) GetDomains() ([]libvirt.Domain, error) {
+func (l *LibvirtService) GetDomains(ctx context.Context) ([]libvirt.Domain, error) {
 	// Connect to libvirtd
-	conn, err := libvirt.NewConnect(l.URI)
+	conn, err := connect(ctx, l.URI)
 	if err != nil {
 		slog.Error("Failed to connect to libvirt: " + err.Error())
 		return nil, err
@@ -402,9 +518,9 @@ func (l *LibvirtService) GetDomains() ([]libvirt.Domain, error) {
 	return domains, nil
 }
 
-func (l *LibvirtService) DeleteDomain(name string) error {
+func (l *LibvirtService) DeleteDomain(ctx context.Context, name string) error {
 	// Connect to libvirtd
-	conn, err := libvirt.NewConnect(l.URI)
+	conn, err := connect(ctx, l.URI)
 	if err != nil {
 		slog.Error("Failed to connect to libvirt: " + err.Error())
 		return err
@@ -434,10 +550,68 @@ func (l *LibvirtService) DeleteDomain(name string) error {
 	return nil
 }
 
-func checkResources(libvirtURI string, requiredMemory uint64, requiredVCPU uint, wg *sync.WaitGroup, results chan<- ResourceCheckResult) {
+// ResizeDomain changes the memory in MiB and the number of vCPUs in the persistent definition of a domain. The new resources apply the next time the domain is started.
+func (l *LibvirtService) ResizeDomain(ctx context.Context, name string, memory uint64, vcpu uint) error {
+	// Connect to libvirtd
+	conn, err := connect(ctx, l.URI)
+	if err != nil {
+		slog.Error("Failed to connect to libvirt: " + err.Error())
+		return err
+	}
+	defer conn.Close()
+
+	// Lookup the domain
+	domain, err := conn.LookupDomainByName(name)
+	if err != nil {
+		slog.Error("Failed to find domain: " + err.Error())
+		return err
+	}
+	defer domain.Free()
+
+	current, err := domain.GetMaxMemory()
+	if err != nil {
+		slog.Error("Failed to get domain memory: " + err.Error())
+		return err
+	}
+
+	// The maximum has to be raised before the current memory and lowered after it
+	memorySteps := []libvirt.DomainMemoryModFlags{libvirt.DOMAIN_MEM_MAXIMUM | libvirt.DOMAIN_MEM_CONFIG, libvirt.DOMAIN_MEM_CONFIG}
+	if memory*1024 < current {
+		memorySteps[0], memorySteps[1] = memorySteps[1], memorySteps[0]
+	}
+
+	for _, flags := range memorySteps {
+		if err := domain.SetMemoryFlags(memory*1024, flags); err != nil {
+			slog.Error("Failed to set domain memory: " + err.Error())
+			return err
+		}
+	}
+
+	vcpus, err := domain.GetVcpusFlags(libvirt.DOMAIN_VCPU_MAXIMUM | libvirt.DOMAIN_VCPU_CONFIG)
+	if err != nil {
+		slog.Error("Failed to get domain vCPUs: " + err.Error())
+		return err
+	}
+
+	vcpuSteps := []libvirt.DomainVcpuFlags{libvirt.DOMAIN_VCPU_MAXIMUM | libvirt.DOMAIN_VCPU_CONFIG, libvirt.DOMAIN_VCPU_CONFIG}
+	if int32(vcpu) < vcpus {
+		vcpuSteps[0], vcpuSteps[1] = vcpuSteps[1], vcpuSteps[0]
+	}
+
+	for _, flags := range vcpuSteps {
+		if err := domain.SetVcpusFlags(vcpu, flags); err != nil {
+			slog.Error("Failed to set domain vCPUs: " + err.Error())
+			return err
+		}
+	}
+
+	return nil
+}
+
+func checkResources(ctx context.Context, libvirtURI string, requiredMemory uint64, requiredVCPU uint, wg *sync.WaitGroup, results chan<- ResourceCheckResult) {
 	defer wg.Done()
 
-	conn, err := libvirt.NewConnect(libvirtURI)
+	conn, err := connect(ctx, libvirtURI)
 	if err != nil {
 		slog.Error("Failed to connect to libvirt: " + err.Error())
 		results <- ResourceCheckResult{LibvirtURI: libvirtURI, HasResources: false, Error: err}
@@ -483,22 +657,29 @@ func checkResources(libvirtURI string, requiredMemory uint64, requiredVCPU uint,
 		usedVCPU += domainInfo.NrVirtCpu
 	}
 
-	// Calculate available resources
-	freeMemory := totalMemory - usedMemory
-	freeVCPU := totalVCPU - usedVCPU
+	// Calculate available resources, overcommitted nodes have nothing left
+	var freeMemory uint64
+	if totalMemory > usedMemory {
+		freeMemory = totalMemory - usedMemory
+	}
+
+	var freeVCPU uint
+	if totalVCPU > usedVCPU {
+		freeVCPU = totalVCPU - usedVCPU
+	}
 
 	hasResources := freeMemory >= requiredMemory && freeVCPU >= requiredVCPU
 
-	results <- ResourceCheckResult{LibvirtURI: libvirtURI, HasResources: hasResources, Error: nil}
+	results <- ResourceCheckResult{LibvirtURI: libvirtURI, HasResources: hasResources, FreeMemory: freeMemory, FreeVCPU: freeVCPU, Error: nil}
 }
 
-func (l *LibvirtService) CheckServersForResources(libvirtURIs []string, requiredMemory uint64, requiredVCPU uint) []ResourceCheckResult {
+func (l *LibvirtService) CheckServersForResources(ctx context.Context, libvirtURIs []string, requiredMemory uint64, requiredVCPU uint) []ResourceCheckResult {
 	var wg sync.WaitGroup
 	results := make(chan ResourceCheckResult, len(libvirtURIs))
 
 	for _, uri := range libvirtURIs {
 		wg.Add(1)
-		go checkResources(uri, requiredMemory, requiredVCPU, &wg, results)
+		go checkResources(ctx, uri, requiredMemory, requiredVCPU, &wg, results)
 	}
 
 	wg.Wait()
@@ -511,3 +692,218 @@ func (l *LibvirtService) CheckServersForResources(libvirtURIs []string, required
 
 	return checkResults
 }
+
+// GetDomainSpecs returns the resources of every domain, running or not, defined on the libvirt host with the given URI.
+func (l *LibvirtService) GetDomainSpecs(ctx context.Context, libvirtURI string) ([]DomainSpec, error) {
+	// Connect to libvirtd
+	conn, err := connect(ctx, libvirtURI)
+	if err != nil {
+		slog.Error("Failed to connect to libvirt: " + err.Error())
+		return nil, err
+	}
+	defer conn.Close()
+
+	// Retrieve the list of domains
+	domains, err := conn.ListAllDomains(0)
+	if err != nil {
+		slog.Error("Failed to list domains: " + err.Error())
+		return nil, err
+	}
+	defer func() {
+		for _, domain := range domains {
+			domain.Free()
+		}
+	}()
+
+	var specs []DomainSpec
+	for _, domain := range domains {
+		name, err := domain.GetName()
+		if err != nil {
+			slog.Error("Failed to get domain name: " + err.Error())
+			return nil, err
+		}
+
+		uuid, err := domain.GetUUIDString()
+		if err != nil {
+			slog.Error("Failed to get domain uuid: " + err.Error())
+			return nil, err
+		}
+
+		info, err := domain.GetInfo()
+		if err != nil {
+			slog.Error("Failed to get domain info: " + err.Error())
+			return nil, err
+		}
+
+		specs = append(specs, DomainSpec{
+			UUID:   uuid,
+			Name:   name,
+			Active: info.State == libvirt.DOMAIN_RUNNING || info.State == libvirt.DOMAIN_PAUSED,
+			Memory: info.MaxMem / 1024,
+			VCPU:   info.NrVirtCpu,
+		})
+	}
+
+	return specs, nil
+}
+
+// MigrateDomain moves a domain between two libvirt hosts. A live migration copies the disks of the running domain to the target while it keeps running. A cold migration shuts the domain down, moves its definition and starts it again on the target, its disks must therefore be reachable from the target.
+func (l *LibvirtService) MigrateDomain(ctx context.Context, sourceURI, targetURI, name string, live bool) error {
+	// Connect to libvirtd on both hosts
+	conn, err := connect(ctx, sourceURI)
+	if err != nil {
+		slog.Error("Failed to connect to libvirt: " + err.Error())
+		return err
+	}
+	defer conn.Close()
+
+	targetConn, err := connect(ctx, targetURI)
+	if err != nil {
+		slog.Error("Failed to connect to libvirt: " + err.Error())
+		return err
+	}
+	defer targetConn.Close()
+
+	// Lookup the domain
+	domain, err := conn.LookupDomainByName(name)
+	if err != nil {
+		slog.Error("Failed to find domain: " + err.Error())
+		return err
+	}
+	defer domain.Free()
+
+	active, err := domain.IsActive()
+	if err != nil {
+		slog.Error("Failed to get domain state: " + err.Error())
+		return err
+	}
+
+	if live && active {
+		migrated, err := domain.Migrate(targetConn, libvirt.MIGRATE_LIVE|libvirt.MIGRATE_PERSIST_DEST|libvirt.MIGRATE_UNDEFINE_SOURCE|libvirt.MIGRATE_NON_SHARED_DISK, "", "", 0)
+		if err != nil {
+			slog.Error("Failed to live migrate domain: " + err.Error())
+			return err
+		}
+		migrated.Free()
+
+		return nil
+	}
+
+	if active {
+		// Stop the domain, forcefully if it does not shut down in time
+		if err := shutdownDomain(domain); err != nil {
+			return err
+		}
+	}
+
+	migrated, err := domain.Migrate(targetConn, libvirt.MIGRATE_OFFLINE|libvirt.MIGRATE_PERSIST_DEST|libvirt.MIGRATE_UNDEFINE_SOURCE, "", "", 0)
+	if err != nil {
+		slog.Error("Failed to migrate domain: " + err.Error())
+		return err
+	}
+	defer migrated.Free()
+
+	if active {
+		// Start the domain again on the target
+		if err := migrated.Create(); err != nil {
+			slog.Error("Failed to start domain: " + err.Error())
+			return err
+		}
+	}
+
+	return nil
+}
+
+// shutdownDomain asks the guest to shut down and destroys the domain if it is still running after shutdownTimeout.
+func shutdownDomain(domain *libvirt.Domain) error {
+	if err := domain.Shutdown(); err != nil {
+		slog.Error("Failed to shutdown domain: " + err.Error())
+		return err
+	}
+
+	deadline := time.Now().Add(shutdownTimeout)
+	for time.Now().Before(deadline) {
+		active, err := domain.IsActive()
+		if err != nil {
+			slog.Error("Failed to get domain state: " + err.Error())
+			return err
+		}
+
+		if !active {
+			return nil
+		}
+
+		time.Sleep(2 * time.Second)
+	}
+
+	if err := domain.Destroy(); err != nil {
+		slog.Error("Failed to destroy domain: " + err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// ProbeNode connects to the libvirt host with the given URI, reads its node info and returns the version of libvirt running on it.
+func (l *LibvirtService) ProbeNode(ctx context.Context, libvirtURI string) (string, error) {
+	// Connect to libvirtd
+	conn, err := connect(ctx, libvirtURI)
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+
+	if _, err := conn.GetNodeInfo(); err != nil {
+		return "", err
+	}
+
+	version, err := conn.GetLibVersion()
+	if err != nil {
+		return "", err
+	}
+
+	return formatVersion(version), nil
+}
+
+// formatVersion converts a version number encoded by libvirt as major * 1,000,000 + minor * 1,000 + release into its dotted representation.
+func formatVersion(version uint32) string {
+	return fmt.Sprintf("%d.%d.%d", version/1000000, version/1000%1000, version%1000)
+}
+
+// DomainStateName returns the name under which the state of a domain is shown and filtered.
+func DomainStateName(state libvirt.DomainState) string {
+	switch state {
+	case libvirt.DOMAIN_RUNNING:
+		return "running"
+	case libvirt.DOMAIN_BLOCKED:
+		return "blocked"
+	case libvirt.DOMAIN_PAUSED:
+		return "paused"
+	case libvirt.DOMAIN_SHUTDOWN:
+		return "shutdown"
+	case libvirt.DOMAIN_SHUTOFF:
+		return "shutoff"
+	case libvirt.DOMAIN_CRASHED:
+		return "crashed"
+	case libvirt.DOMAIN_PMSUSPENDED:
+		return "pmsuspended"
+	default:
+		return "nostate"
+	}
+}
+
+// StoragePoolStateName returns the name under which the state of a storage pool is shown and filtered.
+func StoragePoolStateName(state libvirt.StoragePoolState) string {
+	switch state {
+	case libvirt.STORAGE_POOL_BUILDING:
+		return "building"
+	case libvirt.STORAGE_POOL_RUNNING:
+		return "running"
+	case libvirt.STORAGE_POOL_DEGRADED:
+		return "degraded"
+	case libvirt.STORAGE_POOL_INACCESSIBLE:
+		return "inaccessible"
+	default:
+		return "inactive"
+	}
+}
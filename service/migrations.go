@@ -0,0 +1,254 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/sychonet/vdash-be/db"
+	"github.com/sychonet/vdash-be/db/entity"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// migration is a change of the database schema or indexes. Migrations are applied in order and recorded in the migrations collection so that each runs once.
+type migration struct {
+	ID          string
+	Description string
+	Apply       func(ctx context.Context, d *DatabaseService, database *mongo.Database) error
+}
+
+// appliedMigration represents a migration recorded in the migrations collection.
+type appliedMigration struct {
+	ID        string    `bson:"_id"`
+	AppliedAt time.Time `bson:"appliedAt"`
+}
+
+// migrations lists every migration in the order they are applied. Never change or reorder a migration once released, add a new one instead.
+var migrations = []migration{
+	{
+		ID:          "0001_indexes",
+		Description: "Create the indexes used by the lookups of servers, public IPs, domains, events and power actions",
+		Apply: func(ctx context.Context, d *DatabaseService, database *mongo.Database) error {
+			indexes := []struct {
+				collection string
+				model      mongo.IndexModel
+			}{
+				{d.ServersCollection, mongo.IndexModel{Keys: bson.D{{Key: "provider", Value: 1}}}},
+				{d.ServersCollection, mongo.IndexModel{Keys: bson.D{{Key: "publicIP", Value: 1}}}},
+				{d.IPsCollection, mongo.IndexModel{Keys: bson.D{{Key: "serverID", Value: 1}, {Key: "available", Value: 1}}}},
+				{d.DomainsCollection, mongo.IndexModel{Keys: bson.D{{Key: "serverID", Value: 1}, {Key: "name", Value: 1}}}},
+				{d.EventsCollection, mongo.IndexModel{Keys: bson.D{{Key: "serverID", Value: 1}, {Key: "time", Value: -1}}}},
+				{d.EventsCollection, mongo.IndexModel{Keys: bson.D{{Key: "time", Value: -1}}}},
+				{d.PowerCollection, mongo.IndexModel{Keys: bson.D{{Key: "serverID", Value: 1}, {Key: "time", Value: -1}}}},
+			}
+
+			for _, index := range indexes {
+				if _, err := database.Collection(index.collection).Indexes().CreateOne(ctx, index.model); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		},
+	},
+	{
+		ID:          "0002_server_defaults",
+		Description: "Set the fields added to servers after their import to their defaults",
+		Apply: func(ctx context.Context, d *DatabaseService, database *mongo.Database) error {
+			collection := database.Collection(d.ServersCollection)
+
+			defaults := []bson.D{
+				{{Key: "cordoned", Value: false}},
+				{{Key: "missing", Value: false}},
+				{{Key: "health.status", Value: ServerStatusUnknown}},
+			}
+
+			for _, fields := range defaults {
+				filter := bson.D{{Key: fields[0].Key, Value: bson.D{{Key: "$exists", Value: false}}}}
+				if _, err := collection.UpdateMany(ctx, filter, bson.D{{Key: "$set", Value: fields}}); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		},
+	},
+	{
+		ID:          "0003_api_keys",
+		Description: "Create the unique index used to look up API keys by their hash",
+		Apply: func(ctx context.Context, d *DatabaseService, database *mongo.Database) error {
+			model := mongo.IndexModel{Keys: bson.D{{Key: "hash", Value: 1}}, Options: options.Index().SetUnique(true)}
+			_, err := database.Collection(d.KeysCollection).Indexes().CreateOne(ctx, model)
+			return err
+		},
+	},
+	{
+		ID:          "0004_role_bindings",
+		Description: "Create the index used to look up the role bindings of a subject",
+		Apply: func(ctx context.Context, d *DatabaseService, database *mongo.Database) error {
+			model := mongo.IndexModel{Keys: bson.D{{Key: "subject", Value: 1}, {Key: "project", Value: 1}}}
+			_, err := database.Collection(d.BindingsCollection).Indexes().CreateOne(ctx, model)
+			return err
+		},
+	},
+	{
+		ID:          "0005_projects",
+		Description: "Assign the domains recorded before projects existed to the default project and create the indexes used by the ownership lookups",
+		Apply: func(ctx context.Context, d *DatabaseService, database *mongo.Database) error {
+			// Domains created before projects kept their name in libvirt
+			filter := bson.D{{Key: "project", Value: bson.D{{Key: "$exists", Value: false}}}}
+			update := mongo.Pipeline{{{Key: "$set", Value: bson.D{{Key: "project", Value: DefaultProject}, {Key: "libvirtName", Value: "$name"}}}}}
+			if _, err := database.Collection(d.DomainsCollection).UpdateMany(ctx, filter, update); err != nil {
+				return err
+			}
+
+			indexes := []struct {
+				collection string
+				model      mongo.IndexModel
+			}{
+				{d.DomainsCollection, mongo.IndexModel{Keys: bson.D{{Key: "serverID", Value: 1}, {Key: "libvirtName", Value: 1}}}},
+				{d.DomainsCollection, mongo.IndexModel{Keys: bson.D{{Key: "project", Value: 1}}}},
+				{d.VolumesCollection, mongo.IndexModel{Keys: bson.D{{Key: "serverID", Value: 1}, {Key: "pool", Value: 1}, {Key: "libvirtName", Value: 1}}, Options: options.Index().SetUnique(true)}},
+				{d.VolumesCollection, mongo.IndexModel{Keys: bson.D{{Key: "project", Value: 1}}}},
+				{d.NetworksCollection, mongo.IndexModel{Keys: bson.D{{Key: "serverID", Value: 1}, {Key: "libvirtName", Value: 1}}, Options: options.Index().SetUnique(true)}},
+				{d.NetworksCollection, mongo.IndexModel{Keys: bson.D{{Key: "project", Value: 1}}}},
+				{d.IPsCollection, mongo.IndexModel{Keys: bson.D{{Key: "project", Value: 1}, {Key: "available", Value: 1}}}},
+			}
+
+			for _, index := range indexes {
+				if _, err := database.Collection(index.collection).Indexes().CreateOne(ctx, index.model); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		},
+	},
+	{
+		ID:          "0006_audit_log",
+		Description: "Create the indexes used by the queries of the audit log",
+		Apply: func(ctx context.Context, d *DatabaseService, database *mongo.Database) error {
+			models := []mongo.IndexModel{
+				{Keys: bson.D{{Key: "time", Value: -1}}},
+				{Keys: bson.D{{Key: "actor", Value: 1}, {Key: "time", Value: -1}}},
+				{Keys: bson.D{{Key: "serverID", Value: 1}, {Key: "time", Value: -1}}},
+				{Keys: bson.D{{Key: "project", Value: 1}, {Key: "time", Value: -1}}},
+			}
+
+			_, err := database.Collection(d.AuditCollection).Indexes().CreateMany(ctx, models)
+			return err
+		},
+	},
+	{
+		ID:          "0007_idempotency_keys",
+		Description: "Expire the idempotency keys once their expiry time has passed",
+		Apply: func(ctx context.Context, d *DatabaseService, database *mongo.Database) error {
+			model := mongo.IndexModel{
+				Keys:    bson.D{{Key: "expiresAt", Value: 1}},
+				Options: options.Index().SetExpireAfterSeconds(0),
+			}
+
+			_, err := database.Collection(d.IdempotencyCollection).Indexes().CreateOne(ctx, model)
+			return err
+		},
+	},
+	{
+		ID:          "0008_api_key_subjects",
+		Description: "Bind the roles granted to an API key by its name to the ID of the key, since several keys may share a name",
+		Apply: func(ctx context.Context, d *DatabaseService, database *mongo.Database) error {
+			cursor, err := database.Collection(d.KeysCollection).Find(ctx, bson.D{}, options.Find().SetProjection(bson.D{{Key: "_id", Value: 1}, {Key: "name", Value: 1}}))
+			if err != nil {
+				return err
+			}
+
+			var keys []entity.APIKey
+			if err := cursor.All(ctx, &keys); err != nil {
+				return err
+			}
+
+			ids := map[string][]primitive.ObjectID{}
+			for _, key := range keys {
+				ids[key.Name] = append(ids[key.Name], key.ID)
+			}
+
+			// The bindings of a name shared by several keys are left unmatched rather than granted to every key with the name
+			for name, keyIDs := range ids {
+				if len(keyIDs) != 1 {
+					continue
+				}
+
+				filter := bson.D{{Key: "subject", Value: APIKeySubjectPrefix + name}}
+				update := bson.D{{Key: "$set", Value: bson.D{{Key: "subject", Value: APIKeySubjectPrefix + keyIDs[0].Hex()}}}}
+				if _, err := database.Collection(d.BindingsCollection).UpdateMany(ctx, filter, update); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		},
+	},
+}
+
+// MigrationStatus describes a migration and whether it has been applied.
+type MigrationStatus struct {
+	ID          string
+	Description string
+	Applied     bool
+}
+
+// Migrate applies the migrations which have not been applied yet, in order, and returns the status of every migration. If dryRun is true the pending migrations are only reported.
+func (d *DatabaseService) Migrate(ctx context.Context, dryRun bool) ([]MigrationStatus, error) {
+	// Connect to the database
+	client := db.GetClient(d.GetURI())
+	defer func() {
+		if err := client.Disconnect(context.Background()); err != nil {
+			slog.Error(err.Error())
+		}
+	}()
+
+	database := client.Database(d.Name)
+
+	// Get the collection
+	collection := database.Collection(d.MigrationsCollection)
+
+	cursor, err := collection.Find(ctx, bson.D{}, options.Find().SetProjection(bson.D{{Key: "_id", Value: 1}, {Key: "appliedAt", Value: 1}}))
+	if err != nil {
+		slog.Error(err.Error())
+		return nil, err
+	}
+
+	var applied []appliedMigration
+	if err := cursor.All(ctx, &applied); err != nil {
+		slog.Error(err.Error())
+		return nil, err
+	}
+
+	done := map[string]bool{}
+	for _, m := range applied {
+		done[m.ID] = true
+	}
+
+	statuses := make([]MigrationStatus, 0, len(migrations))
+	for _, m := range migrations {
+		status := MigrationStatus{ID: m.ID, Description: m.Description, Applied: done[m.ID]}
+		if !status.Applied && !dryRun {
+			slog.Info("Applying migration " + m.ID)
+			if err := m.Apply(ctx, d, database); err != nil {
+				return statuses, err
+			}
+
+			if _, err := collection.InsertOne(ctx, appliedMigration{ID: m.ID, AppliedAt: time.Now().UTC()}); err != nil {
+				slog.Error(err.Error())
+				return statuses, err
+			}
+			status.Applied = true
+		}
+
+		statuses = append(statuses, status)
+	}
+
+	return statuses, nil
+}
@@ -0,0 +1,123 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/sychonet/vdash-be/db/entity"
+)
+
+// RejectedError is returned when a server fails the onboarding checks.
+type RejectedError struct {
+	Problems []string
+}
+
+func (e *RejectedError) Error() string {
+	return "server rejected: " + strings.Join(e.Problems, "; ")
+}
+
+// OnboardingService is a service that validates servers before they are added to vdash. It connects to libvirtd on the server, discovers its capabilities and rejects servers which cannot host vdash domains.
+type OnboardingService struct {
+	databaseService      *DatabaseService
+	libvirtService       *LibvirtService
+	minLibvirtVersion    string
+	minHypervisorVersion string
+	connection           entity.ConnectionConfig
+}
+
+func NewOnboardingService(databaseService *DatabaseService, libvirtService *LibvirtService, minLibvirtVersion, minHypervisorVersion string, connection entity.ConnectionConfig) *OnboardingService {
+	return &OnboardingService{
+		databaseService:      databaseService,
+		libvirtService:       libvirtService,
+		minLibvirtVersion:    minLibvirtVersion,
+		minHypervisorVersion: minHypervisorVersion,
+		connection:           connection,
+	}
+}
+
+// LibvirtURI returns the libvirt connection URI of a server built from the global connection settings and the overrides of the server.
+func (o *OnboardingService) LibvirtURI(server entity.ServerInfo) (string, error) {
+	return BuildLibvirtURI(server.PublicIP, MergeConnection(o.connection, server.Connection))
+}
+
+// Onboard discovers the capabilities of a server and stores it in the database if it passes the checks. A *RejectedError is returned otherwise.
+func (o *OnboardingService) Onboard(ctx context.Context, server entity.ServerInfo) (*entity.ServerInfo, error) {
+	libvirtURI, err := o.LibvirtURI(server)
+	if err != nil {
+		return nil, err
+	}
+	server.LibvirtURI = libvirtURI
+
+	capabilities, err := o.discover(ctx, server)
+	if err != nil {
+		return nil, err
+	}
+
+	server.Capabilities = capabilities
+	if err := o.databaseService.AddServer(ctx, server); err != nil {
+		return nil, err
+	}
+
+	return &server, nil
+}
+
+// Rediscover refreshes the capabilities of a server already stored in the database. The capabilities are only stored if the server still passes the checks.
+func (o *OnboardingService) Rediscover(ctx context.Context, id int) (*entity.ServerInfo, error) {
+	server, err := o.databaseService.GetServer(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	capabilities, err := o.discover(ctx, *server)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := o.databaseService.UpdateServerCapabilities(ctx, id, *capabilities); err != nil {
+		return nil, err
+	}
+
+	server.Capabilities = capabilities
+	return server, nil
+}
+
+// UpdateConnection replaces the connection overrides of a server and rebuilds its libvirt URI. A nil connection makes the server use the global settings again.
+func (o *OnboardingService) UpdateConnection(ctx context.Context, id int, connection *entity.ConnectionConfig) (*entity.ServerInfo, error) {
+	server, err := o.databaseService.GetServer(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	server.Connection = connection
+	libvirtURI, err := o.LibvirtURI(*server)
+	if err != nil {
+		return nil, err
+	}
+	server.LibvirtURI = libvirtURI
+
+	if err := o.databaseService.UpdateServerConnection(ctx, id, connection, libvirtURI); err != nil {
+		return nil, err
+	}
+
+	return server, nil
+}
+
+// discover connects to the server and validates its capabilities.
+func (o *OnboardingService) discover(ctx context.Context, server entity.ServerInfo) (*entity.NodeCapabilities, error) {
+	capabilities, err := o.libvirtService.DiscoverCapabilities(ctx, server.LibvirtURI)
+	if err != nil {
+		return nil, &RejectedError{Problems: []string{fmt.Sprintf("failed to connect to libvirt on %s: %v", server.LibvirtURI, err)}}
+	}
+
+	problems, err := ValidateCapabilities(capabilities, o.minLibvirtVersion, o.minHypervisorVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(problems) > 0 {
+		return nil, &RejectedError{Problems: problems}
+	}
+
+	return capabilities, nil
+}
@@ -0,0 +1,123 @@
+package service
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/sychonet/vdash-be/db/entity"
+)
+
+// ZoneLabel is the server label holding the datacenter a server is located in.
+const ZoneLabel = "zone"
+
+// Operators supported by placement constraints.
+const (
+	OperatorIn           = "In"
+	OperatorNotIn        = "NotIn"
+	OperatorExists       = "Exists"
+	OperatorDoesNotExist = "DoesNotExist"
+)
+
+// Placement describes where a resource may be created. NodeSelector, Required and the hard policy of Group must be satisfied by a server for it to be picked, Preferred and the soft policy of Group only influence the score of the servers that are eligible.
+type Placement struct {
+	NodeSelector map[string]string
+	Required     []entity.PlacementConstraint
+	Preferred    []entity.PlacementConstraint
+	Group        *entity.ServerGroup
+	MachineType  string
+	CPUModel     string
+}
+
+// ValidateConstraint checks that a placement constraint uses a known operator with a fitting set of values.
+func ValidateConstraint(constraint entity.PlacementConstraint) error {
+	if constraint.Key == "" {
+		return fmt.Errorf("constraint key is required")
+	}
+
+	switch constraint.Operator {
+	case OperatorIn, OperatorNotIn:
+		if len(constraint.Values) == 0 {
+			return fmt.Errorf("constraint on %q with operator %s needs at least one value", constraint.Key, constraint.Operator)
+		}
+	case OperatorExists, OperatorDoesNotExist:
+		if len(constraint.Values) > 0 {
+			return fmt.Errorf("constraint on %q with operator %s takes no values", constraint.Key, constraint.Operator)
+		}
+	default:
+		return fmt.Errorf("unknown operator %q for constraint on %q", constraint.Operator, constraint.Key)
+	}
+
+	return nil
+}
+
+// MatchesConstraint reports whether the labels of a server satisfy a placement constraint.
+func MatchesConstraint(labels map[string]string, constraint entity.PlacementConstraint) bool {
+	value, ok := labels[constraint.Key]
+
+	switch constraint.Operator {
+	case OperatorIn:
+		return ok && slices.Contains(constraint.Values, value)
+	case OperatorNotIn:
+		return !ok || !slices.Contains(constraint.Values, value)
+	case OperatorExists:
+		return ok
+	case OperatorDoesNotExist:
+		return !ok
+	}
+
+	return false
+}
+
+// CheckRequired returns a reason for the first node selector entry, required constraint, capability or hard group policy the server does not satisfy, or an empty string if the server satisfies all of them.
+func (p Placement) CheckRequired(server entity.ServerInfo) string {
+	for key, value := range p.NodeSelector {
+		if server.Labels[key] != value {
+			return fmt.Sprintf("node selector %s=%s does not match", key, value)
+		}
+	}
+
+	for _, constraint := range p.Required {
+		if !MatchesConstraint(server.Labels, constraint) {
+			return fmt.Sprintf("required constraint %s %s %v is not satisfied", constraint.Key, constraint.Operator, constraint.Values)
+		}
+	}
+
+	if reason := CheckCapabilities(server.Capabilities, p.MachineType, p.CPUModel); reason != "" {
+		return reason
+	}
+
+	return CheckGroupPolicy(p.Group, server.ID)
+}
+
+// CheckCapabilities returns a reason if the server is known not to support the machine type or CPU model, or an empty string otherwise. Servers whose capabilities were never discovered are given the benefit of the doubt.
+func CheckCapabilities(capabilities *entity.NodeCapabilities, machineType, cpuModel string) string {
+	if capabilities == nil {
+		return ""
+	}
+
+	if machineType != "" && !slices.Contains(capabilities.MachineTypes, machineType) {
+		return "machine type " + machineType + " is not supported"
+	}
+
+	if cpuModel != "" && !slices.Contains(capabilities.CPUModels, cpuModel) {
+		return "CPU model " + cpuModel + " is not usable"
+	}
+
+	return ""
+}
+
+// Score returns the sum of the weights of the preferred constraints satisfied by the server, adjusted by the soft policy of the group.
+func (p Placement) Score(server entity.ServerInfo) int {
+	score := groupScore(p.Group, server.ID)
+	for _, constraint := range p.Preferred {
+		if MatchesConstraint(server.Labels, constraint) {
+			weight := constraint.Weight
+			if weight <= 0 {
+				weight = 1
+			}
+			score += weight
+		}
+	}
+
+	return score
+}
@@ -0,0 +1,67 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/sychonet/vdash-be/db/entity"
+)
+
+// ErrUnmanagedServer is returned when a power action is requested for a server which does not belong to a configured provider account.
+var ErrUnmanagedServer = errors.New("server is not managed by a provider")
+
+// PowerService is a service that boots, reboots, shuts down and rescues servers through the API of their provider. Every request is recorded in the database along with its outcome.
+type PowerService struct {
+	databaseService *DatabaseService
+	providers       *Providers
+}
+
+func NewPowerService(databaseService *DatabaseService, providers *Providers) *PowerService {
+	return &PowerService{
+		databaseService: databaseService,
+		providers:       providers,
+	}
+}
+
+// Power requests the power action for a server from its provider and records it. The recorded action is returned along with the error of the provider, if any.
+func (p *PowerService) Power(ctx context.Context, serverID int, action, reason string, automatic bool) (*entity.PowerAction, error) {
+	if err := ValidatePowerAction(action); err != nil {
+		return nil, err
+	}
+
+	server, err := p.databaseService.GetServer(ctx, serverID)
+	if err != nil {
+		return nil, err
+	}
+
+	provider, ok := p.providers.Get(server.Provider)
+	if !ok {
+		return nil, ErrUnmanagedServer
+	}
+
+	record := entity.PowerAction{
+		ServerID:  server.ID,
+		Provider:  provider.Name(),
+		Action:    action,
+		Reason:    reason,
+		Automatic: automatic,
+		Actor:     Actor(ctx),
+		Time:      time.Now().UTC(),
+	}
+
+	powerErr := provider.Power(ctx, providerServerID(*server), action)
+	if powerErr != nil {
+		record.Error = powerErr.Error()
+		slog.Error("Failed to " + action + " server " + server.Hostname + ": " + powerErr.Error())
+	} else {
+		slog.Info("Requested " + action + " of server " + server.Hostname + " from " + provider.Name())
+	}
+
+	if err := p.databaseService.AddPowerAction(ctx, record); err != nil {
+		slog.Error("Failed to record " + action + " of server " + server.Hostname + ": " + err.Error())
+	}
+
+	return &record, powerErr
+}
@@ -0,0 +1,237 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sychonet/vdash-be/db/entity"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// DefaultProject owns the requests which do not name a project and the objects defined in libvirt before projects existed. It cannot be deleted and is only stored once its quota is set.
+const DefaultProject = "default"
+
+// projectSeparator joins the project and the name of an object in its libvirt name. Neither project names nor object names may contain it, so that libvirt names cannot collide across projects.
+const projectSeparator = "--"
+
+// OwnerNamespace is the XML namespace of the ownership metadata vdash writes into the libvirt definition of domains and networks.
+const OwnerNamespace = "https://github.com/sychonet/vdash-be/xmlns/owner/1"
+
+var (
+	// ErrUnknownProject is returned when a request acts in a project which does not exist.
+	ErrUnknownProject = errors.New("unknown project")
+	// ErrInvalidProject is returned when a project has an invalid name.
+	ErrInvalidProject = errors.New("invalid project")
+	// ErrDefaultProject is returned when the default project is changed or deleted.
+	ErrDefaultProject = errors.New("the default project cannot be changed")
+	// ErrProjectInUse is returned when a project which still owns resources is deleted.
+	ErrProjectInUse = errors.New("project still owns resources")
+	// ErrProjectExists is returned when a project is created with the name of an existing one.
+	ErrProjectExists = errors.New("project already exists")
+	// ErrNotOwned is returned when a request names a resource which belongs to another project.
+	ErrNotOwned = errors.New("resource belongs to another project")
+	// ErrInvalidName is returned when the name of a domain, volume or network contains the project separator.
+	ErrInvalidName = errors.New("name must not contain " + projectSeparator)
+)
+
+var projectNamePattern = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+// defaultProject is the entity of the default project, which exists without being stored.
+var defaultProject = entity.Project{Name: DefaultProject, Description: "Resources of requests without a project"}
+
+// LibvirtName returns the name under which an object of the project is defined in libvirt. Objects of the default project keep their name, the other ones are prefixed with their project.
+func LibvirtName(project, name string) string {
+	if project == DefaultProject {
+		return name
+	}
+
+	return project + projectSeparator + name
+}
+
+// ValidateName returns ErrInvalidName if a name cannot be turned into an unambiguous libvirt name.
+func ValidateName(name string) error {
+	if strings.Contains(name, projectSeparator) {
+		return ErrInvalidName
+	}
+
+	return nil
+}
+
+// Owner represents the project owning an object defined in libvirt and the name the project knows it by.
+type Owner struct {
+	Project string
+	Name    string
+}
+
+// VisibleName returns the name under which an object defined in libvirt is shown to the project, and false if the object belongs to another project. owners maps the libvirt names of the objects recorded in the database to their owner, objects which were not created through vdash belong to the default project.
+func VisibleName(owners map[string]Owner, libvirtName, project string) (string, bool) {
+	owner, ok := owners[libvirtName]
+	if !ok {
+		return libvirtName, project == DefaultProject
+	}
+
+	return owner.Name, owner.Project == project
+}
+
+// OwnerMetadata returns the libvirt metadata element recording the project owning a domain or network and the name the project knows it by.
+func OwnerMetadata(project, name string) string {
+	return fmt.Sprintf(`
+	<metadata>
+		<vdash:owner xmlns:vdash='%s'>
+			<vdash:project>%s</vdash:project>
+			<vdash:name>%s</vdash:name>
+		</vdash:owner>
+	</metadata>`, OwnerNamespace, project, name)
+}
+
+// ProjectService is a service that manages the projects owning domains, volumes, networks and public IP allocations.
+type ProjectService struct {
+	databaseService *DatabaseService
+
+	mu    sync.Mutex
+	locks map[string]*projectLock
+}
+
+// projectLock serializes the requests taking resources of a project. users counts the requests holding or waiting for it, so that it is dropped once unused.
+type projectLock struct {
+	mu    sync.Mutex
+	users int
+}
+
+func NewProjectService(databaseService *DatabaseService) *ProjectService {
+	return &ProjectService{databaseService: databaseService, locks: map[string]*projectLock{}}
+}
+
+// Lock blocks until no other request of this process is taking resources of the project, and returns the function releasing the project. Holding it from the quota check until the resources are recorded keeps concurrent creates from overshooting the quota. Instances of vdash sharing a database do not see each other's locks, so they can still overshoot it.
+func (p *ProjectService) Lock(name string) (unlock func()) {
+	p.mu.Lock()
+	lock, ok := p.locks[name]
+	if !ok {
+		lock = &projectLock{}
+		p.locks[name] = lock
+	}
+	lock.users++
+	p.mu.Unlock()
+
+	lock.mu.Lock()
+	return func() {
+		lock.mu.Unlock()
+
+		p.mu.Lock()
+		lock.users--
+		if lock.users == 0 {
+			delete(p.locks, name)
+		}
+		p.mu.Unlock()
+	}
+}
+
+// GetProjects returns the default project followed by the other ones.
+func (p *ProjectService) GetProjects(ctx context.Context) ([]entity.Project, error) {
+	stored, err := p.databaseService.GetProjects(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	projects := []entity.Project{defaultProject}
+	for _, project := range stored {
+		if project.Name == DefaultProject {
+			projects[0].Quota = project.Quota
+			continue
+		}
+		projects = append(projects, project)
+	}
+
+	return projects, nil
+}
+
+// GetProject returns the project with the given name or ErrUnknownProject.
+func (p *ProjectService) GetProject(ctx context.Context, name string) (*entity.Project, error) {
+	project, err := p.databaseService.GetProject(ctx, name)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		if name == DefaultProject {
+			project := defaultProject
+			return &project, nil
+		}
+		return nil, fmt.Errorf("%w: %s", ErrUnknownProject, name)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if name == DefaultProject {
+		project.Description = defaultProject.Description
+	}
+
+	return project, nil
+}
+
+// CreateProject creates a new project.
+func (p *ProjectService) CreateProject(ctx context.Context, name, description string) (*entity.Project, error) {
+	if name == DefaultProject {
+		return nil, ErrDefaultProject
+	}
+
+	if len(name) > 63 || !projectNamePattern.MatchString(name) {
+		return nil, fmt.Errorf("%w: name must be lowercase letters and digits separated by single dashes", ErrInvalidProject)
+	}
+
+	project := entity.Project{Name: name, Description: description, CreatedAt: time.Now().UTC()}
+	err := p.databaseService.AddProject(ctx, project)
+	if mongo.IsDuplicateKeyError(err) {
+		return nil, ErrProjectExists
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &project, nil
+}
+
+// DeleteProject deletes a project which no longer owns any domain, volume, network or public IP.
+func (p *ProjectService) DeleteProject(ctx context.Context, name string) error {
+	if name == DefaultProject {
+		return ErrDefaultProject
+	}
+
+	count, err := p.databaseService.CountProjectResources(ctx, name)
+	if err != nil {
+		return err
+	}
+
+	if count > 0 {
+		return ErrProjectInUse
+	}
+
+	return p.databaseService.DeleteProject(ctx, name)
+}
+
+// NetworkLibvirtName returns the libvirt name of a network a domain of the project attaches to. Networks of the project are found by the name the project knows them by, networks which were not created through vdash, such as the default network of libvirt, are shared by every project.
+func (p *ProjectService) NetworkLibvirtName(ctx context.Context, serverID int, project, name string) (string, error) {
+	libvirtName := LibvirtName(project, name)
+	for _, candidate := range []string{libvirtName, name} {
+		network, err := p.databaseService.GetNetworkByLibvirtName(ctx, serverID, candidate)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			continue
+		}
+
+		if err != nil {
+			return "", err
+		}
+
+		if network.Project != project {
+			return "", fmt.Errorf("%w: network %s", ErrNotOwned, name)
+		}
+
+		return candidate, nil
+	}
+
+	return name, nil
+}
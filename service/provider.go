@@ -0,0 +1,115 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/sychonet/vdash-be/db/entity"
+)
+
+// Types of the supported providers.
+const (
+	ProviderTypeScaleway = "scaleway"
+	ProviderTypeHetzner  = "hetzner"
+	ProviderTypeStatic   = "static"
+)
+
+// Power actions which can be requested from a provider.
+const (
+	PowerBoot     = "boot"
+	PowerReboot   = "reboot"
+	PowerShutdown = "shutdown"
+	PowerRescue   = "rescue"
+)
+
+// ErrNotSupported is returned when a provider does not support an operation.
+var ErrNotSupported = errors.New("operation not supported by provider")
+
+// ProviderServer describes a server in the inventory of a provider.
+type ProviderServer struct {
+	ID         int
+	Hostname   string
+	PublicIP   string
+	Datacenter string
+}
+
+// providerServerID returns the ID of a server in the inventory of its provider account. Servers imported before the IDs of every account were tracked apart have no ProviderID and use the same ID in vdash and in their account.
+func providerServerID(server entity.ServerInfo) int {
+	if server.ProviderID != 0 {
+		return server.ProviderID
+	}
+
+	return server.ID
+}
+
+// FailoverIP describes a failover IP in the inventory of a provider. Destination is the main IP of the server the failover IP is routed to.
+type FailoverIP struct {
+	IP          string
+	Destination string
+	MAC         string
+}
+
+// Provider is an account at a dedicated server provider. It lists the servers and failover IPs of the account and controls the power of its servers.
+type Provider interface {
+	// Name returns the name of the account as configured.
+	Name() string
+	// HostnamePrefix returns the prefix of the hostnames of the servers managed by vdash.
+	HostnamePrefix() string
+	ListServers(ctx context.Context) ([]ProviderServer, error)
+	GetServer(ctx context.Context, id int) (*ProviderServer, error)
+	ListFailoverIPs(ctx context.Context) ([]FailoverIP, error)
+	Power(ctx context.Context, id int, action string) error
+}
+
+// FailoverManager is implemented by providers which can route failover IPs and manage their virtual MACs.
+type FailoverManager interface {
+	// RouteFailoverIP routes a failover IP to the server with the given main IP.
+	RouteFailoverIP(ctx context.Context, ip, destination string) error
+	// GenerateMAC creates a virtual MAC of the given type for a failover IP and returns it.
+	GenerateMAC(ctx context.Context, ip, macType string) (string, error)
+	DeleteMAC(ctx context.Context, ip string) error
+}
+
+// ValidatePowerAction returns an error if the power action is unknown.
+func ValidatePowerAction(action string) error {
+	switch action {
+	case PowerBoot, PowerReboot, PowerShutdown, PowerRescue:
+		return nil
+	}
+
+	return fmt.Errorf("unknown power action %q", action)
+}
+
+// Providers holds the configured provider accounts by name.
+type Providers struct {
+	providers []Provider
+}
+
+func NewProviders(providers ...Provider) (*Providers, error) {
+	names := map[string]bool{}
+	for _, provider := range providers {
+		if names[provider.Name()] {
+			return nil, fmt.Errorf("duplicate provider %q", provider.Name())
+		}
+		names[provider.Name()] = true
+	}
+
+	return &Providers{providers: providers}, nil
+}
+
+// All returns every provider account in the configured order.
+func (p *Providers) All() []Provider {
+	return p.providers
+}
+
+// Get returns the provider account with the given name.
+func (p *Providers) Get(name string) (Provider, bool) {
+	for _, provider := range p.providers {
+		if provider.Name() == name {
+			return provider, true
+		}
+	}
+
+	return nil, false
+}
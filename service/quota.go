@@ -0,0 +1,101 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/sychonet/vdash-be/db/entity"
+)
+
+var (
+	// ErrQuotaExceeded is returned when a request would take a project over its quota.
+	ErrQuotaExceeded = errors.New("quota exceeded")
+	// ErrInvalidQuota is returned when a quota has a negative limit.
+	ErrInvalidQuota = errors.New("invalid quota")
+)
+
+// ResourceUsage represents the resources used by a project, or requested by an operation. Memory is in MiB and Disk in GB.
+type ResourceUsage struct {
+	VCPU      int64 `bson:"vcpu"`
+	Memory    int64 `bson:"memory"`
+	Disk      int64 `bson:"disk"`
+	Domains   int64 `bson:"domains"`
+	PublicIPs int64 `bson:"publicIPs"`
+	Networks  int64 `bson:"networks"`
+}
+
+// quotaLimit pairs a limit of a quota with the usage it applies to.
+type quotaLimit struct {
+	name      string
+	limit     *int64
+	used      int64
+	requested int64
+}
+
+func quotaLimits(quota entity.Quota, usage, requested ResourceUsage) []quotaLimit {
+	return []quotaLimit{
+		{"vcpu", quota.VCPU, usage.VCPU, requested.VCPU},
+		{"memory", quota.Memory, usage.Memory, requested.Memory},
+		{"disk", quota.Disk, usage.Disk, requested.Disk},
+		{"domains", quota.Domains, usage.Domains, requested.Domains},
+		{"publicIPs", quota.PublicIPs, usage.PublicIPs, requested.PublicIPs},
+		{"networks", quota.Networks, usage.Networks, requested.Networks},
+	}
+}
+
+// GetUsage returns the quota of the project along with the resources it uses.
+func (p *ProjectService) GetUsage(ctx context.Context, name string) (*entity.Quota, *ResourceUsage, error) {
+	project, err := p.GetProject(ctx, name)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	usage, err := p.databaseService.GetProjectUsage(ctx, name)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return &project.Quota, usage, nil
+}
+
+// CheckQuota returns ErrQuotaExceeded if adding the requested resources to those the project uses would exceed one of its limits. Only the requested resources are checked, so that a project whose quota was lowered below its usage can still release resources.
+func (p *ProjectService) CheckQuota(ctx context.Context, name string, requested ResourceUsage) error {
+	quota, usage, err := p.GetUsage(ctx, name)
+	if err != nil {
+		return err
+	}
+
+	var exceeded []string
+	for _, limit := range quotaLimits(*quota, *usage, requested) {
+		if limit.limit == nil || limit.requested <= 0 {
+			continue
+		}
+
+		if limit.used+limit.requested > *limit.limit {
+			exceeded = append(exceeded, fmt.Sprintf("%s would be %d, limit is %d", limit.name, limit.used+limit.requested, *limit.limit))
+		}
+	}
+
+	if len(exceeded) > 0 {
+		return fmt.Errorf("%w in project %s: %s", ErrQuotaExceeded, name, strings.Join(exceeded, ", "))
+	}
+
+	return nil
+}
+
+// SetQuota replaces the quota of the project.
+func (p *ProjectService) SetQuota(ctx context.Context, name string, quota entity.Quota) error {
+	for _, limit := range quotaLimits(quota, ResourceUsage{}, ResourceUsage{}) {
+		if limit.limit != nil && *limit.limit < 0 {
+			return fmt.Errorf("%w: %s must not be negative", ErrInvalidQuota, limit.name)
+		}
+	}
+
+	if _, err := p.GetProject(ctx, name); err != nil {
+		return err
+	}
+
+	return p.databaseService.SetProjectQuota(ctx, name, quota)
+}
@@ -0,0 +1,90 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestTokenBucketAllowsBurst(t *testing.T) {
+	bucket := newTokenBucket(10, 3)
+
+	start := time.Now()
+	for range 3 {
+		if err := bucket.Wait(context.Background()); err != nil {
+			t.Fatalf("Wait() error = %v", err)
+		}
+	}
+
+	if elapsed := time.Since(start); elapsed > 50*time.Millisecond {
+		t.Errorf("burst of 3 took %s, want no wait", elapsed)
+	}
+
+	start = time.Now()
+	if err := bucket.Wait(context.Background()); err != nil {
+		t.Fatalf("Wait() error = %v", err)
+	}
+
+	if elapsed := time.Since(start); elapsed < 80*time.Millisecond {
+		t.Errorf("request after the burst waited %s, want about 100ms at 10 requests per second", elapsed)
+	}
+}
+
+func TestTokenBucketRefills(t *testing.T) {
+	bucket := newTokenBucket(20, 1)
+	if err := bucket.Wait(context.Background()); err != nil {
+		t.Fatalf("Wait() error = %v", err)
+	}
+
+	time.Sleep(60 * time.Millisecond)
+
+	start := time.Now()
+	if err := bucket.Wait(context.Background()); err != nil {
+		t.Fatalf("Wait() error = %v", err)
+	}
+
+	if elapsed := time.Since(start); elapsed > 20*time.Millisecond {
+		t.Errorf("Wait() after a refill took %s, want no wait", elapsed)
+	}
+}
+
+func TestTokenBucketWithoutRateNeverBlocks(t *testing.T) {
+	bucket := newTokenBucket(0, 0)
+
+	start := time.Now()
+	for range 1000 {
+		if err := bucket.Wait(context.Background()); err != nil {
+			t.Fatalf("Wait() error = %v", err)
+		}
+	}
+
+	if elapsed := time.Since(start); elapsed > 100*time.Millisecond {
+		t.Errorf("1000 waits took %s, want no wait", elapsed)
+	}
+}
+
+func TestTokenBucketWaitHonorsContext(t *testing.T) {
+	bucket := newTokenBucket(0.1, 1)
+	if err := bucket.Wait(context.Background()); err != nil {
+		t.Fatalf("Wait() error = %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	if err := bucket.Wait(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Wait() error = %v, want context.DeadlineExceeded", err)
+	}
+
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Wait() returned after %s, want it to stop once the context is done", elapsed)
+	}
+
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := newTokenBucket(0, 0).Wait(canceled); !errors.Is(err, context.Canceled) {
+		t.Errorf("Wait() of a bucket without rate error = %v, want context.Canceled", err)
+	}
+}
@@ -0,0 +1,329 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sychonet/vdash-be/db/entity"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Resources of the API. A permission is written resource:verb, either part may be the wildcard *.
+const (
+	ResourceServers   = "servers"
+	ResourceIPs       = "ips"
+	ResourceDomains   = "domains"
+	ResourceNetworks  = "networks"
+	ResourceStorage   = "storage"
+	ResourceGroups    = "groups"
+	ResourceScheduler = "scheduler"
+	ResourceKeys      = "keys"
+	ResourceRoles     = "roles"
+	ResourceProjects  = "projects"
+	ResourceAudit     = "audit"
+)
+
+// Verbs of the permissions.
+const (
+	VerbCreate = "create"
+	VerbRead   = "read"
+	VerbUpdate = "update"
+	VerbDelete = "delete"
+	VerbPower  = "power"
+	VerbDrain  = "drain"
+	VerbSync   = "sync"
+)
+
+// Built-in roles, they cannot be changed or deleted.
+const (
+	RoleAdmin    = "admin"
+	RoleOperator = "operator"
+	RoleViewer   = "viewer"
+)
+
+var (
+	// ErrForbidden is returned when the roles of the caller do not grant the permission needed for a request.
+	ErrForbidden = errors.New("permission denied")
+	// ErrBuiltinRole is returned when a built-in role is changed or deleted.
+	ErrBuiltinRole = errors.New("built-in roles cannot be changed")
+	// ErrRoleInUse is returned when a role which is still bound is deleted.
+	ErrRoleInUse = errors.New("role is still bound")
+	// ErrUnknownRole is returned when a role which does not exist is granted.
+	ErrUnknownRole = errors.New("unknown role")
+	// ErrInvalidRole is returned when a role has an invalid name or permission.
+	ErrInvalidRole = errors.New("invalid role")
+)
+
+// resourceVerbs lists the verbs which apply to every resource.
+var resourceVerbs = map[string][]string{
+	ResourceServers:   {VerbCreate, VerbRead, VerbUpdate, VerbDelete, VerbPower, VerbDrain, VerbSync},
+	ResourceIPs:       {VerbCreate, VerbRead, VerbUpdate, VerbDelete, VerbSync},
+	ResourceDomains:   {VerbCreate, VerbRead, VerbUpdate, VerbDelete},
+	ResourceNetworks:  {VerbCreate, VerbRead, VerbDelete},
+	ResourceStorage:   {VerbCreate, VerbRead, VerbUpdate, VerbDelete},
+	ResourceGroups:    {VerbCreate, VerbRead, VerbDelete},
+	ResourceScheduler: {VerbRead},
+	ResourceKeys:      {VerbCreate, VerbRead, VerbDelete},
+	ResourceRoles:     {VerbCreate, VerbRead, VerbDelete},
+	ResourceProjects:  {VerbCreate, VerbRead, VerbUpdate, VerbDelete},
+	ResourceAudit:     {VerbRead},
+}
+
+// builtinRoles are the roles every installation starts with. Operators run the workloads and the servers but cannot add or remove servers and public IPs, nor manage access. Viewers can read everything but the API keys, roles and audit log.
+var builtinRoles = map[string]entity.Role{
+	RoleAdmin: {
+		Name:        RoleAdmin,
+		Description: "Full access to every resource",
+		Permissions: []string{"*:*"},
+	},
+	RoleOperator: {
+		Name:        RoleOperator,
+		Description: "Manages workloads and operates servers",
+		Permissions: []string{"servers:read", "servers:update", "servers:power", "servers:drain", "servers:sync", "ips:read", "ips:update", "ips:sync", "domains:*", "networks:*", "storage:*", "groups:*", "scheduler:read", "projects:read"},
+	},
+	RoleViewer: {
+		Name:        RoleViewer,
+		Description: "Read-only access to servers and workloads",
+		Permissions: []string{"servers:read", "ips:read", "domains:read", "networks:read", "storage:read", "groups:read", "scheduler:read", "projects:read"},
+	},
+}
+
+var roleNamePattern = regexp.MustCompile(`^[a-z][a-z0-9-]{0,62}$`)
+
+// ValidatePermission checks that a permission is made of a known resource and verb or of wildcards.
+func ValidatePermission(permission string) error {
+	resource, verb, ok := strings.Cut(permission, ":")
+	if !ok {
+		return fmt.Errorf("%w: permission %q is not of the form resource:verb", ErrInvalidRole, permission)
+	}
+
+	if resource == "*" {
+		if verb == "*" {
+			return nil
+		}
+
+		for _, verbs := range resourceVerbs {
+			if slices.Contains(verbs, verb) {
+				return nil
+			}
+		}
+		return fmt.Errorf("%w: unknown verb in permission %q", ErrInvalidRole, permission)
+	}
+
+	verbs, ok := resourceVerbs[resource]
+	if !ok {
+		return fmt.Errorf("%w: unknown resource in permission %q", ErrInvalidRole, permission)
+	}
+
+	if verb != "*" && !slices.Contains(verbs, verb) {
+		return fmt.Errorf("%w: unknown verb in permission %q", ErrInvalidRole, permission)
+	}
+
+	return nil
+}
+
+// roleGrants reports whether a role grants the permission.
+func roleGrants(role entity.Role, permission string) bool {
+	resource, verb, _ := strings.Cut(permission, ":")
+	for _, granted := range role.Permissions {
+		grantedResource, grantedVerb, _ := strings.Cut(granted, ":")
+		if (grantedResource == "*" || grantedResource == resource) && (grantedVerb == "*" || grantedVerb == verb) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// RBACService is a service that decides whether a caller may perform an action. Callers are granted roles by their API key or token, which apply to every project, and by role bindings, which apply to a single project or to every project.
+type RBACService struct {
+	databaseService *DatabaseService
+}
+
+func NewRBACService(databaseService *DatabaseService) *RBACService {
+	return &RBACService{databaseService: databaseService}
+}
+
+// Authorize returns ErrForbidden unless one of the roles of the caller grants the permission in the project. An empty project stands for actions outside of any project, which only roles granted in every project allow.
+func (s *RBACService) Authorize(ctx context.Context, identity *Identity, permission, project string) error {
+	roles := slices.Clone(identity.Roles)
+
+	bindings, err := s.databaseService.GetRoleBindings(ctx, identity.Subject)
+	if err != nil {
+		return err
+	}
+
+	for _, binding := range bindings {
+		if binding.Project == "" || binding.Project == project {
+			roles = append(roles, binding.Role)
+		}
+	}
+
+	// Check the built-in roles first as they do not need a lookup
+	var custom []string
+	for _, name := range roles {
+		role, ok := builtinRoles[name]
+		if !ok {
+			custom = append(custom, name)
+			continue
+		}
+
+		if roleGrants(role, permission) {
+			return nil
+		}
+	}
+
+	if len(custom) > 0 {
+		customRoles, err := s.databaseService.GetRolesWithNames(ctx, custom)
+		if err != nil {
+			return err
+		}
+
+		for _, role := range customRoles {
+			if roleGrants(role, permission) {
+				return nil
+			}
+		}
+	}
+
+	return fmt.Errorf("%w: %s is required", ErrForbidden, permission)
+}
+
+// GetRoles returns the built-in roles followed by the custom ones.
+func (s *RBACService) GetRoles(ctx context.Context) ([]entity.Role, error) {
+	var roles []entity.Role
+	for _, name := range []string{RoleAdmin, RoleOperator, RoleViewer} {
+		roles = append(roles, builtinRoles[name])
+	}
+
+	custom, err := s.databaseService.GetRoles(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(roles, custom...), nil
+}
+
+// IsBuiltinRole reports whether the role is one of the built-in roles.
+func IsBuiltinRole(name string) bool {
+	_, ok := builtinRoles[name]
+	return ok
+}
+
+// SaveRole creates or replaces a custom role.
+func (s *RBACService) SaveRole(ctx context.Context, role entity.Role) error {
+	if IsBuiltinRole(role.Name) {
+		return ErrBuiltinRole
+	}
+
+	if !roleNamePattern.MatchString(role.Name) {
+		return fmt.Errorf("%w: name must be lowercase letters, digits and dashes", ErrInvalidRole)
+	}
+
+	if len(role.Permissions) == 0 {
+		return fmt.Errorf("%w: at least one permission is required", ErrInvalidRole)
+	}
+
+	for _, permission := range role.Permissions {
+		if err := ValidatePermission(permission); err != nil {
+			return err
+		}
+	}
+
+	return s.databaseService.SaveRole(ctx, role)
+}
+
+// DeleteRole deletes a custom role which is not bound anymore.
+func (s *RBACService) DeleteRole(ctx context.Context, name string) error {
+	if IsBuiltinRole(name) {
+		return ErrBuiltinRole
+	}
+
+	count, err := s.databaseService.CountRoleBindings(ctx, name)
+	if err != nil {
+		return err
+	}
+
+	if count > 0 {
+		return ErrRoleInUse
+	}
+
+	return s.databaseService.DeleteRole(ctx, name)
+}
+
+// ValidateRoles returns ErrUnknownRole if one of the roles does not exist.
+func (s *RBACService) ValidateRoles(ctx context.Context, names []string) error {
+	var custom []string
+	for _, name := range names {
+		if !IsBuiltinRole(name) {
+			custom = append(custom, name)
+		}
+	}
+
+	if len(custom) == 0 {
+		return nil
+	}
+
+	roles, err := s.databaseService.GetRolesWithNames(ctx, custom)
+	if err != nil {
+		return err
+	}
+
+	found := map[string]bool{}
+	for _, role := range roles {
+		found[role.Name] = true
+	}
+
+	var missing []string
+	for _, name := range custom {
+		if !found[name] {
+			missing = append(missing, name)
+		}
+	}
+
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return fmt.Errorf("%w: %s", ErrUnknownRole, strings.Join(missing, ", "))
+	}
+
+	return nil
+}
+
+// CreateRoleBinding grants a role to a subject in a project, or in every project if project is empty.
+func (s *RBACService) CreateRoleBinding(ctx context.Context, subject, role, project, createdBy string) (*entity.RoleBinding, error) {
+	if err := s.ValidateRoles(ctx, []string{role}); err != nil {
+		return nil, err
+	}
+
+	binding := entity.RoleBinding{
+		Subject:   subject,
+		Role:      role,
+		Project:   project,
+		CreatedBy: createdBy,
+		CreatedAt: time.Now().UTC(),
+	}
+
+	id, err := s.databaseService.AddRoleBinding(ctx, binding)
+	if err != nil {
+		return nil, err
+	}
+	binding.ID = id
+
+	return &binding, nil
+}
+
+// GetRoleBindings returns the role bindings of the subject, or every role binding if subject is empty.
+func (s *RBACService) GetRoleBindings(ctx context.Context, subject string) ([]entity.RoleBinding, error) {
+	return s.databaseService.GetRoleBindings(ctx, subject)
+}
+
+// DeleteRoleBinding removes a role binding.
+func (s *RBACService) DeleteRoleBinding(ctx context.Context, id primitive.ObjectID) error {
+	return s.databaseService.DeleteRoleBinding(ctx, id)
+}
@@ -0,0 +1,290 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sychonet/vdash-be/db/entity"
+)
+
+// ErrSyncRunning is returned when a reconciliation is requested while another one is in progress.
+var ErrSyncRunning = errors.New("inventory sync is already running")
+
+// InventoryChange describes a field of a server which changed in the provider inventory.
+type InventoryChange struct {
+	ServerID int
+	Field    string
+	Old      string
+	New      string
+}
+
+// InventoryDiff describes the outcome of a reconciliation with the provider inventories.
+type InventoryDiff struct {
+	Added      []entity.ServerInfo
+	Updated    []InventoryChange
+	Missing    []int
+	Returned   []int
+	Errors     []string
+	DryRun     bool
+	StartedAt  time.Time
+	FinishedAt time.Time
+}
+
+// ReconcilerService is a service that keeps the servers in the database in sync with the inventory of every provider account. New servers whose hostname has the prefix of their account are added once they pass the onboarding checks, changed hostnames and public IPs are updated and servers which disappeared from their account are flagged as missing.
+type ReconcilerService struct {
+	databaseService   *DatabaseService
+	providers         *Providers
+	onboardingService *OnboardingService
+	interval          time.Duration
+
+	mu sync.Mutex
+}
+
+func NewReconcilerService(databaseService *DatabaseService, providers *Providers, onboardingService *OnboardingService, interval time.Duration) *ReconcilerService {
+	return &ReconcilerService{
+		databaseService:   databaseService,
+		providers:         providers,
+		onboardingService: onboardingService,
+		interval:          interval,
+	}
+}
+
+// Run reconciles the inventory right away and then on every interval until the context is done.
+func (r *ReconcilerService) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		diff, err := r.Sync(ctx, false)
+		if err != nil {
+			slog.Error("Failed to sync server inventory: " + err.Error())
+		} else {
+			slog.Info(fmt.Sprintf("Server inventory synced: %d added, %d updated, %d missing, %d returned, %d errors", len(diff.Added), len(diff.Updated), len(diff.Missing), len(diff.Returned), len(diff.Errors)))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Sync compares the servers in the database with the inventory of every provider account and applies the differences. If dryRun is true the differences are only reported. The servers of an account whose inventory cannot be read are left untouched, so that they are never flagged as missing because of a failed API call.
+func (r *ReconcilerService) Sync(ctx context.Context, dryRun bool) (*InventoryDiff, error) {
+	if !r.mu.TryLock() {
+		return nil, ErrSyncRunning
+	}
+	defer r.mu.Unlock()
+
+	diff := &InventoryDiff{DryRun: dryRun, StartedAt: time.Now().UTC()}
+
+	servers, err := r.databaseService.GetServers(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Track the IDs in use and the inventory entry of every known server so that an entry is never claimed twice
+	used := map[int]bool{}
+	claimed := map[inventoryKey]bool{}
+	for _, server := range servers {
+		used[server.ID] = true
+		if server.Provider != "" {
+			claimed[inventoryKey{provider: server.Provider, id: providerServerID(server)}] = true
+		}
+	}
+
+	for _, provider := range r.providers.All() {
+		inventory, err := provider.ListServers(ctx)
+		if err != nil {
+			diff.Errors = append(diff.Errors, fmt.Sprintf("%s: failed to list servers: %v", provider.Name(), err))
+			continue
+		}
+
+		r.reconcileProvider(ctx, provider, inventory, servers, used, claimed, diff)
+	}
+
+	diff.FinishedAt = time.Now().UTC()
+	return diff, nil
+}
+
+// inventoryKey identifies a server in the inventory of a provider account. Every account numbers its servers on its own, so that several accounts may list the same ID.
+type inventoryKey struct {
+	provider string
+	id       int
+}
+
+// reconcileProvider applies the differences between the servers in the database and the inventory of a provider account. Servers onboarded without a provider are adopted by the first account listing their ID.
+func (r *ReconcilerService) reconcileProvider(ctx context.Context, provider Provider, inventory []ProviderServer, servers []entity.ServerInfo, used map[int]bool, claimed map[inventoryKey]bool, diff *InventoryDiff) {
+	listed := map[int]ProviderServer{}
+	for _, details := range inventory {
+		listed[details.ID] = details
+	}
+
+	for i, server := range servers {
+		switch server.Provider {
+		case provider.Name():
+			details, ok := listed[providerServerID(server)]
+			if !ok {
+				r.flagMissing(ctx, server, diff)
+				continue
+			}
+
+			r.reconcileServer(ctx, provider, server, details, diff)
+		case "":
+			key := inventoryKey{provider: provider.Name(), id: server.ID}
+			details, ok := listed[server.ID]
+			if !ok || claimed[key] {
+				continue
+			}
+
+			claimed[key] = true
+			servers[i].Provider = provider.Name()
+			r.reconcileServer(ctx, provider, server, details, diff)
+		}
+	}
+
+	// Add the servers which are not known yet
+	for _, id := range slices.Sorted(maps.Keys(listed)) {
+		details := listed[id]
+		key := inventoryKey{provider: provider.Name(), id: id}
+		if claimed[key] || !strings.HasPrefix(details.Hostname, provider.HostnamePrefix()) {
+			continue
+		}
+
+		server, err := r.newServer(ctx, provider, details, newServerID(used, id))
+		if err == nil && !diff.DryRun {
+			err = r.databaseService.AddServer(ctx, server)
+		}
+
+		if err != nil {
+			diff.Errors = append(diff.Errors, fmt.Sprintf("%s: failed to add server %d: %v", provider.Name(), id, err))
+			continue
+		}
+
+		claimed[key] = true
+		used[server.ID] = true
+		diff.Added = append(diff.Added, server)
+	}
+}
+
+// newServerID returns the ID of a server imported from a provider account. The ID of the server in its account is kept unless another server already uses it, in which case the server gets the next free ID.
+func newServerID(used map[int]bool, id int) int {
+	if !used[id] {
+		return id
+	}
+
+	next := 1
+	for usedID := range used {
+		next = max(next, usedID+1)
+	}
+
+	return next
+}
+
+// flagMissing flags a server which disappeared from the inventory of its provider account.
+func (r *ReconcilerService) flagMissing(ctx context.Context, server entity.ServerInfo, diff *InventoryDiff) {
+	if server.Missing {
+		return
+	}
+
+	server.Missing = true
+	server.MissingSince = time.Now().UTC()
+	if err := r.saveInventory(ctx, server, diff); err != nil {
+		diff.Errors = append(diff.Errors, fmt.Sprintf("%s: failed to flag server %d as missing: %v", server.Provider, server.ID, err))
+		return
+	}
+
+	slog.Warn("Server " + server.Hostname + " is missing from the inventory of " + server.Provider)
+	diff.Missing = append(diff.Missing, server.ID)
+}
+
+// reconcileServer applies the differences between a server in the database and its inventory entry.
+func (r *ReconcilerService) reconcileServer(ctx context.Context, provider Provider, server entity.ServerInfo, details ProviderServer, diff *InventoryDiff) {
+	var changes []InventoryChange
+	updated := server
+	updated.Provider = provider.Name()
+
+	if details.Hostname != server.Hostname {
+		updated.Hostname = details.Hostname
+		changes = append(changes, InventoryChange{ServerID: server.ID, Field: "hostname", Old: server.Hostname, New: details.Hostname})
+	}
+
+	if details.PublicIP != "" && details.PublicIP != server.PublicIP {
+		updated.PublicIP = details.PublicIP
+		libvirtURI, err := r.onboardingService.LibvirtURI(updated)
+		if err != nil {
+			diff.Errors = append(diff.Errors, fmt.Sprintf("%s: failed to build libvirt URI of server %d: %v", provider.Name(), server.ID, err))
+			return
+		}
+		updated.LibvirtURI = libvirtURI
+		changes = append(changes, InventoryChange{ServerID: server.ID, Field: "publicIP", Old: server.PublicIP, New: details.PublicIP})
+	}
+
+	returned := server.Missing
+	if returned {
+		updated.Missing = false
+		updated.MissingSince = time.Time{}
+	}
+
+	if len(changes) == 0 && !returned && server.Provider == updated.Provider {
+		return
+	}
+
+	if err := r.saveInventory(ctx, updated, diff); err != nil {
+		diff.Errors = append(diff.Errors, fmt.Sprintf("%s: failed to update server %d: %v", provider.Name(), server.ID, err))
+		return
+	}
+
+	diff.Updated = append(diff.Updated, changes...)
+	if returned {
+		diff.Returned = append(diff.Returned, server.ID)
+	}
+}
+
+// saveInventory records the inventory fields of a server unless the sync is a dry run.
+func (r *ReconcilerService) saveInventory(ctx context.Context, server entity.ServerInfo, diff *InventoryDiff) error {
+	if diff.DryRun {
+		return nil
+	}
+
+	return r.databaseService.UpdateServerInventory(ctx, server)
+}
+
+// newServer converts an inventory entry into a server with the given ID and discovers its capabilities. A *RejectedError is returned if the server fails the onboarding checks, so that it never becomes schedulable without them. The datacenter of the server is imported as its zone label.
+func (r *ReconcilerService) newServer(ctx context.Context, provider Provider, details ProviderServer, id int) (entity.ServerInfo, error) {
+	labels := map[string]string{}
+	if details.Datacenter != "" {
+		labels[ZoneLabel] = details.Datacenter
+	}
+
+	server := entity.ServerInfo{
+		ID:         id,
+		Hostname:   details.Hostname,
+		PublicIP:   details.PublicIP,
+		Labels:     labels,
+		Provider:   provider.Name(),
+		ProviderID: details.ID,
+	}
+
+	libvirtURI, err := r.onboardingService.LibvirtURI(server)
+	if err != nil {
+		return server, err
+	}
+	server.LibvirtURI = libvirtURI
+
+	capabilities, err := r.onboardingService.discover(ctx, server)
+	if err != nil {
+		return server, err
+	}
+	server.Capabilities = capabilities
+
+	return server, nil
+}
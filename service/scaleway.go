@@ -1,79 +1,224 @@
 package service
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
-	"time"
+	"net/url"
+	"strconv"
 
 	"github.com/sychonet/vdash-be/dto/response"
+	"golang.org/x/sync/errgroup"
 )
 
+// ScalewayService is a provider for a Scaleway Dedibox account using the api.online.net API.
 type ScalewayService struct {
-	BaseURL string
-	Token   string
+	name           string
+	hostnamePrefix string
+	rescueImage    string
+	client         *apiClient
 }
 
-func NewScalewayService(baseURL, token string) *ScalewayService {
+func NewScalewayService(name, baseURL, token, hostnamePrefix, rescueImage string, options APIOptions) *ScalewayService {
+	authorize := func(req *http.Request) {
+		// set the bearer token in Authorization header
+		req.Header.Add("Authorization", token)
+	}
+
+	// The API describes errors as {"error": "...", "code": ...}
+	decodeError := func(data []byte, apiErr *APIError) {
+		var body struct {
+			Error string `json:"error"`
+			Code  int    `json:"code"`
+		}
+		if json.Unmarshal(data, &body) == nil {
+			apiErr.Message = body.Error
+			if body.Code != 0 {
+				apiErr.Code = strconv.Itoa(body.Code)
+			}
+		}
+	}
+
 	return &ScalewayService{
-		BaseURL: baseURL,
-		Token:   token,
+		name:           name,
+		hostnamePrefix: hostnamePrefix,
+		rescueImage:    rescueImage,
+		client:         newAPIClient(name, baseURL, options, authorize, decodeError),
 	}
 }
 
+func (s *ScalewayService) Name() string {
+	return s.name
+}
+
+func (s *ScalewayService) HostnamePrefix() string {
+	return s.hostnamePrefix
+}
+
 // Call API endpoint GET https://api.online.net/api/v1/server to fetch all servers running under admin account on Scaleway
-func (s *ScalewayService) GetServers() ([]string, error) {
-	// create a new http client
-	client := &http.Client{Timeout: 5 * time.Second}
+func (s *ScalewayService) GetServers(ctx context.Context) ([]string, error) {
+	var servers []string
+	if err := s.client.do(ctx, http.MethodGet, "/api/v1/server", nil, &servers); err != nil {
+		return nil, err
+	}
 
-	// create a new http request
-	req, err := http.NewRequest("GET", s.BaseURL+"/api/v1/server", nil)
-	if err != nil {
+	// return the list of servers
+	return servers, nil
+}
+
+// Call API endpoint GET https://api.online.net/api/v1/server/{server_id} to fetch server details
+func (s *ScalewayService) GetServerDetails(ctx context.Context, serverString string) (*response.ScalewayServerResponse, error) {
+	var server response.ScalewayServerResponse
+	if err := s.client.do(ctx, http.MethodGet, serverString, nil, &server); err != nil {
 		return nil, err
 	}
 
-	// set the bearer token in Authorization header
-	req.Header.Add("Authorization", s.Token)
-	// send request to the api server for service provider Scaleway
-	resp, err := client.Do(req)
+	// return the server details
+	return &server, nil
+}
+
+// GetServersDetails fetches the details of the given servers with a bounded number of requests in flight. The details are returned in the order of the servers and the first error cancels the remaining requests.
+func (s *ScalewayService) GetServersDetails(ctx context.Context, serverStrings []string) ([]response.ScalewayServerResponse, error) {
+	details := make([]response.ScalewayServerResponse, len(serverStrings))
+
+	group, ctx := errgroup.WithContext(ctx)
+	group.SetLimit(s.client.concurrency)
+	for i, serverString := range serverStrings {
+		group.Go(func() error {
+			server, err := s.GetServerDetails(ctx, serverString)
+			if err != nil {
+				return err
+			}
+
+			details[i] = *server
+			return nil
+		})
+	}
+
+	if err := group.Wait(); err != nil {
+		return nil, err
+	}
+
+	return details, nil
+}
+
+// ListServers returns every server of the account.
+func (s *ScalewayService) ListServers(ctx context.Context) ([]ProviderServer, error) {
+	paths, err := s.GetServers(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	// parse the response
-	var servers []string
-	if err := json.NewDecoder(resp.Body).Decode(&servers); err != nil {
+	details, err := s.GetServersDetails(ctx, paths)
+	if err != nil {
 		return nil, err
 	}
 
-	// return the list of servers
+	servers := make([]ProviderServer, 0, len(details))
+	for _, server := range details {
+		servers = append(servers, newScalewayProviderServer(server))
+	}
+
 	return servers, nil
 }
 
-// Call API endpoint GET https://api.online.net/api/v1/server/{server_id} to fetch server details
-func (s *ScalewayService) GetServerDetails(serverString string) (*response.ScalewayServerResponse, error) {
-	// create a new http client
-	client := &http.Client{Timeout: 5 * time.Second}
-
-	// create a new http request
-	req, err := http.NewRequest("GET", s.BaseURL+serverString, nil)
+// GetServer returns a server of the account.
+func (s *ScalewayService) GetServer(ctx context.Context, id int) (*ProviderServer, error) {
+	details, err := s.GetServerDetails(ctx, fmt.Sprintf("/api/v1/server/%d", id))
 	if err != nil {
 		return nil, err
 	}
 
-	// set the bearer token in Authorization header
-	req.Header.Add("Authorization", s.Token)
-	// send request to the api server for service provider Scaleway
-	resp, err := client.Do(req)
-	if err != nil {
+	server := newScalewayProviderServer(*details)
+	return &server, nil
+}
+
+// Call API endpoint GET https://api.online.net/api/v1/server/failover to fetch the failover IPs of the account along with the server they are routed to
+func (s *ScalewayService) ListFailoverIPs(ctx context.Context) ([]FailoverIP, error) {
+	var failovers []response.ScalewayFailoverResponse
+	if err := s.client.do(ctx, http.MethodGet, "/api/v1/server/failover", nil, &failovers); err != nil {
 		return nil, err
 	}
 
-	// parse the response
-	var server response.ScalewayServerResponse
-	if err := json.NewDecoder(resp.Body).Decode(&server); err != nil {
+	// The virtual MAC of every failover IP is only part of its details
+	ips := make([]FailoverIP, len(failovers))
+
+	group, ctx := errgroup.WithContext(ctx)
+	group.SetLimit(s.client.concurrency)
+	for i, failover := range failovers {
+		group.Go(func() error {
+			var details response.ScalewayIPResponse
+			if err := s.client.do(ctx, http.MethodGet, "/api/v1/server/ip/"+failover.Source, nil, &details); err != nil {
+				return err
+			}
+
+			ips[i] = FailoverIP{IP: failover.Source, Destination: failover.Destination, MAC: details.MAC}
+			return nil
+		})
+	}
+
+	if err := group.Wait(); err != nil {
 		return nil, err
 	}
 
-	// return the server details
-	return &server, nil
+	return ips, nil
+}
+
+// Call API endpoint POST https://api.online.net/api/v1/server/failover/edit to route a failover IP to another server
+func (s *ScalewayService) RouteFailoverIP(ctx context.Context, ip, destination string) error {
+	form := url.Values{"source": {ip}, "destination": {destination}}
+	return s.client.do(ctx, http.MethodPost, "/api/v1/server/failover/edit", form, nil)
+}
+
+// Call API endpoint POST https://api.online.net/api/v1/server/failover/generateMac to create a virtual MAC for a failover IP
+func (s *ScalewayService) GenerateMAC(ctx context.Context, ip, macType string) (string, error) {
+	var mac string
+	form := url.Values{"address": {ip}, "type": {macType}}
+	if err := s.client.do(ctx, http.MethodPost, "/api/v1/server/failover/generateMac", form, &mac); err != nil {
+		return "", err
+	}
+
+	return mac, nil
+}
+
+// Call API endpoint POST https://api.online.net/api/v1/server/failover/deleteMac to delete the virtual MAC of a failover IP
+func (s *ScalewayService) DeleteMAC(ctx context.Context, ip string) error {
+	form := url.Values{"address": {ip}}
+	return s.client.do(ctx, http.MethodPost, "/api/v1/server/failover/deleteMac", form, nil)
+}
+
+// Call API endpoint POST https://api.online.net/api/v1/server/{action}/{server_id} to boot, reboot, shut down or boot a server in rescue mode
+func (s *ScalewayService) Power(ctx context.Context, id int, action string) error {
+	if err := ValidatePowerAction(action); err != nil {
+		return err
+	}
+
+	form := url.Values{}
+	if action == PowerRescue {
+		if s.rescueImage == "" {
+			return fmt.Errorf("%w: no rescue image configured for %s", ErrNotSupported, s.name)
+		}
+		form.Set("image", s.rescueImage)
+	}
+
+	return s.client.do(ctx, http.MethodPost, fmt.Sprintf("/api/v1/server/%s/%d", action, id), form, nil)
+}
+
+// newScalewayProviderServer converts the details of a Scaleway server into a provider server.
+func newScalewayProviderServer(details response.ScalewayServerResponse) ProviderServer {
+	server := ProviderServer{
+		ID:         details.ID,
+		Hostname:   details.Hostname,
+		Datacenter: details.Location.Datacenter,
+	}
+
+	for _, ip := range details.IP {
+		if ip.Type == "public" {
+			server.PublicIP = ip.Address
+			break
+		}
+	}
+
+	return server
 }
@@ -0,0 +1,164 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// newTestScaleway returns a Scaleway provider using a test server serving handler as its API.
+func newTestScaleway(t *testing.T, rescueImage string, handler http.HandlerFunc) *ScalewayService {
+	t.Helper()
+
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	return NewScalewayService("scaleway", server.URL, "Bearer secret", "vdash-", rescueImage, APIOptions{MaxRetries: 2})
+}
+
+// writeJSON writes a value as the JSON body of a response.
+func writeJSON(t *testing.T, w http.ResponseWriter, value any) {
+	t.Helper()
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(value); err != nil {
+		t.Errorf("failed to encode response: %v", err)
+	}
+}
+
+func TestScalewayListServers(t *testing.T) {
+	scaleway := newTestScaleway(t, "", func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Authorization"); got != "Bearer secret" {
+			t.Errorf("Authorization = %q, want the configured token", got)
+		}
+
+		switch r.URL.Path {
+		case "/api/v1/server":
+			writeJSON(t, w, []string{"/api/v1/server/1", "/api/v1/server/2"})
+		case "/api/v1/server/1":
+			writeJSON(t, w, map[string]any{
+				"id":       1,
+				"hostname": "vdash-one",
+				"ip":       []map[string]string{{"address": "10.0.0.1", "type": "private"}, {"address": "192.0.2.1", "type": "public"}},
+				"location": map[string]string{"datacenter": "DC3"},
+			})
+		case "/api/v1/server/2":
+			writeJSON(t, w, map[string]any{"id": 2, "hostname": "vdash-two", "location": map[string]string{"datacenter": "DC5"}})
+		default:
+			http.NotFound(w, r)
+		}
+	})
+
+	servers, err := scaleway.ListServers(context.Background())
+	if err != nil {
+		t.Fatalf("ListServers() error = %v", err)
+	}
+
+	want := []ProviderServer{
+		{ID: 1, Hostname: "vdash-one", PublicIP: "192.0.2.1", Datacenter: "DC3"},
+		{ID: 2, Hostname: "vdash-two", Datacenter: "DC5"},
+	}
+	if len(servers) != len(want) {
+		t.Fatalf("ListServers() = %+v, want %+v", servers, want)
+	}
+
+	for i := range want {
+		if servers[i] != want[i] {
+			t.Errorf("ListServers()[%d] = %+v, want %+v", i, servers[i], want[i])
+		}
+	}
+}
+
+func TestScalewayRetriesThrottledRequests(t *testing.T) {
+	var attempts atomic.Int64
+	scaleway := newTestScaleway(t, "", func(w http.ResponseWriter, r *http.Request) {
+		if attempts.Add(1) == 1 {
+			http.Error(w, `{"error":"Too many requests","code":429}`, http.StatusTooManyRequests)
+			return
+		}
+		writeJSON(t, w, map[string]any{"id": 7, "hostname": "vdash-seven"})
+	})
+
+	server, err := scaleway.GetServer(context.Background(), 7)
+	if err != nil {
+		t.Fatalf("GetServer() error = %v", err)
+	}
+
+	if server.ID != 7 || attempts.Load() != 2 {
+		t.Errorf("GetServer() = %+v after %d attempts, want server 7 after 2 attempts", server, attempts.Load())
+	}
+}
+
+func TestScalewayErrors(t *testing.T) {
+	tests := []struct {
+		name        string
+		status      int
+		body        string
+		wantCode    string
+		wantMessage string
+	}{
+		{"API error", http.StatusForbidden, `{"error":"Access denied","code":7}`, "7", "Access denied"},
+		{"API error without code", http.StatusNotFound, `{"error":"Server not found"}`, "", "Server not found"},
+		{"body which is not JSON", http.StatusBadRequest, "bad request", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scaleway := newTestScaleway(t, "", func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			})
+
+			_, err := scaleway.GetServer(context.Background(), 1)
+
+			var apiErr *APIError
+			if !errors.As(err, &apiErr) {
+				t.Fatalf("GetServer() error = %v, want an APIError", err)
+			}
+
+			if apiErr.Provider != "scaleway" || apiErr.StatusCode != tt.status || apiErr.Code != tt.wantCode || apiErr.Message != tt.wantMessage {
+				t.Errorf("APIError = %+v, want status %d, code %q and message %q", apiErr, tt.status, tt.wantCode, tt.wantMessage)
+			}
+
+			if !strings.Contains(err.Error(), "scaleway: GET /api/v1/server/1") {
+				t.Errorf("Error() = %q, want it to name the provider and the request", err.Error())
+			}
+		})
+	}
+}
+
+func TestScalewayPower(t *testing.T) {
+	var path, image string
+	scaleway := newTestScaleway(t, "ubuntu-rescue", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			t.Errorf("method = %s, want POST", r.Method)
+		}
+
+		path = r.URL.Path
+		image = r.PostFormValue("image")
+	})
+
+	if err := scaleway.Power(context.Background(), 3, PowerRescue); err != nil {
+		t.Fatalf("Power() error = %v", err)
+	}
+
+	if path != "/api/v1/server/rescue/3" || image != "ubuntu-rescue" {
+		t.Errorf("request = %s with image %q, want /api/v1/server/rescue/3 with the rescue image", path, image)
+	}
+}
+
+func TestScalewayRescueNeedsImage(t *testing.T) {
+	scaleway := newTestScaleway(t, "", func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+	})
+
+	if err := scaleway.Power(context.Background(), 3, PowerRescue); !errors.Is(err, ErrNotSupported) {
+		t.Errorf("Power() error = %v, want ErrNotSupported", err)
+	}
+}
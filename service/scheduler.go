@@ -1,18 +1,55 @@
 package service
 
 import (
+	"context"
+	"fmt"
 	"log/slog"
 	"slices"
 
 	"github.com/sychonet/vdash-be/db/entity"
 )
 
+// Names of the filters applied by the scheduler.
+const (
+	FilterCordoned    = "cordoned"
+	FilterHealth      = "health"
+	FilterInventory   = "inventory"
+	FilterPublicIP    = "publicIP"
+	FilterPlacement   = "placement"
+	FilterResources   = "resources"
+	FilterStoragePool = "storagePool"
+)
+
 // SchedulerService is a service that schedules the creation of resources on servers. It uses the DatabaseService and LibvirtService to get the server details, connect to libvirtd and find the best possible server with available resources.
 type SchedulerService struct {
 	databaseService *DatabaseService
 	libvirtService  *LibvirtService
 }
 
+// FilterResult represents the outcome of a single scheduler filter for a server.
+type FilterResult struct {
+	Name   string
+	Passed bool
+	Reason string
+}
+
+// Candidate represents a server evaluated by the scheduler. FreeMemory and FreeSpace are in bytes and are only known for servers which were probed over libvirt.
+type Candidate struct {
+	Server     entity.ServerInfo
+	Filters    []FilterResult
+	FreeMemory uint64
+	FreeVCPU   uint
+	FreeSpace  uint64
+	Score      int
+	Error      error
+}
+
+// Decision represents the outcome of a scheduling run. ServerID is 0 if no candidate passed every filter.
+type Decision struct {
+	Candidates []Candidate
+	ServerID   int
+}
+
 func NewSchedulerService(databaseService *DatabaseService, libvirtService *LibvirtService) *SchedulerService {
 	return &SchedulerService{
 		databaseService: databaseService,
@@ -20,84 +57,209 @@ func NewSchedulerService(databaseService *DatabaseService, libvirtService *Libvi
 	}
 }
 
-func (s *SchedulerService) GetServerIDForVolume(poolName string, size int) (int, error) {
-	var libvirtURIs []string
-	// Get all the server details from the database
-	serverDetails, err := s.databaseService.GetServers()
+// Eligible reports whether the candidate passed every filter it was evaluated against.
+func (c *Candidate) Eligible() bool {
+	for _, filter := range c.Filters {
+		if !filter.Passed {
+			return false
+		}
+	}
+
+	return true
+}
+
+// addFilter records the outcome of a filter for the candidate. An empty reason means the filter passed.
+func (c *Candidate) addFilter(name, reason string) {
+	c.Filters = append(c.Filters, FilterResult{Name: name, Passed: reason == "", Reason: reason})
+}
+
+// GetServerIDForVolume returns the id of a server matching the placement whose storage pool has enough space for a volume of the given size in GB. It returns 0 if no server is available.
+func (s *SchedulerService) GetServerIDForVolume(ctx context.Context, poolName string, size int, placement Placement) (int, error) {
+	decision, err := s.ScheduleVolume(ctx, poolName, size, placement)
 	if err != nil {
 		return 0, err
 	}
 
-	// Convert size from GB to bytes
-	requiredSpace := uint64(size * 1024 * 1024 * 1024)
+	return decision.ServerID, nil
+}
+
+// GetServerIDForCreatingDomain returns the id of a server matching the placement with enough memory (in MiB) and vcpus for a new domain. It returns 0 if no server is available.
+func (s *SchedulerService) GetServerIDForCreatingDomain(ctx context.Context, memory uint64, vcpu uint, publicIP bool, placement Placement) (int, error) {
+	decision, err := s.ScheduleDomain(ctx, memory, vcpu, publicIP, placement)
+	if err != nil {
+		return 0, err
+	}
+
+	return decision.ServerID, nil
+}
 
-	for _, server := range serverDetails {
-		libvirtURIs = append(libvirtURIs, server.LibvirtURI)
+// ScheduleVolume evaluates every server for a new volume of the given size in GB in the storage pool and returns the outcome of each filter along with the chosen server. Nothing is created.
+func (s *SchedulerService) ScheduleVolume(ctx context.Context, poolName string, size int, placement Placement) (*Decision, error) {
+	// Get all the server details from the database
+	servers, err := s.databaseService.GetServers(ctx)
+	if err != nil {
+		slog.Error("Failed to get server details: " + err.Error())
+		return nil, err
 	}
 
-	// Check all servers for pool with sufficient space
-	results := s.libvirtService.CheckPoolsForSpace(libvirtURIs, poolName, requiredSpace)
+	candidates := newCandidates(servers, placement)
+
+	// Convert size from GB to bytes
+	requiredSpace := uint64(size) * 1024 * 1024 * 1024
+
+	// Check the servers which passed every filter so far for a pool with sufficient space
+	libvirtURIs := eligibleURIs(candidates)
+	results := s.libvirtService.CheckPoolsForSpace(ctx, libvirtURIs, poolName, requiredSpace)
 
 	for _, result := range results {
-		if result.HasSpace {
-			index := slices.Index(libvirtURIs, result.LibvirtURI)
-			// Return the server id where the pool has enough space left to create the volume
-			return serverDetails[index].ID, nil
+		for i := range candidates {
+			candidate := &candidates[i]
+			if candidate.Server.LibvirtURI != result.LibvirtURI || !candidate.Eligible() {
+				continue
+			}
+
+			candidate.FreeSpace = result.AvailableSpace
+			candidate.Error = result.Error
+
+			switch {
+			case result.Error != nil:
+				candidate.addFilter(FilterStoragePool, "failed to check storage pool "+poolName+": "+result.Error.Error())
+			case !result.HasSpace:
+				candidate.addFilter(FilterStoragePool, fmt.Sprintf("storage pool %s has %d bytes available, %d required", poolName, result.AvailableSpace, requiredSpace))
+			default:
+				candidate.addFilter(FilterStoragePool, "")
+			}
 		}
 	}
 
-	return 0, nil
+	return decide(candidates, placement), nil
 }
 
-func (s *SchedulerService) GetServerIDForCreatingDomain(memory uint64, vcpu uint, publicIP bool) (int, error) {
-	var serverIDs []int
-	var serverDetails []entity.ServerInfo
-	var libvirtURIs []string
+// ScheduleDomain evaluates every server for a new domain with the given memory in MiB and vcpus and returns the outcome of each filter along with the chosen server. Nothing is created.
+func (s *SchedulerService) ScheduleDomain(ctx context.Context, memory uint64, vcpu uint, publicIP bool, placement Placement) (*Decision, error) {
+	// Get all the server details from the database
+	servers, err := s.databaseService.GetServers(ctx)
+	if err != nil {
+		slog.Error("Failed to get server details: " + err.Error())
+		return nil, err
+	}
+
+	var serverIDsWithIP []int
 	if publicIP {
 		// Get all the servers with public IP available from the database
-		availableServers, err := s.databaseService.GetAvailablePublicIPs()
+		availableIPs, err := s.databaseService.GetAvailablePublicIPs(ctx)
 		if err != nil {
 			slog.Error("Failed to get serverIDs for available public IPs: " + err.Error())
-			return 0, err
+			return nil, err
 		}
-		for _, server := range availableServers {
-			serverIDs = append(serverIDs, server.ServerID)
+
+		for _, ip := range availableIPs {
+			serverIDsWithIP = append(serverIDsWithIP, ip.ServerID)
 		}
+	}
 
-		// Get all the server details from the database for servers with public IP available
-		servers, err := s.databaseService.GetServersWithIDs(serverIDs)
-		serverDetails = servers
-		if err != nil {
-			slog.Error("Failed to get server details for serverIDs: " + err.Error())
-			return 0, err
+	candidates := newCandidates(servers, placement)
+
+	if publicIP {
+		for i := range candidates {
+			if slices.Contains(serverIDsWithIP, candidates[i].Server.ID) {
+				candidates[i].addFilter(FilterPublicIP, "")
+			} else {
+				candidates[i].addFilter(FilterPublicIP, "no public IP available")
+			}
 		}
-	} else {
-		// Get all the server details from the database
-		servers, err := s.databaseService.GetServers()
-		serverDetails = servers
-		if err != nil {
-			slog.Error("Failed to get server details: " + err.Error())
-			return 0, err
+	}
+
+	// Convert memory from MiB to bytes
+	requiredMemory := memory * 1024 * 1024
+
+	// Check the servers which passed every filter so far for available resources
+	libvirtURIs := eligibleURIs(candidates)
+	results := s.libvirtService.CheckServersForResources(ctx, libvirtURIs, requiredMemory, vcpu)
+
+	for _, result := range results {
+		for i := range candidates {
+			candidate := &candidates[i]
+			if candidate.Server.LibvirtURI != result.LibvirtURI || !candidate.Eligible() {
+				continue
+			}
+
+			candidate.FreeMemory = result.FreeMemory
+			candidate.FreeVCPU = result.FreeVCPU
+			candidate.Error = result.Error
+
+			switch {
+			case result.Error != nil:
+				candidate.addFilter(FilterResources, "failed to check resources: "+result.Error.Error())
+			case !result.HasResources:
+				candidate.addFilter(FilterResources, fmt.Sprintf("%d MiB and %d vcpus free, %d MiB and %d vcpus required", result.FreeMemory/1024/1024, result.FreeVCPU, memory, vcpu))
+			default:
+				candidate.addFilter(FilterResources, "")
+			}
 		}
 	}
 
-	// Get list of eligible libvirtURIs
-	for _, server := range serverDetails {
-		libvirtURIs = append(libvirtURIs, server.LibvirtURI)
+	return decide(candidates, placement), nil
+}
+
+// newCandidates returns a candidate for every server with the outcome of the cordon, health and placement filters.
+func newCandidates(servers []entity.ServerInfo, placement Placement) []Candidate {
+	candidates := make([]Candidate, 0, len(servers))
+	for _, server := range servers {
+		candidate := Candidate{Server: server}
+		if server.Cordoned {
+			candidate.addFilter(FilterCordoned, "server is cordoned")
+		} else {
+			candidate.addFilter(FilterCordoned, "")
+		}
+		if server.Health.Status == ServerStatusUnhealthy {
+			candidate.addFilter(FilterHealth, "server is unhealthy: "+server.Health.Error)
+		} else {
+			candidate.addFilter(FilterHealth, "")
+		}
+		if server.Missing {
+			candidate.addFilter(FilterInventory, "server is missing from the provider inventory")
+		} else {
+			candidate.addFilter(FilterInventory, "")
+		}
+		candidate.addFilter(FilterPlacement, placement.CheckRequired(server))
+		candidates = append(candidates, candidate)
 	}
 
-	// Check all servers for available resources
-	results := s.libvirtService.CheckServersForResources(libvirtURIs, memory, vcpu)
+	return candidates
+}
 
-	for _, result := range results {
-		if result.HasResources {
-			index := slices.Index(libvirtURIs, result.LibvirtURI)
-			// Return the server id where the domain can be created
-			return serverDetails[index].ID, nil
+// eligibleURIs returns the libvirt URIs of the candidates which passed every filter so far.
+func eligibleURIs(candidates []Candidate) []string {
+	var libvirtURIs []string
+	for _, candidate := range candidates {
+		if candidate.Eligible() && !slices.Contains(libvirtURIs, candidate.Server.LibvirtURI) {
+			libvirtURIs = append(libvirtURIs, candidate.Server.LibvirtURI)
+		}
+	}
+
+	return libvirtURIs
+}
+
+// decide scores the eligible candidates and picks the one with the highest score. Ties are resolved in favor of the server listed first.
+func decide(candidates []Candidate, placement Placement) *Decision {
+	decision := &Decision{Candidates: candidates}
+
+	bestScore := 0
+	for i := range candidates {
+		candidate := &candidates[i]
+		if !candidate.Eligible() {
+			continue
+		}
+
+		candidate.Score = placement.Score(candidate.Server)
+		if decision.ServerID == 0 || candidate.Score > bestScore {
+			decision.ServerID = candidate.Server.ID
+			bestScore = candidate.Score
 		}
 	}
 
-	return 0, nil
+	return decision
 }
 
 // func (s *SchedulerService) GetServerIDForNetwork() (int, error) {
@@ -0,0 +1,121 @@
+package service
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/sychonet/vdash-be/db/entity"
+)
+
+// Policies supported by server groups. Hard policies are enforced by the scheduler, soft policies only influence the score of the servers.
+const (
+	PolicyAffinity         = "affinity"
+	PolicyAntiAffinity     = "anti-affinity"
+	PolicySoftAffinity     = "soft-affinity"
+	PolicySoftAntiAffinity = "soft-anti-affinity"
+)
+
+// softPolicyWeight is the score added or removed per group member for soft policies. It outweighs a single preferred constraint with the default weight.
+const softPolicyWeight = 10
+
+// ValidatePolicy checks that the policy of a server group is supported.
+func ValidatePolicy(policy string) error {
+	switch policy {
+	case PolicyAffinity, PolicyAntiAffinity, PolicySoftAffinity, PolicySoftAntiAffinity:
+		return nil
+	}
+
+	return fmt.Errorf("unknown policy %q", policy)
+}
+
+// CheckGroupPolicy returns a reason if placing a new member of the group on the server breaks a hard policy, or an empty string otherwise.
+func CheckGroupPolicy(group *entity.ServerGroup, serverID int) string {
+	if group == nil || len(group.Members) == 0 {
+		return ""
+	}
+
+	switch group.Policy {
+	case PolicyAffinity:
+		if group.Members[0].ServerID != serverID {
+			return fmt.Sprintf("group %s requires server %d", group.Name, group.Members[0].ServerID)
+		}
+	case PolicyAntiAffinity:
+		for _, member := range group.Members {
+			if member.ServerID == serverID {
+				return fmt.Sprintf("group %s already has domain %s on this server", group.Name, member.Domain)
+			}
+		}
+	}
+
+	return ""
+}
+
+// groupScore returns the score of the server for a new member of the group according to its soft policy.
+func groupScore(group *entity.ServerGroup, serverID int) int {
+	if group == nil {
+		return 0
+	}
+
+	score := 0
+	for _, member := range group.Members {
+		if member.ServerID != serverID {
+			continue
+		}
+
+		switch group.Policy {
+		case PolicySoftAffinity:
+			score += softPolicyWeight
+		case PolicySoftAntiAffinity:
+			score -= softPolicyWeight
+		}
+	}
+
+	return score
+}
+
+// GroupViolations returns the violations of the policy of a server group given the current placement of its members.
+func GroupViolations(group entity.ServerGroup) []entity.GroupViolation {
+	membersByServer := map[int][]string{}
+	var serverIDs []int
+	for _, member := range group.Members {
+		if _, ok := membersByServer[member.ServerID]; !ok {
+			serverIDs = append(serverIDs, member.ServerID)
+		}
+		membersByServer[member.ServerID] = append(membersByServer[member.ServerID], member.Domain)
+	}
+	slices.Sort(serverIDs)
+
+	var violations []entity.GroupViolation
+	switch group.Policy {
+	case PolicyAffinity, PolicySoftAffinity:
+		if len(serverIDs) > 1 {
+			var domains []string
+			for _, serverID := range serverIDs {
+				domains = append(domains, membersByServer[serverID]...)
+			}
+			violations = append(violations, entity.GroupViolation{
+				Group:     group.Name,
+				Policy:    group.Policy,
+				Soft:      group.Policy == PolicySoftAffinity,
+				Message:   fmt.Sprintf("members are spread over %d servers", len(serverIDs)),
+				Domains:   domains,
+				ServerIDs: serverIDs,
+			})
+		}
+	case PolicyAntiAffinity, PolicySoftAntiAffinity:
+		for _, serverID := range serverIDs {
+			if domains := membersByServer[serverID]; len(domains) > 1 {
+				violations = append(violations, entity.GroupViolation{
+					Group:     group.Name,
+					Policy:    group.Policy,
+					Soft:      group.Policy == PolicySoftAntiAffinity,
+					Message:   fmt.Sprintf("%d members share server %d", len(domains), serverID),
+					Domains:   domains,
+					ServerIDs: []int{serverID},
+				})
+			}
+		}
+	}
+
+	return violations
+}
@@ -0,0 +1,145 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+
+	"github.com/sychonet/vdash-be/db/entity"
+)
+
+// Transports supported for libvirt connections.
+const (
+	TransportSSH    = "ssh"
+	TransportLibSSH = "libssh"
+	TransportTLS    = "tls"
+	TransportTCP    = "tcp"
+	TransportUnix   = "unix"
+)
+
+// ErrInvalidConnection is returned when connection settings cannot be turned into a libvirt URI.
+var ErrInvalidConnection = errors.New("invalid connection settings")
+
+// MergeConnection returns the global connection settings overridden by the settings of a server which are set.
+func MergeConnection(defaults entity.ConnectionConfig, override *entity.ConnectionConfig) entity.ConnectionConfig {
+	merged := defaults
+	if override == nil {
+		return merged
+	}
+
+	for _, field := range []struct {
+		value  string
+		target *string
+	}{
+		{override.Transport, &merged.Transport},
+		{override.User, &merged.User},
+		{override.Port, &merged.Port},
+		{override.Path, &merged.Path},
+		{override.KeyFile, &merged.KeyFile},
+		{override.KnownHosts, &merged.KnownHosts},
+		{override.KnownHostsVerify, &merged.KnownHostsVerify},
+		{override.PKIPath, &merged.PKIPath},
+		{override.Socket, &merged.Socket},
+	} {
+		if field.value != "" {
+			*field.target = field.value
+		}
+	}
+
+	if override.NoVerify != nil {
+		merged.NoVerify = override.NoVerify
+	}
+
+	return merged
+}
+
+// BuildLibvirtURI returns the libvirt connection URI of the QEMU driver on the host for the given connection settings.
+func BuildLibvirtURI(host string, conn entity.ConnectionConfig) (string, error) {
+	transport := conn.Transport
+	if transport == "" {
+		transport = TransportLibSSH
+	}
+
+	path := conn.Path
+	if path == "" {
+		path = "system"
+	}
+
+	noVerify := conn.NoVerify != nil && *conn.NoVerify
+	query := url.Values{}
+
+	switch transport {
+	case TransportSSH, TransportLibSSH:
+		if conn.KeyFile != "" {
+			query.Set("keyfile", conn.KeyFile)
+		}
+
+		if transport == TransportSSH {
+			if conn.KnownHosts != "" || conn.KnownHostsVerify != "" {
+				return "", fmt.Errorf("%w: known hosts settings are only supported by the %s transport", ErrInvalidConnection, TransportLibSSH)
+			}
+
+			if noVerify {
+				query.Set("no_verify", "1")
+			}
+			break
+		}
+
+		if conn.KnownHosts != "" {
+			query.Set("known_hosts", conn.KnownHosts)
+		}
+
+		verify := conn.KnownHostsVerify
+		if verify == "" && noVerify {
+			verify = "ignore"
+		}
+
+		switch verify {
+		case "":
+		case "normal", "auto", "ignore":
+			query.Set("known_hosts_verify", verify)
+		default:
+			return "", fmt.Errorf("%w: unknown known hosts verification %q", ErrInvalidConnection, verify)
+		}
+	case TransportTLS:
+		if conn.PKIPath != "" {
+			query.Set("pkipath", conn.PKIPath)
+		}
+
+		if noVerify {
+			query.Set("no_verify", "1")
+		}
+	case TransportTCP:
+	case TransportUnix:
+		if conn.Socket != "" {
+			query.Set("socket", conn.Socket)
+		}
+
+		// The unix transport only reaches the local libvirtd
+		return (&url.URL{Scheme: "qemu+unix", Path: "/" + path, RawQuery: query.Encode()}).String(), nil
+	default:
+		return "", fmt.Errorf("%w: unknown transport %q", ErrInvalidConnection, transport)
+	}
+
+	if host == "" {
+		return "", fmt.Errorf("%w: a host is required for the %s transport", ErrInvalidConnection, transport)
+	}
+
+	u := &url.URL{
+		Scheme:   "qemu+" + transport,
+		Host:     host,
+		Path:     "/" + path,
+		RawQuery: query.Encode(),
+	}
+
+	if conn.Port != "" {
+		u.Host = net.JoinHostPort(host, conn.Port)
+	}
+
+	if conn.User != "" && transport != TransportTLS && transport != TransportTCP {
+		u.User = url.User(conn.User)
+	}
+
+	return u.String(), nil
+}